@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"embed"
 	"flag"
 	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/sessions"
 	"github.com/julienbonastre/ebay-helpers/internal/database"
@@ -24,6 +29,7 @@ func main() {
 	dbPath := flag.String("db", "ebay-helpers.db", "SQLite database path")
 	sandbox := flag.Bool("sandbox", true, "Use eBay sandbox environment")
 	storeName := flag.String("store", "", "(DEPRECATED) Account is now auto-created via OAuth")
+	noUI := flag.Bool("no-ui", false, "Serve only the API, without the web UI")
 	flag.Parse()
 
 	// Get eBay credentials from environment
@@ -34,6 +40,43 @@ func main() {
 	verificationToken := os.Getenv("EBAY_VERIFICATION_TOKEN")
 	publicEndpoint := os.Getenv("EBAY_PUBLIC_ENDPOINT")
 	sessionSecret := os.Getenv("EBAY_SESSION_SECRET")
+	debugOAuth := os.Getenv("EBAY_DEBUG_OAUTH") == "true"
+	rateLimit := ebay.DefaultRateLimit
+	if v := os.Getenv("EBAY_RATE_LIMIT"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			log.Fatalf("Invalid EBAY_RATE_LIMIT %q: %v", v, err)
+		}
+		rateLimit = parsed
+	}
+	rateBurst := ebay.DefaultRateBurst
+	if v := os.Getenv("EBAY_RATE_BURST"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("Invalid EBAY_RATE_BURST %q: %v", v, err)
+		}
+		rateBurst = parsed
+	}
+	var httpTimeout time.Duration // 0 = ebay.DefaultHTTPTimeout
+	if v := os.Getenv("EBAY_HTTP_TIMEOUT"); v != "" {
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid EBAY_HTTP_TIMEOUT %q (e.g. 45s): %v", v, err)
+		}
+		httpTimeout = parsed
+	}
+	maxRetries := 0
+	if v := os.Getenv("EBAY_MAX_RETRIES"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("Invalid EBAY_MAX_RETRIES %q: %v", v, err)
+		}
+		maxRetries = parsed
+	}
+	scopes, err := ebay.ParseScopes(os.Getenv("EBAY_SCOPES"))
+	if err != nil {
+		log.Fatalf("Invalid EBAY_SCOPES: %v", err)
+	}
 
 	if redirectURI == "" {
 		redirectURI = "http://localhost:" + *port + "/api/oauth/callback"
@@ -105,6 +148,19 @@ func main() {
 		ClientSecret: clientSecret,
 		RedirectURI:  redirectURI,
 		Sandbox:      *sandbox,
+		DebugOAuth:   debugOAuth,
+		RateLimiter:  ebay.NewRateLimiter(rateLimit, rateBurst),
+		HTTPTimeout:  httpTimeout,
+		MaxRetries:   maxRetries,
+		Scopes:       scopes, // nil = ebay.DefaultScopes
+	}
+	if len(scopes) > 0 {
+		log.Printf("eBay OAuth scopes from EBAY_SCOPES: %v", scopes)
+	}
+	if ebayConfig.RateLimiter == nil {
+		log.Println("WARNING: eBay API rate limiting disabled (EBAY_RATE_LIMIT <= 0)")
+	} else {
+		log.Printf("eBay API rate limit: %.1f calls/sec, burst %d", rateLimit, rateBurst)
 	}
 
 	// Initialize encryption key for credential storage
@@ -129,68 +185,23 @@ func main() {
 	// Set up routes
 	mux := http.NewServeMux()
 
-	// API routes
-	mux.HandleFunc("/api/health", h.HealthCheck)
-
-	// Account info (read-only, shows current instance)
-	mux.HandleFunc("/api/account/current", h.GetCurrentAccount)
-	mux.HandleFunc("/api/accounts", h.GetAccounts) // List all accounts in DB
-
-	// OAuth
-	mux.HandleFunc("/api/auth/url", h.GetAuthURL)
-	mux.HandleFunc("/api/auth/status", h.GetAuthStatus)
-	mux.HandleFunc("/api/oauth/callback", h.OAuthCallback)
-	mux.HandleFunc("/api/logout", h.Logout)
-
-	// Marketplace Account Deletion (required for production API activation)
-	mux.HandleFunc("/api/marketplace-account-deletion", h.MarketplaceAccountDeletion)
-	mux.HandleFunc("/api/deletion-notifications", h.GetDeletionNotifications)
-
-	// eBay API
-	mux.HandleFunc("/api/inventory", h.GetInventoryItems)
-	mux.HandleFunc("/api/offers", h.GetOffers)
-	mux.HandleFunc("/api/offers/enriched", h.GetEnrichedData) // Progressive enrichment data
-	mux.HandleFunc("/api/listings", h.GetListings)            // DB-backed listings with server-side sort/filter
-	mux.HandleFunc("/api/policies", h.GetFulfillmentPolicies)
-	mux.HandleFunc("/api/update-shipping", h.UpdateOfferShipping)
-
-	// Sync operations
-	mux.HandleFunc("/api/sync/export", h.SyncExport)         // Export current eBay → DB
-	mux.HandleFunc("/api/sync/import", h.SyncImport)         // Import DB → current eBay
-	mux.HandleFunc("/api/sync/history", h.GetSyncHistory)
-
-	// Calculator
-	mux.HandleFunc("/api/calculate", h.CalculateShipping)
-	mux.HandleFunc("/api/calculate/batch", h.BatchCalculate) // Server-side batch calculation
-	mux.HandleFunc("/api/calculate/all-zones", h.CalculateAllZones) // Multi-zone calculation
-	mux.HandleFunc("/api/brands", h.GetBrands)
-	mux.HandleFunc("/api/weight-bands", h.GetWeightBands)
-	mux.HandleFunc("/api/tariff-countries", h.GetTariffCountries)
-
-	// Settings
-	mux.HandleFunc("/api/settings", h.GetAllSettings)
-	mux.HandleFunc("/api/settings/", h.UpdateSetting) // Handles /api/settings/:key
-
-	// Reference Data CRUD
-	mux.HandleFunc("/api/reference/tariffs/", h.ReferenceTariffByID) // PUT/DELETE /api/reference/tariffs/:id
-	mux.HandleFunc("/api/reference/tariffs", h.ReferenceTariffs)     // GET/POST /api/reference/tariffs
-	mux.HandleFunc("/api/reference/brands/", h.ReferenceBrandByID)   // PUT/DELETE /api/reference/brands/:id
-	mux.HandleFunc("/api/reference/brands", h.ReferenceBrands)       // GET/POST /api/reference/brands
-
-	// eBay Credentials Management
-	mux.HandleFunc("/api/credentials", h.GetCredentials)             // GET /api/credentials
-	mux.HandleFunc("/api/credentials/create", h.CreateCredential)    // POST /api/credentials/create
-	mux.HandleFunc("/api/credentials/", h.HandleCredentialByID)      // PUT/DELETE /api/credentials/:id
-	mux.HandleFunc("/api/credentials/activate", h.SetActiveCredential) // POST /api/credentials/activate
-	mux.HandleFunc("/api/environment", h.GetCurrentEnvironment)      // GET /api/environment
-	mux.HandleFunc("/api/environment/switch", h.SwitchEnvironment)   // POST /api/environment/switch
-
-	// Serve embedded static files
-	webContent, err := fs.Sub(webFS, "web")
-	if err != nil {
-		log.Fatal(err)
+	// API routes - see routes.go; GET /api lists them all
+	registerRoutes(mux, apiRoutes(h))
+
+	// Serve embedded static files, unless running API-only
+	webContent, hasUI := webUI()
+	switch {
+	case *noUI:
+		log.Println("INFO: -no-ui set - serving the API only")
+	case !hasUI:
+		log.Println("WARNING: Embedded web UI not found - serving the API only")
+	}
+	if *noUI || !hasUI {
+		mux.HandleFunc("GET /{$}", apiOnlyIndexHandler)
+	} else {
+		// GET only, so other methods on API paths get a 405 instead of falling through here
+		mux.Handle("GET /", http.FileServer(http.FS(webContent)))
 	}
-	mux.Handle("/", http.FileServer(http.FS(webContent)))
 
 	// Start server
 	addr := ":" + *port
@@ -230,9 +241,45 @@ func main() {
 	// Wrap with security headers middleware
 	secureHandler := securityHeadersMiddleware(mux)
 
-	if err := http.ListenAndServe(addr, secureHandler); err != nil {
+	// Background jobs and the server stop on Ctrl-C / SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go h.RunEnrichmentRefresh(ctx)
+	go h.RunCallCountFlush(ctx)
+
+	server := &http.Server{Addr: addr, Handler: secureHandler}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
 		log.Fatal(err)
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown error: %v", err)
+	}
+	h.FlushCallCounts()
+}
+
+// webUI returns the embedded web/ directory, or false if it has no
+// index.html (e.g. a build with the frontend stripped out)
+func webUI() (fs.FS, bool) {
+	webContent, err := fs.Sub(webFS, "web")
+	if err != nil {
+		return nil, false
+	}
+	if _, err := fs.Stat(webContent, "index.html"); err != nil {
+		return nil, false
 	}
+	return webContent, true
 }
 
 // securityHeadersMiddleware adds security headers to all responses
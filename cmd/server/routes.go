@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/julienbonastre/ebay-helpers/internal/handlers"
+)
+
+// route describes one API endpoint. The same table registers the handlers and
+// feeds the self-describing GET /api endpoint, so the docs can't drift from
+// what's actually wired up. A path served for several methods gets one entry
+// per method, which may point at the same or different handlers.
+type route struct {
+	Method      string           `json:"method"` // Registered as "METHOD /path"; GET also serves HEAD
+	Path        string           `json:"path"`   // Trailing slash = handles sub-paths (e.g. /:id)
+	Handler     http.HandlerFunc `json:"-"`
+	Description string           `json:"description"`
+}
+
+// apiRoutes returns every API route served by the application
+func apiRoutes(h *handlers.Handler) []route {
+	return []route{
+		{"GET", "/api/health", h.HealthCheck, "API health: token expiry, call budget, rate limiter and database status"},
+
+		// Account info (read-only, shows current instance)
+		{"GET", "/api/account/current", h.GetCurrentAccount, "Current instance's eBay account"},
+		{"GET", "/api/ebay/usage", h.GetEbayUsage, "Today's eBay API calls against the daily budget"},
+		{"GET", "/api/accounts", h.GetAccounts, "All accounts with data in the database (?includeDeleted=true for soft-deleted ones)"},
+		{"GET", "/api/accounts/", h.AccountListings, "Browse an account's exported offers, joined to enrichment where available: /api/accounts/:key/listings (same params as /api/listings)"},
+		{"DELETE", "/api/accounts/", h.DeleteAccount, "Soft-delete an account, keeping its history: /api/accounts/:key"},
+		{"POST", "/api/accounts/", h.ReactivateAccount, "Reactivate a soft-deleted account: /api/accounts/:key/reactivate"},
+
+		// OAuth
+		{"GET", "/api/auth/url", h.GetAuthURL, "eBay OAuth authorization URL"},
+		{"GET", "/api/auth/status", h.GetAuthStatus, "Whether the session is authenticated"},
+		{"POST", "/api/auth/refresh", h.RefreshAuth, "Refresh the session's access token now; returns the new expiresAt (401 without a refresh token)"},
+		{"GET", "/api/oauth/callback", h.OAuthCallback, "OAuth redirect target; exchanges the code for a token"},
+		{"GET", "/api/logout", h.Logout, "Clear the session"},
+		{"POST", "/api/logout", h.Logout, "Clear the session"},
+
+		// Marketplace Account Deletion (required for production API activation)
+		{"GET", "/api/marketplace-account-deletion", h.MarketplaceAccountDeletion, "eBay account deletion endpoint validation challenge"},
+		{"POST", "/api/marketplace-account-deletion", h.MarketplaceAccountDeletion, "Receive an eBay account deletion notification"},
+		{"GET", "/api/deletion-notifications", h.GetDeletionNotifications, "Received account deletion notifications, newest first (?processed=true|false, ?limit=&offset=; total counts all matches)"},
+		{"GET", "/api/deletion-notifications/", h.GetDeletionNotificationByID, "One notification with pretty-printed payload: /api/deletion-notifications/:id"},
+		{"GET", "/api/admin/backup", h.AdminBackup, "Download a consistent copy of the SQLite database (VACUUM INTO) without sessions, e.g. before an import (signed-in session only)"},
+		{"GET", "/api/admin/deletion-notifications", h.AdminDeletionNotifications, "Deletion notifications for recovery (?processed=false for unprocessed, oldest first; ?limit=&offset=)"},
+		{"POST", "/api/admin/deletion-notifications/", h.ReprocessDeletionNotification, "Re-run processing for a notification: /api/admin/deletion-notifications/:id/reprocess"},
+		{"GET", "/api/debug/item/", h.DebugItemResponses, "Raw Trading API responses stored for an item while debug_store_raw_responses is on: /api/debug/item/:id (?call=GetItem for the raw XML)"},
+
+		// eBay API
+		{"GET", "/api/inventory", h.GetInventoryItems, "Inventory items from eBay"},
+		{"GET", "/api/inventory/", h.GetInventoryItem, "One inventory item from eBay: /api/inventory/:sku (404 if eBay has no such SKU)"},
+		{"GET", "/api/offers/overrides", h.GetOfferShippingOverrides, "Exported offers with custom domestic/international shipping cost overrides (?account=:key for one account)"},
+		{"GET", "/api/offers", h.GetOffers, "Active listings from eBay (cached; partial and failedPages report pages that failed to load)"},
+		{"GET", "/api/orders", h.GetOrders, "Recent orders from the Fulfillment API (?filter=&limit=&offset=)"},
+		{"GET", "/api/reconcile", h.ReconcileOrders, "Paid vs calculated postage for recent orders (same params as /api/orders)"},
+		{"GET", "/api/offers/enriched", h.GetEnrichedData, "Brand, COO, shipping and images for ?itemIds=id1,id2"},
+		{"POST", "/api/enrich/queue", h.QueueEnrichment, "Queue item IDs for background enrichment"},
+		{"DELETE", "/api/enrich/cache", h.PurgeEnrichmentCache, "Forget enrichment (memory and database) so it is re-fetched from eBay; ?itemIds=id1,id2 for specific items (signed-in session only)"},
+		{"POST", "/api/enrich/recompute", h.RecomputeEnrichment, "Store expected COO, tariff, cost and diff status of all enriched items from current reference data and count those changed since the last recompute (no eBay calls)"},
+		{"GET", "/api/item/by-sku/", h.GetItemBySKU, "Enriched items for a seller SKU: /api/item/by-sku/:sku"},
+		{"GET", "/api/listings", h.GetListings, "DB-backed listings with server-side sort/filter (?sort=quantitySold ranks best-sellers, ?currencyCheck=pass|fail audits shipping currency)"},
+		{"GET", "/api/listings/summary", h.ListingsSummary, "Counts by diffStatus and cooMatch plus total underpriced postage in AUD (same search/currencyCheck params as /api/listings)"},
+		{"GET", "/api/listings/export.csv", h.ExportListingsCSV, "Download listings as CSV (same search/sort params as /api/listings)"},
+		{"POST", "/api/listings/end", h.EndListing, "End (withdraw) an active listing: {itemId, reason}"},
+		{"GET", "/api/policies", h.GetFulfillmentPolicies, "Fulfillment (shipping) policies"},
+		{"GET", "/api/policies/payment", h.GetPaymentPolicies, "Payment policies"},
+		{"GET", "/api/policies/return", h.GetReturnPolicies, "Return policies"},
+		{"POST", "/api/update-shipping", h.UpdateOfferShipping, "Update an offer's shipping cost overrides"},
+		{"POST", "/api/update-shipping/batch", h.UpdateOfferShippingBatch, "Update shipping overrides for many offers: [{offerId, overrides}]"},
+
+		// Sync operations
+		{"POST", "/api/sync/export", h.SyncExport, "Export current eBay account to the database (?resume=true continues a failed inventory export from its last saved page)"},
+		{"GET", "/api/sync/export/stream", h.SyncExportStream, "Export with Server-Sent Events progress (phase, count, error, done; ?resume=true as for /api/sync/export)"},
+		{"POST", "/api/sync/import", h.SyncImport, "Import database data into the current eBay account"},
+		{"GET", "/api/sync/diff", h.SyncDiff, "Compare two accounts' exported inventory and offers (?source=KEY_A&target=KEY_B): added, removed and changed SKUs"},
+		{"GET", "/api/sync/history", h.GetSyncHistory, "Sync history for the current account (?syncType=&status=&limit=&offset=)"},
+		{"GET", "/api/sync/backup", h.SyncBackup, "Download a JSON backup of exported data with a checksummed manifest (?account=key; signed-in session only)"},
+		{"POST", "/api/sync/restore", h.SyncRestore, "Verify and restore a JSON backup into the account named in its manifest (signed-in session only)"},
+
+		// Calculator
+		{"POST", "/api/calculate", h.CalculateShipping, "Calculate USA shipping for one item"},
+		{"POST", "/api/calculate/weight", h.CalculateShippingByWeight, "Calculate USA shipping from weightGrams (band resolved server-side)"},
+		{"POST", "/api/calculate/weights", h.CalculateShippingByWeights, "Calculate USA shipping at each of several weightsGrams (bracket an uncertain weight)"},
+		{"POST", "/api/calculate/batch", h.BatchCalculate, "Server-side calculation for enriched items, with each cost's shipping/duties breakdown"},
+		{"POST", "/api/calculate/all-zones", h.CalculateAllZones, "Calculate shipping for every postal zone"},
+		{"GET", "/api/brands", h.GetBrands, "Brand names known to the calculator"},
+		{"GET", "/api/brands/suggest", h.SuggestBrands, "Known brands closest to ?q= by prefix and spelling (&limit=, default 5)"},
+		{"GET", "/api/brands/", h.GetBrandCOOs, "Primary and secondary countries of origin: /api/brands/:name/coos"},
+		{"GET", "/api/weight-bands", h.GetWeightBands, "Available weight bands"},
+		{"GET", "/api/tariff-countries", h.GetTariffCountries, "Countries with US tariff rates"},
+
+		// Settings
+		{"GET", "/api/settings", h.GetAllSettings, "All application settings"},
+		{"PUT", "/api/settings/", h.UpdateSetting, "Update a setting: /api/settings/:key (400 if the value doesn't fit its dataType, 404 for unknown keys)"},
+
+		// Reference Data CRUD
+		{"POST", "/api/reference/tariffs/preview", h.PreviewTariff, "Preview a tariff change {countryName, newRate}: old and new total calculated postage of affected enriched items, and the delta (nothing is saved)"},
+		{"PUT", "/api/reference/tariffs/", h.ReferenceTariffByID, "Update a tariff rate: /api/reference/tariffs/:id"},
+		{"DELETE", "/api/reference/tariffs/", h.ReferenceTariffByID, "Delete a tariff rate: /api/reference/tariffs/:id"},
+		{"GET", "/api/reference/tariffs", h.ReferenceTariffs, "List tariff rates, with each country's rate history"},
+		{"POST", "/api/reference/tariffs", h.ReferenceTariffs, "Add a tariff rate taking effect on effectiveDate (default today); 409 if the country already has one that day"},
+		{"PUT", "/api/reference/brands/", h.ReferenceBrandByID, "Update a brand mapping: /api/reference/brands/:id"},
+		{"DELETE", "/api/reference/brands/", h.ReferenceBrandByID, "Delete a brand mapping: /api/reference/brands/:id"},
+		{"GET", "/api/reference/brands", h.ReferenceBrands, "List brand-COO mappings (?autoCreated=true for auto-created ones awaiting review)"},
+		{"POST", "/api/reference/brands", h.ReferenceBrands, "Create a brand-COO mapping"},
+		{"PUT", "/api/reference/weight-bands/", h.ReferenceWeightBandByID, "Update a weight band: /api/reference/weight-bands/:id"},
+		{"DELETE", "/api/reference/weight-bands/", h.ReferenceWeightBandByID, "Delete a weight band: /api/reference/weight-bands/:id"},
+		{"GET", "/api/reference/weight-bands", h.ReferenceWeightBands, "List postal weight bands (?zone= to filter)"},
+		{"POST", "/api/reference/weight-bands", h.ReferenceWeightBands, "Create a postal weight band"},
+		{"GET", "/api/reference/zones", h.ReferenceZones, "Import duty and Zonos settings for each postal zone"},
+		{"PUT", "/api/reference/zones/", h.ReferenceZoneByID, "Set a zone's hasTariffs and Zonos applicability/fees: /api/reference/zones/:zoneId"},
+		{"GET", "/api/reference/item-weights", h.ReferenceItemWeights, "List stored per-listing weights"},
+		{"GET", "/api/reference/item-weights/", h.ReferenceItemWeightByID, "Get a listing's stored weight: /api/reference/item-weights/:itemId"},
+		{"PUT", "/api/reference/item-weights/", h.ReferenceItemWeightByID, "Set a listing's weight {weightGrams}; listings and batch calculations use its band instead of a guessed one"},
+		{"DELETE", "/api/reference/item-weights/", h.ReferenceItemWeightByID, "Remove a listing's stored weight: /api/reference/item-weights/:itemId"},
+		{"GET", "/api/reference/export.json", h.ExportReferenceData, "Download brand mappings and tariff rates as a versioned JSON package"},
+		{"POST", "/api/reference/import.json", h.ImportReferenceData, "Upsert brand mappings and tariff rates from a reference package; nothing is written unless every row is valid (?skipInvalid=true writes the valid rows and reports the malformed ones skipped)"},
+
+		// eBay Credentials Management
+		{"GET", "/api/credentials", h.GetCredentials, "Stored eBay credentials (secrets masked)"},
+		{"POST", "/api/credentials/create", h.CreateCredential, "Store a new eBay credential"},
+		{"GET", "/api/credentials/", h.HandleCredentialByID, "Get a credential: /api/credentials/:id"},
+		{"PUT", "/api/credentials/", h.HandleCredentialByID, "Update a credential: /api/credentials/:id"},
+		{"DELETE", "/api/credentials/", h.HandleCredentialByID, "Delete a credential: /api/credentials/:id"},
+		{"POST", "/api/credentials/activate", h.SetActiveCredential, "Make a credential active for its environment"},
+		{"GET", "/api/environment", h.GetCurrentEnvironment, "Active eBay environment"},
+		{"POST", "/api/environment/switch", h.SwitchEnvironment, "Switch between production and sandbox"},
+	}
+}
+
+// registerRoutes adds every route to mux using method patterns, plus GET /api
+// describing them. Requests with an unregistered method get a 405 from the mux.
+func registerRoutes(mux *http.ServeMux, routes []route) {
+	for _, rt := range routes {
+		mux.HandleFunc(rt.Method+" "+rt.Path, rt.Handler)
+	}
+	mux.HandleFunc("GET /api", apiIndexHandler(routes))
+	log.Printf("Registered %d API routes", len(routes))
+}
+
+// apiIndexHandler serves the route table as JSON
+func apiIndexHandler(routes []route) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"routes": routes,
+			"total":  len(routes),
+		})
+	}
+}
+
+// apiOnlyIndexHandler answers GET / when the web UI isn't served, pointing
+// clients at the route list instead of returning a bare 404
+func apiOnlyIndexHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":   "eBay Postage Helper API",
+		"ui":     false,
+		"routes": "/api",
+	})
+}
+
+// writeJSON mirrors the handlers package's jsonResponse for routes served from main
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
@@ -1288,6 +1288,11 @@ async function renderListings() {
             calculated = '<strong class="coo-missing">No COO set!</strong>';
             diff = '<strong class="coo-missing">No COO set!</strong>';
             diffClass = 'coo-missing';
+        } else if (calcData.diffStatus === 'unknown') {
+            // Shipping currency has no AUD rate, so there's nothing to compare
+            calculated = '$' + calcData.calculatedCost.toFixed(2);
+            diff = 'No FX rate';
+            diffClass = 'diff-warn';
         } else {
             // Display backend-calculated values
             calculated = '$' + calcData.calculatedCost.toFixed(2);
@@ -1310,7 +1315,7 @@ async function renderListings() {
                 <td>Medium</td>
                 <td class="shipping-cell" data-item-id="${escapeHtml(offer.offerId)}">${currentUSPostage}</td>
                 <td class="calculated-cell" data-item-id="${escapeHtml(offer.offerId)}">${calculated}</td>
-                <td class="diff-cell ${diffClass}" data-item-id="${escapeHtml(offer.offerId)}">${diff}</td>
+                <td class="diff-cell ${diffClass}" data-item-id="${escapeHtml(offer.offerId)}" title="${escapeHtml(enriched?.conditionDescription || '')}">${diff}</td>
                 <td>
                     <button class="btn btn-sm btn-secondary edit-item-btn">Edit</button>
                 </td>
@@ -1775,7 +1780,7 @@ async function fetchEnrichedData() {
 }
 
 function updateTableRow(enrichedData) {
-    const { itemId, brand, shippingCost, shippingCurrency, countryOfOrigin, images } = enrichedData;
+    const { itemId, brand, shippingCost, shippingCurrency, countryOfOrigin, images, conditionDescription } = enrichedData;
 
     // Update carousel images if we have enriched images
     console.log('[CAROUSEL-DEBUG] updateTableRow itemId:', itemId, 'images received:', images?.length || 0);
@@ -1860,6 +1865,9 @@ function updateTableRow(enrichedData) {
     const diffCell = document.querySelector(`.diff-cell[data-item-id="${itemId}"]`);
 
     if (calculatedCell && diffCell) {
+        // Seller's condition note, shown on hover next to the shipping diff
+        diffCell.title = conditionDescription || '';
+
         const calcData = calculationCache.get(itemId);
 
         if (calcData) {
@@ -1868,6 +1876,10 @@ function updateTableRow(enrichedData) {
                 calculatedCell.innerHTML = '<strong class="coo-missing">No COO set!</strong>';
                 diffCell.innerHTML = '<strong class="coo-missing">No COO set!</strong>';
                 diffCell.className = 'diff-cell coo-missing';
+            } else if (calcData.diffStatus === 'unknown') {
+                calculatedCell.textContent = '$' + calcData.calculatedCost.toFixed(2);
+                diffCell.textContent = 'No FX rate';
+                diffCell.className = 'diff-cell diff-warn';
             } else {
                 calculatedCell.textContent = '$' + calcData.calculatedCost.toFixed(2);
                 const diffClass = calcData.diffStatus === 'ok' ? 'diff-ok' : 'diff-bad';
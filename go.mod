@@ -4,9 +4,10 @@ go 1.24.7
 
 require golang.org/x/oauth2 v0.34.0
 
-require github.com/mattn/go-sqlite3 v1.14.33
-
 require (
-	github.com/gorilla/securecookie v1.1.2 // indirect
-	github.com/gorilla/sessions v1.4.0 // indirect
+	github.com/gorilla/securecookie v1.1.2
+	github.com/gorilla/sessions v1.4.0
+	github.com/mattn/go-sqlite3 v1.14.33
 )
+
+require golang.org/x/sync v0.17.0
@@ -0,0 +1,119 @@
+package calculator
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// BrandSuggestion is a known brand name close to a queried one
+type BrandSuggestion struct {
+	Brand    string `json:"brand"`
+	Distance int    `json:"distance"` // Levenshtein distance between the lower-cased names
+	Prefix   bool   `json:"prefix"`   // One name starts the other, e.g. "Camilla" and "Camilla Franks"
+}
+
+// brandKey lower-cases a brand name and collapses its whitespace, so
+// "Spell  & the Gypsy" and "spell & the gypsy" compare equal
+func brandKey(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// wordPrefix reports whether short starts long and ends at a word boundary
+// of it; "spell" starts "spell & the gypsy" but not "spellbound"
+func wordPrefix(short, long string) bool {
+	return short != "" && strings.HasPrefix(long, short) &&
+		(len(long) == len(short) || long[len(short)] == ' ')
+}
+
+// maxBrandDistance is how many edits a name of n characters may be from a
+// brand to be suggested: about one per four characters, from 1 up to 3
+func maxBrandDistance(n int) int {
+	return min(max(n/4, 1), 3)
+}
+
+// levenshtein returns the number of single-character insertions, deletions
+// and substitutions turning a into b
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(br)]
+}
+
+// SuggestBrands returns up to limit known brands close to query, ignoring
+// case: brands that start with query or that query starts with (at a word
+// boundary) first, then those within a few edits of it, closest first
+func (c *CalculatorConfig) SuggestBrands(query string, limit int) []BrandSuggestion {
+	q := brandKey(query)
+	suggestions := []BrandSuggestion{}
+	if q == "" {
+		return suggestions
+	}
+
+	maxDistance := maxBrandDistance(utf8.RuneCountInString(q))
+	for name := range c.Brands {
+		key := brandKey(name)
+		prefix := wordPrefix(q, key) || wordPrefix(key, q)
+		distance := levenshtein(q, key)
+		if prefix || distance <= maxDistance {
+			suggestions = append(suggestions, BrandSuggestion{Brand: name, Distance: distance, Prefix: prefix})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Prefix != b.Prefix {
+			return a.Prefix
+		}
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		return a.Brand < b.Brand
+	})
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// brand returns the mapping for a brand name: an exact match, then one that
+// differs only in case or spacing (as brand names are matched in SQL), then,
+// when FuzzyBrandMatch is on, the closest known brand. Every brand lookup goes
+// through here so the COO, COO status and weight band always agree.
+func (c *CalculatorConfig) brand(name string) (Brand, bool) {
+	if brand, ok := c.Brands[name]; ok {
+		return brand, true
+	}
+	key := brandKey(name)
+	if key == "" {
+		return Brand{}, false
+	}
+	for known, brand := range c.Brands {
+		if brandKey(known) == key {
+			return brand, true
+		}
+	}
+	if !c.FuzzyBrandMatch {
+		return Brand{}, false
+	}
+	suggestions := c.SuggestBrands(name, 1)
+	if len(suggestions) == 0 {
+		return Brand{}, false
+	}
+	return c.Brands[suggestions[0].Brand], true
+}
@@ -0,0 +1,91 @@
+package calculator_test
+
+import (
+	"testing"
+
+	"github.com/julienbonastre/ebay-helpers/internal/calculator"
+)
+
+func TestSuggestBrandsNearMisses(t *testing.T) {
+	calc := seededConfig(t)
+
+	tests := []struct {
+		query string
+		want  string // Top suggestion, "" for none
+	}{
+		{"Camilla", "Camilla Franks"},         // Prefix of the brand
+		{"Spell & the Gypsy", "Spell"},        // Brand is a prefix of the query
+		{"spell  &  THE gypsy", "Spell"},      // Case and spacing ignored
+		{"Free Poeple", "Free People"},        // Transposition
+		{"Lack of Colour", "Lack of Color"},   // Spelling variant
+		{"LoveShack Fancy", "LoveShackFancy"}, // Extra space
+		{"Spellbound", ""},                    // Not at a word boundary
+		{"Completely Different", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			suggestions := calc.SuggestBrands(tt.query, 1)
+			got := ""
+			if len(suggestions) > 0 {
+				got = suggestions[0].Brand
+			}
+			if got != tt.want {
+				t.Errorf("SuggestBrands(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBrandLookupAgrees checks the COO, COO status, resolved COO and weight
+// band all look a near-miss brand up the same way
+func TestBrandLookupAgrees(t *testing.T) {
+	tests := []struct {
+		brand     string
+		fuzzy     bool
+		wantCOO   string // "" for the default COO
+		secondary string // A secondary COO of the matched brand, "" to skip
+		typeBand  bool   // Weight band comes from the matched brand's type
+	}{
+		{"aje", false, "China", "India", false},         // Case only, matched without fuzzy
+		{"Camilla", true, "India", "China", false},      // Fuzzy prefix
+		{"Camilla", false, "", "", false},               // No fuzzy: default COO
+		{"Lack of Colour", true, "China", "", true},     // Fuzzy, with the brand's type band
+		{"Wildfox Couture", true, "China", "USA", true}, // Brand is a prefix
+	}
+	for _, tt := range tests {
+		t.Run(tt.brand, func(t *testing.T) {
+			calc := seededConfig(t)
+			calc.FuzzyBrandMatch = tt.fuzzy
+			wantCOO := tt.wantCOO
+			if wantCOO == "" {
+				wantCOO = calc.DefaultCOO
+			}
+
+			if got := calc.GetCountryOfOrigin(tt.brand); got != wantCOO {
+				t.Errorf("GetCountryOfOrigin = %q, want %q", got, wantCOO)
+			}
+			if got := calc.COOStatus(tt.brand, wantCOO); got != "match" {
+				t.Errorf("COOStatus(%q) = %q, want match", wantCOO, got)
+			}
+			if coo, known := calc.ResolveCOO(tt.brand, ""); coo != wantCOO || !known {
+				t.Errorf("ResolveCOO(no declared COO) = %q, %v, want %q", coo, known, wantCOO)
+			}
+			if tt.secondary != "" {
+				if coo, known := calc.ResolveCOO(tt.brand, tt.secondary); !known || !calculator.SameCountry(coo, tt.secondary) {
+					t.Errorf("ResolveCOO(%q) = %q, %v, want the brand's secondary", tt.secondary, coo, known)
+				}
+				if got := calc.COOStatus(tt.brand, tt.secondary); got != "match" {
+					t.Errorf("COOStatus(%q) = %q, want match", tt.secondary, got)
+				}
+			}
+
+			wantSource := calculator.WeightSourceDefault
+			if tt.typeBand {
+				wantSource = calculator.WeightSourceBrandType
+			}
+			if band, source := calc.ItemWeightBand(tt.brand, 0); source != wantSource {
+				t.Errorf("ItemWeightBand source = %q (%s), want %q", source, band, wantSource)
+			}
+		})
+	}
+}
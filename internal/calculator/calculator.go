@@ -5,6 +5,7 @@ import (
 	"math"
 	"sort"
 	"strings"
+	"time"
 )
 
 // CalculatorConfig holds all configuration data for postage calculations
@@ -15,6 +16,66 @@ type CalculatorConfig struct {
 	Zonos       ZonosData
 	ExtraCover  ExtraCoverData
 	DefaultCOO  string
+
+	// BrandTypeWeightBands maps a brand's Type (e.g. "Hats") to a likely weight band
+	BrandTypeWeightBands map[string]string
+
+	// DeMinimisAUD is the item value below which no US duties (or Zonos fees)
+	// are charged. 0 disables the exemption.
+	DeMinimisAUD float64
+
+	// CurrencyRates converts eBay amounts to AUD (currency code -> AUD per unit)
+	CurrencyRates map[string]float64
+
+	// HonorSecondaryCOO uses a declared COO from the brand's secondary list
+	// for tariffs and counts it as a COO match. When false, only the primary
+	// COO is used and matches.
+	HonorSecondaryCOO bool
+
+	// FallbackWeightBand and FallbackDiscountBand describe the seller's typical
+	// parcel, assumed for bulk calculations when an item's weight or discount
+	// band isn't known. An empty FallbackWeightBand means DefaultWeightBand;
+	// FallbackDiscountBand is used as-is, since band 0 (no discount) is valid.
+	FallbackWeightBand   string
+	FallbackDiscountBand int
+
+	// FuzzyBrandMatch makes brand lookups (COO, COO status, weight band) fall
+	// back to the closest known brand (see SuggestBrands) for a brand with
+	// no exact mapping
+	FuzzyBrandMatch bool
+
+	// NextTariffChange is when the earliest future-dated tariff rate takes
+	// effect, after which USATariffs is out of date (see TariffsStale). Zero
+	// when no rate is pending.
+	NextTariffChange time.Time
+}
+
+// TariffsStale reports whether a future-dated tariff rate has taken effect
+// since the config was loaded, so it should be reloaded before use
+func (c *CalculatorConfig) TariffsStale(now time.Time) bool {
+	return !c.NextTariffChange.IsZero() && !now.Before(c.NextTariffChange)
+}
+
+// ToAUD converts amount in currency to AUD. An empty currency is assumed to
+// be AUD already, and codes are matched ignoring case (CurrencyRates keys are
+// upper case). Returns 0 and false if there's no rate, rather than passing
+// the unconverted amount off as AUD.
+func (c *CalculatorConfig) ToAUD(amount float64, currency string) (float64, bool) {
+	currency = NormalizeCurrency(currency)
+	if currency == "" || currency == "AUD" {
+		return amount, true
+	}
+	rate, ok := c.CurrencyRates[currency]
+	if !ok || rate <= 0 {
+		return 0, false
+	}
+	return round2(amount * rate), true
+}
+
+// NormalizeCurrency returns a currency code as CurrencyRates keys it:
+// trimmed and upper case
+func NormalizeCurrency(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
 }
 
 // ShippingResult holds the complete calculation breakdown
@@ -34,6 +95,14 @@ type ShippingInputs struct {
 	TariffRate        float64 `json:"tariffRate"`
 	IncludeExtraCover bool    `json:"includeExtraCover"`
 	DiscountBand      int     `json:"discountBand"`
+
+	// Set only when dimensions were given: which weight the band was chosen
+	// from ("actual" or "volumetric") and the volumetric weight itself
+	WeightBasis     string `json:"weightBasis,omitempty"`
+	VolumetricGrams int    `json:"volumetricGrams,omitempty"`
+
+	// True when TariffRate came from the request rather than the tariff table
+	TariffRateOverridden bool `json:"tariffRateOverridden,omitempty"`
 }
 
 // ShippingBreakdown shows individual cost components
@@ -44,27 +113,189 @@ type ShippingBreakdown struct {
 	TariffDuties     float64 `json:"tariffDuties"`
 	ZonosFees        float64 `json:"zonosFees"`
 	DutiesSubtotal   float64 `json:"dutiesSubtotal"`
+
+	DeMinimisThresholdAUD float64 `json:"deMinimisThresholdAUD,omitempty"` // Set when a de minimis threshold is configured
 }
 
 // ShippingWarnings holds any warnings for the user
 type ShippingWarnings struct {
 	ExtraCoverRecommended bool `json:"extraCoverRecommended"`
+	DeMinimisApplied      bool `json:"deMinimisApplied"` // Duties waived: item value is under the de minimis threshold
+
+	// COONotKnownForBrand is set when an explicit country of origin is neither
+	// the brand's primary nor one of its secondary countries
+	COONotKnownForBrand bool `json:"cooNotKnownForBrand"`
 }
 
-// GetCountryOfOrigin returns the COO for a brand, or default
+// DefaultWeightBand is used when nothing is known about an item's weight
+// and no default_weight_band is configured
+const DefaultWeightBand = "Medium"
+
+// DefaultDiscountBand is the AusPost discount band assumed for bulk
+// calculations when no default_discount_band is configured
+const DefaultDiscountBand = 3
+
+// MaxDiscountBand is the highest AusPost discount band (bands run 0-5)
+const MaxDiscountBand = 5
+
+// Where a bulk calculation's weight band came from
+const (
+	WeightSourceItem      = "itemWeight" // The item's stored weight
+	WeightSourceBrandType = "brandType"  // Guessed from the brand's product type
+	WeightSourceDefault   = "default"    // FallbackWeightBand (default_weight_band)
+)
+
+// GuessWeightBand estimates the weight band for an item with no known weight
+// from its brand's product type (e.g. Hats -> XSmall, Sneakers -> Large).
+// Falls back to FallbackWeightBand if the brand, its type or the mapping is unknown.
+func (c *CalculatorConfig) GuessWeightBand(brandName string) string {
+	band, _ := c.ItemWeightBand(brandName, 0)
+	return band
+}
+
+// ItemWeightBand returns the weight band for an item and where it came from:
+// the band for weightGrams when it is known (greater than 0), otherwise
+// GuessWeightBand's guess
+func (c *CalculatorConfig) ItemWeightBand(brandName string, weightGrams int) (band, source string) {
+	if weightGrams > 0 {
+		return GetWeightBandFromGrams(weightGrams), WeightSourceItem
+	}
+	if brand, ok := c.brand(brandName); ok && brand.Type != "" {
+		if band, ok := c.BrandTypeWeightBands[brand.Type]; ok && band != "" {
+			return band, WeightSourceBrandType
+		}
+	}
+	if c.FallbackWeightBand != "" {
+		return c.FallbackWeightBand, WeightSourceDefault
+	}
+	return DefaultWeightBand, WeightSourceDefault
+}
+
+// GetCountryOfOrigin returns the COO for a brand (looked up as brand does),
+// or default
 func (c *CalculatorConfig) GetCountryOfOrigin(brandName string) string {
-	if brand, ok := c.Brands[brandName]; ok {
-		return brand.PrimaryCOO
+	if brand, ok := c.brand(brandName); ok {
+		return NormalizeCountry(brand.PrimaryCOO)
 	}
 	return c.DefaultCOO
 }
 
+// BrandCOOs returns the primary and secondary countries of origin for a brand.
+// Unknown brands get the default COO and no secondaries.
+func (c *CalculatorConfig) BrandCOOs(brandName string) (primary string, secondary []string) {
+	brand, ok := c.brand(brandName)
+	if !ok {
+		return c.DefaultCOO, []string{}
+	}
+	if brand.SecondaryCOO == nil {
+		return brand.PrimaryCOO, []string{}
+	}
+	return brand.PrimaryCOO, brand.SecondaryCOO
+}
+
+// ResolveCOO picks the country of origin to calculate with. An empty declared
+// COO falls back to the brand's primary. A declared secondary COO is used
+// unless HonorSecondaryCOO is off, in which case the primary replaces it. Any
+// other declared COO is used, and known reports whether it is one of the
+// brand's primary/secondary countries (matched by SameCountry, returning the
+// brand's spelling). Brands with no mapping have nothing to check against, so
+// any declared COO is known. Declared names are normalized (NormalizeCountry).
+func (c *CalculatorConfig) ResolveCOO(brandName, declared string) (coo string, known bool) {
+	if declared == "" {
+		return c.GetCountryOfOrigin(brandName), true
+	}
+	brand, ok := c.brand(brandName)
+	if !ok {
+		return NormalizeCountry(declared), true
+	}
+	if SameCountry(brand.PrimaryCOO, declared) {
+		return brand.PrimaryCOO, true
+	}
+	for _, country := range brand.SecondaryCOO {
+		if SameCountry(country, declared) {
+			if !c.HonorSecondaryCOO {
+				return brand.PrimaryCOO, true
+			}
+			return country, true
+		}
+	}
+	return NormalizeCountry(declared), false
+}
+
+// MatchCOO returns a listing's COO status against its brand's countries:
+// "missing" (no COO), "match" (the primary, or a secondary when
+// honorSecondary is set) or "mismatch". Countries are compared with
+// SameCountry, so "USA" matches "United States".
+func MatchCOO(coo, primary string, secondary []string, honorSecondary bool) string {
+	if coo == "" {
+		return "missing"
+	}
+	if SameCountry(coo, primary) {
+		return "match"
+	}
+	if honorSecondary {
+		for _, country := range secondary {
+			if SameCountry(country, coo) {
+				return "match"
+			}
+		}
+	}
+	return "mismatch"
+}
+
+// COOStatus is MatchCOO for a brand in this config
+func (c *CalculatorConfig) COOStatus(brandName, coo string) string {
+	primary, secondary := c.BrandCOOs(brandName)
+	return MatchCOO(coo, primary, secondary, c.HonorSecondaryCOO)
+}
+
 // GetTariffRate returns the US tariff rate for a country
 func (c *CalculatorConfig) GetTariffRate(country string) float64 {
-	if rate, ok := c.USATariffs.Rates[country]; ok {
-		return rate
+	return c.USATariffs.Rates[c.TariffCountry(country)]
+}
+
+// TariffCountry returns the country whose tariff rate applies to goods from
+// country: the tariff table's entry for it, matched exactly or after
+// NormalizeCountry, otherwise the default COO
+func (c *CalculatorConfig) TariffCountry(country string) string {
+	if _, ok := c.USATariffs.Rates[country]; ok {
+		return country
 	}
-	return c.USATariffs.Rates[c.DefaultCOO]
+	normalized := NormalizeCountry(country)
+	if _, ok := c.USATariffs.Rates[normalized]; ok {
+		return normalized
+	}
+	for name := range c.USATariffs.Rates {
+		if SameCountry(name, normalized) {
+			return name
+		}
+	}
+	return c.DefaultCOO
+}
+
+// WithTariffRate returns a copy of the config with country's US tariff rate
+// set to rate, for previewing a change without touching c. The country keeps
+// the tariff table's spelling when it's already there (matched by
+// SameCountry); otherwise it's added under its NormalizeCountry name. The
+// name used is returned alongside.
+func (c *CalculatorConfig) WithTariffRate(country string, rate float64) (*CalculatorConfig, string) {
+	name := NormalizeCountry(country)
+	for existing := range c.USATariffs.Rates {
+		if SameCountry(existing, name) {
+			name = existing
+			break
+		}
+	}
+
+	rates := make(map[string]float64, len(c.USATariffs.Rates)+1)
+	for k, v := range c.USATariffs.Rates {
+		rates[k] = v
+	}
+	rates[name] = rate
+
+	preview := *c
+	preview.USATariffs = TariffData{Rates: rates}
+	return &preview, name
 }
 
 // CalculateAusPostShipping calculates the AusPost shipping cost
@@ -109,17 +340,35 @@ func (c *CalculatorConfig) CalculateExtraCover(itemValueAUD float64, discountBan
 	return round2(cost)
 }
 
-// CalculateTariffDuties calculates US import duties
+// DeMinimisApplies reports whether an item is cheap enough to be exempt from duties
+func (c *CalculatorConfig) DeMinimisApplies(itemValueAUD float64) bool {
+	return c.DeMinimisAUD > 0 && itemValueAUD < c.DeMinimisAUD
+}
+
+// CalculateTariffDuties calculates US import duties.
+// Returns 0 for items under the de minimis threshold.
 func (c *CalculatorConfig) CalculateTariffDuties(itemValueAUD float64, countryOfOrigin string) float64 {
-	rate := c.GetTariffRate(countryOfOrigin)
+	return c.tariffDutiesAtRate(itemValueAUD, c.GetTariffRate(countryOfOrigin))
+}
+
+// tariffDutiesAtRate calculates US import duties at an explicit rate
+func (c *CalculatorConfig) tariffDutiesAtRate(itemValueAUD, rate float64) float64 {
+	if c.DeMinimisApplies(itemValueAUD) {
+		return 0
+	}
 	return round2(itemValueAUD * rate)
 }
 
-// CalculateZonosFees calculates Zonos processing fees
+// CalculateZonosFees calculates Zonos processing fees at the default
+// (settings) fee structure
 func (c *CalculatorConfig) CalculateZonosFees(tariffAmount float64) float64 {
-	percentageFee := tariffAmount * c.Zonos.ProcessingChargePercent
-	total := percentageFee + c.Zonos.FlatFeeAUD
-	return round2(total)
+	return c.Zonos.Fees(tariffAmount)
+}
+
+// zoneZonos returns the Zonos fee structure for zoneID, or nil if Zonos
+// doesn't collect duties there
+func (c *CalculatorConfig) zoneZonos(zoneID string) *ZonosData {
+	return c.PostalZones[zoneID].Zonos
 }
 
 // ShouldWarnExtraCover returns true if extra cover warning should show
@@ -135,21 +384,40 @@ type CalculateUSAShippingParams struct {
 	CountryOfOrigin   string // optional override
 	IncludeExtraCover bool
 	DiscountBand      int
+
+	// Optional parcel dimensions. When all three are set, the item is charged
+	// on the heavier of its WeightBand and its volumetric weight.
+	LengthCm float64
+	WidthCm  float64
+	HeightCm float64
+
+	// TariffRateOverride, when set, is used instead of the tariff table rate
+	// for the country of origin (0-1), for "what if" quotes
+	TariffRateOverride *float64
 }
 
 // CalculateUSAShipping performs the complete shipping calculation
 func (c *CalculatorConfig) CalculateUSAShipping(params CalculateUSAShippingParams) (*ShippingResult, error) {
 	zone := "3-USA & Canada"
 
-	// Determine country of origin
-	coo := params.CountryOfOrigin
-	if coo == "" {
-		coo = c.GetCountryOfOrigin(params.BrandName)
+	// AusPost charges on the greater of actual and volumetric weight
+	weightBand, weightBasis, volumetricGrams, err := chargeableWeightBand(params.WeightBand, params.LengthCm, params.WidthCm, params.HeightCm)
+	if err != nil {
+		return nil, err
 	}
+
+	// Determine country of origin
+	coo, cooKnown := c.ResolveCOO(params.BrandName, params.CountryOfOrigin)
 	tariffRate := c.GetTariffRate(coo)
+	if params.TariffRateOverride != nil {
+		if *params.TariffRateOverride < 0 || *params.TariffRateOverride > 1 {
+			return nil, fmt.Errorf("tariff rate override must be between 0 and 1, got %v", *params.TariffRateOverride)
+		}
+		tariffRate = *params.TariffRateOverride
+	}
 
 	// Calculate components
-	ausPostShipping, err := c.CalculateAusPostShipping(zone, params.WeightBand, params.DiscountBand)
+	ausPostShipping, err := c.CalculateAusPostShipping(zone, weightBand, params.DiscountBand)
 	if err != nil {
 		return nil, err
 	}
@@ -159,8 +427,13 @@ func (c *CalculatorConfig) CalculateUSAShipping(params CalculateUSAShippingParam
 		extraCover = c.CalculateExtraCover(params.ItemValueAUD, params.DiscountBand)
 	}
 
-	tariffDuties := c.CalculateTariffDuties(params.ItemValueAUD, coo)
-	zonosFees := c.CalculateZonosFees(tariffDuties)
+	// No duties to collect under de minimis, so no Zonos processing either
+	deMinimis := c.DeMinimisApplies(params.ItemValueAUD)
+	tariffDuties := c.tariffDutiesAtRate(params.ItemValueAUD, tariffRate)
+	var zonosFees float64
+	if zonos := c.zoneZonos(zone); zonos != nil && !deMinimis {
+		zonosFees = zonos.Fees(tariffDuties)
+	}
 
 	shippingSubtotal := ausPostShipping + extraCover
 	dutiesSubtotal := tariffDuties + zonosFees
@@ -169,12 +442,16 @@ func (c *CalculatorConfig) CalculateUSAShipping(params CalculateUSAShippingParam
 	return &ShippingResult{
 		Inputs: ShippingInputs{
 			ItemValueAUD:      params.ItemValueAUD,
-			WeightBand:        params.WeightBand,
+			WeightBand:        weightBand,
 			BrandName:         params.BrandName,
 			CountryOfOrigin:   coo,
 			TariffRate:        tariffRate,
 			IncludeExtraCover: params.IncludeExtraCover,
 			DiscountBand:      params.DiscountBand,
+			WeightBasis:       weightBasis,
+			VolumetricGrams:   volumetricGrams,
+
+			TariffRateOverridden: params.TariffRateOverride != nil,
 		},
 		Breakdown: ShippingBreakdown{
 			AusPostShipping:  ausPostShipping,
@@ -183,14 +460,21 @@ func (c *CalculatorConfig) CalculateUSAShipping(params CalculateUSAShippingParam
 			TariffDuties:     tariffDuties,
 			ZonosFees:        zonosFees,
 			DutiesSubtotal:   dutiesSubtotal,
+
+			DeMinimisThresholdAUD: c.DeMinimisAUD,
 		},
 		Total: round2(total),
 		Warnings: ShippingWarnings{
 			ExtraCoverRecommended: c.ShouldWarnExtraCover(params.ItemValueAUD, params.IncludeExtraCover),
+			DeMinimisApplied:      deMinimis,
+			COONotKnownForBrand:   !cooKnown,
 		},
 	}, nil
 }
 
+// MaxParcelGrams is the heaviest parcel any weight band covers (XLarge max)
+const MaxParcelGrams = 2000
+
 // GetWeightBandFromGrams returns the weight band for a given weight
 func GetWeightBandFromGrams(weightGrams int) string {
 	switch {
@@ -207,6 +491,56 @@ func GetWeightBandFromGrams(weightGrams int) string {
 	}
 }
 
+// VolumetricDivisor converts cubic centimetres to volumetric kilograms
+// (L×W×H / 5000), as used by Australia Post
+const VolumetricDivisor = 5000
+
+// VolumetricWeightGrams returns the volumetric weight of a parcel in grams,
+// or 0 unless all three dimensions are positive
+func VolumetricWeightGrams(lengthCm, widthCm, heightCm float64) int {
+	if lengthCm <= 0 || widthCm <= 0 || heightCm <= 0 {
+		return 0
+	}
+	return int(math.Ceil(lengthCm * widthCm * heightCm / VolumetricDivisor * 1000))
+}
+
+// chargeableWeightBand returns the heavier of band and the band for the
+// parcel's volumetric weight, which weight it came from ("actual" or
+// "volumetric") and the volumetric grams. Without dimensions, band is
+// returned unchanged with an empty basis. A volumetric weight over
+// MaxParcelGrams is an error, as an actual weight over it is.
+func chargeableWeightBand(band string, lengthCm, widthCm, heightCm float64) (string, string, int, error) {
+	volumetricGrams := VolumetricWeightGrams(lengthCm, widthCm, heightCm)
+	if volumetricGrams == 0 {
+		return band, "", 0, nil
+	}
+	if volumetricGrams > MaxParcelGrams {
+		return "", "", 0, fmt.Errorf("volumetric weight %dg is over the %dg limit of the largest weight band (XLarge)", volumetricGrams, MaxParcelGrams)
+	}
+	if volumetricBand := GetWeightBandFromGrams(volumetricGrams); weightBandRank(volumetricBand) > weightBandRank(band) {
+		return volumetricBand, "volumetric", volumetricGrams, nil
+	}
+	return band, "actual", volumetricGrams, nil
+}
+
+// weightBandOrder lists weight bands from lightest to heaviest
+var weightBandOrder = []string{"XSmall", "Small", "Medium", "Large", "XLarge"}
+
+// IsWeightBand reports whether band is one of the known weight band keys
+func IsWeightBand(band string) bool {
+	return weightBandRank(band) >= 0
+}
+
+// weightBandRank returns a band's position in weightBandOrder, or -1 if unknown
+func weightBandRank(band string) int {
+	for i, b := range weightBandOrder {
+		if b == band {
+			return i
+		}
+	}
+	return -1
+}
+
 // GetAvailableBrands returns all brand names sorted
 func (c *CalculatorConfig) GetAvailableBrands() []string {
 	brands := make([]string, 0, len(c.Brands))
@@ -231,8 +565,7 @@ func (c *CalculatorConfig) GetWeightBands() []WeightBandInfo {
 	bands := make([]WeightBandInfo, 0, len(zone.WeightBands))
 
 	// Order matters for display
-	order := []string{"XSmall", "Small", "Medium", "Large", "XLarge"}
-	for _, key := range order {
+	for _, key := range weightBandOrder {
 		if wb, ok := zone.WeightBands[key]; ok {
 			bands = append(bands, WeightBandInfo{
 				Key:       key,
@@ -275,13 +608,14 @@ func round2(val float64) float64 {
 
 // ZoneShippingResult holds calculation results for a single zone
 type ZoneShippingResult struct {
-	ZoneID      string            `json:"zoneId"`      // e.g., "1-New Zealand"
-	ZoneName    string            `json:"zoneName"`    // e.g., "New Zealand"
-	Inputs      ShippingInputs    `json:"inputs"`
-	Breakdown   ShippingBreakdown `json:"breakdown"`
-	Total       float64           `json:"totalShipping"`
-	Warnings    ShippingWarnings  `json:"warnings"`
-	HasTariffs  bool              `json:"hasTariffs"`  // Whether this zone applies tariffs
+	ZoneID       string            `json:"zoneId"`   // e.g., "1-New Zealand"
+	ZoneName     string            `json:"zoneName"` // e.g., "New Zealand"
+	Inputs       ShippingInputs    `json:"inputs"`
+	Breakdown    ShippingBreakdown `json:"breakdown"`
+	Total        float64           `json:"totalShipping"`
+	Warnings     ShippingWarnings  `json:"warnings"`
+	HasTariffs   bool              `json:"hasTariffs"`   // Whether this zone applies tariffs
+	ZonosApplied bool              `json:"zonosApplied"` // Whether Zonos fees were charged on the duties
 }
 
 // MultiZoneResult holds calculation results for all zones
@@ -297,31 +631,49 @@ type CalculateAllZonesParams struct {
 	CountryOfOrigin   string // optional override
 	IncludeExtraCover bool
 	DiscountBand      int
+
+	// Optional parcel dimensions, as for CalculateUSAShippingParams
+	LengthCm float64
+	WidthCm  float64
+	HeightCm float64
+
+	// TariffRateOverride, as for CalculateUSAShippingParams, applies to every
+	// zone with tariffs
+	TariffRateOverride *float64
 }
 
 // CalculateAllZones performs shipping calculation for all zones
 func (c *CalculatorConfig) CalculateAllZones(params CalculateAllZonesParams) (*MultiZoneResult, error) {
+	weightBand, weightBasis, volumetricGrams, err := chargeableWeightBand(params.WeightBand, params.LengthCm, params.WidthCm, params.HeightCm)
+	if err != nil {
+		return nil, err
+	}
+
 	// Determine country of origin
-	coo := params.CountryOfOrigin
-	if coo == "" {
-		coo = c.GetCountryOfOrigin(params.BrandName)
+	coo, cooKnown := c.ResolveCOO(params.BrandName, params.CountryOfOrigin)
+	cooTariffRate := c.GetTariffRate(coo)
+	if params.TariffRateOverride != nil {
+		if *params.TariffRateOverride < 0 || *params.TariffRateOverride > 1 {
+			return nil, fmt.Errorf("tariff rate override must be between 0 and 1, got %v", *params.TariffRateOverride)
+		}
+		cooTariffRate = *params.TariffRateOverride
 	}
 
 	// Get all zones in a consistent order
-	zoneOrder := []string{"1-New Zealand", "3-USA & Canada", "4-UK & Ireland"}
+	zoneOrder := []string{"1-New Zealand", "2-Asia", "3-USA & Canada", "4-UK & Ireland", "5-Europe"}
 	results := make([]ZoneShippingResult, 0, len(zoneOrder))
 
 	for _, zoneID := range zoneOrder {
-		_, ok := c.PostalZones[zoneID]
+		zone, ok := c.PostalZones[zoneID]
 		if !ok {
 			continue // Skip if zone not found
 		}
 
-		// Determine if this zone has tariffs (only USA)
-		hasTariffs := zoneID == "3-USA & Canada"
+		// Duties and Zonos are configured per zone (postal_zones)
+		hasTariffs := zone.HasTariffs
 
 		// Calculate components
-		ausPostShipping, err := c.CalculateAusPostShipping(zoneID, params.WeightBand, params.DiscountBand)
+		ausPostShipping, err := c.CalculateAusPostShipping(zoneID, weightBand, params.DiscountBand)
 		if err != nil {
 			return nil, fmt.Errorf("zone %s: %w", zoneID, err)
 		}
@@ -333,13 +685,19 @@ func (c *CalculatorConfig) CalculateAllZones(params CalculateAllZonesParams) (*M
 
 		shippingSubtotal := ausPostShipping + extraCover
 
-		// Calculate tariffs and duties (only for USA)
+		// Calculate tariffs and duties for zones that have them
 		var tariffDuties, zonosFees, dutiesSubtotal float64
 		var tariffRate float64
+		var deMinimisThreshold float64
+		deMinimis := hasTariffs && c.DeMinimisApplies(params.ItemValueAUD)
+		zonosApplied := hasTariffs && zone.Zonos != nil && !deMinimis
 		if hasTariffs {
-			tariffRate = c.GetTariffRate(coo)
-			tariffDuties = c.CalculateTariffDuties(params.ItemValueAUD, coo)
-			zonosFees = c.CalculateZonosFees(tariffDuties)
+			deMinimisThreshold = c.DeMinimisAUD
+			tariffRate = cooTariffRate
+			tariffDuties = c.tariffDutiesAtRate(params.ItemValueAUD, tariffRate)
+			if zonosApplied {
+				zonosFees = zone.Zonos.Fees(tariffDuties)
+			}
 			dutiesSubtotal = tariffDuties + zonosFees
 		}
 
@@ -356,12 +714,14 @@ func (c *CalculatorConfig) CalculateAllZones(params CalculateAllZonesParams) (*M
 			ZoneName: zoneName,
 			Inputs: ShippingInputs{
 				ItemValueAUD:      params.ItemValueAUD,
-				WeightBand:        params.WeightBand,
+				WeightBand:        weightBand,
 				BrandName:         params.BrandName,
 				CountryOfOrigin:   coo,
 				TariffRate:        tariffRate,
 				IncludeExtraCover: params.IncludeExtraCover,
 				DiscountBand:      params.DiscountBand,
+				WeightBasis:       weightBasis,
+				VolumetricGrams:   volumetricGrams,
 			},
 			Breakdown: ShippingBreakdown{
 				AusPostShipping:  ausPostShipping,
@@ -370,12 +730,17 @@ func (c *CalculatorConfig) CalculateAllZones(params CalculateAllZonesParams) (*M
 				TariffDuties:     tariffDuties,
 				ZonosFees:        zonosFees,
 				DutiesSubtotal:   dutiesSubtotal,
+
+				DeMinimisThresholdAUD: deMinimisThreshold,
 			},
 			Total: round2(total),
 			Warnings: ShippingWarnings{
 				ExtraCoverRecommended: c.ShouldWarnExtraCover(params.ItemValueAUD, params.IncludeExtraCover),
+				DeMinimisApplied:      deMinimis,
+				COONotKnownForBrand:   !cooKnown,
 			},
-			HasTariffs: hasTariffs,
+			HasTariffs:   hasTariffs,
+			ZonosApplied: zonosApplied,
 		})
 	}
 
@@ -383,3 +748,39 @@ func (c *CalculatorConfig) CalculateAllZones(params CalculateAllZonesParams) (*M
 		Zones: results,
 	}, nil
 }
+
+// Zone returns the result for zoneID, or nil if it wasn't calculated
+func (r *MultiZoneResult) Zone(zoneID string) *ZoneShippingResult {
+	for i := range r.Zones {
+		if r.Zones[i].ZoneID == zoneID {
+			return &r.Zones[i]
+		}
+	}
+	return nil
+}
+
+// countryZones maps ISO 3166 two-letter country codes to AusPost zones.
+// Countries in zones the calculator has no rates for are left out.
+var countryZones = map[string]string{
+	"NZ": "1-New Zealand",
+
+	"CN": "2-Asia", "HK": "2-Asia", "ID": "2-Asia", "IN": "2-Asia", "JP": "2-Asia",
+	"KR": "2-Asia", "MO": "2-Asia", "MY": "2-Asia", "PH": "2-Asia", "SG": "2-Asia",
+	"TH": "2-Asia", "TW": "2-Asia", "VN": "2-Asia",
+
+	"US": "3-USA & Canada", "CA": "3-USA & Canada",
+
+	"GB": "4-UK & Ireland", "IE": "4-UK & Ireland",
+
+	"AT": "5-Europe", "BE": "5-Europe", "CH": "5-Europe", "CZ": "5-Europe", "DE": "5-Europe",
+	"DK": "5-Europe", "ES": "5-Europe", "FI": "5-Europe", "FR": "5-Europe", "GR": "5-Europe",
+	"HU": "5-Europe", "IT": "5-Europe", "LU": "5-Europe", "NL": "5-Europe", "NO": "5-Europe",
+	"PL": "5-Europe", "PT": "5-Europe", "SE": "5-Europe",
+}
+
+// ZoneForCountry returns the AusPost zone a country code ships to. ok is
+// false for Australia and for countries with no zone in countryZones.
+func ZoneForCountry(countryCode string) (zoneID string, ok bool) {
+	zoneID, ok = countryZones[strings.ToUpper(strings.TrimSpace(countryCode))]
+	return zoneID, ok
+}
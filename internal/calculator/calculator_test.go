@@ -0,0 +1,236 @@
+package calculator_test
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/julienbonastre/ebay-helpers/internal/calculator"
+	"github.com/julienbonastre/ebay-helpers/internal/database"
+)
+
+// seededConfig loads the calculator config from a freshly seeded database,
+// as the server does at startup
+func seededConfig(t *testing.T) *calculator.CalculatorConfig {
+	t.Helper()
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.SeedInitialData(); err != nil {
+		t.Fatalf("SeedInitialData: %v", err)
+	}
+	calc, err := db.GetCalculatorConfig()
+	if err != nil {
+		t.Fatalf("GetCalculatorConfig: %v", err)
+	}
+	return calc
+}
+
+// TestCalculateUSAShippingReferenceValues locks in totals for the default
+// seed data. The figures were captured from the calculator, not copied from
+// the spreadsheet; if a change moves them, confirm the new figures against
+// the spreadsheet before updating them here.
+func TestCalculateUSAShippingReferenceValues(t *testing.T) {
+	calc := seededConfig(t)
+
+	tests := []struct {
+		name         string
+		params       calculator.CalculateUSAShippingParams
+		ausPost      float64
+		extraCover   float64
+		tariffDuties float64
+		zonosFees    float64
+		total        float64
+	}{
+		{
+			name:    "$80 Small China",
+			params:  calculator.CalculateUSAShippingParams{ItemValueAUD: 80, WeightBand: "Small", CountryOfOrigin: "China"},
+			ausPost: 29.58, extraCover: 0, tariffDuties: 16.00, zonosFees: 3.29, total: 48.87,
+		},
+		{
+			name:    "$300 Large India with extra cover",
+			params:  calculator.CalculateUSAShippingParams{ItemValueAUD: 300, WeightBand: "Large", CountryOfOrigin: "India", IncludeExtraCover: true},
+			ausPost: 56.66, extraCover: 8.00, tariffDuties: 150.00, zonosFees: 16.69, total: 231.35,
+		},
+		{
+			name:    "$150 Medium Australia with extra cover, discount band 3",
+			params:  calculator.CalculateUSAShippingParams{ItemValueAUD: 150, WeightBand: "Medium", CountryOfOrigin: "Australia", IncludeExtraCover: true, DiscountBand: 3},
+			ausPost: 34.44, extraCover: 1.20, tariffDuties: 15.00, zonosFees: 3.19, total: 53.83,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := calc.CalculateUSAShipping(tc.params)
+			if err != nil {
+				t.Fatalf("CalculateUSAShipping: %v", err)
+			}
+			b := result.Breakdown
+			for _, got := range []struct {
+				field     string
+				got, want float64
+			}{
+				{"ausPostShipping", b.AusPostShipping, tc.ausPost},
+				{"extraCover", b.ExtraCover, tc.extraCover},
+				{"tariffDuties", b.TariffDuties, tc.tariffDuties},
+				{"zonosFees", b.ZonosFees, tc.zonosFees},
+				{"total", result.Total, tc.total},
+			} {
+				if math.Abs(got.got-got.want) > 0.005 {
+					t.Errorf("%s = %.2f, want %.2f", got.field, got.got, got.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateUSAShippingVolumetricWeight(t *testing.T) {
+	calc := seededConfig(t)
+
+	tests := []struct {
+		name                  string
+		band                  string
+		length, width, height float64
+		wantBand, wantBasis   string
+		wantVolumetricGrams   int
+		wantErr               bool
+	}{
+		{name: "no dimensions", band: "Small", wantBand: "Small"},
+		{name: "volumetric dominates", band: "Small", length: 20, width: 20, height: 10, wantBand: "Medium", wantBasis: "volumetric", wantVolumetricGrams: 800},
+		{name: "actual dominates", band: "Large", length: 20, width: 20, height: 10, wantBand: "Large", wantBasis: "actual", wantVolumetricGrams: 800},
+		{name: "volumetric at the maximum", band: "XSmall", length: 20, width: 20, height: 25, wantBand: "XLarge", wantBasis: "volumetric", wantVolumetricGrams: 2000},
+		{name: "volumetric over the maximum", band: "XSmall", length: 30, width: 30, height: 30, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := calculator.CalculateUSAShippingParams{
+				ItemValueAUD: 100, WeightBand: tt.band, CountryOfOrigin: "China",
+				LengthCm: tt.length, WidthCm: tt.width, HeightCm: tt.height,
+			}
+			result, err := calc.CalculateUSAShipping(params)
+			_, allZonesErr := calc.CalculateAllZones(calculator.CalculateAllZonesParams{
+				ItemValueAUD: 100, WeightBand: tt.band, CountryOfOrigin: "China",
+				LengthCm: tt.length, WidthCm: tt.width, HeightCm: tt.height,
+			})
+			if tt.wantErr {
+				if err == nil || allZonesErr == nil {
+					t.Fatalf("errors = %v, %v; want both to reject the volumetric weight", err, allZonesErr)
+				}
+				return
+			}
+			if err != nil || allZonesErr != nil {
+				t.Fatalf("errors = %v, %v", err, allZonesErr)
+			}
+
+			in := result.Inputs
+			if in.WeightBand != tt.wantBand || in.WeightBasis != tt.wantBasis || in.VolumetricGrams != tt.wantVolumetricGrams {
+				t.Errorf("inputs = band %q basis %q volumetric %dg, want %q %q %dg",
+					in.WeightBand, in.WeightBasis, in.VolumetricGrams, tt.wantBand, tt.wantBasis, tt.wantVolumetricGrams)
+			}
+
+			// Charged as if the chargeable band had been given directly
+			direct, err := calc.CalculateUSAShipping(calculator.CalculateUSAShippingParams{ItemValueAUD: 100, WeightBand: tt.wantBand, CountryOfOrigin: "China"})
+			if err != nil {
+				t.Fatalf("CalculateUSAShipping(%s): %v", tt.wantBand, err)
+			}
+			if result.Total != direct.Total {
+				t.Errorf("total = %v, want the %s total %v", result.Total, tt.wantBand, direct.Total)
+			}
+		})
+	}
+}
+
+func TestCalculateAllZonesAsiaAndEurope(t *testing.T) {
+	calc := seededConfig(t)
+	result, err := calc.CalculateAllZones(calculator.CalculateAllZonesParams{ItemValueAUD: 300, WeightBand: "Medium", CountryOfOrigin: "China"})
+	if err != nil {
+		t.Fatalf("CalculateAllZones: %v", err)
+	}
+
+	var order []string
+	for _, z := range result.Zones {
+		order = append(order, z.ZoneID)
+	}
+	wantOrder := []string{"1-New Zealand", "2-Asia", "3-USA & Canada", "4-UK & Ireland", "5-Europe"}
+	if len(order) != len(wantOrder) {
+		t.Fatalf("zones = %v, want %v", order, wantOrder)
+	}
+	for i := range wantOrder {
+		if order[i] != wantOrder[i] {
+			t.Fatalf("zones = %v, want %v", order, wantOrder)
+		}
+	}
+
+	for _, zoneID := range []string{"2-Asia", "5-Europe"} {
+		z := result.Zone(zoneID)
+		if z.HasTariffs || z.ZonosApplied || z.Breakdown.DutiesSubtotal != 0 {
+			t.Errorf("%s: hasTariffs %v, zonos %v, duties %v; want no duties", zoneID, z.HasTariffs, z.ZonosApplied, z.Breakdown.DutiesSubtotal)
+		}
+		postage, err := calc.CalculateAusPostShipping(zoneID, "Medium", 0)
+		if err != nil {
+			t.Fatalf("CalculateAusPostShipping(%s): %v", zoneID, err)
+		}
+		if postage <= 0 || z.Total != postage {
+			t.Errorf("%s total = %v, want its postage %v", zoneID, z.Total, postage)
+		}
+	}
+	if usa := result.Zone("3-USA & Canada"); !usa.HasTariffs || usa.Breakdown.TariffDuties == 0 {
+		t.Errorf("USA: hasTariffs %v, duties %v; want tariffs charged", usa.HasTariffs, usa.Breakdown.TariffDuties)
+	}
+}
+
+func TestResolveCOOSecondaryCountries(t *testing.T) {
+	calc := seededConfig(t)
+
+	primary, secondary := calc.BrandCOOs("Aje")
+	if primary != "China" || !reflect.DeepEqual(secondary, []string{"India", "Malaysia"}) {
+		t.Errorf("BrandCOOs(Aje) = %q, %v; want China, [India Malaysia]", primary, secondary)
+	}
+
+	tests := []struct {
+		brand, declared string
+		wantCOO         string
+		wantKnown       bool
+	}{
+		{"Aje", "", "China", true},
+		{"Aje", "China", "China", true},
+		{"Aje", "india", "India", true},
+		{"Aje", "Malaysia", "Malaysia", true},
+		{"Aje", "Vietnam", "Vietnam", false},
+		{"Innika Choo [Bali]", "Vietnam", "Vietnam", true},
+		{"Innika Choo [Bali]", "MALAYSIA", "Malaysia", true},
+		{"Innika Choo [Bali]", "India", "India", false},
+		{"Unmapped Label", "Peru", "Peru", true},
+	}
+	for _, tt := range tests {
+		coo, known := calc.ResolveCOO(tt.brand, tt.declared)
+		if coo != tt.wantCOO || known != tt.wantKnown {
+			t.Errorf("ResolveCOO(%q, %q) = %q, %v; want %q, %v", tt.brand, tt.declared, coo, known, tt.wantCOO, tt.wantKnown)
+		}
+	}
+
+	quote := func(coo string) *calculator.ShippingResult {
+		t.Helper()
+		result, err := calc.CalculateUSAShipping(calculator.CalculateUSAShippingParams{
+			ItemValueAUD: 300, BrandName: "Aje", CountryOfOrigin: coo, WeightBand: "Medium",
+		})
+		if err != nil {
+			t.Fatalf("CalculateUSAShipping(%q): %v", coo, err)
+		}
+		return result
+	}
+	china, india, vietnam := quote(""), quote("India"), quote("Vietnam")
+	if india.Inputs.CountryOfOrigin != "India" || india.Warnings.COONotKnownForBrand {
+		t.Errorf("India: calculated with %q, unknown warning %v", india.Inputs.CountryOfOrigin, india.Warnings.COONotKnownForBrand)
+	}
+	if india.Breakdown.TariffDuties <= china.Breakdown.TariffDuties {
+		t.Errorf("India duties %.2f should exceed China's %.2f", india.Breakdown.TariffDuties, china.Breakdown.TariffDuties)
+	}
+	if !vietnam.Warnings.COONotKnownForBrand || vietnam.Inputs.CountryOfOrigin != "Vietnam" {
+		t.Errorf("Vietnam: calculated with %q, unknown warning %v", vietnam.Inputs.CountryOfOrigin, vietnam.Warnings.COONotKnownForBrand)
+	}
+}
@@ -0,0 +1,131 @@
+package calculator
+
+import (
+	"strings"
+	"unicode"
+)
+
+// countryAliases maps spellings seen in eBay item specifics to the canonical
+// country names used by the tariff and brand tables. Keys are in the form
+// countryKey produces: lower case, letters and spaces only.
+var countryAliases = map[string]string{
+	// United States
+	"united states":            "United States",
+	"united states of america": "United States",
+	"usa":                      "United States",
+	"us":                       "United States",
+	"america":                  "United States",
+
+	// United Kingdom
+	"united kingdom":                  "United Kingdom",
+	"uk":                              "United Kingdom",
+	"great britain":                   "United Kingdom",
+	"gb":                              "United Kingdom",
+	"britain":                         "United Kingdom",
+	"england":                         "United Kingdom",
+	"scotland":                        "United Kingdom",
+	"wales":                           "United Kingdom",
+	"northern ireland":                "United Kingdom",
+	"united kingdom of great britain": "United Kingdom",
+
+	// China and its special administrative regions, which have their own rates
+	"china":                     "China",
+	"prc":                       "China",
+	"peoples republic of china": "China",
+	"mainland china":            "China",
+	"cn":                        "China",
+	"hong kong":                 "Hong Kong",
+	"hong kong sar":             "Hong Kong",
+	"hong kong sar china":       "Hong Kong",
+	"hong kong china":           "Hong Kong",
+	"hk":                        "Hong Kong",
+	"macau":                     "Macau",
+	"macao":                     "Macau",
+	"macau sar":                 "Macau",
+	"macao sar":                 "Macau",
+	"taiwan":                    "Taiwan",
+	"republic of china":         "Taiwan",
+	"taiwan roc":                "Taiwan",
+
+	// Asia
+	"vietnam":            "Vietnam",
+	"viet nam":           "Vietnam",
+	"south korea":        "South Korea",
+	"korea":              "South Korea",
+	"korea south":        "South Korea",
+	"republic of korea":  "South Korea",
+	"korea republic of":  "South Korea",
+	"japan":              "Japan",
+	"india":              "India",
+	"indonesia":          "Indonesia",
+	"malaysia":           "Malaysia",
+	"thailand":           "Thailand",
+	"philippines":        "Philippines",
+	"bangladesh":         "Bangladesh",
+	"cambodia":           "Cambodia",
+	"sri lanka":          "Sri Lanka",
+	"pakistan":           "Pakistan",
+	"turkey":             "Turkey",
+	"turkiye":            "Turkey",
+	"türkiye":            "Turkey",
+	"republic of turkey": "Turkey",
+
+	// Oceania and the Americas
+	"australia":   "Australia",
+	"aus":         "Australia",
+	"au":          "Australia",
+	"new zealand": "New Zealand",
+	"nz":          "New Zealand",
+	"mexico":      "Mexico",
+	"canada":      "Canada",
+
+	// Europe
+	"italy":       "Italy",
+	"france":      "France",
+	"germany":     "Germany",
+	"spain":       "Spain",
+	"portugal":    "Portugal",
+	"netherlands": "Netherlands",
+	"holland":     "Netherlands",
+}
+
+// countryKey reduces a country name to lower-case letters separated by
+// single spaces, so "U.S.A.", "People's Republic of China", "Hong Kong (SAR)"
+// and "hong  kong" compare by their letters alone
+func countryKey(raw string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(raw) {
+		switch {
+		case r == '.' || r == '\'' || r == '’':
+			// Dropped outright so U.S.A. and People's join up
+		case unicode.IsLetter(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// NormalizeCountry returns the canonical name for a country of origin as
+// written in item specifics or reference data, e.g. "USA" and "U.S.A." both
+// become "United States". Names with no alias are returned trimmed but
+// otherwise unchanged.
+func NormalizeCountry(raw string) string {
+	trimmed := strings.TrimSpace(raw)
+	if canonical, ok := countryAliases[countryKey(trimmed)]; ok {
+		return canonical
+	}
+	return trimmed
+}
+
+// SameCountry reports whether two country names refer to the same country
+// once normalized, ignoring case
+func SameCountry(a, b string) bool {
+	return strings.EqualFold(NormalizeCountry(a), NormalizeCountry(b))
+}
@@ -0,0 +1,68 @@
+package calculator_test
+
+import (
+	"testing"
+
+	"github.com/julienbonastre/ebay-helpers/internal/calculator"
+)
+
+func TestNormalizeCountry(t *testing.T) {
+	tests := []struct {
+		raw, want string
+	}{
+		{"United States", "United States"},
+		{"USA", "United States"},
+		{"U.S.A.", "United States"},
+		{"us", "United States"},
+		{"United States of America", "United States"},
+		{"UK", "United Kingdom"},
+		{"Great Britain", "United Kingdom"},
+		{"GB", "United Kingdom"},
+		{"PRC", "China"},
+		{"People's Republic of China", "China"},
+		{"People’s Republic of China", "China"},
+		{"  china ", "China"},
+		{"Hong Kong (SAR)", "Hong Kong"},
+		{"Hong Kong SAR, China", "Hong Kong"},
+		{"hong  kong", "Hong Kong"},
+		{"Macao", "Macau"},
+		{"Viet Nam", "Vietnam"},
+		{"Korea, Republic of", "South Korea"},
+		{"Türkiye", "Turkey"},
+		{"Holland", "Netherlands"},
+		// No alias: trimmed, otherwise as written
+		{" Peru ", "Peru"},
+		{"Unknown", "Unknown"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := calculator.NormalizeCountry(tt.raw); got != tt.want {
+			t.Errorf("NormalizeCountry(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestAliasedCountriesUseTheirTariffRate(t *testing.T) {
+	calc := seededConfig(t)
+	china := calc.GetTariffRate("China")
+	india := calc.GetTariffRate("India")
+	if china == india {
+		t.Fatalf("China and India share the rate %v; pick countries with different rates", china)
+	}
+	for _, raw := range []string{"PRC", "People's Republic of China", "china", "CN"} {
+		if got := calc.GetTariffRate(raw); got != china {
+			t.Errorf("GetTariffRate(%q) = %v, want China's %v", raw, got, china)
+		}
+		if got := calc.TariffCountry(raw); got != "China" {
+			t.Errorf("TariffCountry(%q) = %q, want China", raw, got)
+		}
+	}
+	if got := calc.GetTariffRate(" INDIA "); got != india {
+		t.Errorf("GetTariffRate(\" INDIA \") = %v, want India's %v", got, india)
+	}
+
+	// A brand's declared COO is compared after normalizing
+	if coo, known := calc.ResolveCOO("Aje", "PRC"); coo != "China" || !known {
+		t.Errorf("ResolveCOO(Aje, PRC) = %q, %v; want China, true", coo, known)
+	}
+}
@@ -0,0 +1,31 @@
+package calculator_test
+
+import (
+	"testing"
+
+	"github.com/julienbonastre/ebay-helpers/internal/calculator"
+)
+
+func TestToAUD(t *testing.T) {
+	calc := &calculator.CalculatorConfig{CurrencyRates: map[string]float64{"USD": 1.5}}
+
+	tests := []struct {
+		currency string
+		want     float64
+		wantOK   bool
+	}{
+		{"", 10, true},
+		{"AUD", 10, true},
+		{"aud", 10, true},
+		{"USD", 15, true},
+		{"usd", 15, true},
+		{" Usd ", 15, true},
+		{"GBP", 0, false}, // No rate: not passed off as 10 AUD
+	}
+	for _, tt := range tests {
+		got, ok := calc.ToAUD(10, tt.currency)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ToAUD(10, %q) = %v, %v, want %v, %v", tt.currency, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
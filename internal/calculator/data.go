@@ -5,6 +5,12 @@ type PostalZone struct {
 	HandlingFee   float64                   `json:"handlingFee"`
 	DiscountBands map[int]float64           `json:"discountBands"`
 	WeightBands   map[string]WeightBand     `json:"weightBands"`
+
+	// HasTariffs is set for zones whose parcels attract import duties. Zonos
+	// is the fee structure Zonos charges for collecting them; nil when Zonos
+	// isn't used for the zone.
+	HasTariffs bool       `json:"hasTariffs"`
+	Zonos      *ZonosData `json:"zonos,omitempty"`
 }
 
 // WeightBand represents a weight category with pricing
@@ -32,6 +38,11 @@ type ZonosData struct {
 	FlatFeeAUD              float64 `json:"flatFeeAUD"`
 }
 
+// Fees returns the Zonos processing fees on a duty amount
+func (z ZonosData) Fees(tariffAmount float64) float64 {
+	return round2(tariffAmount*z.ProcessingChargePercent + z.FlatFeeAUD)
+}
+
 // ExtraCoverData holds insurance pricing info
 type ExtraCoverData struct {
 	BasePricePer100     float64         `json:"basePricePer100"`
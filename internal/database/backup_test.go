@@ -0,0 +1,66 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackupTo(t *testing.T) {
+	db := openTestDB(t)
+	acc := createTestAccount(t, db, "seller_sandbox_EBAY_AU")
+	if _, err := db.Exec(`
+		INSERT INTO inventory_items (account_id, sku, title, data) VALUES (?, 'SKU-1', 'Wool hat', '{}')
+	`, acc.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`
+		INSERT INTO sessions (session_id, data, expires_at) VALUES ('sess', 'token', ?)
+	`, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateOAuthState("state", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "backup.db")
+	if err := db.BackupTo(path); err != nil {
+		t.Fatalf("BackupTo: %v", err)
+	}
+	if err := db.BackupTo(path); err == nil {
+		t.Error("BackupTo over an existing file succeeded, want error")
+	}
+
+	backup, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open backup: %v", err)
+	}
+	defer backup.Close()
+
+	got, err := backup.GetAccountByKey(acc.AccountKey)
+	if err != nil || got == nil {
+		t.Fatalf("account missing from backup: %v, %v", got, err)
+	}
+	var title string
+	if err := backup.QueryRow(`SELECT title FROM inventory_items WHERE sku = 'SKU-1'`).Scan(&title); err != nil {
+		t.Fatalf("inventory item missing from backup: %v", err)
+	}
+	if title != "Wool hat" {
+		t.Errorf("title = %q, want %q", title, "Wool hat")
+	}
+	for _, table := range backupExcludedTables {
+		var n int
+		if err := backup.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if n != 0 {
+			t.Errorf("%s has %d rows in backup, want 0", table, n)
+		}
+	}
+
+	// The live database keeps its sessions
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sessions`).Scan(&n); err != nil || n != 1 {
+		t.Errorf("live sessions = %d (%v), want 1", n, err)
+	}
+}
@@ -0,0 +1,21 @@
+package database
+
+// AddEbayCallCount adds calls eBay API calls to the count for day (an eBay
+// quota day, YYYY-MM-DD). The upsert is atomic, so concurrent callers never
+// lose a count.
+func (db *DB) AddEbayCallCount(day string, calls int64) error {
+	_, err := db.Exec(`
+		INSERT INTO ebay_call_usage (day, calls) VALUES (?, ?)
+		ON CONFLICT(day) DO UPDATE SET calls = calls + excluded.calls, updated_at = CURRENT_TIMESTAMP
+	`, day, calls)
+	return err
+}
+
+// GetEbayCallCount returns the number of eBay API calls counted for day
+func (db *DB) GetEbayCallCount(day string) (int64, error) {
+	var calls int64
+	err := db.QueryRow(`
+		SELECT COALESCE(SUM(calls), 0) FROM ebay_call_usage WHERE day = ?
+	`, day).Scan(&calls)
+	return calls, err
+}
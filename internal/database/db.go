@@ -3,12 +3,16 @@ package database
 import (
 	"database/sql"
 	_ "embed"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/julienbonastre/ebay-helpers/internal/calculator"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 //go:embed schema.sql
@@ -102,6 +106,33 @@ var (
 				"XLarge": {Label: "XLarge [1.5kg - 2kg]", MaxWeight: 2000, BasePrice: 39.90},
 			},
 		},
+		// Asia and Europe use the same band structure as the other zones
+		"2-Asia": {
+			HandlingFee: 0.02,
+			DiscountBands: map[int]float64{
+				0: 0, 1: 0.05, 2: 0.15, 3: 0.20, 4: 0.25, 5: 0.30,
+			},
+			WeightBands: map[string]calculator.WeightBand{
+				"XSmall": {Label: "XSmall [< 250g]", MaxWeight: 250, BasePrice: 19.40},
+				"Small":  {Label: "Small [250 - 500g]", MaxWeight: 500, BasePrice: 24.45},
+				"Medium": {Label: "Medium [500 - 1kg]", MaxWeight: 1000, BasePrice: 34.60},
+				"Large":  {Label: "Large [1 - 1.5kg]", MaxWeight: 1500, BasePrice: 44.70},
+				"XLarge": {Label: "XLarge [1.5kg - 2kg]", MaxWeight: 2000, BasePrice: 54.85},
+			},
+		},
+		"5-Europe": {
+			HandlingFee: 0.02,
+			DiscountBands: map[int]float64{
+				0: 0, 1: 0.05, 2: 0.15, 3: 0.20, 4: 0.25, 5: 0.30,
+			},
+			WeightBands: map[string]calculator.WeightBand{
+				"XSmall": {Label: "XSmall [< 250g]", MaxWeight: 250, BasePrice: 28.90},
+				"Small":  {Label: "Small [250 - 500g]", MaxWeight: 500, BasePrice: 36.15},
+				"Medium": {Label: "Medium [500 - 1kg]", MaxWeight: 1000, BasePrice: 50.75},
+				"Large":  {Label: "Large [1 - 1.5kg]", MaxWeight: 1500, BasePrice: 65.30},
+				"XLarge": {Label: "XLarge [1.5kg - 2kg]", MaxWeight: 2000, BasePrice: 79.90},
+			},
+		},
 	}
 
 	// Zonos processing fees
@@ -138,6 +169,8 @@ type Account struct {
 	LastExportAt  *time.Time `json:"lastExportAt,omitempty"`
 	CreatedAt     time.Time  `json:"createdAt"`
 	UpdatedAt     time.Time  `json:"updatedAt"`
+
+	DeletedAt *time.Time `json:"deletedAt,omitempty"` // Set when soft-deleted
 }
 
 // SyncHistory represents a sync operation record
@@ -150,6 +183,16 @@ type SyncHistory struct {
 	ErrorMessage string     `json:"errorMessage,omitempty"`
 	StartedAt    time.Time  `json:"startedAt"`
 	CompletedAt  *time.Time `json:"completedAt,omitempty"`
+
+	// Details breaks ItemsSynced down per resource (e.g. "offers"), so a
+	// partial sync shows what succeeded and what failed
+	Details map[string]SyncResourceDetail `json:"details,omitempty"`
+}
+
+// SyncResourceDetail is the outcome of syncing one resource type
+type SyncResourceDetail struct {
+	Count int    `json:"count"`
+	Error string `json:"error,omitempty"`
 }
 
 // Open opens or creates the database
@@ -169,9 +212,28 @@ func Open(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to create schema: %w", err)
 	}
 
+	// Bring databases from older versions up to date
+	if err := migrate(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
+	// Fail fast if anything is still missing
+	if err := verifySchema(db); err != nil {
+		return nil, err
+	}
+
 	return &DB{db}, nil
 }
 
+// CheckConnection runs a trivial query to confirm the database is usable
+func (db *DB) CheckConnection() error {
+	var one int
+	if err := db.QueryRow("SELECT 1").Scan(&one); err != nil {
+		return fmt.Errorf("database check failed: %w", err)
+	}
+	return nil
+}
+
 // GetOrCreateAccount gets an account by key or creates it if it doesn't exist
 func (db *DB) GetOrCreateAccount(accountKey, displayName, environment, marketplaceID string) (*Account, error) {
 	// Try to get existing
@@ -227,7 +289,8 @@ func (db *DB) UpdateAccountWithEbayInfo(accountID int64, ebayUserID, ebayUsernam
 	return err
 }
 
-// GetOrCreateAccountFromEbay gets or creates an account using eBay user info
+// GetOrCreateAccountFromEbay gets or creates an account using eBay user info.
+// Signing in as a soft-deleted account reactivates it.
 func (db *DB) GetOrCreateAccountFromEbay(ebayUserID, ebayUsername, environment, marketplaceID string) (*Account, error) {
 	accountKey := ebayUsername + "_" + environment + "_" + marketplaceID
 	displayName := ebayUsername
@@ -241,12 +304,12 @@ func (db *DB) GetOrCreateAccountFromEbay(ebayUserID, ebayUsername, environment,
 	var acc Account
 	err := db.QueryRow(`
 		SELECT id, account_key, display_name, COALESCE(ebay_user_id, ''), COALESCE(ebay_username, ''),
-		       environment, marketplace_id, last_export_at, created_at, updated_at
+		       environment, marketplace_id, last_export_at, created_at, updated_at, deleted_at
 		FROM accounts
 		WHERE ebay_user_id = ? AND environment = ? AND marketplace_id = ?
 	`, ebayUserID, environment, marketplaceID).Scan(&acc.ID, &acc.AccountKey, &acc.DisplayName,
 		&acc.EbayUserID, &acc.EbayUsername, &acc.Environment, &acc.MarketplaceID,
-		&acc.LastExportAt, &acc.CreatedAt, &acc.UpdatedAt)
+		&acc.LastExportAt, &acc.CreatedAt, &acc.UpdatedAt, &acc.DeletedAt)
 
 	if err == nil {
 		// Update username if it changed
@@ -254,6 +317,12 @@ func (db *DB) GetOrCreateAccountFromEbay(ebayUserID, ebayUsername, environment,
 			_ = db.UpdateAccountWithEbayInfo(acc.ID, ebayUserID, ebayUsername)
 			acc.EbayUsername = ebayUsername
 		}
+		if acc.DeletedAt != nil {
+			if err := db.SetAccountDeleted(acc.AccountKey, false); err != nil {
+				return nil, fmt.Errorf("failed to reactivate account: %w", err)
+			}
+			acc.DeletedAt = nil
+		}
 		return &acc, nil
 	}
 
@@ -261,8 +330,10 @@ func (db *DB) GetOrCreateAccountFromEbay(ebayUserID, ebayUsername, environment,
 		return nil, err
 	}
 
-	// Create new or update if account_key already exists
-	result, err := db.Exec(`
+	// Create new or update if account_key already exists. LastInsertId isn't
+	// the updated row's ID when the key exists, so the ID is returned instead.
+	var id int64
+	err = db.QueryRow(`
 		INSERT INTO accounts (account_key, display_name, ebay_user_id, ebay_username, environment, marketplace_id)
 		VALUES (?, ?, ?, ?, ?, ?)
 		ON CONFLICT(account_key) DO UPDATE SET
@@ -271,17 +342,14 @@ func (db *DB) GetOrCreateAccountFromEbay(ebayUserID, ebayUsername, environment,
 			ebay_username = excluded.ebay_username,
 			environment = excluded.environment,
 			marketplace_id = excluded.marketplace_id,
+			deleted_at = NULL,
 			updated_at = CURRENT_TIMESTAMP
-	`, accountKey, displayName, ebayUserID, ebayUsername, environment, marketplaceID)
+		RETURNING id
+	`, accountKey, displayName, ebayUserID, ebayUsername, environment, marketplaceID).Scan(&id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create/update account: %w", err)
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		return nil, err
-	}
-
 	acc.ID = id
 	acc.AccountKey = accountKey
 	acc.DisplayName = displayName
@@ -306,14 +374,16 @@ func (db *DB) UpdateLastExport(accountID int64) error {
 	return err
 }
 
-// GetAccounts returns all tracked accounts (that have exported data)
-func (db *DB) GetAccounts() ([]Account, error) {
+// GetAccounts returns all tracked accounts (that have exported data).
+// Soft-deleted accounts are left out unless includeDeleted is set.
+func (db *DB) GetAccounts(includeDeleted bool) ([]Account, error) {
 	rows, err := db.Query(`
 		SELECT id, account_key, display_name, COALESCE(ebay_user_id, ''), COALESCE(ebay_username, ''),
-		       environment, marketplace_id, last_export_at, created_at, updated_at
+		       environment, marketplace_id, last_export_at, created_at, updated_at, deleted_at
 		FROM accounts
+		WHERE ? OR deleted_at IS NULL
 		ORDER BY last_export_at DESC, created_at DESC
-	`)
+	`, includeDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -323,7 +393,7 @@ func (db *DB) GetAccounts() ([]Account, error) {
 	for rows.Next() {
 		var acc Account
 		err := rows.Scan(&acc.ID, &acc.AccountKey, &acc.DisplayName, &acc.EbayUserID, &acc.EbayUsername,
-			&acc.Environment, &acc.MarketplaceID, &acc.LastExportAt, &acc.CreatedAt, &acc.UpdatedAt)
+			&acc.Environment, &acc.MarketplaceID, &acc.LastExportAt, &acc.CreatedAt, &acc.UpdatedAt, &acc.DeletedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -332,16 +402,17 @@ func (db *DB) GetAccounts() ([]Account, error) {
 	return accounts, rows.Err()
 }
 
-// GetAccountByKey retrieves an account by its unique key
+// GetAccountByKey retrieves an account by its unique key, including
+// soft-deleted accounts (check DeletedAt)
 func (db *DB) GetAccountByKey(accountKey string) (*Account, error) {
 	var acc Account
 	err := db.QueryRow(`
 		SELECT id, account_key, display_name, COALESCE(ebay_user_id, ''), COALESCE(ebay_username, ''),
-		       environment, marketplace_id, last_export_at, created_at, updated_at
+		       environment, marketplace_id, last_export_at, created_at, updated_at, deleted_at
 		FROM accounts
 		WHERE account_key = ?
 	`, accountKey).Scan(&acc.ID, &acc.AccountKey, &acc.DisplayName, &acc.EbayUserID, &acc.EbayUsername,
-		&acc.Environment, &acc.MarketplaceID, &acc.LastExportAt, &acc.CreatedAt, &acc.UpdatedAt)
+		&acc.Environment, &acc.MarketplaceID, &acc.LastExportAt, &acc.CreatedAt, &acc.UpdatedAt, &acc.DeletedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -351,6 +422,18 @@ func (db *DB) GetAccountByKey(accountKey string) (*Account, error) {
 	return &acc, nil
 }
 
+// SetAccountDeleted soft-deletes (deleted=true) or reactivates an account.
+// Only the deleted_at marker changes; sync history and synced data are kept.
+func (db *DB) SetAccountDeleted(accountKey string, deleted bool) error {
+	_, err := db.Exec(`
+		UPDATE accounts
+		SET deleted_at = CASE WHEN ? THEN COALESCE(deleted_at, CURRENT_TIMESTAMP) ELSE NULL END,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE account_key = ?
+	`, deleted, accountKey)
+	return err
+}
+
 // CreateSyncHistory creates a new sync history record
 func (db *DB) CreateSyncHistory(sh *SyncHistory) error {
 	result, err := db.Exec(`
@@ -371,49 +454,92 @@ func (db *DB) CreateSyncHistory(sh *SyncHistory) error {
 
 // UpdateSyncHistory updates a sync history record
 func (db *DB) UpdateSyncHistory(sh *SyncHistory) error {
+	var details interface{} // NULL when there's no breakdown
+	if len(sh.Details) > 0 {
+		data, err := json.Marshal(sh.Details)
+		if err != nil {
+			return fmt.Errorf("failed to marshal sync details: %w", err)
+		}
+		details = string(data)
+	}
+
 	_, err := db.Exec(`
 		UPDATE sync_history
-		SET status = ?, items_synced = ?, error_message = ?, completed_at = ?
+		SET status = ?, items_synced = ?, error_message = ?, completed_at = ?, details = ?
 		WHERE id = ?
-	`, sh.Status, sh.ItemsSynced, sh.ErrorMessage, sh.CompletedAt, sh.ID)
+	`, sh.Status, sh.ItemsSynced, sh.ErrorMessage, sh.CompletedAt, details, sh.ID)
 	return err
 }
 
-// GetSyncHistory returns sync history for an account
-func (db *DB) GetSyncHistory(accountID int64, limit int) ([]SyncHistory, error) {
+// SyncHistoryQuery filters and pages GetSyncHistory. Empty SyncType and
+// Status match everything.
+type SyncHistoryQuery struct {
+	SyncType string // "export" or "import"
+	Status   string // "success", "partial" or "failed"
+	Limit    int
+	Offset   int
+}
+
+// GetSyncHistory returns one page of an account's sync history, newest first,
+// and the total number of entries matching the filters
+func (db *DB) GetSyncHistory(accountID int64, query SyncHistoryQuery) ([]SyncHistory, int, error) {
+	where := " WHERE account_id = ?"
+	args := []interface{}{accountID}
+	if query.SyncType != "" {
+		where += " AND sync_type = ?"
+		args = append(args, query.SyncType)
+	}
+	if query.Status != "" {
+		where += " AND status = ?"
+		args = append(args, query.Status)
+	}
+
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sync_history"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count sync history: %w", err)
+	}
+
 	rows, err := db.Query(`
-		SELECT id, account_id, sync_type, status, items_synced, error_message, started_at, completed_at
-		FROM sync_history
-		WHERE account_id = ?
-		ORDER BY started_at DESC
-		LIMIT ?
-	`, accountID, limit)
+		SELECT id, account_id, sync_type, status, items_synced, error_message, started_at, completed_at,
+		       COALESCE(details, '')
+		FROM sync_history`+where+`
+		ORDER BY started_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, append(args, query.Limit, query.Offset)...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
-	var history []SyncHistory
+	history := []SyncHistory{}
 	for rows.Next() {
 		var sh SyncHistory
+		var details string
 		err := rows.Scan(&sh.ID, &sh.AccountID, &sh.SyncType, &sh.Status,
-			&sh.ItemsSynced, &sh.ErrorMessage, &sh.StartedAt, &sh.CompletedAt)
+			&sh.ItemsSynced, &sh.ErrorMessage, &sh.StartedAt, &sh.CompletedAt, &details)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
+		}
+		if details != "" {
+			if err := json.Unmarshal([]byte(details), &sh.Details); err != nil {
+				return nil, 0, fmt.Errorf("failed to parse details for sync %d: %w", sh.ID, err)
+			}
 		}
 		history = append(history, sh)
 	}
-	return history, rows.Err()
+	return history, total, rows.Err()
 }
 
 // BrandCOOMapping represents a brand to country of origin mapping
 type BrandCOOMapping struct {
-	ID         int64     `json:"id"`
-	BrandName  string    `json:"brandName"`
-	PrimaryCOO string    `json:"primaryCoo"`
-	Notes      string    `json:"notes,omitempty"`
-	CreatedAt  time.Time `json:"createdAt"`
-	UpdatedAt  time.Time `json:"updatedAt"`
+	ID          int64     `json:"id"`
+	BrandName   string    `json:"brandName"`
+	PrimaryCOO  string    `json:"primaryCoo"`
+	Notes       string    `json:"notes,omitempty"`
+	BrandType   string    `json:"brandType,omitempty"` // e.g. "Hats", "Sneakers"
+	AutoCreated bool      `json:"autoCreated"`         // Added by enrichment and not yet reviewed
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
 }
 
 // TariffRate represents a tariff rate by country
@@ -718,7 +844,8 @@ func (db *DB) DeleteCredential(id int64) error {
 // GetAllBrandCOOMappings returns all brand-COO mappings
 func (db *DB) GetAllBrandCOOMappings() ([]BrandCOOMapping, error) {
 	rows, err := db.Query(`
-		SELECT id, brand_name, primary_coo, COALESCE(notes, ''), created_at, updated_at
+		SELECT id, brand_name, primary_coo, COALESCE(notes, ''), COALESCE(brand_type, ''),
+		       COALESCE(auto_created, 0), created_at, updated_at
 		FROM brand_coo_mappings
 		ORDER BY brand_name
 	`)
@@ -730,7 +857,7 @@ func (db *DB) GetAllBrandCOOMappings() ([]BrandCOOMapping, error) {
 	var mappings []BrandCOOMapping
 	for rows.Next() {
 		var m BrandCOOMapping
-		err := rows.Scan(&m.ID, &m.BrandName, &m.PrimaryCOO, &m.Notes, &m.CreatedAt, &m.UpdatedAt)
+		err := rows.Scan(&m.ID, &m.BrandName, &m.PrimaryCOO, &m.Notes, &m.BrandType, &m.AutoCreated, &m.CreatedAt, &m.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -754,24 +881,41 @@ func (db *DB) GetBrandCOO(brandName string) (string, error) {
 }
 
 // CreateBrandCOOMapping creates a new brand-COO mapping
-func (db *DB) CreateBrandCOOMapping(brandName, primaryCOO, notes string) (int64, error) {
+func (db *DB) CreateBrandCOOMapping(brandName, primaryCOO, notes, brandType string) (int64, error) {
 	result, err := db.Exec(`
-		INSERT INTO brand_coo_mappings (brand_name, primary_coo, notes)
-		VALUES (?, ?, ?)
-	`, brandName, primaryCOO, notes)
+		INSERT INTO brand_coo_mappings (brand_name, primary_coo, notes, brand_type)
+		VALUES (?, ?, ?, ?)
+	`, brandName, primaryCOO, notes, brandType)
 	if err != nil {
 		return 0, err
 	}
 	return result.LastInsertId()
 }
 
-// UpdateBrandCOOMapping updates an existing brand-COO mapping
-func (db *DB) UpdateBrandCOOMapping(id int64, brandName, primaryCOO, notes string) error {
+// CreateAutoBrandMapping adds a mapping flagged auto_created for a brand
+// first seen during enrichment. It does nothing if the brand is already
+// mapped under any capitalisation, and reports whether a row was added.
+func (db *DB) CreateAutoBrandMapping(brandName, primaryCOO, notes string) (bool, error) {
+	result, err := db.Exec(`
+		INSERT INTO brand_coo_mappings (brand_name, primary_coo, notes, auto_created)
+		SELECT ?, ?, ?, 1
+		WHERE NOT EXISTS (SELECT 1 FROM brand_coo_mappings WHERE LOWER(brand_name) = LOWER(?))
+	`, brandName, primaryCOO, notes, brandName)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// UpdateBrandCOOMapping updates an existing brand-COO mapping. Saving an
+// auto-created mapping counts as reviewing it, so the flag is cleared.
+func (db *DB) UpdateBrandCOOMapping(id int64, brandName, primaryCOO, notes, brandType string) error {
 	_, err := db.Exec(`
 		UPDATE brand_coo_mappings
-		SET brand_name = ?, primary_coo = ?, notes = ?, updated_at = CURRENT_TIMESTAMP
+		SET brand_name = ?, primary_coo = ?, notes = ?, brand_type = ?, auto_created = 0, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
-	`, brandName, primaryCOO, notes, id)
+	`, brandName, primaryCOO, notes, brandType, id)
 	return err
 }
 
@@ -781,12 +925,13 @@ func (db *DB) DeleteBrandCOOMapping(id int64) error {
 	return err
 }
 
-// GetAllTariffRates returns all tariff rates
+// GetAllTariffRates returns all tariff rates, including superseded and
+// future ones, oldest first within each country
 func (db *DB) GetAllTariffRates() ([]TariffRate, error) {
 	rows, err := db.Query(`
 		SELECT id, country_name, tariff_rate, COALESCE(notes, ''), COALESCE(effective_date, ''), created_at, updated_at
 		FROM tariff_rates
-		ORDER BY country_name
+		ORDER BY country_name, effective_date
 	`)
 	if err != nil {
 		return nil, err
@@ -805,40 +950,68 @@ func (db *DB) GetAllTariffRates() ([]TariffRate, error) {
 	return rates, rows.Err()
 }
 
-// GetTariffRate returns the tariff rate for a specific country
+// GetTariffRate returns the tariff rate currently in effect for a country
 func (db *DB) GetTariffRate(countryName string) (float64, error) {
+	return db.GetTariffRateAsOf(countryName, time.Now().UTC())
+}
+
+// GetTariffRateAsOf returns the tariff rate in effect for a country on the
+// given date: the rate with the latest effective date on or before it
+func (db *DB) GetTariffRateAsOf(countryName string, date time.Time) (float64, error) {
 	var rate float64
 	err := db.QueryRow(`
 		SELECT tariff_rate
 		FROM tariff_rates
-		WHERE country_name = ?
-	`, countryName).Scan(&rate)
+		WHERE country_name = ? AND effective_date <= ?
+		ORDER BY effective_date DESC
+		LIMIT 1
+	`, countryName, date.Format(tariffDateLayout)).Scan(&rate)
 	if err == sql.ErrNoRows {
-		return 0, nil // Country not found, return 0%
+		return 0, nil // No rate in effect on that date, return 0%
 	}
 	return rate, err
 }
 
-// CreateTariffRate creates a new tariff rate
-func (db *DB) CreateTariffRate(countryName string, rate float64, notes string) (int64, error) {
+// tariffDateLayout is the format of tariff_rates.effective_date
+const tariffDateLayout = "2006-01-02"
+
+// ErrTariffDateExists is returned when a country already has a rate taking
+// effect on the same date
+var ErrTariffDateExists = errors.New("country already has a tariff rate with this effective date")
+
+// tariffWriteError maps a unique constraint failure to ErrTariffDateExists
+func tariffWriteError(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return ErrTariffDateExists
+	}
+	return err
+}
+
+// CreateTariffRate creates a tariff rate taking effect on effectiveDate
+// (YYYY-MM-DD), or today if it is empty. A country keeps one row per
+// effective date, so rate changes are added rather than overwritten.
+func (db *DB) CreateTariffRate(countryName string, rate float64, notes, effectiveDate string) (int64, error) {
 	result, err := db.Exec(`
 		INSERT INTO tariff_rates (country_name, tariff_rate, notes, effective_date)
-		VALUES (?, ?, ?, DATE('now'))
-	`, countryName, rate, notes)
+		VALUES (?, ?, ?, COALESCE(NULLIF(?, ''), DATE('now')))
+	`, countryName, rate, notes, effectiveDate)
 	if err != nil {
-		return 0, err
+		return 0, tariffWriteError(err)
 	}
 	return result.LastInsertId()
 }
 
-// UpdateTariffRate updates an existing tariff rate
-func (db *DB) UpdateTariffRate(id int64, countryName string, rate float64, notes string) error {
+// UpdateTariffRate updates an existing tariff rate. An empty effectiveDate
+// keeps the current one.
+func (db *DB) UpdateTariffRate(id int64, countryName string, rate float64, notes, effectiveDate string) error {
 	_, err := db.Exec(`
 		UPDATE tariff_rates
-		SET country_name = ?, tariff_rate = ?, notes = ?, updated_at = CURRENT_TIMESTAMP
+		SET country_name = ?, tariff_rate = ?, notes = ?,
+			effective_date = COALESCE(NULLIF(?, ''), effective_date), updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
-	`, countryName, rate, notes, id)
-	return err
+	`, countryName, rate, notes, effectiveDate, id)
+	return tariffWriteError(err)
 }
 
 // TariffCountryExists checks if a country exists in the tariff_rates table
@@ -858,15 +1031,20 @@ func (db *DB) TariffCountryExists(countryName string) (bool, error) {
 	return count > 0, nil
 }
 
-// DeleteTariffRate deletes a tariff rate
+// DeleteTariffRate deletes a tariff rate. A country's last rate can't be
+// deleted while brands reference it.
 func (db *DB) DeleteTariffRate(id int64) error {
-	// Check if any brands reference this country
+	// Check if any brands reference this country once the rate is gone
 	var count int
 	err := db.QueryRow(`
 		SELECT COUNT(*)
 		FROM brand_coo_mappings bcm
 		JOIN tariff_rates tr ON LOWER(bcm.primary_coo) = LOWER(tr.country_name)
 		WHERE tr.id = ?
+		AND NOT EXISTS (
+			SELECT 1 FROM tariff_rates other
+			WHERE LOWER(other.country_name) = LOWER(tr.country_name) AND other.id != tr.id
+		)
 	`, id).Scan(&count)
 	if err != nil {
 		return err
@@ -893,31 +1071,80 @@ type DeletionNotification struct {
 	RawPayload     string     `json:"rawPayload"`
 }
 
-// CreateDeletionNotification stores a new deletion notification
-func (db *DB) CreateDeletionNotification(dn *DeletionNotification) error {
-	_, err := db.Exec(`
+// CreateDeletionNotification stores a new deletion notification. eBay
+// retries deliveries, so a notification ID that is already stored is left
+// as it is; created reports whether this call added the row.
+func (db *DB) CreateDeletionNotification(dn *DeletionNotification) (created bool, err error) {
+	result, err := db.Exec(`
 		INSERT INTO deletion_notifications
 		(notification_id, username, user_id, eias_token, event_date, raw_payload)
 		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(notification_id) DO NOTHING
 	`, dn.NotificationID, dn.Username, dn.UserID, dn.EiasToken, dn.EventDate, dn.RawPayload)
-	return err
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// IsDeletionNotificationProcessed reports whether the notification with
+// eBay's notificationID is stored and already processed
+func (db *DB) IsDeletionNotificationProcessed(notificationID string) (bool, error) {
+	var processed bool
+	err := db.QueryRow(`
+		SELECT processed FROM deletion_notifications WHERE notification_id = ?
+	`, notificationID).Scan(&processed)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return processed, err
+}
+
+// DeletionNotificationsQuery filters and pages QueryDeletionNotifications
+type DeletionNotificationsQuery struct {
+	Processed   *bool // Only processed (true) or unprocessed (false) ones; nil for all
+	Limit       int   // Defaults to 100
+	Offset      int
+	OldestFirst bool // Arrival order, for replays; newest first otherwise
 }
 
-// GetDeletionNotifications returns all deletion notifications
-func (db *DB) GetDeletionNotifications(limit int) ([]DeletionNotification, error) {
-	if limit <= 0 {
-		limit = 100
+// QueryDeletionNotifications returns one page of deletion notifications and
+// the total number matching the query's processed filter
+func (db *DB) QueryDeletionNotifications(query DeletionNotificationsQuery) ([]DeletionNotification, int, error) {
+	if query.Limit <= 0 {
+		query.Limit = 100
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	where := ""
+	var args []interface{}
+	if query.Processed != nil {
+		where = "WHERE processed = ?"
+		args = append(args, *query.Processed)
+	}
+	order := "DESC"
+	if query.OldestFirst {
+		order = "ASC"
+	}
+
+	var total int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM deletion_notifications `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count deletion notifications: %w", err)
 	}
 
 	rows, err := db.Query(`
 		SELECT id, notification_id, username, user_id, eias_token,
 		       event_date, received_at, processed, processed_at, raw_payload
 		FROM deletion_notifications
-		ORDER BY received_at DESC
-		LIMIT ?
-	`, limit)
+		`+where+`
+		ORDER BY received_at `+order+`, id `+order+`
+		LIMIT ? OFFSET ?
+	`, append(args, query.Limit, query.Offset)...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
@@ -928,11 +1155,32 @@ func (db *DB) GetDeletionNotifications(limit int) ([]DeletionNotification, error
 			&dn.EiasToken, &dn.EventDate, &dn.ReceivedAt, &dn.Processed,
 			&dn.ProcessedAt, &dn.RawPayload)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		notifications = append(notifications, dn)
 	}
-	return notifications, rows.Err()
+	return notifications, total, rows.Err()
+}
+
+// GetDeletionNotificationByID returns a single deletion notification, or nil if
+// there is none with that ID
+func (db *DB) GetDeletionNotificationByID(id int64) (*DeletionNotification, error) {
+	var dn DeletionNotification
+	err := db.QueryRow(`
+		SELECT id, notification_id, username, user_id, eias_token,
+		       event_date, received_at, processed, processed_at, raw_payload
+		FROM deletion_notifications
+		WHERE id = ?
+	`, id).Scan(&dn.ID, &dn.NotificationID, &dn.Username, &dn.UserID,
+		&dn.EiasToken, &dn.EventDate, &dn.ReceivedAt, &dn.Processed,
+		&dn.ProcessedAt, &dn.RawPayload)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dn, nil
 }
 
 // MarkDeletionNotificationProcessed marks a notification as processed
@@ -955,15 +1203,44 @@ func (db *DB) SeedInitialData() error {
 		return err
 	}
 	if count > 0 {
-		return nil // Already seeded
+		// Backfill brand types for databases seeded before brand_type existed
+		for brandName, brandData := range seedBrands {
+			if brandData.Type == "" {
+				continue
+			}
+			if _, err := db.Exec(`
+				UPDATE brand_coo_mappings SET brand_type = ?
+				WHERE brand_name = ? AND (brand_type IS NULL OR brand_type = '')
+			`, brandData.Type, brandName); err != nil {
+				return fmt.Errorf("failed to backfill brand type for %s: %w", brandName, err)
+			}
+		}
+		if err := db.seedSecondaryCOOs(); err != nil {
+			return err
+		}
+		// Backfill weight band labels for databases seeded before postal_rates.label existed
+		for zoneID, zone := range seedPostalZones {
+			for bandKey, band := range zone.WeightBands {
+				if _, err := db.Exec(`
+					UPDATE postal_rates SET label = ?
+					WHERE zone_id = ? AND weight_band = ? AND (label IS NULL OR label = '')
+				`, band.Label, zoneID, bandKey); err != nil {
+					return fmt.Errorf("failed to backfill label for %s/%s: %w", zoneID, bandKey, err)
+				}
+			}
+		}
+		return db.seedMissingPostalZones() // Already seeded; just add new zones
 	}
 
 	// Seed brand-COO mappings from local seed data
 	for brandName, brandData := range seedBrands {
-		if _, err := db.CreateBrandCOOMapping(brandName, brandData.PrimaryCOO, ""); err != nil {
+		if _, err := db.CreateBrandCOOMapping(brandName, brandData.PrimaryCOO, "", brandData.Type); err != nil {
 			return fmt.Errorf("failed to seed brand %s: %w", brandName, err)
 		}
 	}
+	if err := db.seedSecondaryCOOs(); err != nil {
+		return err
+	}
 
 	// Seed tariff rates from local seed data
 	for country, rate := range seedTariffs {
@@ -977,7 +1254,55 @@ func (db *DB) SeedInitialData() error {
 	}
 
 	// Seed postal zones from local seed data
+	if err := db.seedMissingPostalZones(); err != nil {
+		return err
+	}
+
+	// Seed Zonos settings
+	_, err := db.Exec(`
+		INSERT OR IGNORE INTO settings (key, value, description, data_type) VALUES
+		('zonos_processing_charge_percent', ?, 'Zonos processing charge percentage (e.g., 0.10 for 10%)', 'float'),
+		('zonos_flat_fee_aud', ?, 'Zonos flat fee in AUD', 'float')
+	`, fmt.Sprintf("%.2f", seedZonos.ProcessingChargePercent), fmt.Sprintf("%.2f", seedZonos.FlatFeeAUD))
+	if err != nil {
+		return fmt.Errorf("failed to seed Zonos settings: %w", err)
+	}
+
+	// Seed ExtraCover settings
+	_, err = db.Exec(`
+		INSERT OR IGNORE INTO settings (key, value, description, data_type) VALUES
+		('extra_cover_base_price_per_100', ?, 'Extra cover base price per $100 AUD', 'float'),
+		('extra_cover_threshold_aud', ?, 'Threshold value for extra cover (AUD)', 'float'),
+		('extra_cover_warning_threshold_aud', ?, 'Warning threshold for suggesting extra cover (AUD)', 'float'),
+		('extra_cover_discount_band_0', '0', 'Extra cover discount for band 0', 'float'),
+		('extra_cover_discount_band_1', '0.40', 'Extra cover discount for band 1', 'float'),
+		('extra_cover_discount_band_2', '0.40', 'Extra cover discount for band 2', 'float'),
+		('extra_cover_discount_band_3', '0.40', 'Extra cover discount for band 3', 'float'),
+		('extra_cover_discount_band_4', '0.40', 'Extra cover discount for band 4', 'float'),
+		('extra_cover_discount_band_5', '0.40', 'Extra cover discount for band 5', 'float')
+	`, fmt.Sprintf("%.2f", seedExtraCover.BasePricePer100),
+		fmt.Sprintf("%.2f", seedExtraCover.ThresholdAUD),
+		fmt.Sprintf("%.2f", seedExtraCover.WarningThresholdAUD))
+	if err != nil {
+		return fmt.Errorf("failed to seed ExtraCover settings: %w", err)
+	}
+
+	return nil
+}
+
+// seedMissingPostalZones adds any seed postal zone (with its weight and
+// discount bands) that isn't in the database yet, so zones added in later
+// versions reach databases that were seeded before they existed
+func (db *DB) seedMissingPostalZones() error {
 	for zoneID, zone := range seedPostalZones {
+		var exists int
+		if err := db.QueryRow("SELECT COUNT(*) FROM postal_zones WHERE zone_id = ?", zoneID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check postal zone %s: %w", zoneID, err)
+		}
+		if exists > 0 {
+			continue // Keep any user edits to existing zones
+		}
+
 		hasTariffs := zoneID == "3-USA & Canada"
 		// Extract zone name from ID (e.g., "3-USA & Canada" → "USA & Canada")
 		zoneName := zoneID
@@ -996,9 +1321,9 @@ func (db *DB) SeedInitialData() error {
 		// Seed weight bands for this zone
 		for bandKey, band := range zone.WeightBands {
 			_, err := db.Exec(`
-				INSERT INTO postal_rates (zone_id, weight_band, max_weight_grams, base_price_aud)
-				VALUES (?, ?, ?, ?)
-			`, zoneID, bandKey, band.MaxWeight, band.BasePrice)
+				INSERT INTO postal_rates (zone_id, weight_band, label, max_weight_grams, base_price_aud)
+				VALUES (?, ?, ?, ?, ?)
+			`, zoneID, bandKey, band.Label, band.MaxWeight, band.BasePrice)
 			if err != nil {
 				return fmt.Errorf("failed to seed postal rate %s/%s: %w", zoneID, bandKey, err)
 			}
@@ -1015,63 +1340,76 @@ func (db *DB) SeedInitialData() error {
 			}
 		}
 	}
+	return nil
+}
 
-	// Seed Zonos settings
-	_, err := db.Exec(`
-		INSERT OR IGNORE INTO settings (key, value, description, data_type) VALUES
-		('zonos_processing_charge_percent', ?, 'Zonos processing charge percentage (e.g., 0.10 for 10%)', 'float'),
-		('zonos_flat_fee_aud', ?, 'Zonos flat fee in AUD', 'float')
-	`, fmt.Sprintf("%.2f", seedZonos.ProcessingChargePercent), fmt.Sprintf("%.2f", seedZonos.FlatFeeAUD))
-	if err != nil {
-		return fmt.Errorf("failed to seed Zonos settings: %w", err)
-	}
-
-	// Seed ExtraCover settings
-	_, err = db.Exec(`
-		INSERT OR IGNORE INTO settings (key, value, description, data_type) VALUES
-		('extra_cover_base_price_per_100', ?, 'Extra cover base price per $100 AUD', 'float'),
-		('extra_cover_threshold_aud', ?, 'Threshold value for extra cover (AUD)', 'float'),
-		('extra_cover_warning_threshold_aud', ?, 'Warning threshold for suggesting extra cover (AUD)', 'float'),
-		('extra_cover_discount_band_0', '0', 'Extra cover discount for band 0', 'float'),
-		('extra_cover_discount_band_1', '0.40', 'Extra cover discount for band 1', 'float'),
-		('extra_cover_discount_band_2', '0.40', 'Extra cover discount for band 2', 'float'),
-		('extra_cover_discount_band_3', '0.40', 'Extra cover discount for band 3', 'float'),
-		('extra_cover_discount_band_4', '0.40', 'Extra cover discount for band 4', 'float'),
-		('extra_cover_discount_band_5', '0.40', 'Extra cover discount for band 5', 'float')
-	`, fmt.Sprintf("%.2f", seedExtraCover.BasePricePer100),
-		fmt.Sprintf("%.2f", seedExtraCover.ThresholdAUD),
-		fmt.Sprintf("%.2f", seedExtraCover.WarningThresholdAUD))
-	if err != nil {
-		return fmt.Errorf("failed to seed ExtraCover settings: %w", err)
+// seedSecondaryCOOs fills in secondary countries of origin for seeded brands
+// that don't have any recorded yet (including databases seeded before the
+// secondary_coos column existed). Lists edited since are left alone.
+func (db *DB) seedSecondaryCOOs() error {
+	for brandName, brandData := range seedBrands {
+		if len(brandData.SecondaryCOO) == 0 {
+			continue
+		}
+		data, err := json.Marshal(brandData.SecondaryCOO)
+		if err != nil {
+			return fmt.Errorf("failed to marshal secondary COOs for %s: %w", brandName, err)
+		}
+		if _, err := db.Exec(`
+			UPDATE brand_coo_mappings SET secondary_coos = ?
+			WHERE brand_name = ? AND secondary_coos IS NULL
+		`, string(data), brandName); err != nil {
+			return fmt.Errorf("failed to seed secondary COOs for %s: %w", brandName, err)
+		}
 	}
-
 	return nil
 }
 
+// defaultBrandTypeWeightBands is the brand_type_weight_bands setting as
+// seeded by schema.sql, used when the stored value can't be parsed
+var defaultBrandTypeWeightBands = map[string]string{
+	"Hats":      "XSmall",
+	"Headbands": "XSmall",
+	"Sunnies":   "XSmall",
+	"Sneakers":  "Large",
+}
+
 // GetCalculatorConfig loads all calculator configuration from database
 // Returns a complete CalculatorConfig ready for use by calculator functions
 func (db *DB) GetCalculatorConfig() (*calculator.CalculatorConfig, error) {
 	// Load brands
 	brands := make(map[string]calculator.Brand)
 	brandRows, err := db.Query(`
-		SELECT brand_name, primary_coo FROM brand_coo_mappings ORDER BY brand_name
+		SELECT brand_name, primary_coo, COALESCE(brand_type, ''), COALESCE(secondary_coos, '')
+		FROM brand_coo_mappings ORDER BY brand_name
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load brands: %w", err)
 	}
 	defer brandRows.Close()
 	for brandRows.Next() {
-		var name, coo string
-		if err := brandRows.Scan(&name, &coo); err != nil {
+		var name, coo, brandType, secondaryJSON string
+		if err := brandRows.Scan(&name, &coo, &brandType, &secondaryJSON); err != nil {
 			return nil, fmt.Errorf("failed to scan brand: %w", err)
 		}
-		brands[name] = calculator.Brand{PrimaryCOO: coo}
+		secondary := []string{}
+		if secondaryJSON != "" {
+			if err := json.Unmarshal([]byte(secondaryJSON), &secondary); err != nil {
+				return nil, fmt.Errorf("invalid secondary COOs for brand %s: %w", name, err)
+			}
+		}
+		brands[name] = calculator.Brand{PrimaryCOO: coo, SecondaryCOO: secondary, Type: brandType}
 	}
 
-	// Load tariff rates
+	// Load the tariff rates in effect today
 	tariffRates := make(map[string]float64)
 	tariffRows, err := db.Query(`
-		SELECT country_name, tariff_rate FROM tariff_rates ORDER BY country_name
+		SELECT country_name, tariff_rate FROM tariff_rates tr
+		WHERE effective_date = (
+			SELECT MAX(effective_date) FROM tariff_rates
+			WHERE country_name = tr.country_name AND effective_date <= DATE('now')
+		)
+		ORDER BY country_name
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load tariffs: %w", err)
@@ -1086,26 +1424,58 @@ func (db *DB) GetCalculatorConfig() (*calculator.CalculatorConfig, error) {
 		tariffRates[country] = rate
 	}
 
+	// Rates are resolved for today, so note when the next future-dated one
+	// takes effect (at UTC midnight, as DATE('now') is UTC)
+	var nextTariffChange time.Time
+	var nextDate sql.NullString
+	if err := db.QueryRow(`
+		SELECT MIN(effective_date) FROM tariff_rates WHERE effective_date > DATE('now')
+	`).Scan(&nextDate); err != nil {
+		return nil, fmt.Errorf("failed to load pending tariffs: %w", err)
+	}
+	if nextDate.Valid {
+		if nextTariffChange, err = time.Parse(tariffDateLayout, nextDate.String); err != nil {
+			return nil, fmt.Errorf("invalid tariff effective date %q: %w", nextDate.String, err)
+		}
+	}
+
+	// Load Zonos settings: the fee structure for zones that don't set their own
+	zonosPercent, _ := db.GetSettingFloat("zonos_processing_charge_percent", 0.10)
+	zonosFlatFee, _ := db.GetSettingFloat("zonos_flat_fee_aud", 1.69)
+
 	// Load postal zones with weight bands and discount bands
 	postalZones := make(map[string]calculator.PostalZone)
 	zoneRows, err := db.Query(`
-		SELECT zone_id, handling_fee_percent FROM postal_zones ORDER BY zone_id
+		SELECT ` + postalZoneDutiesColumns + `, handling_fee_percent
+		FROM postal_zones ORDER BY zone_id
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load postal zones: %w", err)
 	}
 	defer zoneRows.Close()
 	for zoneRows.Next() {
-		var zoneID string
 		var handlingFee float64
-		if err := zoneRows.Scan(&zoneID, &handlingFee); err != nil {
+		duties, err := scanPostalZoneDuties(zoneRows, &handlingFee)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan postal zone: %w", err)
 		}
+		zoneID := duties.ZoneID
+
+		var zonos *calculator.ZonosData
+		if duties.UsesZonos() {
+			zonos = &calculator.ZonosData{ProcessingChargePercent: zonosPercent, FlatFeeAUD: zonosFlatFee}
+			if duties.ZonosProcessingChargePercent != nil {
+				zonos.ProcessingChargePercent = *duties.ZonosProcessingChargePercent
+			}
+			if duties.ZonosFlatFeeAUD != nil {
+				zonos.FlatFeeAUD = *duties.ZonosFlatFeeAUD
+			}
+		}
 
 		// Load weight bands for this zone
 		weightBands := make(map[string]calculator.WeightBand)
 		wbRows, err := db.Query(`
-			SELECT weight_band, max_weight_grams, base_price_aud
+			SELECT weight_band, COALESCE(NULLIF(label, ''), weight_band), max_weight_grams, base_price_aud
 			FROM postal_rates
 			WHERE zone_id = ?
 			ORDER BY max_weight_grams
@@ -1114,15 +1484,15 @@ func (db *DB) GetCalculatorConfig() (*calculator.CalculatorConfig, error) {
 			return nil, fmt.Errorf("failed to load weight bands for %s: %w", zoneID, err)
 		}
 		for wbRows.Next() {
-			var bandKey string
+			var bandKey, label string
 			var maxWeight int
 			var basePrice float64
-			if err := wbRows.Scan(&bandKey, &maxWeight, &basePrice); err != nil {
+			if err := wbRows.Scan(&bandKey, &label, &maxWeight, &basePrice); err != nil {
 				wbRows.Close()
 				return nil, fmt.Errorf("failed to scan weight band: %w", err)
 			}
 			weightBands[bandKey] = calculator.WeightBand{
-				Label:     bandKey,
+				Label:     label,
 				MaxWeight: maxWeight,
 				BasePrice: basePrice,
 			}
@@ -1155,18 +1525,40 @@ func (db *DB) GetCalculatorConfig() (*calculator.CalculatorConfig, error) {
 			HandlingFee:   handlingFee,
 			DiscountBands: discountBands,
 			WeightBands:   weightBands,
+			HasTariffs:    duties.HasTariffs,
+			Zonos:         zonos,
 		}
 	}
 
-	// Load Zonos settings
-	zonosPercent, _ := db.GetSettingFloat("zonos_processing_charge_percent", 0.10)
-	zonosFlatFee, _ := db.GetSettingFloat("zonos_flat_fee_aud", 1.69)
-
 	// Load ExtraCover settings
 	extraCoverBasePer100, _ := db.GetSettingFloat("extra_cover_base_price_per_100", 4.00)
 	extraCoverThreshold, _ := db.GetSettingFloat("extra_cover_threshold_aud", 100.0)
 	extraCoverWarning, _ := db.GetSettingFloat("extra_cover_warning_threshold_aud", 250.0)
 
+	// De minimis threshold for US duties (0 = duties on every item)
+	deMinimis, _ := db.GetSettingFloat("tariff_de_minimis_aud", 0)
+
+	honorSecondaryCOO, _ := db.GetSettingBool("coo_honor_secondary", true)
+	fuzzyBrandMatch, _ := db.GetSettingBool("brand_fuzzy_match", false)
+
+	// The typical parcel for bulk calculations. Values that aren't a real band
+	// fall back to the defaults rather than failing every calculation.
+	fallbackWeightBand := calculator.DefaultWeightBand
+	if setting, err := db.GetSetting("default_weight_band"); err == nil && setting != nil && calculator.IsWeightBand(setting.Value) {
+		fallbackWeightBand = setting.Value
+	}
+	fallbackDiscountBand := calculator.DefaultDiscountBand
+	if setting, err := db.GetSetting("default_discount_band"); err == nil && setting != nil {
+		if band, err := strconv.Atoi(setting.Value); err == nil && band >= 0 && band <= calculator.MaxDiscountBand {
+			fallbackDiscountBand = band
+		}
+	}
+
+	currencyRates, err := db.GetCurrencyRates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load currency rates: %w", err)
+	}
+
 	extraCoverDiscounts := make(map[int]float64)
 	for i := 0; i <= 5; i++ {
 		key := fmt.Sprintf("extra_cover_discount_band_%d", i)
@@ -1178,9 +1570,25 @@ func (db *DB) GetCalculatorConfig() (*calculator.CalculatorConfig, error) {
 		extraCoverDiscounts[i] = discount
 	}
 
+	// Load brand type -> weight band heuristic (JSON object). A bad value
+	// mustn't break every calculation, so it falls back to the defaults, and
+	// entries that aren't weight bands are ignored.
+	brandTypeWeightBands := make(map[string]string)
+	if setting, err := db.GetSetting("brand_type_weight_bands"); err == nil && setting != nil {
+		if err := json.Unmarshal([]byte(setting.Value), &brandTypeWeightBands); err != nil {
+			brandTypeWeightBands = maps.Clone(defaultBrandTypeWeightBands)
+		}
+	}
+	for brandType, band := range brandTypeWeightBands {
+		if !calculator.IsWeightBand(band) {
+			delete(brandTypeWeightBands, brandType)
+		}
+	}
+
 	return &calculator.CalculatorConfig{
-		PostalZones: postalZones,
-		Brands:      brands,
+		PostalZones:          postalZones,
+		Brands:               brands,
+		BrandTypeWeightBands: brandTypeWeightBands,
 		USATariffs: calculator.TariffData{
 			Rates: tariffRates,
 		},
@@ -1194,48 +1602,135 @@ func (db *DB) GetCalculatorConfig() (*calculator.CalculatorConfig, error) {
 			WarningThresholdAUD: extraCoverWarning,
 			DiscountBands:       extraCoverDiscounts,
 		},
-		DefaultCOO: "China",
+		DefaultCOO:    "China",
+		DeMinimisAUD:  deMinimis,
+		CurrencyRates: currencyRates,
+
+		HonorSecondaryCOO:    honorSecondaryCOO,
+		FallbackWeightBand:   fallbackWeightBand,
+		FallbackDiscountBand: fallbackDiscountBand,
+		FuzzyBrandMatch:      fuzzyBrandMatch,
+		NextTariffChange:     nextTariffChange,
 	}, nil
 }
 
-// GetSettingFloat retrieves a float setting with default fallback
-func (db *DB) GetSettingFloat(key string, defaultValue float64) (float64, error) {
-	setting, err := db.GetSetting(key)
-	if err != nil || setting == nil {
-		return defaultValue, err
+// currencyRateSettingPrefix prefixes settings that override a currency_rates row
+const currencyRateSettingPrefix = "currency_rate_"
+
+// GetCurrencyRates returns AUD conversion rates keyed by currency code, with
+// any currency_rate_<code> setting taking precedence over the table
+func (db *DB) GetCurrencyRates() (map[string]float64, error) {
+	rates := make(map[string]float64)
+	rows, err := db.Query(`SELECT currency, rate_to_aud FROM currency_rates`)
+	if err != nil {
+		return nil, err
 	}
-	var value float64
-	if _, err := fmt.Sscanf(setting.Value, "%f", &value); err != nil {
-		return defaultValue, fmt.Errorf("invalid float value for %s: %w", key, err)
+	defer rows.Close()
+	for rows.Next() {
+		var currency string
+		var rate float64
+		if err := rows.Scan(&currency, &rate); err != nil {
+			return nil, fmt.Errorf("failed to scan currency rate: %w", err)
+		}
+		rates[calculator.NormalizeCurrency(currency)] = rate
 	}
-	return value, nil
-}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	overrides, err := db.Query(`SELECT key, value FROM settings WHERE key LIKE ?`, currencyRateSettingPrefix+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer overrides.Close()
+	for overrides.Next() {
+		var key, value string
+		if err := overrides.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan currency override: %w", err)
+		}
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid currency rate override %s=%q", key, value)
+		}
+		rates[calculator.NormalizeCurrency(strings.TrimPrefix(key, currencyRateSettingPrefix))] = rate
+	}
+	return rates, overrides.Err()
+}
+
+// GetSettingFloat retrieves a float setting with default fallback
+func (db *DB) GetSettingFloat(key string, defaultValue float64) (float64, error) {
+	setting, err := db.GetSetting(key)
+	if err != nil || setting == nil {
+		return defaultValue, err
+	}
+	var value float64
+	if _, err := fmt.Sscanf(setting.Value, "%f", &value); err != nil {
+		return defaultValue, fmt.Errorf("invalid float value for %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// GetSettingInt retrieves an int setting with default fallback
+func (db *DB) GetSettingInt(key string, defaultValue int) (int, error) {
+	setting, err := db.GetSetting(key)
+	if err != nil || setting == nil {
+		return defaultValue, err
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(setting.Value))
+	if err != nil {
+		return defaultValue, fmt.Errorf("invalid int value for %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// GetSettingBool retrieves a bool setting with default fallback
+func (db *DB) GetSettingBool(key string, defaultValue bool) (bool, error) {
+	setting, err := db.GetSetting(key)
+	if err != nil || setting == nil {
+		return defaultValue, err
+	}
+	value, err := strconv.ParseBool(setting.Value)
+	if err != nil {
+		return defaultValue, fmt.Errorf("invalid bool value for %s: %w", key, err)
+	}
+	return value, nil
+}
 
 // EnrichedItem represents cached enriched item data from GetItem API
 type EnrichedItem struct {
 	ItemID           string    `json:"itemId"`
+	SKU              string    `json:"sku"` // Empty until seen in GetItem or GetMyeBaySelling
 	Brand            string    `json:"brand"`
 	CountryOfOrigin  string    `json:"countryOfOrigin"`
 	ShippingCost     string    `json:"shippingCost"`
 	ShippingCurrency string    `json:"shippingCurrency"`
+	Images           []string  `json:"images"`
 	EnrichedAt       time.Time `json:"enrichedAt"`
 	CreatedAt        time.Time `json:"createdAt"`
 	UpdatedAt        time.Time `json:"updatedAt"`
+
+	ConditionDescription string `json:"conditionDescription,omitempty"` // Seller's note on wear
+
+	// International shipping cost (in ShippingCurrency) by destination, e.g.
+	// {"United Kingdom": "25.00"}; ShippingCost stays the US cost
+	ShippingByDestination map[string]string `json:"shippingByDestination,omitempty"`
 }
 
 // GetEnrichedItem retrieves cached enriched data for an item
 // Returns nil if not found or expired (based on TTL)
 func (db *DB) GetEnrichedItem(itemID string, ttlDays int) (*EnrichedItem, error) {
 	var item EnrichedItem
+	var imagesJSON, byDestinationJSON string
 	err := db.QueryRow(`
-		SELECT item_id, COALESCE(brand, ''), COALESCE(country_of_origin, ''),
+		SELECT item_id, COALESCE(sku, ''), COALESCE(brand, ''), COALESCE(country_of_origin, ''),
 		       COALESCE(shipping_cost, ''), COALESCE(shipping_currency, ''),
-		       enriched_at, created_at, updated_at
+		       COALESCE(images, '[]'), enriched_at, created_at, updated_at,
+		       COALESCE(condition_description, ''), COALESCE(shipping_by_destination, '{}')
 		FROM enriched_items
 		WHERE item_id = ?
-	`, itemID).Scan(&item.ItemID, &item.Brand, &item.CountryOfOrigin,
-		&item.ShippingCost, &item.ShippingCurrency, &item.EnrichedAt,
-		&item.CreatedAt, &item.UpdatedAt)
+	`, itemID).Scan(&item.ItemID, &item.SKU, &item.Brand, &item.CountryOfOrigin,
+		&item.ShippingCost, &item.ShippingCurrency, &imagesJSON, &item.EnrichedAt,
+		&item.CreatedAt, &item.UpdatedAt, &item.ConditionDescription, &byDestinationJSON)
 
 	if err == sql.ErrNoRows {
 		return nil, nil // Not found
@@ -1249,25 +1744,298 @@ func (db *DB) GetEnrichedItem(itemID string, ttlDays int) (*EnrichedItem, error)
 		return nil, nil // Expired
 	}
 
+	item.Images = parseImages(imagesJSON)
+	item.ShippingByDestination = parseShippingByDestination(byDestinationJSON)
 	return &item, nil
 }
 
-// SaveEnrichedItem saves or updates enriched item data
+// upsertEnrichedItemSQL saves one enriched item. An empty SKU or shipping by
+// destination keeps the stored one, since the Browse API fallback returns
+// neither.
+const upsertEnrichedItemSQL = `
+	INSERT INTO enriched_items (item_id, sku, brand, country_of_origin, shipping_cost, shipping_currency, images, condition_description, shipping_by_destination, enriched_at)
+	VALUES (?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(item_id) DO UPDATE SET
+		sku = COALESCE(excluded.sku, enriched_items.sku),
+		brand = excluded.brand,
+		country_of_origin = excluded.country_of_origin,
+		shipping_cost = excluded.shipping_cost,
+		shipping_currency = excluded.shipping_currency,
+		images = excluded.images,
+		condition_description = excluded.condition_description,
+		shipping_by_destination = COALESCE(excluded.shipping_by_destination, enriched_items.shipping_by_destination),
+		enriched_at = excluded.enriched_at,
+		updated_at = CURRENT_TIMESTAMP
+`
+
+// enrichedItemArgs returns the upsertEnrichedItemSQL arguments for item
+func enrichedItemArgs(item *EnrichedItem) ([]any, error) {
+	imagesJSON, err := marshalImages(item.Images)
+	if err != nil {
+		return nil, err
+	}
+	var byDestinationJSON any // NULL when there is none
+	if len(item.ShippingByDestination) > 0 {
+		data, err := json.Marshal(item.ShippingByDestination)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal shipping by destination: %w", err)
+		}
+		byDestinationJSON = string(data)
+	}
+	return []any{item.ItemID, item.SKU, item.Brand, item.CountryOfOrigin, item.ShippingCost,
+		item.ShippingCurrency, imagesJSON, item.ConditionDescription, byDestinationJSON, item.EnrichedAt}, nil
+}
+
+// SaveEnrichedItem saves or updates enriched item data. An empty SKU or
+// shipping by destination keeps the stored one, since the Browse API fallback
+// returns neither.
 func (db *DB) SaveEnrichedItem(item *EnrichedItem) error {
-	_, err := db.Exec(`
-		INSERT INTO enriched_items (item_id, brand, country_of_origin, shipping_cost, shipping_currency, enriched_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-		ON CONFLICT(item_id) DO UPDATE SET
-			brand = excluded.brand,
-			country_of_origin = excluded.country_of_origin,
-			shipping_cost = excluded.shipping_cost,
-			shipping_currency = excluded.shipping_currency,
-			enriched_at = excluded.enriched_at,
-			updated_at = CURRENT_TIMESTAMP
-	`, item.ItemID, item.Brand, item.CountryOfOrigin, item.ShippingCost, item.ShippingCurrency, item.EnrichedAt)
+	args, err := enrichedItemArgs(item)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(upsertEnrichedItemSQL, args...)
 	return err
 }
 
+// SaveEnrichedItemsBatch saves items as SaveEnrichedItem does, in one
+// transaction. Either every item is saved or none is, and saving the same
+// items again is harmless, so a failed batch can simply be retried.
+func (db *DB) SaveEnrichedItemsBatch(items []*EnrichedItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(upsertEnrichedItemSQL)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, item := range items {
+		args, err := enrichedItemArgs(item)
+		if err != nil {
+			return fmt.Errorf("item %s: %w", item.ItemID, err)
+		}
+		if _, err := stmt.Exec(args...); err != nil {
+			return fmt.Errorf("item %s: %w", item.ItemID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteEnrichedItems removes the enrichment of the given items so they are
+// fetched from eBay again. Returns how many rows were deleted.
+func (db *DB) DeleteEnrichedItems(itemIDs []string) (int64, error) {
+	if len(itemIDs) == 0 {
+		return 0, nil
+	}
+	args := make([]interface{}, len(itemIDs))
+	for i, id := range itemIDs {
+		args[i] = id
+	}
+	result, err := db.Exec(`DELETE FROM enriched_items WHERE item_id IN (?`+generatePlaceholders(len(itemIDs)-1)+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteAllEnrichedItems removes every item's enrichment. Returns how many
+// rows were deleted.
+func (db *DB) DeleteAllEnrichedItems() (int64, error) {
+	result, err := db.Exec(`DELETE FROM enriched_items`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// SetEnrichedItemSKUs records SKUs (itemID -> SKU) from a listings fetch on
+// items that are already enriched. Items not yet enriched get their SKU when
+// they are. Returns how many rows changed.
+func (db *DB) SetEnrichedItemSKUs(skus map[string]string) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		UPDATE enriched_items SET sku = ?
+		WHERE item_id = ? AND sku IS NOT ?
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	updated := 0
+	for itemID, sku := range skus {
+		if sku == "" {
+			continue
+		}
+		result, err := stmt.Exec(sku, itemID, sku)
+		if err != nil {
+			return 0, fmt.Errorf("failed to set SKU for item %s: %w", itemID, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		updated += int(n)
+	}
+	return updated, tx.Commit()
+}
+
+// ListingQuantity is a listing's quantity and units sold as reported by
+// GetMyeBaySelling
+type ListingQuantity struct {
+	Quantity     int
+	QuantitySold int
+}
+
+// SetEnrichedItemQuantities records quantities (itemID -> quantity) from a
+// listings fetch on items that are already enriched, like
+// SetEnrichedItemSKUs. Returns how many rows changed.
+func (db *DB) SetEnrichedItemQuantities(quantities map[string]ListingQuantity) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		UPDATE enriched_items SET quantity = ?, quantity_sold = ?
+		WHERE item_id = ? AND (quantity IS NOT ? OR quantity_sold IS NOT ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	updated := 0
+	for itemID, q := range quantities {
+		result, err := stmt.Exec(q.Quantity, q.QuantitySold, itemID, q.Quantity, q.QuantitySold)
+		if err != nil {
+			return 0, fmt.Errorf("failed to set quantity for item %s: %w", itemID, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		updated += int(n)
+	}
+	return updated, tx.Commit()
+}
+
+// GetEnrichedItemsBySKU returns the enriched items carrying a SKU, most
+// recently enriched first. SKUs are unique per seller, but enriched_items is
+// shared by all accounts, so there can be more than one. Expired entries are
+// included; the SKU mapping doesn't go stale with the rest of the data.
+func (db *DB) GetEnrichedItemsBySKU(sku string) ([]EnrichedItem, error) {
+	rows, err := db.Query(`
+		SELECT item_id, COALESCE(sku, ''), COALESCE(brand, ''), COALESCE(country_of_origin, ''),
+		       COALESCE(shipping_cost, ''), COALESCE(shipping_currency, ''),
+		       COALESCE(images, '[]'), enriched_at, created_at, updated_at,
+		       COALESCE(condition_description, ''), COALESCE(shipping_by_destination, '{}')
+		FROM enriched_items
+		WHERE sku = ?
+		ORDER BY enriched_at DESC
+	`, sku)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []EnrichedItem{}
+	for rows.Next() {
+		var item EnrichedItem
+		var imagesJSON, byDestinationJSON string
+		err := rows.Scan(&item.ItemID, &item.SKU, &item.Brand, &item.CountryOfOrigin,
+			&item.ShippingCost, &item.ShippingCurrency, &imagesJSON, &item.EnrichedAt,
+			&item.CreatedAt, &item.UpdatedAt, &item.ConditionDescription, &byDestinationJSON)
+		if err != nil {
+			return nil, err
+		}
+		item.Images = parseImages(imagesJSON)
+		item.ShippingByDestination = parseShippingByDestination(byDestinationJSON)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// marshalImages serializes image URLs for the images column (always a JSON array)
+func marshalImages(images []string) (string, error) {
+	if images == nil {
+		images = []string{}
+	}
+	data, err := json.Marshal(images)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal images: %w", err)
+	}
+	return string(data), nil
+}
+
+// parseImages deserializes the images column, returning an empty slice for
+// missing or malformed data so API responses always contain an array
+func parseImages(imagesJSON string) []string {
+	var images []string
+	if err := json.Unmarshal([]byte(imagesJSON), &images); err != nil || images == nil {
+		return []string{}
+	}
+	return images
+}
+
+// parseShippingByDestination deserializes the shipping_by_destination
+// column, returning nil for missing, empty or malformed data
+func parseShippingByDestination(data string) map[string]string {
+	var costs map[string]string
+	if err := json.Unmarshal([]byte(data), &costs); err != nil || len(costs) == 0 {
+		return nil
+	}
+	return costs
+}
+
+// GetStaleEnrichedItemIDs returns listing IDs for the environment's accounts
+// whose offer or inventory item changed in a sync after the listing was last
+// enriched, least recently enriched first. Items never enriched are skipped;
+// they are enriched on demand.
+func (db *DB) GetStaleEnrichedItemIDs(environment string, limit int) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT o.listing_id
+		FROM offers o
+		JOIN accounts a ON a.id = o.account_id
+		JOIN enriched_items e ON e.item_id = o.listing_id
+		LEFT JOIN inventory_items i ON i.account_id = o.account_id AND i.sku = o.sku
+		WHERE a.environment = ? AND o.listing_id != ''
+		  AND (o.updated_at > e.updated_at OR i.updated_at > e.updated_at)
+		GROUP BY o.listing_id
+		ORDER BY MIN(e.updated_at)
+		LIMIT ?
+	`, environment, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var itemIDs []string
+	for rows.Next() {
+		var itemID string
+		if err := rows.Scan(&itemID); err != nil {
+			return nil, err
+		}
+		itemIDs = append(itemIDs, itemID)
+	}
+	return itemIDs, rows.Err()
+}
+
 // GetEnrichedItemsBatch retrieves multiple enriched items at once
 // Returns a map of itemID -> EnrichedItem for items that exist and are not expired
 func (db *DB) GetEnrichedItemsBatch(itemIDs []string, ttlDays int) (map[string]*EnrichedItem, error) {
@@ -1285,9 +2053,10 @@ func (db *DB) GetEnrichedItemsBatch(itemIDs []string, ttlDays int) (map[string]*
 
 	// Create the query with proper number of placeholders
 	query := `
-		SELECT item_id, COALESCE(brand, ''), COALESCE(country_of_origin, ''),
+		SELECT item_id, COALESCE(sku, ''), COALESCE(brand, ''), COALESCE(country_of_origin, ''),
 		       COALESCE(shipping_cost, ''), COALESCE(shipping_currency, ''),
-		       enriched_at, created_at, updated_at
+		       COALESCE(images, '[]'), enriched_at, created_at, updated_at,
+		       COALESCE(condition_description, ''), COALESCE(shipping_by_destination, '{}')
 		FROM enriched_items
 		WHERE item_id IN (?` + generatePlaceholders(len(itemIDs)-1) + `)`
 
@@ -1301,12 +2070,15 @@ func (db *DB) GetEnrichedItemsBatch(itemIDs []string, ttlDays int) (map[string]*
 
 	for rows.Next() {
 		var item EnrichedItem
-		err := rows.Scan(&item.ItemID, &item.Brand, &item.CountryOfOrigin,
-			&item.ShippingCost, &item.ShippingCurrency, &item.EnrichedAt,
-			&item.CreatedAt, &item.UpdatedAt)
+		var imagesJSON, byDestinationJSON string
+		err := rows.Scan(&item.ItemID, &item.SKU, &item.Brand, &item.CountryOfOrigin,
+			&item.ShippingCost, &item.ShippingCurrency, &imagesJSON, &item.EnrichedAt,
+			&item.CreatedAt, &item.UpdatedAt, &item.ConditionDescription, &byDestinationJSON)
 		if err != nil {
 			return nil, err
 		}
+		item.Images = parseImages(imagesJSON)
+		item.ShippingByDestination = parseShippingByDestination(byDestinationJSON)
 
 		// Only include if not expired
 		if item.EnrichedAt.After(cutoffTime) {
@@ -1329,33 +2101,114 @@ func generatePlaceholders(count int) string {
 	return result
 }
 
+// Data sources reported in the source field of enriched item and listing
+// responses, so clients can judge how fresh a row is
+const (
+	SourceLive        = "live"         // Listings fetched from eBay for this request
+	SourceFetched     = "fetched"      // Item enrichment fetched from eBay for this request
+	SourceMemoryCache = "memory-cache" // Served from the server's in-memory cache
+	SourceDBCache     = "db-cache"     // Read from enriched_items
+	SourceExport      = "export"       // Exported offer whose listing isn't enriched yet
+)
+
 // ListingItem represents a fully enriched listing for the frontend
 type ListingItem struct {
 	ItemID          string   `json:"itemId"`
 	OfferID         string   `json:"offerId"`
 	Title           string   `json:"title"`
-	Price           float64  `json:"price"`
-	Currency        string   `json:"currency"`
+	Price           float64  `json:"price"`        // From the listing's synced offer, 0 if not synced
+	Currency        string   `json:"currency"`     // Price currency
+	Quantity        int      `json:"quantity"`     // From the last listings fetch, 0 if not seen yet
+	QuantitySold    int      `json:"quantitySold"` // Units sold, from the last listings fetch
 	ImageURL        string   `json:"imageUrl"`
 	Brand           string   `json:"brand"`
 	CountryOfOrigin string   `json:"countryOfOrigin"`
 	ExpectedCOO     string   `json:"expectedCoo"` // From brand mapping
 	COOMatch        string   `json:"cooMatch"`    // "match", "mismatch", "missing"
 	WeightBand      string   `json:"weightBand"`
-	ShippingCost    float64  `json:"shippingCost"`
-	CalculatedCost  float64  `json:"calculatedCost"` // Server-calculated postage
-	Diff            float64  `json:"diff"`           // ShippingCost - CalculatedCost
-	DiffStatus      string   `json:"diffStatus"`     // "ok" (green) or "bad" (red)
+	ShippingCost    float64  `json:"shippingCost"`    // In ShippingCurrency, as listed on eBay
+	ShippingCostAUD float64  `json:"shippingCostAUD"` // Converted to AUD; Diff is based on this
+	CalculatedCost  float64  `json:"calculatedCost"`  // Server-calculated postage
+	TariffRate      float64  `json:"tariffRate"`      // Rate CalculatedCost's duties used
+	Diff            float64  `json:"diff"`            // ShippingCostAUD - CalculatedCost
+	DiffStatus      string   `json:"diffStatus"`      // "ok" (green), "bad" (red) or DiffStatusUnknown; empty with CalcError
 	Images          []string `json:"images"`
+
+	// Why CalculatedCost couldn't be worked out, e.g. no postal rate for the
+	// weight band. CalculatedCost, Diff and DiffStatus are then left empty.
+	CalcError string `json:"calcError,omitempty"`
+
+	ConditionDescription string `json:"conditionDescription,omitempty"`
+	ShippingCurrency     string `json:"shippingCurrency,omitempty"`
+
+	// True when ShippingCurrency has no AUD rate (currency_rates or a
+	// currency_rate_<code> setting). ShippingCostAUD and Diff are then 0 and
+	// DiffStatus is DiffStatusUnknown.
+	ShippingRateMissing bool `json:"shippingRateMissing,omitempty"`
+
+	// Where WeightBand came from: "itemWeight" (the band for WeightGrams,
+	// from item_weights), "brandType" or "default"
+	WeightSource string `json:"weightSource"`
+	WeightGrams  int    `json:"weightGrams,omitempty"`
+
+	// Whether ShippingCurrency is the audit_shipping_currency setting, the
+	// currency buyers in the audited zone expect shipping quoted in
+	ExpectedCurrency string `json:"expectedCurrency"`
+	CurrencyCheck    string `json:"currencyCheck"` // "pass", "fail" or "unknown" (no shipping currency)
+
+	Source     string    `json:"source"`     // SourceDBCache, or SourceExport for unenriched account listings
+	EnrichedAt time.Time `json:"enrichedAt"` // When the enrichment was fetched from eBay
+	AgeSeconds int64     `json:"ageSeconds"` // Age of the enrichment when served
+
+	SKU string `json:"sku,omitempty"` // Account listings only
 }
 
+// DiffStatusUnknown is the diff status of a listing whose shipping cost
+// can't be converted to AUD
+const DiffStatusUnknown = "unknown"
+
 // ListingsQuery represents query parameters for listing search
 type ListingsQuery struct {
-	Search    string
-	SortBy    string // title, price, brand, coo, shipping, calculated, diff
-	SortOrder string // asc, desc
-	Page      int
-	PageSize  int
+	Search        string
+	SortBy        string // brand, coo, shipping, quantitySold; anything else sorts by item ID
+	SortOrder     string // asc, desc
+	CurrencyCheck string // "pass" or "fail" to filter by ListingItem.CurrencyCheck; empty for all
+	AccountID     int64  // Enriched listings take their price from this account's offers; 0 for any account
+	Page          int
+	PageSize      int
+}
+
+// Values of ListingItem.CurrencyCheck
+const (
+	CurrencyCheckPass    = "pass"
+	CurrencyCheckFail    = "fail"
+	CurrencyCheckUnknown = "unknown"
+)
+
+// DefaultAuditCurrency is used when the audit_shipping_currency setting is
+// missing or empty. Listings are audited against US postage.
+const DefaultAuditCurrency = "USD"
+
+// CheckShippingCurrency compares a listing's shipping currency with the
+// currency expected for the audited zone, ignoring case
+func CheckShippingCurrency(shippingCurrency, expected string) string {
+	if shippingCurrency == "" {
+		return CurrencyCheckUnknown
+	}
+	if strings.EqualFold(shippingCurrency, expected) {
+		return CurrencyCheckPass
+	}
+	return CurrencyCheckFail
+}
+
+// GetAuditCurrency returns the audit_shipping_currency setting, upper-cased,
+// or DefaultAuditCurrency when it isn't set
+func (db *DB) GetAuditCurrency() (string, error) {
+	setting, err := db.GetSetting("audit_shipping_currency")
+	if err != nil || setting == nil || strings.TrimSpace(setting.Value) == "" {
+		return DefaultAuditCurrency, err
+	}
+	return strings.ToUpper(strings.TrimSpace(setting.Value)), nil
 }
 
 // ListingsResult represents paginated listings response
@@ -1368,34 +2221,36 @@ type ListingsResult struct {
 }
 
 // GetListings retrieves enriched listings with sorting, filtering, and pagination
-// All business logic (COO matching, postage calculation) happens server-side
-func (db *DB) GetListings(query ListingsQuery) (*ListingsResult, error) {
-	// Build the query with JOINs to get all data
-	baseQuery := `
-		SELECT
-			e.item_id,
-			e.item_id as offer_id,
-			COALESCE(e.brand, '') as brand,
-			COALESCE(e.country_of_origin, '') as country_of_origin,
-			COALESCE(e.shipping_cost, '0') as shipping_cost,
-			COALESCE(e.images, '[]') as images,
-			COALESCE(bcm.primary_coo, 'China') as expected_coo,
-			COALESCE(tr.tariff_rate, 0.20) as tariff_rate
-		FROM enriched_items e
-		LEFT JOIN brand_coo_mappings bcm ON LOWER(e.brand) = LOWER(bcm.brand_name)
-		LEFT JOIN tariff_rates tr ON LOWER(COALESCE(e.country_of_origin, bcm.primary_coo, 'China')) = LOWER(tr.country_name)
-		WHERE 1=1
-	`
-
-	var args []interface{}
+// All business logic (COO matching, postage calculation) happens server-side,
+// with calc (normally the handler's live config)
+func (db *DB) GetListings(query ListingsQuery, calc *calculator.CalculatorConfig) (*ListingsResult, error) {
+	baseQuery, args := listingsBaseQuery(query)
+	return db.listingsPage(baseQuery, args, listingsOrderBy(query, "e.item_id"), query, func(rows *sql.Rows) (*ListingItem, error) {
+		return scanListing(rows, calc)
+	})
+}
 
-	// Add search filter
-	if query.Search != "" {
-		baseQuery += " AND (LOWER(e.brand) LIKE ? OR LOWER(e.item_id) LIKE ?)"
-		searchTerm := "%" + query.Search + "%"
-		args = append(args, searchTerm, searchTerm)
-	}
+// GetAccountListings is GetListings for one account's exported offers
+// rather than every enriched item. Each offer is joined to its inventory
+// item (for title and brand) and, once its listing has been enriched, to the
+// enrichment; offers without enrichment have Source SourceExport and no
+// shipping cost.
+func (db *DB) GetAccountListings(accountID int64, query ListingsQuery, calc *calculator.CalculatorConfig) (*ListingsResult, error) {
+	baseQuery, args := accountListingsBaseQuery(accountID, query)
+	return db.listingsPage(baseQuery, args, listingsOrderBy(query, "o.offer_id"), query, func(rows *sql.Rows) (*ListingItem, error) {
+		var sku string
+		item, err := scanListing(rows, calc, &sku)
+		if err != nil {
+			return nil, err
+		}
+		item.SKU = sku
+		return item, nil
+	})
+}
 
+// listingsPage counts baseQuery's rows and returns query's page of them,
+// sorted by orderBy and read with scan
+func (db *DB) listingsPage(baseQuery string, args []interface{}, orderBy string, query ListingsQuery, scan func(*sql.Rows) (*ListingItem, error)) (*ListingsResult, error) {
 	// Get total count
 	countQuery := "SELECT COUNT(*) FROM (" + baseQuery + ")"
 	var total int
@@ -1404,23 +2259,6 @@ func (db *DB) GetListings(query ListingsQuery) (*ListingsResult, error) {
 		return nil, fmt.Errorf("failed to count listings: %w", err)
 	}
 
-	// Add sorting
-	orderBy := " ORDER BY "
-	switch query.SortBy {
-	case "brand":
-		orderBy += "brand"
-	case "coo":
-		orderBy += "country_of_origin"
-	case "shipping":
-		orderBy += "CAST(shipping_cost AS REAL)"
-	default:
-		orderBy += "e.item_id"
-	}
-	if query.SortOrder == "desc" {
-		orderBy += " DESC"
-	} else {
-		orderBy += " ASC"
-	}
 	baseQuery += orderBy
 
 	// Add pagination
@@ -1442,50 +2280,11 @@ func (db *DB) GetListings(query ListingsQuery) (*ListingsResult, error) {
 
 	var items []ListingItem
 	for rows.Next() {
-		var item ListingItem
-		var imagesJSON string
-		var tariffRate float64
-		var shippingCostStr string
-
-		err := rows.Scan(
-			&item.ItemID,
-			&item.OfferID,
-			&item.Brand,
-			&item.CountryOfOrigin,
-			&shippingCostStr,
-			&imagesJSON,
-			&item.ExpectedCOO,
-			&tariffRate,
-		)
+		item, err := scan(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan listing: %w", err)
-		}
-
-		// Parse shipping cost
-		fmt.Sscanf(shippingCostStr, "%f", &item.ShippingCost)
-
-		// Calculate COO match status
-		if item.CountryOfOrigin == "" {
-			item.COOMatch = "missing"
-		} else if item.CountryOfOrigin == item.ExpectedCOO {
-			item.COOMatch = "match"
-		} else {
-			item.COOMatch = "mismatch"
-		}
-
-		// Server-side postage calculation
-		item.CalculatedCost = calculatePostage(item.Price, tariffRate)
-		item.Diff = item.ShippingCost - item.CalculatedCost
-
-		// 5% threshold for diff status
-		threshold := item.CalculatedCost * 1.05
-		if item.ShippingCost >= threshold {
-			item.DiffStatus = "ok"
-		} else {
-			item.DiffStatus = "bad"
+			return nil, err
 		}
-
-		items = append(items, item)
+		items = append(items, *item)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -1503,36 +2302,276 @@ func (db *DB) GetListings(query ListingsQuery) (*ListingsResult, error) {
 	}, nil
 }
 
-// Server-side postage calculation
-// Formula: AusPost Shipping + Extra Cover + Tariff Duties + Zonos Fees
-func calculatePostage(price, tariffRate float64) float64 {
-	const (
-		handlingFee         = 0.02
-		zonosPercentage     = 0.10
-		zonosFixedCost      = 1.69
-		extraCoverBase      = 4.00
-		extraCoverDiscount  = 0.40
-		extraCoverThreshold = 100.0
-		savingsDiscount     = 0.175 // Band 3 default
-		ausPostBase         = 60.00 // Medium weight band
-	)
+// EachListing calls fn for every listing matching query's search and sort,
+// ignoring pagination. Rows are read one at a time so large exports don't
+// need to be held in memory. Iteration stops at the first error from fn.
+func (db *DB) EachListing(query ListingsQuery, calc *calculator.CalculatorConfig, fn func(*ListingItem) error) error {
+	baseQuery, args := listingsBaseQuery(query)
+	baseQuery += listingsOrderBy(query, "e.item_id")
+
+	rows, err := db.Query(baseQuery, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query listings: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanListing(rows, calc)
+		if err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// listingsBaseQuery builds the listings SELECT (with JOINs to get all data) and search filter.
+// The title is the inventory item of the offer the price comes from, so it's
+// empty until the listing's account has been synced.
+func listingsBaseQuery(query ListingsQuery) (string, []interface{}) {
+	baseQuery := `
+		SELECT
+			e.item_id,
+			e.item_id as offer_id,
+			COALESCE(e.brand, '') as brand,
+			COALESCE(e.country_of_origin, '') as country_of_origin,
+			COALESCE(e.shipping_cost, '0') as shipping_cost,
+			COALESCE(e.shipping_currency, '') as shipping_currency,
+			COALESCE(e.images, '[]') as images,
+			COALESCE(e.condition_description, '') as condition_description,
+			e.enriched_at,
+			UPPER(COALESCE(NULLIF(TRIM(audit_fx.value), ''), '` + DefaultAuditCurrency + `')) as expected_currency,
+			COALESCE(CAST(json_extract(price.data, '$.pricingSummary.price.value') AS REAL), 0) as price,
+			COALESCE(json_extract(price.data, '$.pricingSummary.price.currency'), '') as price_currency,
+			COALESCE(e.quantity, 0) as quantity,
+			COALESCE(e.quantity_sold, 0) as quantity_sold,
+			COALESCE(iw.weight_grams, 0) as weight_grams,
+			COALESCE(inv.title, '') as title
+		FROM enriched_items e
+		LEFT JOIN item_weights iw ON iw.item_id = e.item_id
+		LEFT JOIN offers price ON price.id = (
+			-- Price from the account's most recently synced offer for the
+			-- listing; one idx_offers_listing lookup per row
+			SELECT o.id FROM offers o
+			WHERE o.listing_id = e.item_id AND (? = 0 OR o.account_id = ?)
+			ORDER BY o.updated_at DESC
+			LIMIT 1
+		)
+		LEFT JOIN inventory_items inv ON inv.account_id = price.account_id AND inv.sku = price.sku
+		` + listingsCurrencyJoins + `
+		WHERE 1=1
+	`
+
+	args := []interface{}{query.AccountID, query.AccountID}
+	return listingsFilter(baseQuery, args, query, "LOWER(e.brand)", "LOWER(e.item_id)")
+}
+
+// accountListingsBaseQuery is listingsBaseQuery for one account's exported
+// offers, selecting the same columns followed by sku
+func accountListingsBaseQuery(accountID int64, query ListingsQuery) (string, []interface{}) {
+	baseQuery := `
+		SELECT
+			COALESCE(o.listing_id, '') as item_id,
+			o.offer_id,
+			COALESCE(NULLIF(e.brand, ''), i.brand, '') as brand,
+			COALESCE(e.country_of_origin, '') as country_of_origin,
+			COALESCE(e.shipping_cost, '0') as shipping_cost,
+			COALESCE(e.shipping_currency, '') as shipping_currency,
+			COALESCE(e.images, '[]') as images,
+			COALESCE(e.condition_description, '') as condition_description,
+			e.enriched_at,
+			UPPER(COALESCE(NULLIF(TRIM(audit_fx.value), ''), '` + DefaultAuditCurrency + `')) as expected_currency,
+			COALESCE(CAST(json_extract(o.data, '$.pricingSummary.price.value') AS REAL), 0) as price,
+			COALESCE(json_extract(o.data, '$.pricingSummary.price.currency'), '') as price_currency,
+			COALESCE(e.quantity, 0) as quantity,
+			COALESCE(e.quantity_sold, 0) as quantity_sold,
+			COALESCE(iw.weight_grams, 0) as weight_grams,
+			COALESCE(i.title, '') as title,
+			o.sku
+		FROM offers o
+		LEFT JOIN inventory_items i ON i.account_id = o.account_id AND i.sku = o.sku
+		LEFT JOIN enriched_items e ON e.item_id = o.listing_id AND o.listing_id != ''
+		LEFT JOIN item_weights iw ON iw.item_id = o.listing_id AND o.listing_id != ''
+		` + listingsCurrencyJoins + `
+		WHERE o.account_id = ?
+	`
+
+	return listingsFilter(baseQuery, []interface{}{accountID}, query,
+		"LOWER(COALESCE(NULLIF(e.brand, ''), i.brand))", "LOWER(o.listing_id)", "LOWER(i.title)", "LOWER(o.sku)")
+}
 
-	// AusPost shipping with handling fee and savings discount
-	ausPostShipping := ausPostBase * (1 + handlingFee) * (1 - savingsDiscount)
+// listingsCurrencyJoins joins the audit_shipping_currency setting. AUD
+// rates aren't joined here: scanListing converts with the calculator's
+// rates (GetCurrencyRates), so currency codes are matched in one place.
+const listingsCurrencyJoins = `LEFT JOIN settings audit_fx ON audit_fx.key = 'audit_shipping_currency'`
 
-	// Extra cover for items over $100
-	var extraCover float64
-	if price > extraCoverThreshold {
-		coverableAmount := price - extraCoverThreshold
-		coverUnits := (coverableAmount + 99) / 100 // Ceiling division
-		extraCover = coverUnits * extraCoverBase * (1 - extraCoverDiscount)
+// listingsFilter appends query's search (matched against searchExprs) and
+// currency filters to a listings SELECT
+func listingsFilter(baseQuery string, args []interface{}, query ListingsQuery, searchExprs ...string) (string, []interface{}) {
+	// Add search filter
+	if query.Search != "" {
+		searchTerm := "%" + query.Search + "%"
+		conditions := make([]string, len(searchExprs))
+		for i, expr := range searchExprs {
+			conditions[i] = expr + " LIKE ?"
+			args = append(args, searchTerm)
+		}
+		baseQuery += " AND (" + strings.Join(conditions, " OR ") + ")"
 	}
 
-	// Tariff duties
-	tariffDuties := price * tariffRate
+	// Currency audit filter; mirrors CheckShippingCurrency, so listings with
+	// no shipping currency match neither pass nor fail
+	switch query.CurrencyCheck {
+	case CurrencyCheckPass:
+		baseQuery += " AND COALESCE(e.shipping_currency, '') != '' AND UPPER(e.shipping_currency) = expected_currency"
+	case CurrencyCheckFail:
+		baseQuery += " AND COALESCE(e.shipping_currency, '') != '' AND UPPER(e.shipping_currency) != expected_currency"
+	}
+
+	return baseQuery, args
+}
+
+// listingsOrderBy returns the ORDER BY clause for query's sort options.
+// idColumn is the default sort and breaks quantity ties.
+func listingsOrderBy(query ListingsQuery, idColumn string) string {
+	orderBy := " ORDER BY "
+	switch query.SortBy {
+	case "brand":
+		orderBy += "brand"
+	case "coo":
+		orderBy += "country_of_origin"
+	case "shipping":
+		orderBy += "CAST(shipping_cost AS REAL)"
+	case "quantitySold":
+		// Ties (most often unsold listings) keep a stable order
+		if query.SortOrder == "desc" {
+			return orderBy + "quantity_sold DESC, " + idColumn + " ASC"
+		}
+		return orderBy + "quantity_sold ASC, " + idColumn + " ASC"
+	default:
+		orderBy += idColumn
+	}
+	if query.SortOrder == "desc" {
+		orderBy += " DESC"
+	} else {
+		orderBy += " ASC"
+	}
+	return orderBy
+}
 
-	// Zonos fees
-	zonosFees := (tariffDuties * zonosPercentage) + zonosFixedCost
+// scanListing reads one listings row and applies the server-side business
+// logic. Columns selected after the standard ones are scanned into extra.
+func scanListing(rows *sql.Rows, calc *calculator.CalculatorConfig, extra ...any) (*ListingItem, error) {
+	var item ListingItem
+	var imagesJSON string
+	var shippingCostStr string
+	var enrichedAt sql.NullTime
+
+	dest := []any{
+		&item.ItemID,
+		&item.OfferID,
+		&item.Brand,
+		&item.CountryOfOrigin,
+		&shippingCostStr,
+		&item.ShippingCurrency,
+		&imagesJSON,
+		&item.ConditionDescription,
+		&enrichedAt,
+		&item.ExpectedCurrency,
+		&item.Price,
+		&item.Currency,
+		&item.Quantity,
+		&item.QuantitySold,
+		&item.WeightGrams,
+		&item.Title,
+	}
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return nil, fmt.Errorf("failed to scan listing: %w", err)
+	}
+
+	if enrichedAt.Valid {
+		item.Source = SourceDBCache
+		item.EnrichedAt = enrichedAt.Time
+		item.AgeSeconds = int64(time.Since(item.EnrichedAt).Seconds())
+	} else {
+		item.Source = SourceExport
+	}
+
+	// Parse shipping cost and convert to AUD so it compares with the calculation
+	fmt.Sscanf(shippingCostStr, "%f", &item.ShippingCost)
+	var converted bool
+	item.ShippingCostAUD, converted = calc.ToAUD(item.ShippingCost, item.ShippingCurrency)
+	item.ShippingRateMissing = !converted
+
+	// Images are stored as a JSON array; the first doubles as the thumbnail
+	item.Images = parseImages(imagesJSON)
+	if len(item.Images) > 0 {
+		item.ImageURL = item.Images[0]
+	}
+
+	item.CurrencyCheck = CheckShippingCurrency(item.ShippingCurrency, item.ExpectedCurrency)
+
+	// Expected COO and match status come from the calculator's brand lookup,
+	// as in /api/calculate/batch, so near-miss brand names are matched the
+	// same way (brand_fuzzy_match) everywhere
+	item.ExpectedCOO = calc.GetCountryOfOrigin(item.Brand)
+	item.COOMatch = calc.COOStatus(item.Brand, item.CountryOfOrigin)
+
+	// Postage comes from the calculator package, with the same inputs as
+	// /api/calculate/batch. This used to be a private copy of the formula
+	// that hardcoded the Medium band, discount band 3 and the seed rates, so
+	// it silently drifted whenever rates, bands or tariffs were edited and
+	// disagreed with every other screen.
+	coo := item.CountryOfOrigin
+	if coo == "" {
+		coo = item.ExpectedCOO
+	}
+	weightBand, weightSource := calc.ItemWeightBand(item.Brand, item.WeightGrams)
+	priceAUD, ok := calc.ToAUD(item.Price, item.Currency)
+	if !ok {
+		// Extra cover and duties depend on the item's value
+		item.WeightBand = weightBand
+		item.WeightSource = weightSource
+		item.CalcError = fmt.Sprintf("no AUD rate for price currency %s", item.Currency)
+		return &item, nil
+	}
+	result, err := calc.CalculateUSAShipping(calculator.CalculateUSAShippingParams{
+		ItemValueAUD:      priceAUD,
+		WeightBand:        weightBand,
+		BrandName:         item.Brand,
+		CountryOfOrigin:   coo,
+		IncludeExtraCover: priceAUD > 100,
+		DiscountBand:      calc.FallbackDiscountBand, // Same default as BatchCalculate
+	})
+	if err != nil {
+		// A row the calculator can't price (e.g. its band has no rate in the
+		// zone) is reported on the row rather than failing the whole page
+		item.WeightBand = weightBand
+		item.WeightSource = weightSource
+		item.CalcError = err.Error()
+		return &item, nil
+	}
+	item.WeightBand = result.Inputs.WeightBand
+	item.WeightSource = weightSource
+	item.CalculatedCost = result.Total
+	item.TariffRate = result.Inputs.TariffRate
+	if item.ShippingRateMissing {
+		// The listed cost is in a currency with no AUD rate, so there's
+		// nothing to compare it with
+		item.DiffStatus = DiffStatusUnknown
+		return &item, nil
+	}
+	item.Diff = item.ShippingCostAUD - item.CalculatedCost
+
+	// 5% threshold for diff status
+	threshold := item.CalculatedCost * 1.05
+	if item.ShippingCostAUD >= threshold {
+		item.DiffStatus = "ok"
+	} else {
+		item.DiffStatus = "bad"
+	}
 
-	return ausPostShipping + extraCover + tariffDuties + zonosFees
+	return &item, nil
 }
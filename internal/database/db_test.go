@@ -0,0 +1,675 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/calculator"
+)
+
+func TestSaveEnrichedItemKeepsShippingByDestination(t *testing.T) {
+	db := openTestDB(t)
+	byDestination := map[string]string{"United States": "25.00", "New Zealand": "30.00"}
+
+	// Trading API enrichment: SKU and per-destination shipping
+	err := db.SaveEnrichedItem(&EnrichedItem{
+		ItemID:                "item-1",
+		SKU:                   "SKU-1",
+		Brand:                 "Acme",
+		ShippingCost:          "25.00",
+		ShippingCurrency:      "AUD",
+		ShippingByDestination: byDestination,
+		EnrichedAt:            time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("SaveEnrichedItem: %v", err)
+	}
+
+	// Browse API fallback: neither is returned, so the stored values stay
+	err = db.SaveEnrichedItem(&EnrichedItem{
+		ItemID:           "item-1",
+		Brand:            "Acme",
+		ShippingCost:     "26.00",
+		ShippingCurrency: "AUD",
+		EnrichedAt:       time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("SaveEnrichedItem (fallback): %v", err)
+	}
+
+	item, err := db.GetEnrichedItem("item-1", 30)
+	if err != nil || item == nil {
+		t.Fatalf("GetEnrichedItem = %v, %v", item, err)
+	}
+	if item.SKU != "SKU-1" {
+		t.Errorf("SKU = %q, want SKU-1", item.SKU)
+	}
+	if item.ShippingCost != "26.00" {
+		t.Errorf("ShippingCost = %q, want 26.00", item.ShippingCost)
+	}
+	if !reflect.DeepEqual(item.ShippingByDestination, byDestination) {
+		t.Errorf("ShippingByDestination = %v, want %v", item.ShippingByDestination, byDestination)
+	}
+}
+
+func TestListingsTitleFromInventoryItem(t *testing.T) {
+	db := openSeededDB(t)
+	calc, err := db.GetCalculatorConfig()
+	if err != nil {
+		t.Fatalf("GetCalculatorConfig: %v", err)
+	}
+	acc := createTestAccount(t, db, "seller")
+	saveTestEnrichedItem(t, db, "item-1", "Acme", "China", "25.00", "AUD")
+	saveTestEnrichedItem(t, db, "item-2", "Acme", "China", "25.00", "AUD")
+	addTestOffer(t, db, acc.ID, "1", "item-1", 50)
+	if _, err := db.Exec(`INSERT INTO inventory_items (account_id, sku, title, data) VALUES (?, 'SKU-1', 'Blue teapot', '{}')`, acc.ID); err != nil {
+		t.Fatalf("insert inventory item: %v", err)
+	}
+
+	if got := listingByID(t, db, ListingsQuery{}, calc, "item-1").Title; got != "Blue teapot" {
+		t.Errorf("item-1 title = %q, want Blue teapot", got)
+	}
+	// Not synced from any account yet
+	if got := listingByID(t, db, ListingsQuery{}, calc, "item-2").Title; got != "" {
+		t.Errorf("item-2 title = %q, want empty", got)
+	}
+}
+
+func TestListingsReportCalcErrorPerRow(t *testing.T) {
+	db := openSeededDB(t)
+	acc := createTestAccount(t, db, "seller")
+	saveTestEnrichedItem(t, db, "item-1", "Aje", "China", "30.00", "AUD")
+	saveTestEnrichedItem(t, db, "item-2", "Lack of Color", "China", "30.00", "AUD")
+	addTestOffer(t, db, acc.ID, "1", "item-1", 80)
+	addTestOffer(t, db, acc.ID, "2", "item-2", 80)
+
+	calc, err := db.GetCalculatorConfig()
+	if err != nil {
+		t.Fatalf("GetCalculatorConfig: %v", err)
+	}
+	// Lack of Color is a hat brand; drop its band's US rate so only it fails
+	hatBand, _ := calc.ItemWeightBand("Lack of Color", 0)
+	defaultBand, _ := calc.ItemWeightBand("Aje", 0)
+	if hatBand == defaultBand {
+		t.Fatalf("test needs different bands, both are %s", hatBand)
+	}
+	delete(calc.PostalZones["3-USA & Canada"].WeightBands, hatBand)
+
+	failed := listingByID(t, db, ListingsQuery{}, calc, "item-2")
+	if failed.CalcError == "" || failed.DiffStatus != "" || failed.CalculatedCost != 0 {
+		t.Errorf("item-2 = {calcError %q diffStatus %q cost %.2f}, want an error and no status", failed.CalcError, failed.DiffStatus, failed.CalculatedCost)
+	}
+	if ok := listingByID(t, db, ListingsQuery{}, calc, "item-1"); ok.CalcError != "" || ok.DiffStatus == "" {
+		t.Errorf("item-1 = {calcError %q diffStatus %q}, want it priced", ok.CalcError, ok.DiffStatus)
+	}
+
+	summary, err := db.GetListingsSummary(ListingsQuery{}, calc)
+	if err != nil {
+		t.Fatalf("GetListingsSummary: %v", err)
+	}
+	if summary.Total != 2 || summary.CalcErrors != 1 || summary.ByDiffStatus["ok"]+summary.ByDiffStatus["bad"] != 1 {
+		t.Errorf("summary = %+v, want 2 listings with 1 calc error", summary)
+	}
+}
+
+func TestListingsShippingCurrency(t *testing.T) {
+	db := openSeededDB(t)
+	acc := createTestAccount(t, db, "seller")
+	calc, err := db.GetCalculatorConfig()
+	if err != nil {
+		t.Fatalf("GetCalculatorConfig: %v", err)
+	}
+	rate, ok := calc.CurrencyRates["USD"]
+	if !ok {
+		t.Fatal("no seeded USD rate")
+	}
+
+	// 40 USD of shipping on an 80 AUD item: compared in AUD, not as 40 AUD
+	saveTestEnrichedItem(t, db, "item-usd", "Aje", "China", "40.00", "USD")
+	saveTestEnrichedItem(t, db, "item-zzz", "Aje", "China", "1.00", "ZZZ")
+	addTestOffer(t, db, acc.ID, "1", "item-usd", 80)
+	addTestOffer(t, db, acc.ID, "2", "item-zzz", 80)
+
+	usd := listingByID(t, db, ListingsQuery{}, calc, "item-usd")
+	wantAUD := math.Round(40*rate*100) / 100
+	if usd.ShippingRateMissing || usd.ShippingCostAUD != wantAUD {
+		t.Errorf("USD shipping = %.2f AUD (missing %v), want %.2f", usd.ShippingCostAUD, usd.ShippingRateMissing, wantAUD)
+	}
+	wantStatus := "bad"
+	if wantAUD >= usd.CalculatedCost*1.05 {
+		wantStatus = "ok"
+	}
+	if usd.DiffStatus != wantStatus {
+		t.Errorf("USD diff status = %s, want %s for %.2f AUD against %.2f", usd.DiffStatus, wantStatus, wantAUD, usd.CalculatedCost)
+	}
+
+	zzz := listingByID(t, db, ListingsQuery{}, calc, "item-zzz")
+	if !zzz.ShippingRateMissing || zzz.DiffStatus != DiffStatusUnknown || zzz.Diff != 0 {
+		t.Errorf("ZZZ listing = {missing %v status %q diff %.2f}, want unknown with no diff", zzz.ShippingRateMissing, zzz.DiffStatus, zzz.Diff)
+	}
+
+	summary, err := db.GetListingsSummary(ListingsQuery{}, calc)
+	if err != nil {
+		t.Fatalf("GetListingsSummary: %v", err)
+	}
+	if summary.ByDiffStatus[DiffStatusUnknown] != 1 {
+		t.Errorf("unknown count = %d, want 1", summary.ByDiffStatus[DiffStatusUnknown])
+	}
+	wantUnderpriced := 0.0
+	if usd.Diff < 0 {
+		wantUnderpriced = math.Round(usd.Diff*100) / 100
+	}
+	if summary.UnderpricedAUD != wantUnderpriced {
+		t.Errorf("underpriced = %.2f, want %.2f (the USD listing only)", summary.UnderpricedAUD, wantUnderpriced)
+	}
+}
+
+func TestListingsExpectedCOOUsesFuzzyBrandMatch(t *testing.T) {
+	db := openSeededDB(t)
+	acc := createTestAccount(t, db, "seller")
+	saveTestEnrichedItem(t, db, "item-1", "Camilla", "India", "30.00", "AUD")
+	addTestOffer(t, db, acc.ID, "1", "item-1", 80)
+
+	for _, fuzzy := range []bool{false, true} {
+		if err := db.UpdateSetting("brand_fuzzy_match", fmt.Sprint(fuzzy)); err != nil {
+			t.Fatalf("UpdateSetting: %v", err)
+		}
+		calc, err := db.GetCalculatorConfig()
+		if err != nil {
+			t.Fatalf("GetCalculatorConfig: %v", err)
+		}
+		item := listingByID(t, db, ListingsQuery{}, calc, "item-1")
+		if want := calc.GetCountryOfOrigin("Camilla"); item.ExpectedCOO != want {
+			t.Errorf("fuzzy %v: expected COO = %q, want the calculator's %q", fuzzy, item.ExpectedCOO, want)
+		}
+		wantMatch := "mismatch" // Default COO
+		if fuzzy {
+			wantMatch = "match" // Camilla Franks is made in India
+		}
+		if item.COOMatch != wantMatch {
+			t.Errorf("fuzzy %v: COO match = %q, want %q", fuzzy, item.COOMatch, wantMatch)
+		}
+	}
+}
+
+func TestCurrencyRateOverrideAnyCase(t *testing.T) {
+	db := openSeededDB(t)
+	acc := createTestAccount(t, db, "seller")
+	saveTestEnrichedItem(t, db, "item-1", "Aje", "China", "10.00", "usd")
+	addTestOffer(t, db, acc.ID, "1", "item-1", 80)
+
+	for _, key := range []string{"currency_rate_USD", "currency_rate_usd"} {
+		t.Run(key, func(t *testing.T) {
+			if _, err := db.Exec(`DELETE FROM settings WHERE key LIKE 'currency_rate_%'`); err != nil {
+				t.Fatalf("clear overrides: %v", err)
+			}
+			if _, err := db.Exec(`INSERT INTO settings (key, value, data_type) VALUES (?, '2.5', 'float')`, key); err != nil {
+				t.Fatalf("insert %s: %v", key, err)
+			}
+			calc, err := db.GetCalculatorConfig()
+			if err != nil {
+				t.Fatalf("GetCalculatorConfig: %v", err)
+			}
+			item := listingByID(t, db, ListingsQuery{}, calc, "item-1")
+			if item.ShippingRateMissing || item.ShippingCostAUD != 25 {
+				t.Errorf("shipping = %.2f AUD (missing %v), want 25.00 from the override", item.ShippingCostAUD, item.ShippingRateMissing)
+			}
+		})
+	}
+}
+
+func TestListingsPriceWithoutRate(t *testing.T) {
+	db := openSeededDB(t)
+	acc := createTestAccount(t, db, "seller")
+	saveTestEnrichedItem(t, db, "item-1", "Aje", "China", "30.00", "AUD")
+	data := `{"pricingSummary":{"price":{"value":"80.00","currency":"ZZZ"}}}`
+	if _, err := db.Exec(`INSERT INTO offers (account_id, offer_id, sku, listing_id, data) VALUES (?, '1', 'SKU-1', 'item-1', ?)`, acc.ID, data); err != nil {
+		t.Fatalf("insert offer: %v", err)
+	}
+	calc, err := db.GetCalculatorConfig()
+	if err != nil {
+		t.Fatalf("GetCalculatorConfig: %v", err)
+	}
+
+	item := listingByID(t, db, ListingsQuery{}, calc, "item-1")
+	if item.CalcError == "" || item.CalculatedCost != 0 {
+		t.Errorf("listing = {calcError %q cost %.2f}, want an error rather than pricing 80 ZZZ as 80 AUD", item.CalcError, item.CalculatedCost)
+	}
+}
+
+func TestGetOrCreateAccountFromEbayReactivatesDeletedAccount(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, db *DB) *Account
+	}{
+		{
+			// Matched on the eBay user ID
+			name: "signed in before",
+			setup: func(t *testing.T, db *DB) *Account {
+				acc, err := db.GetOrCreateAccountFromEbay("u-1", "seller", "sandbox", "EBAY_AU")
+				if err != nil {
+					t.Fatalf("GetOrCreateAccountFromEbay: %v", err)
+				}
+				return acc
+			},
+		},
+		{
+			// No eBay user ID yet, so the insert hits the account_key conflict
+			name: "same account key",
+			setup: func(t *testing.T, db *DB) *Account {
+				return createTestAccount(t, db, "seller_sandbox_EBAY_AU")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			existing := tt.setup(t, db)
+			if err := db.SetAccountDeleted(existing.AccountKey, true); err != nil {
+				t.Fatalf("SetAccountDeleted: %v", err)
+			}
+
+			acc, err := db.GetOrCreateAccountFromEbay("u-1", "seller", "sandbox", "EBAY_AU")
+			if err != nil {
+				t.Fatalf("GetOrCreateAccountFromEbay: %v", err)
+			}
+			if acc.ID != existing.ID {
+				t.Errorf("ID = %d, want the existing account %d", acc.ID, existing.ID)
+			}
+			if acc.DeletedAt != nil {
+				t.Errorf("DeletedAt = %v, want nil", acc.DeletedAt)
+			}
+
+			accounts, err := db.GetAccounts(false)
+			if err != nil {
+				t.Fatalf("GetAccounts: %v", err)
+			}
+			if len(accounts) != 1 || accounts[0].ID != existing.ID {
+				t.Errorf("active accounts = %+v, want only account %d", accounts, existing.ID)
+			}
+		})
+	}
+}
+
+func TestSaveEnrichedItemsBatch(t *testing.T) {
+	db := openTestDB(t)
+	saveTestEnrichedItem(t, db, "item-1", "Old", "China", "10.00", "AUD")
+
+	items := benchmarkEnrichedItems(3)
+	items[1].ItemID = "item-1" // Replaces the stored row
+	if err := db.SaveEnrichedItemsBatch(items); err != nil {
+		t.Fatalf("SaveEnrichedItemsBatch: %v", err)
+	}
+
+	for _, want := range items {
+		got, err := db.GetEnrichedItem(want.ItemID, 30)
+		if err != nil || got == nil {
+			t.Fatalf("GetEnrichedItem(%s) = %v, %v", want.ItemID, got, err)
+		}
+		if got.Brand != want.Brand || got.ShippingCost != want.ShippingCost {
+			t.Errorf("%s = brand %q shipping %q, want %q %q", want.ItemID, got.Brand, got.ShippingCost, want.Brand, want.ShippingCost)
+		}
+	}
+}
+
+// benchmarkEnrichedItems returns n distinct enriched items
+func benchmarkEnrichedItems(n int) []*EnrichedItem {
+	items := make([]*EnrichedItem, n)
+	for i := range items {
+		items[i] = &EnrichedItem{
+			ItemID:           fmt.Sprintf("item-%d", i),
+			SKU:              fmt.Sprintf("SKU-%d", i),
+			Brand:            "Acme",
+			CountryOfOrigin:  "China",
+			ShippingCost:     "25.00",
+			ShippingCurrency: "AUD",
+			EnrichedAt:       time.Now(),
+		}
+	}
+	return items
+}
+
+// The two benchmarks write the same 200-item enrichment batch
+func BenchmarkSaveEnrichedItem(b *testing.B) {
+	db := openTestDB(b)
+	items := benchmarkEnrichedItems(200)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, item := range items {
+			if err := db.SaveEnrichedItem(item); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkSaveEnrichedItemsBatch(b *testing.B) {
+	db := openTestDB(b)
+	items := benchmarkEnrichedItems(200)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := db.SaveEnrichedItemsBatch(items); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGetCalculatorConfigBrandTypeWeightBands(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string // "" leaves the seeded setting
+		want  map[string]string
+	}{
+		{"seeded", "", defaultBrandTypeWeightBands},
+		{"custom", `{"Hats":"Small"}`, map[string]string{"Hats": "Small"}},
+		{"invalid JSON falls back to defaults", `{"Hats":`, defaultBrandTypeWeightBands},
+		{"entries that aren't bands are ignored", `{"Hats":"Tiny","Sneakers":"Large"}`, map[string]string{"Sneakers": "Large"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openSeededDB(t)
+			if tt.value != "" {
+				if err := db.UpdateSetting("brand_type_weight_bands", tt.value); err != nil {
+					t.Fatalf("UpdateSetting: %v", err)
+				}
+			}
+			calc, err := db.GetCalculatorConfig()
+			if err != nil {
+				t.Fatalf("GetCalculatorConfig: %v", err)
+			}
+			if !reflect.DeepEqual(calc.BrandTypeWeightBands, tt.want) {
+				t.Errorf("BrandTypeWeightBands = %v, want %v", calc.BrandTypeWeightBands, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSyncHistoryFiltersAndPages(t *testing.T) {
+	db := openTestDB(t)
+	alice := createTestAccount(t, db, "alice")
+	bob := createTestAccount(t, db, "bob")
+
+	// Oldest first; IDs below are in insert order
+	entries := []struct {
+		accountID        int64
+		syncType, status string
+	}{
+		{alice.ID, "export", "success"}, // 1
+		{alice.ID, "import", "failed"},  // 2
+		{alice.ID, "export", "partial"}, // 3
+		{alice.ID, "export", "success"}, // 4
+		{bob.ID, "export", "success"},   // 5
+		{alice.ID, "import", "success"}, // 6
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, e := range entries {
+		sh := &SyncHistory{AccountID: e.accountID, SyncType: e.syncType, Status: e.status, StartedAt: start.Add(time.Duration(i) * time.Hour)}
+		if err := db.CreateSyncHistory(sh); err != nil {
+			t.Fatalf("CreateSyncHistory: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		query     SyncHistoryQuery
+		wantIDs   []int64
+		wantTotal int
+	}{
+		{"all", SyncHistoryQuery{Limit: 10}, []int64{6, 4, 3, 2, 1}, 5},
+		{"exports", SyncHistoryQuery{SyncType: "export", Limit: 10}, []int64{4, 3, 1}, 3},
+		{"imports", SyncHistoryQuery{SyncType: "import", Limit: 10}, []int64{6, 2}, 2},
+		{"successes", SyncHistoryQuery{Status: "success", Limit: 10}, []int64{6, 4, 1}, 3},
+		{"failed", SyncHistoryQuery{Status: "failed", Limit: 10}, []int64{2}, 1},
+		{"successful exports", SyncHistoryQuery{SyncType: "export", Status: "success", Limit: 10}, []int64{4, 1}, 2},
+		{"first page", SyncHistoryQuery{Limit: 2}, []int64{6, 4}, 5},
+		{"second page", SyncHistoryQuery{Limit: 2, Offset: 2}, []int64{3, 2}, 5},
+		{"past the end", SyncHistoryQuery{Limit: 2, Offset: 6}, nil, 5},
+		{"filtered page", SyncHistoryQuery{SyncType: "export", Limit: 1, Offset: 1}, []int64{3}, 3},
+	}
+	for _, tt := range tests {
+		history, total, err := db.GetSyncHistory(alice.ID, tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var ids []int64
+		for _, sh := range history {
+			ids = append(ids, sh.ID)
+		}
+		if !reflect.DeepEqual(ids, tt.wantIDs) || total != tt.wantTotal {
+			t.Errorf("%s: ids = %v, total = %d; want %v, %d", tt.name, ids, total, tt.wantIDs, tt.wantTotal)
+		}
+	}
+}
+
+func TestCreateDeletionNotificationIdempotent(t *testing.T) {
+	db := openTestDB(t)
+	dn := &DeletionNotification{NotificationID: "n-1", Username: "buyer", EventDate: time.Now(), RawPayload: "{}"}
+
+	for i, want := range []bool{true, false} {
+		created, err := db.CreateDeletionNotification(dn)
+		if err != nil || created != want {
+			t.Fatalf("delivery %d: created = %v, %v; want %v", i+1, created, err, want)
+		}
+	}
+	var rows int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM deletion_notifications`).Scan(&rows); err != nil || rows != 1 {
+		t.Fatalf("stored %d rows (%v), want 1", rows, err)
+	}
+
+	if processed, err := db.IsDeletionNotificationProcessed("n-1"); err != nil || processed {
+		t.Errorf("before processing: processed = %v, %v", processed, err)
+	}
+	if err := db.MarkDeletionNotificationProcessed("n-1"); err != nil {
+		t.Fatalf("MarkDeletionNotificationProcessed: %v", err)
+	}
+	if processed, err := db.IsDeletionNotificationProcessed("n-1"); err != nil || !processed {
+		t.Errorf("after processing: processed = %v, %v", processed, err)
+	}
+	if processed, err := db.IsDeletionNotificationProcessed("unknown"); err != nil || processed {
+		t.Errorf("unknown notification: processed = %v, %v", processed, err)
+	}
+}
+
+func TestQueryDeletionNotifications(t *testing.T) {
+	db := openTestDB(t)
+	for _, id := range []string{"n-1", "n-2", "n-3", "n-4", "n-5"} {
+		if _, err := db.CreateDeletionNotification(&DeletionNotification{NotificationID: id, EventDate: time.Now(), RawPayload: "{}"}); err != nil {
+			t.Fatalf("CreateDeletionNotification(%s): %v", id, err)
+		}
+	}
+	for _, id := range []string{"n-2", "n-4"} {
+		if err := db.MarkDeletionNotificationProcessed(id); err != nil {
+			t.Fatalf("MarkDeletionNotificationProcessed(%s): %v", id, err)
+		}
+	}
+	processed, unprocessed := true, false
+
+	tests := []struct {
+		name      string
+		query     DeletionNotificationsQuery
+		want      []string
+		wantTotal int
+	}{
+		{"all, newest first", DeletionNotificationsQuery{}, []string{"n-5", "n-4", "n-3", "n-2", "n-1"}, 5},
+		{"processed", DeletionNotificationsQuery{Processed: &processed}, []string{"n-4", "n-2"}, 2},
+		{"unprocessed", DeletionNotificationsQuery{Processed: &unprocessed}, []string{"n-5", "n-3", "n-1"}, 3},
+		{"unprocessed, oldest first", DeletionNotificationsQuery{Processed: &unprocessed, OldestFirst: true}, []string{"n-1", "n-3", "n-5"}, 3},
+		{"second page", DeletionNotificationsQuery{Limit: 2, Offset: 2}, []string{"n-3", "n-2"}, 5},
+		{"last partial page", DeletionNotificationsQuery{Processed: &unprocessed, Limit: 2, Offset: 2}, []string{"n-1"}, 3},
+		{"past the end", DeletionNotificationsQuery{Offset: 10}, nil, 5},
+		{"negative offset", DeletionNotificationsQuery{Limit: 1, Offset: -3}, []string{"n-5"}, 5},
+	}
+	for _, tt := range tests {
+		notifications, total, err := db.QueryDeletionNotifications(tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []string
+		for _, dn := range notifications {
+			got = append(got, dn.NotificationID)
+		}
+		if !reflect.DeepEqual(got, tt.want) || total != tt.wantTotal {
+			t.Errorf("%s = %v (total %d), want %v (total %d)", tt.name, got, total, tt.want, tt.wantTotal)
+		}
+	}
+}
+
+func TestListingsSortByQuantitySold(t *testing.T) {
+	db := openSeededDB(t)
+	calc, err := db.GetCalculatorConfig()
+	if err != nil {
+		t.Fatalf("GetCalculatorConfig: %v", err)
+	}
+	for _, id := range []string{"item-1", "item-2", "item-3", "item-4"} {
+		saveTestEnrichedItem(t, db, id, "Aje", "China", "30.00", "AUD")
+	}
+
+	quantities := map[string]ListingQuantity{
+		"item-1":  {Quantity: 5, QuantitySold: 2},
+		"item-2":  {Quantity: 1, QuantitySold: 9},
+		"item-3":  {Quantity: 3, QuantitySold: 2},
+		"missing": {Quantity: 1, QuantitySold: 100}, // Not enriched: ignored
+	}
+	if updated, err := db.SetEnrichedItemQuantities(quantities); err != nil || updated != 3 {
+		t.Fatalf("SetEnrichedItemQuantities = %d, %v; want 3", updated, err)
+	}
+	// Unchanged quantities don't count as updates
+	if updated, err := db.SetEnrichedItemQuantities(quantities); err != nil || updated != 0 {
+		t.Errorf("repeat SetEnrichedItemQuantities = %d, %v; want 0", updated, err)
+	}
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		// item-4 was never in a listings fetch, so has sold none; ties keep item ID order
+		{"desc", []string{"item-2", "item-1", "item-3", "item-4"}},
+		{"asc", []string{"item-4", "item-1", "item-3", "item-2"}},
+	}
+	for _, tt := range tests {
+		result, err := db.GetListings(ListingsQuery{SortBy: "quantitySold", SortOrder: tt.order, PageSize: 10}, calc)
+		if err != nil {
+			t.Fatalf("GetListings: %v", err)
+		}
+		var got []string
+		for _, item := range result.Items {
+			got = append(got, item.ItemID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("sorted %s = %v, want %v", tt.order, got, tt.want)
+		}
+	}
+
+	if item := listingByID(t, db, ListingsQuery{}, calc, "item-2"); item.Quantity != 1 || item.QuantitySold != 9 {
+		t.Errorf("item-2 quantity = %d, sold %d; want 1, 9", item.Quantity, item.QuantitySold)
+	}
+}
+
+func TestListingWeightOverride(t *testing.T) {
+	db := openSeededDB(t)
+	calc, err := db.GetCalculatorConfig()
+	if err != nil {
+		t.Fatalf("GetCalculatorConfig: %v", err)
+	}
+	saveTestEnrichedItem(t, db, "item-1", "Unmapped Label", "China", "30.00", "AUD")
+	saveTestEnrichedItem(t, db, "item-2", "Unmapped Label", "China", "30.00", "AUD")
+	if err := db.SetItemWeight("item-1", 1500); err != nil {
+		t.Fatalf("SetItemWeight: %v", err)
+	}
+
+	withWeight := listingByID(t, db, ListingsQuery{}, calc, "item-1")
+	without := listingByID(t, db, ListingsQuery{}, calc, "item-2")
+	if withWeight.WeightBand != calculator.GetWeightBandFromGrams(1500) || withWeight.WeightSource != calculator.WeightSourceItem {
+		t.Errorf("with a stored weight: %s from %s, want %s from itemWeight",
+			withWeight.WeightBand, withWeight.WeightSource, calculator.GetWeightBandFromGrams(1500))
+	}
+	if without.WeightBand != calculator.DefaultWeightBand || without.WeightSource != calculator.WeightSourceDefault {
+		t.Errorf("without a stored weight: %s from %s, want %s from default", without.WeightBand, without.WeightSource, calculator.DefaultWeightBand)
+	}
+	if withWeight.CalculatedCost <= without.CalculatedCost {
+		t.Errorf("1.5kg listing costs %.2f, want more than the Medium default's %.2f", withWeight.CalculatedCost, without.CalculatedCost)
+	}
+}
+
+func TestGetAccountListings(t *testing.T) {
+	db := openSeededDB(t)
+	calc, err := db.GetCalculatorConfig()
+	if err != nil {
+		t.Fatalf("GetCalculatorConfig: %v", err)
+	}
+	alice := createTestAccount(t, db, "alice")
+	bob := createTestAccount(t, db, "bob")
+
+	addInventory := func(accountID int64, sku, title, brand string) {
+		t.Helper()
+		if _, err := db.Exec(`INSERT INTO inventory_items (account_id, sku, title, brand, data) VALUES (?, ?, ?, ?, '{}')`,
+			accountID, sku, title, brand); err != nil {
+			t.Fatalf("insert inventory item %s: %v", sku, err)
+		}
+	}
+	// addTestOffer gives offer N the SKU "SKU-N"
+	addInventory(alice.ID, "SKU-1", "Silk dress", "Aje")
+	addInventory(alice.ID, "SKU-2", "Linen shirt", "Camilla Franks")
+	addInventory(bob.ID, "SKU-3", "Wool coat", "Auguste")
+	addTestOffer(t, db, alice.ID, "1", "item-1", 120)
+	addTestOffer(t, db, alice.ID, "2", "item-2", 90)
+	addTestOffer(t, db, bob.ID, "3", "item-3", 200)
+	saveTestEnrichedItem(t, db, "item-1", "Aje", "China", "30.00", "AUD")
+	saveTestEnrichedItem(t, db, "item-3", "Auguste", "China", "40.00", "AUD")
+
+	listings := func(accountID int64, query ListingsQuery) *ListingsResult {
+		t.Helper()
+		if query.PageSize == 0 {
+			query.PageSize = 10
+		}
+		result, err := db.GetAccountListings(accountID, query, calc)
+		if err != nil {
+			t.Fatalf("GetAccountListings: %v", err)
+		}
+		return result
+	}
+	ids := func(result *ListingsResult) []string {
+		var ids []string
+		for _, item := range result.Items {
+			ids = append(ids, item.ItemID)
+		}
+		return ids
+	}
+
+	result := listings(alice.ID, ListingsQuery{})
+	if got := ids(result); !reflect.DeepEqual(got, []string{"item-1", "item-2"}) || result.Total != 2 {
+		t.Fatalf("alice's listings = %v (total %d), want item-1 and item-2", got, result.Total)
+	}
+	enriched, exported := result.Items[0], result.Items[1]
+	if enriched.Source != SourceDBCache || enriched.Title != "Silk dress" || enriched.SKU != "SKU-1" ||
+		enriched.Price != 120 || enriched.CountryOfOrigin != "China" {
+		t.Errorf("enriched listing = %+v", enriched)
+	}
+	if exported.Source != SourceExport || exported.Brand != "Camilla Franks" || exported.Title != "Linen shirt" || exported.Price != 90 {
+		t.Errorf("unenriched listing = %+v", exported)
+	}
+
+	if got := ids(listings(bob.ID, ListingsQuery{})); !reflect.DeepEqual(got, []string{"item-3"}) {
+		t.Errorf("bob's listings = %v, want only item-3", got)
+	}
+
+	// Same search, sort and paging as GetListings
+	if got := ids(listings(alice.ID, ListingsQuery{Search: "linen"})); !reflect.DeepEqual(got, []string{"item-2"}) {
+		t.Errorf("search linen = %v, want item-2", got)
+	}
+	if got := ids(listings(alice.ID, ListingsQuery{Search: "wool"})); got != nil {
+		t.Errorf("search for bob's title in alice's listings = %v, want none", got)
+	}
+	if got := ids(listings(alice.ID, ListingsQuery{SortBy: "brand", SortOrder: "desc"})); !reflect.DeepEqual(got, []string{"item-2", "item-1"}) {
+		t.Errorf("by brand descending = %v, want item-2, item-1", got)
+	}
+	page := listings(alice.ID, ListingsQuery{Page: 1, PageSize: 1})
+	if got := ids(page); !reflect.DeepEqual(got, []string{"item-2"}) || page.Total != 2 || page.TotalPages != 2 {
+		t.Errorf("second page = %v (total %d, %d pages), want item-2 of 2 over 2 pages", got, page.Total, page.TotalPages)
+	}
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/calculator"
+)
+
+// openTestDB opens a fresh database in a temp directory, closed when the test ends
+func openTestDB(t testing.TB) *DB {
+	t.Helper()
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// createTestAccount adds an account row, which exported data needs for its foreign key
+func createTestAccount(t *testing.T, db *DB, accountKey string) *Account {
+	t.Helper()
+	acc, err := db.GetOrCreateAccount(accountKey, accountKey, "sandbox", "EBAY_AU")
+	if err != nil {
+		t.Fatalf("GetOrCreateAccount(%s): %v", accountKey, err)
+	}
+	return acc
+}
+
+// openSeededDB is openTestDB with the reference data (brands, tariffs,
+// postal zones and rates) the calculator needs
+func openSeededDB(t *testing.T) *DB {
+	t.Helper()
+	db := openTestDB(t)
+	if err := db.SeedInitialData(); err != nil {
+		t.Fatalf("SeedInitialData: %v", err)
+	}
+	return db
+}
+
+// saveTestEnrichedItem stores enrichment for a listing with a US shipping cost
+func saveTestEnrichedItem(t *testing.T, db *DB, itemID, brand, coo, shippingCost, currency string) {
+	t.Helper()
+	err := db.SaveEnrichedItem(&EnrichedItem{
+		ItemID:           itemID,
+		Brand:            brand,
+		CountryOfOrigin:  coo,
+		ShippingCost:     shippingCost,
+		ShippingCurrency: currency,
+		EnrichedAt:       time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("SaveEnrichedItem(%s): %v", itemID, err)
+	}
+}
+
+// addTestOffer stores an exported offer for listingID priced in AUD
+func addTestOffer(t *testing.T, db *DB, accountID int64, offerID, listingID string, price float64) {
+	t.Helper()
+	data := fmt.Sprintf(`{"offerId":%q,"pricingSummary":{"price":{"value":"%.2f","currency":"AUD"}}}`, offerID, price)
+	_, err := db.Exec(`
+		INSERT INTO offers (account_id, offer_id, sku, listing_id, data) VALUES (?, ?, ?, ?, ?)
+	`, accountID, offerID, "SKU-"+offerID, listingID, data)
+	if err != nil {
+		t.Fatalf("insert offer %s: %v", offerID, err)
+	}
+}
+
+// listingByID returns the listing for itemID as GetListings computes it
+func listingByID(t *testing.T, db *DB, query ListingsQuery, calc *calculator.CalculatorConfig, itemID string) ListingItem {
+	t.Helper()
+	query.PageSize = 1000
+	result, err := db.GetListings(query, calc)
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	for _, item := range result.Items {
+		if item.ItemID == itemID {
+			return item
+		}
+	}
+	t.Fatalf("listing %s not found", itemID)
+	return ListingItem{}
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ItemWeight is a seller-entered parcel weight for one listing
+type ItemWeight struct {
+	ItemID      string    `json:"itemId"`
+	WeightGrams int       `json:"weightGrams"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// GetItemWeights returns every stored item weight, by item ID
+func (db *DB) GetItemWeights() ([]ItemWeight, error) {
+	rows, err := db.Query(`SELECT item_id, weight_grams, updated_at FROM item_weights ORDER BY item_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	weights := []ItemWeight{}
+	for rows.Next() {
+		var w ItemWeight
+		if err := rows.Scan(&w.ItemID, &w.WeightGrams, &w.UpdatedAt); err != nil {
+			return nil, err
+		}
+		weights = append(weights, w)
+	}
+	return weights, rows.Err()
+}
+
+// GetItemWeight returns an item's stored weight, or nil if it has none
+func (db *DB) GetItemWeight(itemID string) (*ItemWeight, error) {
+	var w ItemWeight
+	err := db.QueryRow(`
+		SELECT item_id, weight_grams, updated_at FROM item_weights WHERE item_id = ?
+	`, itemID).Scan(&w.ItemID, &w.WeightGrams, &w.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// SetItemWeight stores an item's weight, replacing any previous one
+func (db *DB) SetItemWeight(itemID string, weightGrams int) error {
+	_, err := db.Exec(`
+		INSERT INTO item_weights (item_id, weight_grams) VALUES (?, ?)
+		ON CONFLICT(item_id) DO UPDATE SET
+			weight_grams = excluded.weight_grams,
+			updated_at = CURRENT_TIMESTAMP
+	`, itemID, weightGrams)
+	return err
+}
+
+// DeleteItemWeight removes an item's weight, returning false if it had none
+func (db *DB) DeleteItemWeight(itemID string) (bool, error) {
+	result, err := db.Exec(`DELETE FROM item_weights WHERE item_id = ?`, itemID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
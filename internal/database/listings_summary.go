@@ -0,0 +1,53 @@
+package database
+
+import (
+	"math"
+
+	"github.com/julienbonastre/ebay-helpers/internal/calculator"
+)
+
+// ListingsSummary aggregates the listings page's derived values for a
+// dashboard: how many listings fall in each diff status and COO match, and
+// how far short of the calculated postage the underpriced ones are.
+type ListingsSummary struct {
+	Total        int            `json:"total"`
+	ByDiffStatus map[string]int `json:"byDiffStatus"` // "ok", "bad", "unknown"
+	ByCOOMatch   map[string]int `json:"byCooMatch"`   // "match", "mismatch", "missing"
+	CalcErrors   int            `json:"calcErrors"`   // Listings the calculator couldn't price (see ListingItem.CalcError)
+
+	// Listings whose shipping is below the calculated cost (Diff < 0), and
+	// the sum of those negative diffs in AUD, rounded to cents. Listings with
+	// an unknown diff status aren't counted.
+	Underpriced    int     `json:"underpriced"`
+	UnderpricedAUD float64 `json:"underpricedAud"`
+}
+
+// GetListingsSummary summarizes every listing matching query's search and
+// currency filters, computed exactly as GetListings computes each row.
+// Sorting and pagination are ignored.
+func (db *DB) GetListingsSummary(query ListingsQuery, calc *calculator.CalculatorConfig) (*ListingsSummary, error) {
+	query.SortBy = "" // Order doesn't matter for totals
+	summary := &ListingsSummary{
+		ByDiffStatus: map[string]int{"ok": 0, "bad": 0, DiffStatusUnknown: 0},
+		ByCOOMatch:   map[string]int{"match": 0, "mismatch": 0, "missing": 0},
+	}
+	err := db.EachListing(query, calc, func(item *ListingItem) error {
+		summary.Total++
+		summary.ByCOOMatch[item.COOMatch]++
+		if item.CalcError != "" {
+			summary.CalcErrors++
+			return nil // No cost to compare against
+		}
+		summary.ByDiffStatus[item.DiffStatus]++
+		if item.DiffStatus != DiffStatusUnknown && item.Diff < 0 {
+			summary.Underpriced++
+			summary.UnderpricedAUD += item.Diff
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	summary.UnderpricedAUD = math.Round(summary.UnderpricedAUD*100) / 100
+	return summary, nil
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestGetListingsSummary(t *testing.T) {
+	db := openSeededDB(t)
+	calc, err := db.GetCalculatorConfig()
+	if err != nil {
+		t.Fatalf("GetCalculatorConfig: %v", err)
+	}
+	saveTestEnrichedItem(t, db, "item-1", "Aje", "China", "1.00", "AUD")     // match, underpriced
+	saveTestEnrichedItem(t, db, "item-2", "Aje", "China", "500.00", "AUD")   // match, ok
+	saveTestEnrichedItem(t, db, "item-3", "Aje", "Vietnam", "2.00", "AUD")   // mismatch, underpriced
+	saveTestEnrichedItem(t, db, "item-4", "Aje", "", "10.00", "XYZ")         // missing, no AUD rate
+	saveTestEnrichedItem(t, db, "item-5", "Auguste", "China", "3.00", "AUD") // underpriced, only this one matches the search
+
+	summary, err := db.GetListingsSummary(ListingsQuery{}, calc)
+	if err != nil {
+		t.Fatalf("GetListingsSummary: %v", err)
+	}
+	if summary.Total != 5 || summary.CalcErrors != 0 {
+		t.Errorf("total = %d with %d calc errors, want 5 and 0", summary.Total, summary.CalcErrors)
+	}
+	if want := map[string]int{"ok": 1, "bad": 3, DiffStatusUnknown: 1}; !reflect.DeepEqual(summary.ByDiffStatus, want) {
+		t.Errorf("byDiffStatus = %v, want %v", summary.ByDiffStatus, want)
+	}
+	if want := map[string]int{"match": 3, "mismatch": 1, "missing": 1}; !reflect.DeepEqual(summary.ByCOOMatch, want) {
+		t.Errorf("byCooMatch = %v, want %v", summary.ByCOOMatch, want)
+	}
+
+	// The underpriced total is the sum of the rows' own negative diffs
+	var want float64
+	for _, id := range []string{"item-1", "item-3", "item-5"} {
+		item := listingByID(t, db, ListingsQuery{}, calc, id)
+		if item.Diff >= 0 {
+			t.Fatalf("%s diff = %.2f, want it underpriced", id, item.Diff)
+		}
+		want += item.Diff
+	}
+	want = math.Round(want*100) / 100
+	if summary.Underpriced != 3 || summary.UnderpricedAUD != want {
+		t.Errorf("underpriced = %d totalling %.2f, want 3 totalling %.2f", summary.Underpriced, summary.UnderpricedAUD, want)
+	}
+
+	// The search filter applies as it does on the listings page; sorting and paging don't
+	searched, err := db.GetListingsSummary(ListingsQuery{Search: "Auguste", SortBy: "diff", PageSize: 1, Page: 3}, calc)
+	if err != nil {
+		t.Fatalf("GetListingsSummary(search): %v", err)
+	}
+	item5 := listingByID(t, db, ListingsQuery{}, calc, "item-5")
+	if searched.Total != 1 || searched.Underpriced != 1 || searched.UnderpricedAUD != math.Round(item5.Diff*100)/100 {
+		t.Errorf("searched summary = %+v, want only item-5", searched)
+	}
+}
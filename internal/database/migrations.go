@@ -0,0 +1,303 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// columnMigration describes a column added to an existing table after the
+// initial schema was released. schema.sql always contains the full, current
+// table definitions for fresh databases; these entries bring older database
+// files up to date.
+type columnMigration struct {
+	table      string
+	column     string
+	definition string
+}
+
+// columnMigrations lists columns added since the original schema, in order
+var columnMigrations = []columnMigration{
+	{"enriched_items", "images", "TEXT"},
+	{"brand_coo_mappings", "brand_type", "TEXT"},
+	{"sync_history", "details", "TEXT"},
+	{"enriched_items", "condition_description", "TEXT"},
+	{"brand_coo_mappings", "secondary_coos", "TEXT"},
+	{"postal_rates", "label", "TEXT"},
+	{"accounts", "deleted_at", "DATETIME"},
+	{"enriched_items", "sku", "TEXT"},
+	{"brand_coo_mappings", "auto_created", "INTEGER DEFAULT 0"},
+	{"postal_zones", "zonos_enabled", "BOOLEAN"},
+	{"postal_zones", "zonos_processing_charge_percent", "REAL"},
+	{"postal_zones", "zonos_flat_fee_aud", "REAL"},
+	{"enriched_items", "quantity", "INTEGER"},
+	{"enriched_items", "quantity_sold", "INTEGER"},
+	{"enriched_items", "shipping_by_destination", "TEXT"},
+	{"offers", "override_domestic_cost", "REAL"},
+	{"offers", "override_domestic_currency", "TEXT"},
+	{"offers", "override_international_cost", "REAL"},
+	{"offers", "override_international_currency", "TEXT"},
+	{"enriched_items", "expected_coo", "TEXT"},
+	{"enriched_items", "tariff_rate", "REAL"},
+	{"enriched_items", "calculated_cost", "REAL"},
+	{"enriched_items", "diff_status", "TEXT"},
+	{"enriched_items", "computed_at", "DATETIME"},
+}
+
+// columnBackfills fill a column from columnMigrations in for rows that
+// already exist, keyed by "table.column". Each runs once, right after its
+// column is added.
+var columnBackfills = map[string]string{
+	"offers.override_international_currency": backfillOfferOverrides, // Last of the four
+}
+
+// backfillOfferOverrides fills the override_* columns of offers exported
+// before they existed from the offer JSON in data, as offerOverrideValues in
+// the sync package does on export: the cost of the first (lowest priority)
+// domestic and international override that sets one
+var backfillOfferOverrides = fmt.Sprintf(`
+	UPDATE offers SET
+		override_domestic_cost = %s,
+		override_domestic_currency = %s,
+		override_international_cost = %s,
+		override_international_currency = %s
+	WHERE json_valid(data)`,
+	firstShippingOverride("DOMESTIC", overrideCostSQL), firstShippingOverride("DOMESTIC", overrideCurrencySQL),
+	firstShippingOverride("INTERNATIONAL", overrideCostSQL), firstShippingOverride("INTERNATIONAL", overrideCurrencySQL))
+
+// The cost and currency of a shippingCostOverrides element, both NULL when
+// the cost has no value
+const (
+	overrideCostSQL     = `CAST(NULLIF(json_extract(value, '$.shippingCost.value'), '') AS REAL)`
+	overrideCurrencySQL = `CASE WHEN NULLIF(json_extract(value, '$.shippingCost.value'), '') IS NOT NULL
+		THEN json_extract(value, '$.shippingCost.currency') END`
+)
+
+// firstShippingOverride returns a subquery selecting expr from an offer's
+// first serviceType override with a shipping cost
+func firstShippingOverride(serviceType, expr string) string {
+	return fmt.Sprintf(`(
+		SELECT %s FROM json_each(offers.data, '$.listingPolicies.shippingCostOverrides')
+		WHERE json_extract(value, '$.shippingServiceType') = '%s'
+			AND json_extract(value, '$.shippingCost') IS NOT NULL
+		ORDER BY COALESCE(json_extract(value, '$.priority'), 0), key
+		LIMIT 1)`, expr, serviceType)
+}
+
+// migratedIndexes index columns from columnMigrations. They can't live in
+// schema.sql, which runs before migrate has added the column to older files.
+var migratedIndexes = []string{
+	"CREATE INDEX IF NOT EXISTS idx_enriched_items_sku ON enriched_items(sku)",
+}
+
+// migrate adds any columns missing from databases created by older versions
+func migrate(db *sql.DB) error {
+	if err := migrateTariffRateHistory(db); err != nil {
+		return err
+	}
+	for _, m := range columnMigrations {
+		exists, err := columnExists(db, m.table, m.column)
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s.%s: %w", m.table, m.column, err)
+		}
+		if exists {
+			continue
+		}
+		// Table/column names come from the static list above, never from user input
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", m.table, m.column, err)
+		}
+		if backfill, ok := columnBackfills[m.table+"."+m.column]; ok {
+			if _, err := db.Exec(backfill); err != nil {
+				return fmt.Errorf("failed to backfill %s.%s: %w", m.table, m.column, err)
+			}
+		}
+	}
+	for _, stmt := range migratedIndexes {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create index: %w", err)
+		}
+	}
+	return nil
+}
+
+// migrateTariffRateHistory rebuilds tariff_rates from older versions, where
+// country_name was UNIQUE, so a country can hold a row per effective date.
+// SQLite can't drop a constraint in place, so the rows are copied to a new
+// table; rows without an effective date take the day they were created.
+func migrateTariffRateHistory(db *sql.DB) error {
+	single, err := hasUniqueIndex(db, "tariff_rates", "country_name")
+	if err != nil {
+		return fmt.Errorf("failed to inspect tariff_rates: %w", err)
+	}
+	if !single {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // No-op once committed
+
+	for _, stmt := range []string{
+		`CREATE TABLE tariff_rates_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			country_name TEXT NOT NULL,
+			tariff_rate REAL NOT NULL,
+			notes TEXT,
+			effective_date DATE NOT NULL DEFAULT (DATE('now')),
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(country_name, effective_date)
+		)`,
+		`INSERT INTO tariff_rates_history (id, country_name, tariff_rate, notes, effective_date, created_at, updated_at)
+			SELECT id, country_name, tariff_rate, notes,
+				COALESCE(NULLIF(effective_date, ''), DATE(created_at), DATE('now')), created_at, updated_at
+			FROM tariff_rates`,
+		`DROP TABLE tariff_rates`,
+		`ALTER TABLE tariff_rates_history RENAME TO tariff_rates`,
+		`CREATE INDEX IF NOT EXISTS idx_tariff_country ON tariff_rates(country_name)`,
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to migrate tariff_rates: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// hasUniqueIndex reports whether a table has a unique index (or UNIQUE
+// constraint) covering exactly the given columns, in order
+func hasUniqueIndex(db *sql.DB, table string, columns ...string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA index_list(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	var unique []string
+	for rows.Next() {
+		var (
+			seq     int
+			name    string
+			isUniq  bool
+			origin  string
+			partial bool
+		)
+		if err := rows.Scan(&seq, &name, &isUniq, &origin, &partial); err != nil {
+			rows.Close()
+			return false, err
+		}
+		if isUniq && !partial {
+			unique = append(unique, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	for _, index := range unique {
+		indexed, err := indexColumns(db, index)
+		if err != nil {
+			return false, err
+		}
+		if strings.Join(indexed, ",") == strings.Join(columns, ",") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// indexColumns lists the columns of an index, in order
+func indexColumns(db *sql.DB, index string) ([]string, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA index_info(%q)", index))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var (
+			seqno int
+			cid   int
+			name  string
+		)
+		if err := rows.Scan(&seqno, &cid, &name); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+	return columns, rows.Err()
+}
+
+// columnExists reports whether a table has the named column
+func columnExists(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// createTablePattern finds the tables defined in schema.sql
+var createTablePattern = regexp.MustCompile(`(?i)CREATE TABLE IF NOT EXISTS\s+(\w+)`)
+
+// expectedTables lists every table schema.sql defines, in order
+func expectedTables() []string {
+	var tables []string
+	for _, m := range createTablePattern.FindAllStringSubmatch(schemaSQL, -1) {
+		tables = append(tables, m[1])
+	}
+	return tables
+}
+
+// verifySchema checks that every table from schema.sql exists, so a database
+// file that couldn't be brought up to date fails at startup with a clear
+// message rather than with "no such table" mid-request
+func verifySchema(db *sql.DB) error {
+	var missing []string
+	for _, table := range expectedTables() {
+		exists, err := tableExists(db, table)
+		if err != nil {
+			return fmt.Errorf("failed to inspect table %s: %w", table, err)
+		}
+		if !exists {
+			missing = append(missing, table)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("database is missing tables: %s (is -db pointing at a database from an incompatible version?)", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// tableExists reports whether a table exists. PRAGMA table_info returns no
+// rows (rather than an error) for a missing table.
+func tableExists(db *sql.DB, table string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	return rows.Next(), rows.Err()
+}
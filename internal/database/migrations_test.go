@@ -0,0 +1,207 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// baselineTariffRatesSQL is tariff_rates as created by versions before
+// effective-dated rates, with one row per country
+const baselineTariffRatesSQL = `
+	CREATE TABLE tariff_rates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		country_name TEXT NOT NULL UNIQUE,
+		tariff_rate REAL NOT NULL,
+		notes TEXT,
+		effective_date DATE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	INSERT INTO tariff_rates (country_name, tariff_rate, notes, effective_date, created_at)
+		VALUES ('China', 0.20, 'seeded', '2025-02-01', '2025-01-15 10:00:00');
+	INSERT INTO tariff_rates (country_name, tariff_rate, effective_date, created_at)
+		VALUES ('Vietnam', 0.10, NULL, '2025-03-04 09:30:00');
+`
+
+// openBaselineTariffDB creates a database file holding only the baseline
+// tariff_rates table and returns its path
+func openBaselineTariffDB(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "baseline.db")
+	raw, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer raw.Close()
+	if _, err := raw.Exec(baselineTariffRatesSQL); err != nil {
+		t.Fatalf("create baseline tariff_rates: %v", err)
+	}
+	return path
+}
+
+func TestHasUniqueIndex(t *testing.T) {
+	raw, err := sql.Open("sqlite3", openBaselineTariffDB(t))
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer raw.Close()
+
+	for _, tc := range []struct {
+		columns []string
+		want    bool
+	}{
+		{[]string{"country_name"}, true},
+		{[]string{"country_name", "effective_date"}, false},
+		{[]string{"tariff_rate"}, false},
+	} {
+		got, err := hasUniqueIndex(raw, "tariff_rates", tc.columns...)
+		if err != nil {
+			t.Fatalf("hasUniqueIndex(%v): %v", tc.columns, err)
+		}
+		if got != tc.want {
+			t.Errorf("hasUniqueIndex(%v) = %v, want %v", tc.columns, got, tc.want)
+		}
+	}
+}
+
+func TestMigrateTariffRateHistory(t *testing.T) {
+	path := openBaselineTariffDB(t)
+	raw, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer raw.Close()
+
+	if err := migrateTariffRateHistory(raw); err != nil {
+		t.Fatalf("migrateTariffRateHistory: %v", err)
+	}
+	// A second run finds nothing to do
+	if err := migrateTariffRateHistory(raw); err != nil {
+		t.Fatalf("migrateTariffRateHistory (again): %v", err)
+	}
+
+	if single, _ := hasUniqueIndex(raw, "tariff_rates", "country_name"); single {
+		t.Error("country_name is still unique after migrating")
+	}
+	if dated, _ := hasUniqueIndex(raw, "tariff_rates", "country_name", "effective_date"); !dated {
+		t.Error("(country_name, effective_date) is not unique after migrating")
+	}
+
+	// Existing rows keep their IDs and notes; a missing date becomes the
+	// day the row was created
+	for _, tc := range []struct {
+		id            int64
+		country, date string
+		rate          float64
+		notes         sql.NullString
+	}{
+		{1, "China", "2025-02-01", 0.20, sql.NullString{String: "seeded", Valid: true}},
+		{2, "Vietnam", "2025-03-04", 0.10, sql.NullString{}},
+	} {
+		var country, date string
+		var rate float64
+		var notes sql.NullString
+		err := raw.QueryRow(`SELECT country_name, tariff_rate, notes, CAST(effective_date AS TEXT) FROM tariff_rates WHERE id = ?`, tc.id).
+			Scan(&country, &rate, &notes, &date)
+		if err != nil {
+			t.Fatalf("read row %d: %v", tc.id, err)
+		}
+		if country != tc.country || rate != tc.rate || notes != tc.notes || date != tc.date {
+			t.Errorf("row %d = %s %v %v %s, want %s %v %v %s", tc.id, country, rate, notes, date, tc.country, tc.rate, tc.notes, tc.date)
+		}
+	}
+}
+
+func TestOpenMigratesBaselineTariffRates(t *testing.T) {
+	path := openBaselineTariffDB(t)
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+
+	// A country can now take a second rate from a later date
+	if _, err := db.CreateTariffRate("China", 0.30, "raised", "2025-06-01"); err != nil {
+		t.Fatalf("CreateTariffRate: %v", err)
+	}
+	if _, err := db.CreateTariffRate("China", 0.35, "", "2025-06-01"); !errors.Is(err, ErrTariffDateExists) {
+		t.Errorf("CreateTariffRate on a taken date = %v, want ErrTariffDateExists", err)
+	}
+
+	for _, tc := range []struct {
+		date string
+		want float64
+	}{
+		{"2025-01-31", 0},    // Before any rate
+		{"2025-02-01", 0.20}, // First day of the seeded rate
+		{"2025-05-31", 0.20},
+		{"2025-06-01", 0.30}, // Rate change
+		{"2026-01-01", 0.30},
+	} {
+		date, _ := time.Parse(tariffDateLayout, tc.date)
+		got, err := db.GetTariffRateAsOf("China", date)
+		if err != nil {
+			t.Fatalf("GetTariffRateAsOf(%s): %v", tc.date, err)
+		}
+		if got != tc.want {
+			t.Errorf("GetTariffRateAsOf(%s) = %v, want %v", tc.date, got, tc.want)
+		}
+	}
+}
+
+func TestMigrateBackfillsOfferOverrides(t *testing.T) {
+	db := openTestDB(t)
+	acc := createTestAccount(t, db, "seller")
+	offers := map[string]string{
+		// Priority 0 (omitted) beats priority 1 wherever it is in the list
+		"both": `{"listingPolicies":{"shippingCostOverrides":[
+			{"shippingServiceType":"DOMESTIC","priority":1,"shippingCost":{"value":"12.00","currency":"AUD"}},
+			{"shippingServiceType":"INTERNATIONAL","priority":1,"shippingCost":{"value":"45.50","currency":"AUD"}},
+			{"shippingServiceType":"DOMESTIC","shippingCost":{"value":"9.95","currency":"AUD"}}]}}`,
+		// Overrides without a cost don't count
+		"additional only": `{"listingPolicies":{"shippingCostOverrides":[
+			{"shippingServiceType":"DOMESTIC","additionalShippingCost":{"value":"2.00","currency":"AUD"}}]}}`,
+		"none": `{"pricingSummary":{"price":{"value":"10.00","currency":"AUD"}}}`,
+	}
+	for offerID, data := range offers {
+		if _, err := db.Exec(`INSERT INTO offers (account_id, offer_id, sku, data) VALUES (?, ?, ?, ?)`, acc.ID, offerID, offerID, data); err != nil {
+			t.Fatalf("insert offer %s: %v", offerID, err)
+		}
+	}
+
+	// Back to a database from before the override columns
+	for _, column := range []string{"override_domestic_cost", "override_domestic_currency", "override_international_cost", "override_international_currency"} {
+		if _, err := db.Exec("ALTER TABLE offers DROP COLUMN " + column); err != nil {
+			t.Fatalf("drop %s: %v", column, err)
+		}
+	}
+	if err := migrate(db.DB); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	want := map[string][4]any{
+		"both":            {9.95, "AUD", 45.5, "AUD"},
+		"additional only": {nil, nil, nil, nil},
+		"none":            {nil, nil, nil, nil},
+	}
+	for offerID, w := range want {
+		var got [4]any
+		err := db.QueryRow(`
+			SELECT override_domestic_cost, override_domestic_currency, override_international_cost, override_international_currency
+			FROM offers WHERE offer_id = ?`, offerID).Scan(&got[0], &got[1], &got[2], &got[3])
+		if err != nil {
+			t.Fatalf("read offer %s: %v", offerID, err)
+		}
+		for i := range got {
+			if b, ok := got[i].([]byte); ok {
+				got[i] = string(b)
+			}
+		}
+		if got != w {
+			t.Errorf("offer %s overrides = %v, want %v", offerID, got, w)
+		}
+	}
+}
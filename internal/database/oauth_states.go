@@ -0,0 +1,39 @@
+package database
+
+import (
+	"time"
+)
+
+// CreateOAuthState records an issued OAuth state that expires after ttl.
+// Expired states are purged at the same time so the table stays small.
+func (db *DB) CreateOAuthState(state string, ttl time.Duration) error {
+	now := time.Now().UTC()
+	if _, err := db.Exec(`DELETE FROM oauth_states WHERE expires_at <= ?`, now); err != nil {
+		return err
+	}
+	_, err := db.Exec(`
+		INSERT INTO oauth_states (state, expires_at)
+		VALUES (?, ?)
+	`, state, now.Add(ttl))
+	return err
+}
+
+// ConsumeOAuthState validates and deletes an OAuth state in one step.
+// Returns false if the state was never issued, has expired, or was already used.
+func (db *DB) ConsumeOAuthState(state string) (bool, error) {
+	if state == "" {
+		return false, nil
+	}
+	result, err := db.Exec(`
+		DELETE FROM oauth_states
+		WHERE state = ? AND expires_at > ?
+	`, state, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// OfferShippingOverride is an exported offer with custom postage: the cost of
+// its first domestic and/or international shipping cost override
+type OfferShippingOverride struct {
+	AccountKey string    `json:"accountKey"`
+	OfferID    string    `json:"offerId"`
+	SKU        string    `json:"sku"`
+	ListingID  string    `json:"listingId,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+
+	DomesticCost          *float64 `json:"domesticCost"` // nil = no domestic override
+	DomesticCurrency      string   `json:"domesticCurrency,omitempty"`
+	InternationalCost     *float64 `json:"internationalCost"` // nil = no international override
+	InternationalCurrency string   `json:"internationalCurrency,omitempty"`
+}
+
+// GetOfferShippingOverrides lists exported offers that override domestic or
+// international shipping cost, for one account or, with accountID 0, all of
+// them. The override columns are filled when offers are exported or restored,
+// and from data for offers stored before the columns existed.
+func (db *DB) GetOfferShippingOverrides(accountID int64) ([]OfferShippingOverride, error) {
+	rows, err := db.Query(`
+		SELECT a.account_key, o.offer_id, o.sku, COALESCE(o.listing_id, ''), COALESCE(o.status, ''), o.updated_at,
+			o.override_domestic_cost, COALESCE(o.override_domestic_currency, ''),
+			o.override_international_cost, COALESCE(o.override_international_currency, '')
+		FROM offers o
+		JOIN accounts a ON a.id = o.account_id
+		WHERE (o.override_domestic_cost IS NOT NULL OR o.override_international_cost IS NOT NULL)
+		  AND (? = 0 OR o.account_id = ?)
+		ORDER BY a.account_key, o.sku, o.offer_id
+	`, accountID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := []OfferShippingOverride{}
+	for rows.Next() {
+		var o OfferShippingOverride
+		var domestic, international sql.NullFloat64
+		err := rows.Scan(&o.AccountKey, &o.OfferID, &o.SKU, &o.ListingID, &o.Status, &o.UpdatedAt,
+			&domestic, &o.DomesticCurrency, &international, &o.InternationalCurrency)
+		if err != nil {
+			return nil, err
+		}
+		if domestic.Valid {
+			o.DomesticCost = &domestic.Float64
+		}
+		if international.Valid {
+			o.InternationalCost = &international.Float64
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// PostalRate is the AusPost base price for one weight band in a postal zone
+type PostalRate struct {
+	ID             int64   `json:"id"`
+	ZoneID         string  `json:"zoneId"`
+	WeightBand     string  `json:"weightBand"` // "XSmall", "Small", "Medium", "Large", "XLarge"
+	Label          string  `json:"label"`      // e.g. "XSmall [< 250g]"
+	MaxWeightGrams int     `json:"maxWeightGrams"`
+	BasePriceAUD   float64 `json:"basePriceAud"`
+
+	HandlingFeePercent float64 `json:"handlingFeePercent"` // Set per zone, shared by all its bands
+}
+
+const postalRateColumns = `
+	pr.id, pr.zone_id, pr.weight_band, COALESCE(NULLIF(pr.label, ''), pr.weight_band),
+	pr.max_weight_grams, pr.base_price_aud, COALESCE(pz.handling_fee_percent, 0)
+`
+
+func scanPostalRate(row interface{ Scan(...interface{}) error }) (*PostalRate, error) {
+	var r PostalRate
+	err := row.Scan(&r.ID, &r.ZoneID, &r.WeightBand, &r.Label, &r.MaxWeightGrams, &r.BasePriceAUD, &r.HandlingFeePercent)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetPostalRates returns the weight bands for a zone, or for every zone when
+// zoneID is empty, lightest first
+func (db *DB) GetPostalRates(zoneID string) ([]PostalRate, error) {
+	rows, err := db.Query(`
+		SELECT `+postalRateColumns+`
+		FROM postal_rates pr
+		LEFT JOIN postal_zones pz ON pz.zone_id = pr.zone_id
+		WHERE ? = '' OR pr.zone_id = ?
+		ORDER BY pr.zone_id, pr.max_weight_grams
+	`, zoneID, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []PostalRate{}
+	for rows.Next() {
+		r, err := scanPostalRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, *r)
+	}
+	return rates, rows.Err()
+}
+
+// GetPostalRateByID returns a single weight band, or nil if it doesn't exist
+func (db *DB) GetPostalRateByID(id int64) (*PostalRate, error) {
+	r, err := scanPostalRate(db.QueryRow(`
+		SELECT `+postalRateColumns+`
+		FROM postal_rates pr
+		LEFT JOIN postal_zones pz ON pz.zone_id = pr.zone_id
+		WHERE pr.id = ?
+	`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return r, err
+}
+
+// PostalZoneExists checks if a zone exists in postal_zones
+func (db *DB) PostalZoneExists(zoneID string) (bool, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM postal_zones WHERE zone_id = ?", zoneID).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CreatePostalRate adds a weight band to a zone
+func (db *DB) CreatePostalRate(zoneID, weightBand, label string, maxWeightGrams int, basePriceAUD float64) (int64, error) {
+	result, err := db.Exec(`
+		INSERT INTO postal_rates (zone_id, weight_band, label, max_weight_grams, base_price_aud)
+		VALUES (?, ?, ?, ?, ?)
+	`, zoneID, weightBand, label, maxWeightGrams, basePriceAUD)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdatePostalRate updates a weight band's label, max weight and price. The
+// zone and band key identify the rate and can't be changed.
+func (db *DB) UpdatePostalRate(id int64, label string, maxWeightGrams int, basePriceAUD float64) error {
+	_, err := db.Exec(`
+		UPDATE postal_rates
+		SET label = ?, max_weight_grams = ?, base_price_aud = ?
+		WHERE id = ?
+	`, label, maxWeightGrams, basePriceAUD, id)
+	return err
+}
+
+// DeletePostalRate removes a weight band
+func (db *DB) DeletePostalRate(id int64) error {
+	_, err := db.Exec("DELETE FROM postal_rates WHERE id = ?", id)
+	return err
+}
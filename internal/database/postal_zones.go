@@ -0,0 +1,95 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// PostalZoneDuties is a zone's import duty settings: whether duties are
+// charged on parcels to it and whether Zonos collects them, at what fees.
+// Unset Zonos fields fall back to HasTariffs and the zonos_* settings.
+type PostalZoneDuties struct {
+	ZoneID     string `json:"zoneId"`
+	ZoneName   string `json:"zoneName"`
+	HasTariffs bool   `json:"hasTariffs"`
+
+	ZonosEnabled                 *bool    `json:"zonosEnabled"`                 // nil = same as HasTariffs
+	ZonosProcessingChargePercent *float64 `json:"zonosProcessingChargePercent"` // nil = zonos_processing_charge_percent setting
+	ZonosFlatFeeAUD              *float64 `json:"zonosFlatFeeAud"`              // nil = zonos_flat_fee_aud setting
+}
+
+// UsesZonos reports whether Zonos collects duties for the zone
+func (d *PostalZoneDuties) UsesZonos() bool {
+	if d.ZonosEnabled != nil {
+		return *d.ZonosEnabled
+	}
+	return d.HasTariffs
+}
+
+const postalZoneDutiesColumns = `
+	zone_id, zone_name, COALESCE(has_tariffs, 0),
+	zonos_enabled, zonos_processing_charge_percent, zonos_flat_fee_aud`
+
+// scanPostalZoneDuties scans postalZoneDutiesColumns, then into extra for
+// any columns selected after them
+func scanPostalZoneDuties(row interface{ Scan(...any) error }, extra ...any) (*PostalZoneDuties, error) {
+	var d PostalZoneDuties
+	var enabled sql.NullBool
+	var percent, flatFee sql.NullFloat64
+	dest := append([]any{&d.ZoneID, &d.ZoneName, &d.HasTariffs, &enabled, &percent, &flatFee}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if enabled.Valid {
+		d.ZonosEnabled = &enabled.Bool
+	}
+	if percent.Valid {
+		d.ZonosProcessingChargePercent = &percent.Float64
+	}
+	if flatFee.Valid {
+		d.ZonosFlatFeeAUD = &flatFee.Float64
+	}
+	return &d, nil
+}
+
+// GetPostalZoneDuties returns the duty settings of every zone
+func (db *DB) GetPostalZoneDuties() ([]PostalZoneDuties, error) {
+	rows, err := db.Query(`SELECT ` + postalZoneDutiesColumns + ` FROM postal_zones ORDER BY zone_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	zones := []PostalZoneDuties{}
+	for rows.Next() {
+		d, err := scanPostalZoneDuties(rows)
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, *d)
+	}
+	return zones, rows.Err()
+}
+
+// GetPostalZoneDutiesByID returns one zone's duty settings, or nil if the
+// zone doesn't exist
+func (db *DB) GetPostalZoneDutiesByID(zoneID string) (*PostalZoneDuties, error) {
+	d, err := scanPostalZoneDuties(db.QueryRow(`
+		SELECT `+postalZoneDutiesColumns+` FROM postal_zones WHERE zone_id = ?
+	`, zoneID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return d, err
+}
+
+// UpdatePostalZoneDuties saves a zone's duty settings. Nil Zonos fields are
+// stored as NULL, returning them to their defaults.
+func (db *DB) UpdatePostalZoneDuties(d *PostalZoneDuties) error {
+	_, err := db.Exec(`
+		UPDATE postal_zones
+		SET has_tariffs = ?, zonos_enabled = ?, zonos_processing_charge_percent = ?, zonos_flat_fee_aud = ?
+		WHERE zone_id = ?
+	`, d.HasTariffs, d.ZonosEnabled, d.ZonosProcessingChargePercent, d.ZonosFlatFeeAUD, d.ZoneID)
+	return err
+}
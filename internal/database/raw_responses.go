@@ -0,0 +1,72 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// RawAPIResponse is the latest raw response to one API call for an item
+type RawAPIResponse struct {
+	ItemID     string    `json:"itemId"`
+	CallName   string    `json:"callName"`
+	StatusCode int       `json:"statusCode"`
+	Response   string    `json:"response"`
+	FetchedAt  time.Time `json:"fetchedAt"`
+}
+
+// SaveRawAPIResponse stores a raw response, replacing the previous one for
+// the same item and call
+func (db *DB) SaveRawAPIResponse(itemID, callName string, statusCode int, body []byte) error {
+	_, err := db.Exec(`
+		INSERT INTO raw_api_responses (item_id, call_name, status_code, response)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(item_id, call_name) DO UPDATE SET
+			status_code = excluded.status_code,
+			response = excluded.response,
+			fetched_at = CURRENT_TIMESTAMP
+	`, itemID, callName, statusCode, string(body))
+	return err
+}
+
+// GetRawAPIResponses returns the stored raw responses for an item, one per call
+func (db *DB) GetRawAPIResponses(itemID string) ([]RawAPIResponse, error) {
+	rows, err := db.Query(`
+		SELECT item_id, call_name, COALESCE(status_code, 0), response, fetched_at
+		FROM raw_api_responses
+		WHERE item_id = ?
+		ORDER BY call_name
+	`, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	responses := []RawAPIResponse{}
+	for rows.Next() {
+		var r RawAPIResponse
+		if err := rows.Scan(&r.ItemID, &r.CallName, &r.StatusCode, &r.Response, &r.FetchedAt); err != nil {
+			return nil, err
+		}
+		responses = append(responses, r)
+	}
+	return responses, rows.Err()
+}
+
+// GetRawAPIResponse returns the stored raw response to one call for an item,
+// or nil if there is none
+func (db *DB) GetRawAPIResponse(itemID, callName string) (*RawAPIResponse, error) {
+	var r RawAPIResponse
+	err := db.QueryRow(`
+		SELECT item_id, call_name, COALESCE(status_code, 0), response, fetched_at
+		FROM raw_api_responses
+		WHERE item_id = ? AND call_name = ?
+	`, itemID, callName).Scan(&r.ItemID, &r.CallName, &r.StatusCode, &r.Response, &r.FetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+
+	"github.com/julienbonastre/ebay-helpers/internal/calculator"
+)
+
+// RecomputeResult counts the enriched items whose derived values changed in
+// RecomputeEnrichedItems. An item can count towards several fields.
+type RecomputeResult struct {
+	Items              int `json:"items"`    // Enriched items recomputed
+	Computed           int `json:"computed"` // Items stored for the first time, not counted as changed
+	Changed            int `json:"changed"`  // Items with any derived value changed
+	ExpectedCOOChanged int `json:"expectedCooChanged"`
+	TariffRateChanged  int `json:"tariffRateChanged"`
+	CostChanged        int `json:"calculatedCostChanged"`
+	DiffStatusChanged  int `json:"diffStatusChanged"`
+}
+
+// derivedValues are the enriched_items columns computed from reference data
+type derivedValues struct {
+	expectedCOO    string
+	tariffRate     float64
+	calculatedCost float64
+	diffStatus     string
+}
+
+// RecomputeEnrichedItems re-derives every enriched item's expected COO,
+// tariff rate, calculated cost and diff status from calc, exactly as the
+// listings page computes them, and compares them with the values stored by
+// the previous recompute. Changed values are stored and counted; items never
+// recomputed before are stored and counted in Computed only. Prices come
+// from accountID's offers as in ListingsQuery. Nothing is fetched from eBay
+// and enriched_at/updated_at are left alone, so the TTL and stale-item
+// checks still reflect the last real fetch.
+func (db *DB) RecomputeEnrichedItems(accountID int64, calc *calculator.CalculatorConfig) (*RecomputeResult, error) {
+	stored, err := db.storedDerivedValues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load derived values: %w", err)
+	}
+
+	// Collected first: the listing rows stay open while EachListing runs
+	fresh := make(map[string]derivedValues)
+	err = db.EachListing(ListingsQuery{AccountID: accountID}, calc, func(item *ListingItem) error {
+		fresh[item.ItemID] = listingDerivedValues(item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // No-op once committed
+
+	stmt, err := tx.Prepare(`
+		UPDATE enriched_items
+		SET expected_coo = ?, tariff_rate = ?, calculated_cost = ?, diff_status = ?, computed_at = CURRENT_TIMESTAMP
+		WHERE item_id = ?
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	result := &RecomputeResult{Items: len(fresh)}
+	for itemID, cur := range fresh {
+		prev, ok := stored[itemID]
+		if ok && prev == cur {
+			continue
+		}
+		if !ok {
+			result.Computed++
+		} else {
+			result.Changed++
+			if prev.expectedCOO != cur.expectedCOO {
+				result.ExpectedCOOChanged++
+			}
+			if prev.tariffRate != cur.tariffRate {
+				result.TariffRateChanged++
+			}
+			if prev.calculatedCost != cur.calculatedCost {
+				result.CostChanged++
+			}
+			if prev.diffStatus != cur.diffStatus {
+				result.DiffStatusChanged++
+			}
+		}
+		if _, err := stmt.Exec(cur.expectedCOO, cur.tariffRate, cur.calculatedCost, cur.diffStatus, itemID); err != nil {
+			return nil, fmt.Errorf("item %s: %w", itemID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// storedDerivedValues returns the derived values of every enriched item a
+// previous recompute stored, keyed by item ID
+func (db *DB) storedDerivedValues() (map[string]derivedValues, error) {
+	rows, err := db.Query(`
+		SELECT item_id, expected_coo, tariff_rate, calculated_cost, diff_status
+		FROM enriched_items
+		WHERE computed_at IS NOT NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stored := make(map[string]derivedValues)
+	for rows.Next() {
+		var itemID string
+		var coo, status sql.NullString
+		var rate, cost sql.NullFloat64
+		if err := rows.Scan(&itemID, &coo, &rate, &cost, &status); err != nil {
+			return nil, err
+		}
+		stored[itemID] = derivedValues{
+			expectedCOO:    coo.String,
+			tariffRate:     rate.Float64,
+			calculatedCost: cost.Float64,
+			diffStatus:     status.String,
+		}
+	}
+	return stored, rows.Err()
+}
+
+func listingDerivedValues(item *ListingItem) derivedValues {
+	return derivedValues{
+		expectedCOO:    item.ExpectedCOO,
+		tariffRate:     item.TariffRate,
+		calculatedCost: math.Round(item.CalculatedCost*100) / 100,
+		diffStatus:     item.DiffStatus,
+	}
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRecomputeEnrichedItemsAfterTariffEdit(t *testing.T) {
+	db := openSeededDB(t)
+	acc := createTestAccount(t, db, "seller_sandbox_EBAY_AU")
+	before, err := db.GetCalculatorConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A 200 AUD China-made item whose listed postage is just above the
+	// calculated cost, so it's "ok" until China's tariff goes up
+	saveTestEnrichedItem(t, db, "1001", "TestBrand", "China", "0", "AUD")
+	addTestOffer(t, db, acc.ID, "501", "1001", 200)
+	query := ListingsQuery{AccountID: acc.ID}
+	cost := listingByID(t, db, query, before, "1001").CalculatedCost
+	saveTestEnrichedItem(t, db, "1001", "TestBrand", "China", fmt.Sprintf("%.2f", cost*1.06), "AUD")
+	if got := listingByID(t, db, query, before, "1001").DiffStatus; got != "ok" {
+		t.Fatalf("diff status before tariff edit = %q, want ok", got)
+	}
+
+	// The first recompute stores the values without counting them as changes
+	result, err := db.RecomputeEnrichedItems(acc.ID, before)
+	if err != nil {
+		t.Fatalf("RecomputeEnrichedItems: %v", err)
+	}
+	if result.Items != 1 || result.Computed != 1 || result.Changed != 0 {
+		t.Errorf("first recompute = %+v, want 1 item computed and none changed", result)
+	}
+
+	rates, err := db.GetAllTariffRates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := false
+	for _, r := range rates {
+		if r.CountryName == "China" {
+			if err := db.UpdateTariffRate(r.ID, r.CountryName, r.TariffRate+0.5, r.Notes, r.EffectiveDate); err != nil {
+				t.Fatal(err)
+			}
+			edited = true
+		}
+	}
+	if !edited {
+		t.Fatal("no seeded China tariff to edit")
+	}
+	after, err := db.GetCalculatorConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = db.RecomputeEnrichedItems(acc.ID, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Items != 1 || result.Computed != 0 || result.Changed != 1 || result.DiffStatusChanged != 1 || result.TariffRateChanged != 1 {
+		t.Errorf("result = %+v, want 1 item with changed tariff and diff status", result)
+	}
+	var status string
+	if err := db.QueryRow(`SELECT diff_status FROM enriched_items WHERE item_id = '1001'`).Scan(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status != "bad" {
+		t.Errorf("stored diff status after tariff edit = %q, want bad", status)
+	}
+
+	// Nothing changes when the reference data hasn't
+	result, err = db.RecomputeEnrichedItems(acc.ID, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Changed != 0 || result.Computed != 0 {
+		t.Errorf("recompute without edits = %+v, want nothing changed", result)
+	}
+}
+
+func TestListingsPriceFromAccountOffers(t *testing.T) {
+	db := openSeededDB(t)
+	calc, err := db.GetCalculatorConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := createTestAccount(t, db, "a_sandbox_EBAY_AU")
+	b := createTestAccount(t, db, "b_sandbox_EBAY_AU")
+	saveTestEnrichedItem(t, db, "2001", "TestBrand", "China", "50", "AUD")
+	addTestOffer(t, db, a.ID, "601", "2001", 120)
+	addTestOffer(t, db, b.ID, "602", "2001", 80)
+
+	if got := listingByID(t, db, ListingsQuery{AccountID: a.ID}, calc, "2001").Price; got != 120 {
+		t.Errorf("price for account a = %v, want 120", got)
+	}
+	if got := listingByID(t, db, ListingsQuery{AccountID: b.ID}, calc, "2001").Price; got != 80 {
+		t.Errorf("price for account b = %v, want 80", got)
+	}
+	if got := listingByID(t, db, ListingsQuery{AccountID: b.ID + 100}, calc, "2001").Price; got != 0 {
+		t.Errorf("price for an account without offers = %v, want 0", got)
+	}
+}
@@ -0,0 +1,303 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ReferenceDataVersion is bumped whenever the reference package format changes.
+// Version 2 added brands' secondary COOs.
+const ReferenceDataVersion = 2
+
+// ErrReferenceInvalid is returned (wrapped) when an imported reference package
+// fails validation, so callers can tell bad input apart from a database error
+var ErrReferenceInvalid = errors.New("invalid reference data")
+
+// ReferenceData is a portable package of the curated brand-COO mappings and
+// tariff rates, for sharing between installations. It carries no account data.
+type ReferenceData struct {
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exportedAt"`
+	Tariffs    []ReferenceTariff `json:"tariffs"`
+	Brands     []ReferenceBrand  `json:"brands"`
+}
+
+// ReferenceTariff is a tariff rate as exported, keyed by country name
+type ReferenceTariff struct {
+	CountryName   string  `json:"countryName"`
+	TariffRate    float64 `json:"tariffRate"`
+	Notes         string  `json:"notes,omitempty"`
+	EffectiveDate string  `json:"effectiveDate,omitempty"`
+}
+
+// ReferenceBrand is a brand-COO mapping as exported, keyed by brand name
+type ReferenceBrand struct {
+	BrandName  string `json:"brandName"`
+	PrimaryCOO string `json:"primaryCoo"`
+	Notes      string `json:"notes,omitempty"`
+	BrandType  string `json:"brandType,omitempty"`
+	// SecondaryCOOs is nil in version 1 packages, which leaves a brand's
+	// existing list alone on import
+	SecondaryCOOs []string `json:"secondaryCoos,omitempty"`
+}
+
+// ExportReferenceData returns every tariff rate and brand mapping as a
+// versioned package. IDs and timestamps are left out since they are local.
+func (db *DB) ExportReferenceData() (*ReferenceData, error) {
+	tariffs, err := db.GetAllTariffRates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tariff rates: %w", err)
+	}
+	brands, err := db.GetAllBrandCOOMappings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load brand mappings: %w", err)
+	}
+	secondary, err := db.allSecondaryCOOs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load secondary COOs: %w", err)
+	}
+
+	data := &ReferenceData{
+		Version:    ReferenceDataVersion,
+		ExportedAt: time.Now().UTC(),
+		Tariffs:    make([]ReferenceTariff, 0, len(tariffs)),
+		Brands:     make([]ReferenceBrand, 0, len(brands)),
+	}
+	for _, t := range tariffs {
+		data.Tariffs = append(data.Tariffs, ReferenceTariff{
+			CountryName:   t.CountryName,
+			TariffRate:    t.TariffRate,
+			Notes:         t.Notes,
+			EffectiveDate: t.EffectiveDate,
+		})
+	}
+	for _, b := range brands {
+		data.Brands = append(data.Brands, ReferenceBrand{
+			BrandName:     b.BrandName,
+			PrimaryCOO:    b.PrimaryCOO,
+			Notes:         b.Notes,
+			BrandType:     b.BrandType,
+			SecondaryCOOs: secondary[b.BrandName],
+		})
+	}
+	return data, nil
+}
+
+// allSecondaryCOOs returns the secondary COO list of every brand that has one
+func (db *DB) allSecondaryCOOs() (map[string][]string, error) {
+	rows, err := db.Query(`
+		SELECT brand_name, secondary_coos FROM brand_coo_mappings
+		WHERE secondary_coos IS NOT NULL AND secondary_coos != '' AND secondary_coos != '[]'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	secondary := make(map[string][]string)
+	for rows.Next() {
+		var name, listJSON string
+		if err := rows.Scan(&name, &listJSON); err != nil {
+			return nil, err
+		}
+		var list []string
+		if err := json.Unmarshal([]byte(listJSON), &list); err != nil {
+			return nil, fmt.Errorf("invalid secondary COOs for brand %s: %w", name, err)
+		}
+		secondary[name] = list
+	}
+	return secondary, rows.Err()
+}
+
+// validate checks the version and every record before anything is written
+func (d *ReferenceData) validate() error {
+	if err := d.validateVersion(); err != nil {
+		return err
+	}
+	for i, t := range d.Tariffs {
+		if err := t.validate(i); err != nil {
+			return err
+		}
+	}
+	for i, b := range d.Brands {
+		if err := b.validate(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *ReferenceData) validateVersion() error {
+	if d.Version < 1 || d.Version > ReferenceDataVersion {
+		return fmt.Errorf("%w: unsupported version %d (expected %d)", ErrReferenceInvalid, d.Version, ReferenceDataVersion)
+	}
+	return nil
+}
+
+// validate checks tariff i of a package
+func (t *ReferenceTariff) validate(i int) error {
+	if strings.TrimSpace(t.CountryName) == "" {
+		return fmt.Errorf("%w: tariff %d has no country name", ErrReferenceInvalid, i)
+	}
+	if t.TariffRate < 0 || t.TariffRate > 1 {
+		return fmt.Errorf("%w: tariff rate for %s must be between 0 and 1", ErrReferenceInvalid, t.CountryName)
+	}
+	if t.EffectiveDate != "" {
+		if _, err := time.Parse(tariffDateLayout, t.EffectiveDate); err != nil {
+			return fmt.Errorf("%w: effective date for %s must be YYYY-MM-DD", ErrReferenceInvalid, t.CountryName)
+		}
+	}
+	return nil
+}
+
+// validate checks brand i of a package
+func (b *ReferenceBrand) validate(i int) error {
+	if strings.TrimSpace(b.BrandName) == "" {
+		return fmt.Errorf("%w: brand %d has no name", ErrReferenceInvalid, i)
+	}
+	if strings.TrimSpace(b.PrimaryCOO) == "" {
+		return fmt.Errorf("%w: brand %s has no primary COO", ErrReferenceInvalid, b.BrandName)
+	}
+	for _, coo := range b.SecondaryCOOs {
+		if strings.TrimSpace(coo) == "" {
+			return fmt.Errorf("%w: brand %s has an empty secondary COO", ErrReferenceInvalid, b.BrandName)
+		}
+	}
+	return nil
+}
+
+// ReferenceImportResult counts what an import wrote and, for
+// ImportReferenceDataSkippingInvalid, what it skipped and why
+type ReferenceImportResult struct {
+	Tariffs        int      `json:"tariffs"`
+	Brands         int      `json:"brands"`
+	SkippedTariffs int      `json:"skippedTariffs"`
+	SkippedBrands  int      `json:"skippedBrands"`
+	Skipped        []string `json:"skipped,omitempty"` // One reason per skipped row
+}
+
+// ImportReferenceData upserts a reference package in a single transaction.
+// Tariffs are matched by country name and effective date, and brands by brand
+// name; existing rows are updated and anything not in the package is left
+// alone. A tariff without an effective date takes effect today. Each brand's COO
+// must exist in tariff_rates once the package's tariffs are applied.
+// Returns the number of tariffs and brands written.
+func (db *DB) ImportReferenceData(data *ReferenceData) (tariffs, brands int, err error) {
+	if err := data.validate(); err != nil {
+		return 0, 0, err
+	}
+	result, err := db.importReferenceData(data, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	return result.Tariffs, result.Brands, nil
+}
+
+// ImportReferenceDataSkippingInvalid is ImportReferenceData for hand-edited
+// or partly stale packages: malformed tariffs and brands (including brands
+// whose COO has no tariff rate) are skipped and reported instead of failing
+// the import. An unsupported version still fails it.
+func (db *DB) ImportReferenceDataSkippingInvalid(data *ReferenceData) (*ReferenceImportResult, error) {
+	if err := data.validateVersion(); err != nil {
+		return nil, err
+	}
+	return db.importReferenceData(data, true)
+}
+
+// importReferenceData writes data's rows in one transaction. With
+// skipInvalid, rows that fail validation are counted in the result rather
+// than returned as an error.
+func (db *DB) importReferenceData(data *ReferenceData, skipInvalid bool) (*ReferenceImportResult, error) {
+	result := &ReferenceImportResult{}
+	skip := func(err error) {
+		result.Skipped = append(result.Skipped, strings.TrimPrefix(err.Error(), ErrReferenceInvalid.Error()+": "))
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // No-op once committed
+
+	for i, t := range data.Tariffs {
+		if skipInvalid {
+			if err := t.validate(i); err != nil {
+				result.SkippedTariffs++
+				skip(err)
+				continue
+			}
+		}
+		_, err := tx.Exec(`
+			INSERT INTO tariff_rates (country_name, tariff_rate, notes, effective_date)
+			VALUES (?, ?, ?, COALESCE(NULLIF(?, ''), DATE('now')))
+			ON CONFLICT(country_name, effective_date) DO UPDATE SET
+				tariff_rate = excluded.tariff_rate,
+				notes = excluded.notes,
+				updated_at = CURRENT_TIMESTAMP
+		`, t.CountryName, t.TariffRate, t.Notes, t.EffectiveDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import tariff %s: %w", t.CountryName, err)
+		}
+		result.Tariffs++
+	}
+
+	for i, b := range data.Brands {
+		if skipInvalid {
+			if err := b.validate(i); err != nil {
+				result.SkippedBrands++
+				skip(err)
+				continue
+			}
+		}
+
+		var count int
+		err := tx.QueryRow(`
+			SELECT COUNT(*) FROM tariff_rates WHERE LOWER(country_name) = LOWER(?)
+		`, b.PrimaryCOO).Scan(&count)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			err := fmt.Errorf("%w: brand %s has country %s which does not exist in tariff rates", ErrReferenceInvalid, b.BrandName, b.PrimaryCOO)
+			if !skipInvalid {
+				return nil, err
+			}
+			result.SkippedBrands++
+			skip(err)
+			continue
+		}
+
+		var secondary interface{} // NULL keeps the existing list
+		if b.SecondaryCOOs != nil {
+			list, err := json.Marshal(b.SecondaryCOOs)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal secondary COOs for %s: %w", b.BrandName, err)
+			}
+			secondary = string(list)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO brand_coo_mappings (brand_name, primary_coo, notes, brand_type, secondary_coos)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(brand_name) DO UPDATE SET
+				primary_coo = excluded.primary_coo,
+				notes = excluded.notes,
+				brand_type = excluded.brand_type,
+				secondary_coos = COALESCE(excluded.secondary_coos, brand_coo_mappings.secondary_coos),
+				auto_created = 0,
+				updated_at = CURRENT_TIMESTAMP
+		`, b.BrandName, b.PrimaryCOO, b.Notes, b.BrandType, secondary)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import brand %s: %w", b.BrandName, err)
+		}
+		result.Brands++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit reference import: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReferenceDataRoundTrip(t *testing.T) {
+	src := openSeededDB(t)
+	data, err := src.ExportReferenceData()
+	if err != nil {
+		t.Fatalf("ExportReferenceData: %v", err)
+	}
+
+	var aje *ReferenceBrand
+	for i := range data.Brands {
+		if data.Brands[i].BrandName == "Aje" {
+			aje = &data.Brands[i]
+		}
+	}
+	if aje == nil {
+		t.Fatal("Aje missing from export")
+	}
+	if want := []string{"India", "Malaysia"}; !reflect.DeepEqual(aje.SecondaryCOOs, want) {
+		t.Fatalf("exported secondary COOs = %v, want %v", aje.SecondaryCOOs, want)
+	}
+
+	dst := openTestDB(t)
+	if _, _, err := dst.ImportReferenceData(data); err != nil {
+		t.Fatalf("ImportReferenceData: %v", err)
+	}
+	again, err := dst.ExportReferenceData()
+	if err != nil {
+		t.Fatalf("ExportReferenceData after import: %v", err)
+	}
+	if !reflect.DeepEqual(again.Brands, data.Brands) {
+		t.Errorf("brands changed in round trip:\n got %+v\nwant %+v", again.Brands, data.Brands)
+	}
+	if !reflect.DeepEqual(again.Tariffs, data.Tariffs) {
+		t.Errorf("tariffs changed in round trip:\n got %+v\nwant %+v", again.Tariffs, data.Tariffs)
+	}
+}
+
+func TestImportReferenceDataKeepsSecondaryCOOsFromVersion1(t *testing.T) {
+	db := openSeededDB(t)
+	// A version 1 package has no secondaryCoos, which must not clear the list
+	data := &ReferenceData{
+		Version: 1,
+		Brands:  []ReferenceBrand{{BrandName: "Aje", PrimaryCOO: "China", Notes: "edited"}},
+	}
+	if _, _, err := db.ImportReferenceData(data); err != nil {
+		t.Fatalf("ImportReferenceData: %v", err)
+	}
+	config, err := db.GetCalculatorConfig()
+	if err != nil {
+		t.Fatalf("GetCalculatorConfig: %v", err)
+	}
+	if got, want := config.Brands["Aje"].SecondaryCOO, []string{"India", "Malaysia"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("secondary COOs = %v, want %v", got, want)
+	}
+}
@@ -9,6 +9,7 @@ CREATE TABLE IF NOT EXISTS accounts (
     environment TEXT NOT NULL,              -- "production" or "sandbox"
     marketplace_id TEXT NOT NULL,           -- e.g., "EBAY_AU"
     last_export_at DATETIME,                -- When last export happened
+    deleted_at DATETIME,                    -- Soft-delete: hidden from account lists, data kept
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
@@ -23,6 +24,7 @@ CREATE TABLE IF NOT EXISTS sync_history (
     error_message TEXT,
     started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     completed_at DATETIME,
+    details TEXT,                           -- JSON: resource -> {count, error}
     FOREIGN KEY (account_id) REFERENCES accounts(id)
 );
 
@@ -93,6 +95,10 @@ CREATE TABLE IF NOT EXISTS offers (
     listing_id TEXT,                        -- eBay listing ID for reference
     status TEXT,                            -- "PUBLISHED", "UNPUBLISHED" for filtering
     data TEXT NOT NULL,                     -- Full eBay Offer JSON
+    override_domestic_cost REAL,            -- First DOMESTIC shipping cost override in data, NULL if none
+    override_domestic_currency TEXT,
+    override_international_cost REAL,       -- First INTERNATIONAL shipping cost override, NULL if none
+    override_international_currency TEXT,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (account_id) REFERENCES accounts(id),
@@ -105,19 +111,25 @@ CREATE TABLE IF NOT EXISTS brand_coo_mappings (
     brand_name TEXT NOT NULL UNIQUE,        -- Brand name (e.g., "Free People")
     primary_coo TEXT NOT NULL,              -- Country of Origin (e.g., "China", "India")
     notes TEXT,                             -- Optional notes about the brand/supplier
+    brand_type TEXT,                        -- Product type (e.g., "Hats", "Sneakers") used to guess weight band
+    secondary_coos TEXT,                    -- JSON array of other countries the brand manufactures in
+    auto_created INTEGER DEFAULT 0,         -- 1 = added by enrichment and not yet reviewed
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
 
--- Tariff rates by country (less frequently changed, government policy)
+-- Tariff rates by country (less frequently changed, government policy).
+-- A country has one row per rate change; the rate in effect on a date is the
+-- row with the latest effective_date on or before it.
 CREATE TABLE IF NOT EXISTS tariff_rates (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
-    country_name TEXT NOT NULL UNIQUE,      -- e.g., "China", "Vietnam"
+    country_name TEXT NOT NULL,             -- e.g., "China", "Vietnam"
     tariff_rate REAL NOT NULL,              -- e.g., 0.20 for 20%
     notes TEXT,                             -- Context about the tariff
-    effective_date DATE,                    -- When this rate became effective
+    effective_date DATE NOT NULL DEFAULT (DATE('now')), -- When this rate became effective
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(country_name, effective_date)
 );
 
 -- Marketplace account deletion notifications (eBay compliance requirement)
@@ -138,12 +150,22 @@ CREATE TABLE IF NOT EXISTS deletion_notifications (
 -- Uses TTL to avoid redundant API calls (data rarely changes)
 CREATE TABLE IF NOT EXISTS enriched_items (
     item_id TEXT PRIMARY KEY,               -- eBay Item ID (unique identifier)
+    sku TEXT,                               -- Seller's SKU, linking the listing to inventory
     brand TEXT,                             -- Brand from GetItem API
     country_of_origin TEXT,                 -- Country of Origin from ItemSpecifics
     shipping_cost TEXT,                     -- US shipping cost
     shipping_currency TEXT,                 -- Shipping cost currency
     images TEXT,                            -- JSON array of full-size image URLs
+    condition_description TEXT,             -- Seller's condition note (truncated)
+    shipping_by_destination TEXT,           -- JSON object: international destination -> shipping cost
     enriched_at DATETIME NOT NULL,          -- When this data was fetched (for TTL checking)
+    quantity INTEGER,                       -- Listed quantity from the last listings fetch
+    quantity_sold INTEGER,                  -- Units sold from the last listings fetch
+    expected_coo TEXT,                      -- Derived by the last recompute (POST /api/enrich/recompute)
+    tariff_rate REAL,                       -- Derived by the last recompute
+    calculated_cost REAL,                   -- Derived by the last recompute
+    diff_status TEXT,                       -- Derived by the last recompute
+    computed_at DATETIME,                   -- When the last recompute stored the derived values
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
@@ -157,6 +179,14 @@ CREATE TABLE IF NOT EXISTS sessions (
     expires_at DATETIME NOT NULL            -- When session expires (30 days default)
 );
 
+-- OAuth states - issued by /api/auth/url and consumed once by the callback
+-- Stored in the database so the callback can land on any instance
+CREATE TABLE IF NOT EXISTS oauth_states (
+    state TEXT PRIMARY KEY,                 -- Random state parameter sent to eBay
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL            -- States are short-lived (10 minutes default)
+);
+
 -- Global application settings - key-value store for user preferences
 CREATE TABLE IF NOT EXISTS settings (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -193,6 +223,9 @@ CREATE TABLE IF NOT EXISTS postal_zones (
     zone_name TEXT NOT NULL,                -- Display name
     handling_fee_percent REAL DEFAULT 0.02, -- 2% handling fee
     has_tariffs BOOLEAN DEFAULT false,      -- Whether this zone has tariffs (USA only)
+    zonos_enabled BOOLEAN,                  -- Zonos collects the duties; NULL = same as has_tariffs
+    zonos_processing_charge_percent REAL,   -- NULL = zonos_processing_charge_percent setting
+    zonos_flat_fee_aud REAL,                -- NULL = zonos_flat_fee_aud setting
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -201,6 +234,7 @@ CREATE TABLE IF NOT EXISTS postal_rates (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     zone_id TEXT NOT NULL,
     weight_band TEXT NOT NULL,              -- "XSmall", "Small", "Medium", "Large", "XLarge"
+    label TEXT,                             -- Display label, e.g. "XSmall [< 250g]"
     max_weight_grams INTEGER NOT NULL,
     base_price_aud REAL NOT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -218,11 +252,67 @@ CREATE TABLE IF NOT EXISTS discount_bands (
     UNIQUE(zone_id, band_level)
 );
 
+-- eBay API calls per quota day (eBay's daily quotas reset at midnight Pacific time)
+CREATE TABLE IF NOT EXISTS ebay_call_usage (
+    day TEXT PRIMARY KEY,                   -- Quota day, YYYY-MM-DD in Pacific time
+    calls INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Raw Trading API responses, kept while the debug_store_raw_responses setting
+-- is on to diagnose odd ItemSpecifics naming. Only the latest per call is kept.
+CREATE TABLE IF NOT EXISTS raw_api_responses (
+    item_id TEXT NOT NULL,                  -- eBay Item ID the call was for
+    call_name TEXT NOT NULL,                -- e.g. "GetItem"
+    status_code INTEGER,                    -- HTTP status of the response
+    response TEXT NOT NULL,                 -- Raw response body (XML)
+    fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (item_id, call_name)
+);
+
+-- Known parcel weights for individual listings. Postage for these items uses
+-- the band for the weight instead of one guessed from the brand type.
+CREATE TABLE IF NOT EXISTS item_weights (
+    item_id TEXT PRIMARY KEY,               -- eBay Item ID
+    weight_grams INTEGER NOT NULL CHECK (weight_grams > 0),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Where an unfinished export of a paged resource got to, so it can resume
+-- instead of re-fetching everything. Cleared when the export completes.
+CREATE TABLE IF NOT EXISTS sync_cursors (
+    account_id INTEGER NOT NULL,
+    resource TEXT NOT NULL,                 -- e.g. "inventoryItems"
+    next_offset INTEGER NOT NULL,           -- First offset not yet saved
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES accounts(id),
+    PRIMARY KEY (account_id, resource)
+);
+
+-- Currency rates - converts eBay amounts to AUD so they compare with calculated costs
+-- Individual rates can be overridden with a 'currency_rate_<code>' setting (e.g. currency_rate_usd or currency_rate_USD; case doesn't matter)
+CREATE TABLE IF NOT EXISTS currency_rates (
+    currency TEXT PRIMARY KEY,              -- ISO 4217 code, e.g. "USD"
+    rate_to_aud REAL NOT NULL,              -- 1 unit of currency in AUD, e.g. 1.52
+    source TEXT DEFAULT 'seed',             -- Where the rate came from
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT OR IGNORE INTO currency_rates (currency, rate_to_aud) VALUES
+    ('AUD', 1.00),
+    ('USD', 1.52),
+    ('CAD', 1.10),
+    ('GBP', 2.03),
+    ('EUR', 1.77),
+    ('NZD', 0.88);
+
 -- Indexes for performance
 CREATE INDEX IF NOT EXISTS idx_settings_key ON settings(key);
 CREATE INDEX IF NOT EXISTS idx_inventory_sku ON inventory_items(account_id, sku);
 CREATE INDEX IF NOT EXISTS idx_offers_sku ON offers(account_id, sku);
 CREATE INDEX IF NOT EXISTS idx_offers_status ON offers(account_id, status);
+CREATE INDEX IF NOT EXISTS idx_offers_listing ON offers(listing_id, updated_at);
 CREATE INDEX IF NOT EXISTS idx_sync_history_account ON sync_history(account_id, started_at);
 CREATE INDEX IF NOT EXISTS idx_brand_coo_brand ON brand_coo_mappings(brand_name);
 CREATE INDEX IF NOT EXISTS idx_tariff_country ON tariff_rates(country_name);
@@ -235,4 +325,19 @@ INSERT OR IGNORE INTO settings (key, value, description, data_type) VALUES
     ('auspost_api_enabled', 'false', 'Enable AusPost API integration (future)', 'bool'),
     ('auspost_api_key', '', 'AusPost API key (future)', 'string'),
     ('auspost_api_secret', '', 'AusPost API secret (future)', 'string'),
-    ('active_ebay_environment', 'production', 'Current active eBay environment (production/sandbox)', 'string');
+    ('active_ebay_environment', 'production', 'Current active eBay environment (production/sandbox)', 'string'),
+    ('trading_strict_ack', 'false', 'Treat Trading API Warning responses as errors instead of success', 'bool'),
+    ('debug_store_raw_responses', 'false', 'Keep the raw XML of the latest Trading API GetItem response per item (GET /api/debug/item/:id)', 'bool'),
+    ('enrichment_auto_refresh', 'false', 'Periodically re-fetch enrichment for items whose offer or inventory item changed in a sync since they were enriched', 'bool'),
+    ('enrichment_auto_create_brands', 'false', 'Add a brand-COO mapping (flagged for review) when enrichment finds a brand with none, using the item''s declared COO if it is a tariff country', 'bool'),
+    ('default_weight_band', 'Medium', 'Weight band assumed for bulk calculations when an item''s weight is unknown and its brand type doesn''t suggest one (XSmall, Small, Medium, Large, XLarge)', 'string'),
+    ('default_discount_band', '3', 'AusPost discount band (0-5) assumed for bulk calculations', 'int'),
+    ('brand_fuzzy_match', 'false', 'Use the closest known brand''s COO (by prefix or spelling, see /api/brands/suggest) for brands with no exact mapping', 'bool'),
+    ('coo_honor_secondary', 'true', 'Use a declared country of origin from the brand''s secondary list for tariffs and COO matching; false uses the primary COO only', 'bool'),
+    ('audit_shipping_currency', 'USD', 'Currency buyers in the audited zone (USA) expect shipping quoted in; listings in any other currency fail the currency check', 'string'),
+    ('ebay_daily_call_budget', '5000', 'eBay API calls allowed per day (resets midnight Pacific time); warns at 80% (0 = no budget)', 'int'),
+    ('ebay_budget_block_bulk', 'false', 'Refuse bulk operations (sync, enrichment, batch updates) once 80% of the daily eBay call budget is used', 'bool'),
+    ('listings_fetch_concurrency', '5', 'GetMyeBaySelling pages fetched at once when loading listings (clamped to 1-10); lower it if eBay rate-limits you', 'int'),
+    ('image_target_size', '1600', 'Size token (s-lNNN) eBay image URLs are upscaled to; 1600 is the largest eBay serves', 'int'),
+    ('tariff_de_minimis_aud', '0', 'Item value (AUD) below which no US duties or Zonos fees apply (0 = disabled)', 'float'),
+    ('brand_type_weight_bands', '{"Hats":"XSmall","Headbands":"XSmall","Sunnies":"XSmall","Sneakers":"Large"}', 'Weight band guessed from brand type when an item has no known weight (JSON: type -> band)', 'json');
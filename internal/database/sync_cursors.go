@@ -0,0 +1,40 @@
+package database
+
+import (
+	"database/sql"
+)
+
+// GetSyncCursor returns the offset an unfinished export of resource stopped
+// at for an account, or 0 if there's none (nothing to resume)
+func (db *DB) GetSyncCursor(accountID int64, resource string) (int, error) {
+	var offset int
+	err := db.QueryRow(`
+		SELECT next_offset FROM sync_cursors
+		WHERE account_id = ? AND resource = ?
+	`, accountID, resource).Scan(&offset)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return offset, err
+}
+
+// SetSyncCursor records that an export of resource has saved everything
+// before offset
+func (db *DB) SetSyncCursor(accountID int64, resource string, offset int) error {
+	_, err := db.Exec(`
+		INSERT INTO sync_cursors (account_id, resource, next_offset, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(account_id, resource) DO UPDATE SET
+			next_offset = excluded.next_offset,
+			updated_at = CURRENT_TIMESTAMP
+	`, accountID, resource, offset)
+	return err
+}
+
+// ClearSyncCursor forgets an export's cursor once it has completed
+func (db *DB) ClearSyncCursor(accountID int64, resource string) error {
+	_, err := db.Exec(`
+		DELETE FROM sync_cursors WHERE account_id = ? AND resource = ?
+	`, accountID, resource)
+	return err
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"math"
+
+	"github.com/julienbonastre/ebay-helpers/internal/calculator"
+)
+
+// TariffPreview is the effect a tariff rate change would have on the
+// calculated postage of enriched items, computed without saving anything
+type TariffPreview struct {
+	CountryName   string  `json:"countryName"` // As spelled in the tariff table
+	OldRate       float64 `json:"oldRate"`     // Rate affected items pay today (the default COO's for a new country)
+	NewRate       float64 `json:"newRate"`
+	AffectedItems int     `json:"affectedItems"`
+	OldTotal      float64 `json:"oldTotal"` // Sum of affected items' calculated cost, AUD
+	NewTotal      float64 `json:"newTotal"`
+	Delta         float64 `json:"delta"` // NewTotal - OldTotal
+}
+
+// PreviewTariffChange totals the calculated cost of every enriched item
+// whose country of origin would take country's tariff rate, under calc and
+// under calc with that rate set to newRate. Costs are computed exactly as the
+// listings page computes them, with prices from accountID's offers.
+func (db *DB) PreviewTariffChange(accountID int64, calc *calculator.CalculatorConfig, country string, newRate float64) (*TariffPreview, error) {
+	query := ListingsQuery{AccountID: accountID}
+	previewCalc, name := calc.WithTariffRate(country, newRate)
+	preview := &TariffPreview{
+		CountryName: name,
+		OldRate:     calc.GetTariffRate(name),
+		NewRate:     newRate,
+	}
+
+	// The same COO the listings page calculates with; affected items are
+	// those whose COO resolves to the previewed country once it has a rate
+	affected := make(map[string]bool)
+	err := db.EachListing(query, calc, func(item *ListingItem) error {
+		declared := item.CountryOfOrigin
+		if declared == "" {
+			declared = item.ExpectedCOO
+		}
+		coo, _ := previewCalc.ResolveCOO(item.Brand, declared)
+		if previewCalc.TariffCountry(coo) != name {
+			return nil
+		}
+		affected[item.ItemID] = true
+		preview.OldTotal += item.CalculatedCost
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	preview.AffectedItems = len(affected)
+	if len(affected) == 0 {
+		return preview, nil
+	}
+
+	err = db.EachListing(query, previewCalc, func(item *ListingItem) error {
+		if affected[item.ItemID] {
+			preview.NewTotal += item.CalculatedCost
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	preview.OldTotal = math.Round(preview.OldTotal*100) / 100
+	preview.NewTotal = math.Round(preview.NewTotal*100) / 100
+	preview.Delta = math.Round((preview.NewTotal-preview.OldTotal)*100) / 100
+	return preview, nil
+}
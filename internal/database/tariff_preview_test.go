@@ -0,0 +1,58 @@
+package database
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestPreviewTariffChange(t *testing.T) {
+	db := openSeededDB(t)
+	calc, err := db.GetCalculatorConfig()
+	if err != nil {
+		t.Fatalf("GetCalculatorConfig: %v", err)
+	}
+	acc := createTestAccount(t, db, "alice")
+	for i, coo := range []string{"China", "China", "India"} {
+		n := strconv.Itoa(i + 1)
+		addTestOffer(t, db, acc.ID, n, "item-"+n, 300)
+		saveTestEnrichedItem(t, db, "item-"+n, "Aje", coo, "30.00", "AUD")
+	}
+	oldRate := calc.GetTariffRate("China")
+	query := ListingsQuery{AccountID: acc.ID}
+	wantOld := listingByID(t, db, query, calc, "item-1").CalculatedCost + listingByID(t, db, query, calc, "item-2").CalculatedCost
+	india := listingByID(t, db, query, calc, "item-3").CalculatedCost
+
+	// The country is matched however it's spelled
+	preview, err := db.PreviewTariffChange(acc.ID, calc, "PRC", oldRate+0.5)
+	if err != nil {
+		t.Fatalf("PreviewTariffChange: %v", err)
+	}
+	if preview.CountryName != "China" || preview.OldRate != oldRate || preview.AffectedItems != 2 {
+		t.Errorf("preview = %+v, want China at %.2f affecting 2 items", preview, oldRate)
+	}
+	if preview.OldTotal != math.Round(wantOld*100)/100 {
+		t.Errorf("old total = %.2f, want the China items' %.2f", preview.OldTotal, wantOld)
+	}
+	if preview.NewTotal <= preview.OldTotal {
+		t.Errorf("new total %.2f should exceed old total %.2f after raising the rate", preview.NewTotal, preview.OldTotal)
+	}
+	if want := math.Round((preview.NewTotal-preview.OldTotal)*100) / 100; preview.Delta != want {
+		t.Errorf("delta = %.2f, want %.2f", preview.Delta, want)
+	}
+
+	// Nothing is saved: the config and stored rates are untouched, as is the unaffected item
+	if got := calc.GetTariffRate("China"); got != oldRate {
+		t.Errorf("calculator's China rate = %.2f after previewing, want %.2f", got, oldRate)
+	}
+	stored, err := db.GetCalculatorConfig()
+	if err != nil {
+		t.Fatalf("GetCalculatorConfig: %v", err)
+	}
+	if got := stored.GetTariffRate("China"); got != oldRate {
+		t.Errorf("stored China rate = %.2f after previewing, want %.2f", got, oldRate)
+	}
+	if got := listingByID(t, db, query, stored, "item-3").CalculatedCost; got != india {
+		t.Errorf("India item cost = %.2f after previewing, want %.2f", got, india)
+	}
+}
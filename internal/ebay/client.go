@@ -5,15 +5,20 @@ import (
 	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/julienbonastre/ebay-helpers/internal/calculator"
 	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
 )
 
 const (
@@ -39,6 +44,31 @@ type Config struct {
 	RedirectURI  string
 	Sandbox      bool
 	Scopes       []string
+	DebugOAuth   bool // Log OAuth URLs and token metadata ([OAUTH-DEBUG]); off by default
+	StrictAck    bool // Treat Trading API Ack=Warning as a failure; off by default
+	ImageSize    int  // s-lNNN size token image URLs are rewritten to; 0 uses DefaultImageSize
+
+	// HTTPTimeout bounds each call including retries; 0 uses DefaultHTTPTimeout.
+	// MaxRetries is how many times an idempotent GET is retried on 429/5xx; 0 disables retries.
+	HTTPTimeout time.Duration
+	MaxRetries  int
+
+	// RateLimiter is shared by all clients built from this config. Clients are
+	// created per request, so the limiter must live outside them. Nil disables limiting.
+	RateLimiter *RateLimiter
+
+	// CallCounter, if set, is told about every outbound call (for daily quota tracking)
+	CallCounter CallCounter
+
+	// ResponseRecorder, if set, receives raw Trading API GetItem responses (for debugging)
+	ResponseRecorder ResponseRecorder
+}
+
+// ResponseRecorder is given the raw body of Trading API responses, e.g. to
+// keep them for diagnosing how a listing's ItemSpecifics are named.
+// Implementations must be safe for concurrent use.
+type ResponseRecorder interface {
+	RecordResponse(itemID, callName string, statusCode int, body []byte)
 }
 
 // Client is the eBay API client
@@ -46,10 +76,15 @@ type Client struct {
 	config          Config
 	httpClient      *http.Client
 	oauthConfig     *oauth2.Config
-	token           *oauth2.Token
 	baseURL         string // For Sell APIs (api.ebay.com)
 	commerceBaseURL string // For Commerce APIs (apiz.ebay.com)
 	tradingAPIURL   string // For Trading API (XML-based)
+
+	// token is refreshed by whichever request finds it expired; sessions
+	// share a Client across concurrent requests, so it's only read or
+	// replaced under tokenMu
+	tokenMu sync.Mutex
+	token   *oauth2.Token
 }
 
 // NewClient creates a new eBay API client
@@ -71,16 +106,7 @@ func NewClient(cfg Config) *Client {
 
 	// Default scopes for inventory management
 	if len(cfg.Scopes) == 0 {
-		cfg.Scopes = []string{
-			"https://api.ebay.com/oauth/api_scope",
-			"https://api.ebay.com/oauth/api_scope/sell.inventory",
-			"https://api.ebay.com/oauth/api_scope/sell.inventory.readonly",
-			"https://api.ebay.com/oauth/api_scope/sell.account",
-			"https://api.ebay.com/oauth/api_scope/sell.account.readonly",
-			"https://api.ebay.com/oauth/api_scope/sell.fulfillment",
-			"https://api.ebay.com/oauth/api_scope/sell.fulfillment.readonly",
-			"https://api.ebay.com/oauth/api_scope/commerce.identity.readonly", // For User API
-		}
+		cfg.Scopes = DefaultScopes
 	}
 
 	oauthConfig := &oauth2.Config{
@@ -94,10 +120,24 @@ func NewClient(cfg Config) *Client {
 		},
 	}
 
+	timeout := cfg.HTTPTimeout
+	if timeout <= 0 {
+		timeout = DefaultHTTPTimeout
+	}
+
+	// Every outbound call (and every retry of it) goes through the shared rate limiter
+	httpClient := &http.Client{
+		Timeout: timeout,
+		Transport: &retryTransport{
+			maxRetries: cfg.MaxRetries,
+			base:       &rateLimitedTransport{limiter: cfg.RateLimiter, counter: cfg.CallCounter, base: http.DefaultTransport},
+		},
+	}
+
 	return &Client{
 		config:          cfg,
 		oauthConfig:     oauthConfig,
-		httpClient:      &http.Client{Timeout: 30 * time.Second},
+		httpClient:      httpClient,
 		baseURL:         baseURL,
 		commerceBaseURL: commerceBaseURL,
 		tradingAPIURL:   tradingAPIURL,
@@ -111,6 +151,13 @@ func (c *Client) GetAuthURL(state string) string {
 	url := c.oauthConfig.AuthCodeURL(state,
 		oauth2.SetAuthURLParam("prompt", "login"))
 
+	if c.config.DebugOAuth {
+		log.Printf("[OAUTH-DEBUG] Auth URL: %s", url)
+		log.Printf("[OAUTH-DEBUG] Client ID: %s...", c.config.ClientID[:min(8, len(c.config.ClientID))])
+		log.Printf("[OAUTH-DEBUG] Redirect URI: %s", c.config.RedirectURI)
+		log.Printf("[OAUTH-DEBUG] Scopes: %v", c.oauthConfig.Scopes)
+	}
+
 	return url
 }
 
@@ -122,7 +169,12 @@ func (c *Client) ExchangeCode(ctx context.Context, code string) error {
 		return fmt.Errorf("failed to exchange code: %w", err)
 	}
 
-	c.token = token
+	if c.config.DebugOAuth {
+		log.Printf("[OAUTH-DEBUG] Token exchange succeeded: type=%s, expiry=%s, has refresh token=%v",
+			token.TokenType, token.Expiry.Format(time.RFC3339), token.RefreshToken != "")
+	}
+
+	c.SetToken(token)
 	return nil
 }
 
@@ -135,17 +187,41 @@ func min(a, b int) int {
 
 // SetToken sets the OAuth token directly
 func (c *Client) SetToken(token *oauth2.Token) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
 	c.token = token
 }
 
 // GetToken returns the current token
 func (c *Client) GetToken() *oauth2.Token {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
 	return c.token
 }
 
 // IsAuthenticated returns true if we have a valid token
 func (c *Client) IsAuthenticated() bool {
-	return c.token != nil && c.token.Valid()
+	token := c.GetToken()
+	return token != nil && token.Valid()
+}
+
+// freshToken returns the current token, refreshing it first if it has
+// expired. Holding tokenMu across the refresh means concurrent requests
+// refresh an expired token once rather than each racing to replace it.
+func (c *Client) freshToken(ctx context.Context) (*oauth2.Token, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	token, err := c.oauthConfig.TokenSource(ctx, c.token).Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get valid token: %w", err)
+	}
+	c.token = token
+	return token, nil
+}
+
+// RateLimitStats returns the shared rate limiter's remaining budget
+func (c *Client) RateLimitStats() RateLimiterStats {
+	return c.config.RateLimiter.Stats()
 }
 
 // IsConfigured returns true if eBay API credentials are set
@@ -153,13 +229,23 @@ func (c *Client) IsConfigured() bool {
 	return c.config.ClientID != "" && c.config.ClientSecret != ""
 }
 
-// RefreshToken refreshes the access token if needed
+// ErrNoRefreshToken is returned by RefreshToken when the client's token
+// can't be refreshed and the user has to log in again
+var ErrNoRefreshToken = errors.New("no refresh token")
+
+// RefreshToken exchanges the refresh token for a new access token, even if
+// the current one hasn't expired yet. eBay doesn't rotate refresh tokens, so
+// the existing one is kept.
 func (c *Client) RefreshToken(ctx context.Context) error {
-	if c.token == nil {
-		return fmt.Errorf("no token to refresh")
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.token == nil || c.token.RefreshToken == "" {
+		return ErrNoRefreshToken
 	}
 
-	src := c.oauthConfig.TokenSource(ctx, c.token)
+	// A token with only the refresh token is never valid, so the token
+	// source always goes to eBay rather than handing back the current one
+	src := c.oauthConfig.TokenSource(ctx, &oauth2.Token{RefreshToken: c.token.RefreshToken})
 	newToken, err := src.Token()
 	if err != nil {
 		return fmt.Errorf("failed to refresh token: %w", err)
@@ -168,19 +254,39 @@ func (c *Client) RefreshToken(ctx context.Context) error {
 	return nil
 }
 
+// ApplicationScope is the public-data scope granted to client-credentials tokens
+const ApplicationScope = ScopePrefix
+
+// FetchApplicationToken obtains an application access token using the
+// client-credentials grant. Application tokens aren't tied to a user, so they
+// only work for public read-only APIs such as the Browse API, but they can be
+// used from background jobs that have no user session.
+func (c *Client) FetchApplicationToken(ctx context.Context) (*oauth2.Token, error) {
+	ccConfig := &clientcredentials.Config{
+		ClientID:     c.config.ClientID,
+		ClientSecret: c.config.ClientSecret,
+		TokenURL:     c.oauthConfig.Endpoint.TokenURL,
+		Scopes:       []string{ApplicationScope},
+		AuthStyle:    oauth2.AuthStyleInHeader,
+	}
+
+	token, err := ccConfig.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch application token: %w", err)
+	}
+	return token, nil
+}
+
 // doRequest makes an authenticated API request (for Sell APIs)
 func (c *Client) doRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
 	if !c.IsAuthenticated() {
 		return nil, fmt.Errorf("client not authenticated")
 	}
 
-	// Ensure token is fresh
-	src := c.oauthConfig.TokenSource(ctx, c.token)
-	token, err := src.Token()
+	token, err := c.freshToken(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get valid token: %w", err)
+		return nil, err
 	}
-	c.token = token
 
 	reqURL := c.baseURL + path
 	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
@@ -192,7 +298,21 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body io.Rea
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
-	return c.httpClient.Do(req)
+	return c.doHTTP(req)
+}
+
+// doHTTP sends a REST request, converting 4xx/5xx responses into *APIError
+func (c *Client) doHTTP(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newRESTAPIError(resp.StatusCode, body)
+	}
+	return resp, nil
 }
 
 // doCommerceRequest makes an authenticated API request (for Commerce APIs using apiz.ebay.com)
@@ -201,13 +321,10 @@ func (c *Client) doCommerceRequest(ctx context.Context, method, path string, bod
 		return nil, fmt.Errorf("client not authenticated")
 	}
 
-	// Ensure token is fresh
-	src := c.oauthConfig.TokenSource(ctx, c.token)
-	token, err := src.Token()
+	token, err := c.freshToken(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get valid token: %w", err)
+		return nil, err
 	}
-	c.token = token
 
 	reqURL := c.commerceBaseURL + path
 	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
@@ -219,7 +336,7 @@ func (c *Client) doCommerceRequest(ctx context.Context, method, path string, bod
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
-	return c.httpClient.Do(req)
+	return c.doHTTP(req)
 }
 
 // User represents an eBay user
@@ -237,7 +354,8 @@ func (c *Client) GetUser(ctx context.Context) (*User, error) {
 	// Commerce APIs use apiz.ebay.com not api.ebay.com
 	fullURL := c.commerceBaseURL + "/commerce/identity/v1/user/"
 	log.Printf("[USER-API-DEBUG] Calling User API: GET %s", fullURL)
-	log.Printf("[USER-API-DEBUG] Has token: %v, Token valid: %v", c.token != nil, c.token != nil && c.token.Valid())
+	token := c.GetToken()
+	log.Printf("[USER-API-DEBUG] Has token: %v, Token valid: %v", token != nil, token != nil && token.Valid())
 
 	// Call Commerce API directly (uses different base URL than Sell APIs)
 	resp, err := c.doCommerceRequest(ctx, "GET", "/commerce/identity/v1/user/", nil)
@@ -265,6 +383,55 @@ func (c *Client) GetUser(ctx context.Context) (*User, error) {
 	return &user, nil
 }
 
+// getUserBackoff is the wait after GetUserWithRetry's first failed attempt;
+// each further wait is one backoff longer (1s, 2s, ...). A var so tests can
+// shorten it.
+var getUserBackoff = time.Second
+
+// GetUserWithRetry calls GetUser up to attempts times (at least once). Each
+// attempt gets a longer timeout (10s, 15s, 20s, ...) and a failed one is
+// followed by a growing backoff. Only IsRetryable errors are retried, so a
+// rejected token fails at once. Waiting stops as soon as ctx is done, which
+// returns the last error (or ctx's, if no attempt was made).
+func (c *Client) GetUserWithRetry(ctx context.Context, attempts int) (*User, error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	tried := 0
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			if lastErr == nil {
+				lastErr = err
+			}
+			break
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, time.Duration(5+attempt*5)*time.Second)
+		user, err := c.GetUser(attemptCtx)
+		cancel()
+		tried++
+		if err == nil {
+			return user, nil
+		}
+
+		lastErr = err
+		log.Printf("WARNING: GetUser attempt %d/%d failed: %v", attempt, attempts, err)
+		if attempt == attempts || !IsRetryable(err) {
+			break
+		}
+
+		timer := time.NewTimer(time.Duration(attempt) * getUserBackoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+	return nil, fmt.Errorf("failed to get user after %d attempt(s): %w", tried, lastErr)
+}
+
 // InventoryItem represents an eBay inventory item
 type InventoryItem struct {
 	SKU          string        `json:"sku"`
@@ -310,6 +477,16 @@ type PricingSummary struct {
 	Price *Amount `json:"price,omitempty"`
 }
 
+// Price returns the offer's price, or a zero Amount when it has none.
+// PricingSummary and its Price are both optional: unpublished draft offers
+// often omit them, so read pricing through here rather than the fields.
+func (o *Offer) Price() Amount {
+	if o.PricingSummary == nil || o.PricingSummary.Price == nil {
+		return Amount{}
+	}
+	return *o.PricingSummary.Price
+}
+
 // Amount holds monetary values
 type Amount struct {
 	Value    string `json:"value,omitempty"`
@@ -332,6 +509,30 @@ type ShippingCostOverride struct {
 	AdditionalShippingCost *Amount `json:"additionalShippingCost,omitempty"`
 }
 
+// ShippingCostOverride returns the shipping cost of the offer's first
+// override (lowest priority) for serviceType (ShippingServiceDomestic or
+// ShippingServiceInternational), or nil when no override for that type sets a
+// cost
+func (o *Offer) ShippingCostOverride(serviceType string) *Amount {
+	if o.ListingPolicies == nil {
+		return nil
+	}
+	var first *ShippingCostOverride
+	for i := range o.ListingPolicies.ShippingCostOverrides {
+		override := &o.ListingPolicies.ShippingCostOverrides[i]
+		if override.ShippingServiceType != serviceType || override.ShippingCost == nil {
+			continue
+		}
+		if first == nil || override.Priority < first.Priority {
+			first = override
+		}
+	}
+	if first == nil {
+		return nil
+	}
+	return first.ShippingCost
+}
+
 // ListingDetails holds listing info
 type ListingDetails struct {
 	ListingID string `json:"listingId,omitempty"`
@@ -357,6 +558,93 @@ type InventoryItemsResponse struct {
 	Next           string          `json:"next,omitempty"`
 }
 
+// Order is a buyer's order from the Fulfillment API (fields used for
+// postage reconciliation only)
+type Order struct {
+	OrderID                      string                        `json:"orderId"`
+	LegacyOrderID                string                        `json:"legacyOrderId,omitempty"`
+	CreationDate                 string                        `json:"creationDate,omitempty"` // ISO 8601 UTC
+	OrderFulfillmentStatus       string                        `json:"orderFulfillmentStatus,omitempty"`
+	OrderPaymentStatus           string                        `json:"orderPaymentStatus,omitempty"`
+	Buyer                        *OrderBuyer                   `json:"buyer,omitempty"`
+	PricingSummary               *OrderPricingSummary          `json:"pricingSummary,omitempty"`
+	FulfillmentStartInstructions []FulfillmentStartInstruction `json:"fulfillmentStartInstructions,omitempty"`
+	LineItems                    []OrderLineItem               `json:"lineItems,omitempty"`
+}
+
+// BuyerCountry returns the two-letter country the order ships to, or ""
+func (o *Order) BuyerCountry() string {
+	for _, instruction := range o.FulfillmentStartInstructions {
+		if step := instruction.ShippingStep; step != nil && step.ShipTo != nil && step.ShipTo.ContactAddress != nil {
+			return step.ShipTo.ContactAddress.CountryCode
+		}
+	}
+	return ""
+}
+
+// OrderBuyer identifies the buyer
+type OrderBuyer struct {
+	Username string `json:"username,omitempty"`
+}
+
+// OrderPricingSummary holds order totals. DeliveryCost is the postage the
+// buyer paid for the whole order.
+type OrderPricingSummary struct {
+	PriceSubtotal *Amount `json:"priceSubtotal,omitempty"`
+	DeliveryCost  *Amount `json:"deliveryCost,omitempty"`
+	Total         *Amount `json:"total,omitempty"`
+}
+
+// FulfillmentStartInstruction says where and how an order ships
+type FulfillmentStartInstruction struct {
+	ShippingStep *ShippingStep `json:"shippingStep,omitempty"`
+}
+
+// ShippingStep holds the ship-to address and chosen shipping service
+type ShippingStep struct {
+	ShipTo              *ShipTo `json:"shipTo,omitempty"`
+	ShippingServiceCode string  `json:"shippingServiceCode,omitempty"`
+}
+
+// ShipTo holds the recipient's address
+type ShipTo struct {
+	ContactAddress *ContactAddress `json:"contactAddress,omitempty"`
+}
+
+// ContactAddress holds the parts of an address needed for postage
+type ContactAddress struct {
+	StateOrProvince string `json:"stateOrProvince,omitempty"`
+	PostalCode      string `json:"postalCode,omitempty"`
+	CountryCode     string `json:"countryCode,omitempty"` // ISO 3166 two-letter code
+}
+
+// OrderLineItem is one listing within an order
+type OrderLineItem struct {
+	LineItemID   string            `json:"lineItemId"`
+	LegacyItemID string            `json:"legacyItemId,omitempty"` // Trading API item ID
+	SKU          string            `json:"sku,omitempty"`
+	Title        string            `json:"title,omitempty"`
+	Quantity     int               `json:"quantity,omitempty"`
+	LineItemCost *Amount           `json:"lineItemCost,omitempty"`
+	DeliveryCost *LineDeliveryCost `json:"deliveryCost,omitempty"`
+	Total        *Amount           `json:"total,omitempty"`
+}
+
+// LineDeliveryCost is the postage the buyer paid for one line item
+type LineDeliveryCost struct {
+	ShippingCost *Amount `json:"shippingCost,omitempty"`
+}
+
+// OrdersResponse is the response from getOrders
+type OrdersResponse struct {
+	Orders []Order `json:"orders,omitempty"`
+	Total  int     `json:"total,omitempty"`
+	Limit  int     `json:"limit,omitempty"`
+	Offset int     `json:"offset,omitempty"`
+	Href   string  `json:"href,omitempty"`
+	Next   string  `json:"next,omitempty"`
+}
+
 // FulfillmentPolicy represents a shipping/fulfillment policy
 type FulfillmentPolicy struct {
 	FulfillmentPolicyID string           `json:"fulfillmentPolicyId,omitempty"`
@@ -482,6 +770,24 @@ func (c *Client) GetInventoryItems(ctx context.Context, limit, offset int) (*Inv
 	return &result, nil
 }
 
+// GetInventoryItem retrieves the inventory item for one SKU. A SKU eBay
+// doesn't know fails with an *APIError that IsNotFound reports.
+func (c *Client) GetInventoryItem(ctx context.Context, sku string) (*InventoryItem, error) {
+	path := "/sell/inventory/v1/inventory_item/" + url.PathEscape(sku)
+
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var item InventoryItem
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		return nil, fmt.Errorf("failed to decode inventory item: %w", err)
+	}
+	return &item, nil
+}
+
 // GetOffers retrieves offers for a SKU or all offers
 func (c *Client) GetOffers(ctx context.Context, sku string, limit, offset int) (*OffersResponse, error) {
 	path := fmt.Sprintf("/sell/inventory/v1/offer?limit=%d&offset=%d", limit, offset)
@@ -517,6 +823,34 @@ func (c *Client) GetOffers(ctx context.Context, sku string, limit, offset int) (
 	return &result, nil
 }
 
+// GetOrders retrieves the seller's orders, newest first. filter is passed to
+// eBay as-is, e.g. "creationdate:[2024-01-01T00:00:00.000Z..]" or
+// "orderfulfillmentstatus:{NOT_STARTED|IN_PROGRESS}"; empty returns orders
+// from the last 90 days.
+func (c *Client) GetOrders(ctx context.Context, filter string, limit, offset int) (*OrdersResponse, error) {
+	path := fmt.Sprintf("/sell/fulfillment/v1/order?limit=%d&offset=%d", limit, offset)
+	if filter != "" {
+		path += "&filter=" + url.QueryEscape(filter)
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result OrdersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &result, nil
+}
+
 // GetFulfillmentPolicies retrieves all fulfillment policies
 func (c *Client) GetFulfillmentPolicies(ctx context.Context, marketplaceID string) (*FulfillmentPoliciesResponse, error) {
 	path := "/sell/account/v1/fulfillment_policy?marketplace_id=" + url.QueryEscape(marketplaceID)
@@ -583,6 +917,57 @@ func (c *Client) GetReturnPolicies(ctx context.Context, marketplaceID string) (*
 	return &result, nil
 }
 
+// CreateFulfillmentPolicy creates a fulfillment policy and returns it with its new ID
+func (c *Client) CreateFulfillmentPolicy(ctx context.Context, policy *FulfillmentPolicy) (*FulfillmentPolicy, error) {
+	var created FulfillmentPolicy
+	if err := c.createPolicy(ctx, "/sell/account/v1/fulfillment_policy", policy, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// CreatePaymentPolicy creates a payment policy and returns it with its new ID
+func (c *Client) CreatePaymentPolicy(ctx context.Context, policy *PaymentPolicy) (*PaymentPolicy, error) {
+	var created PaymentPolicy
+	if err := c.createPolicy(ctx, "/sell/account/v1/payment_policy", policy, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// CreateReturnPolicy creates a return policy and returns it with its new ID
+func (c *Client) CreateReturnPolicy(ctx context.Context, policy *ReturnPolicy) (*ReturnPolicy, error) {
+	var created ReturnPolicy
+	if err := c.createPolicy(ctx, "/sell/account/v1/return_policy", policy, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// createPolicy POSTs a policy to the Account API and decodes the created policy into out
+func (c *Client) createPolicy(ctx context.Context, path string, policy, out interface{}) error {
+	body, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal policy: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodPost, path, strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
 // UpdateOfferShipping updates shipping cost overrides for an offer
 func (c *Client) UpdateOfferShipping(ctx context.Context, offerID string, overrides []ShippingCostOverride) error {
 	// First get the current offer
@@ -630,6 +1015,45 @@ func (c *Client) UpdateOfferShipping(ctx context.Context, offerID string, overri
 	return nil
 }
 
+// OfferShippingUpdate is one offer's shipping overrides in a bulk update
+type OfferShippingUpdate struct {
+	OfferID   string                 `json:"offerId"`
+	Overrides []ShippingCostOverride `json:"overrides"`
+}
+
+// BulkUpdateOfferShipping applies UpdateOfferShipping to each offer with bounded
+// concurrency. One offer failing doesn't stop the others; the returned map has
+// an entry for every offer, nil on success.
+func (c *Client) BulkUpdateOfferShipping(ctx context.Context, updates []OfferShippingUpdate) map[string]error {
+	const maxConcurrent = 5 // Writes, so stay well under the read concurrency used by GetItems
+
+	results := make(map[string]error, len(updates))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrent)
+
+	for _, update := range updates {
+		wg.Add(1)
+		sem <- struct{}{} // Acquire semaphore
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }() // Release semaphore
+
+			err := c.UpdateOfferShipping(ctx, update.OfferID, update.Overrides)
+			if err != nil {
+				log.Printf("[BULK-SHIPPING] Failed to update offer %s: %v", update.OfferID, err)
+			}
+			mu.Lock()
+			results[update.OfferID] = err
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
+
 // TradingItem represents an item from GetMyeBaySelling (simplified)
 type TradingItem struct {
 	ItemID           string
@@ -695,11 +1119,7 @@ type GetMyeBaySellingResponse struct {
 			TotalNumberOfEntries int `xml:"TotalNumberOfEntries"`
 		} `xml:"PaginationResult"`
 	} `xml:"ActiveList"`
-	Errors []struct {
-		ShortMessage string `xml:"ShortMessage"`
-		LongMessage  string `xml:"LongMessage"`
-		ErrorCode    string `xml:"ErrorCode"`
-	} `xml:"Errors>Error"`
+	Errors []TradingError `xml:"Errors>Error"`
 }
 
 // GetItemResponse represents the XML response from GetItem
@@ -707,8 +1127,10 @@ type GetItemResponse struct {
 	XMLName xml.Name `xml:"GetItemResponse"`
 	Ack     string   `xml:"Ack"`
 	Item    struct {
-		ItemID        string `xml:"ItemID"`
-		ItemSpecifics struct {
+		ItemID               string `xml:"ItemID"`
+		SKU                  string `xml:"SKU"`
+		ConditionDescription string `xml:"ConditionDescription"` // Seller's free-text note on wear
+		ItemSpecifics        struct {
 			NameValueList []struct {
 				Name  string `xml:"Name"`
 				Value string `xml:"Value"`
@@ -724,48 +1146,129 @@ type GetItemResponse struct {
 					CurrencyID string `xml:"currencyID,attr"`
 				} `xml:"ShippingServiceCost"`
 			} `xml:"ShippingServiceOptions"`
-			InternationalShippingServiceOption []struct {
-				ShippingServiceCost struct {
-					Value      string `xml:",chardata"`
-					CurrencyID string `xml:"currencyID,attr"`
-				} `xml:"ShippingServiceCost"`
-				ShipToLocation []string `xml:"ShipToLocation"`
-			} `xml:"InternationalShippingServiceOption"`
+			InternationalShippingServiceOption []internationalShippingOption `xml:"InternationalShippingServiceOption"`
 		} `xml:"ShippingDetails"`
 	} `xml:"Item"`
-	Errors []struct {
-		ShortMessage string `xml:"ShortMessage"`
-		LongMessage  string `xml:"LongMessage"`
-		ErrorCode    string `xml:"ErrorCode"`
-	} `xml:"Errors>Error"`
+	Errors []TradingError `xml:"Errors>Error"`
+}
+
+// internationalShippingOption is one of a GetItem listing's international
+// shipping services and the destinations it ships to
+type internationalShippingOption struct {
+	ShippingServiceCost struct {
+		Value      string `xml:",chardata"`
+		CurrencyID string `xml:"currencyID,attr"`
+	} `xml:"ShippingServiceCost"`
+	ShipToLocation []string `xml:"ShipToLocation"`
+}
+
+// shippingByDestination maps each destination the options ship to (country
+// names normalized with calculator.NormalizeCountry, so "GB" and "UK" are
+// both "United Kingdom"; regions such as "Worldwide" or "Europe" as eBay
+// names them) to its shipping cost. eBay lists options in the seller's
+// order of preference, so the first option for a destination wins.
+func shippingByDestination(options []internationalShippingOption) map[string]string {
+	costs := make(map[string]string)
+	for _, option := range options {
+		if option.ShippingServiceCost.Value == "" {
+			continue
+		}
+		for _, location := range option.ShipToLocation {
+			destination := calculator.NormalizeCountry(location)
+			if _, ok := costs[destination]; !ok && destination != "" {
+				costs[destination] = option.ShippingServiceCost.Value
+			}
+		}
+	}
+	return costs
 }
 
 // BrowseAPIItemResponse represents the response from Browse API getItem
 type BrowseAPIItemResponse struct {
 	ItemID           string `json:"itemId"`
 	Title            string `json:"title"`
+	Brand            string `json:"brand"`
 	LocalizedAspects []struct {
 		Type  string `json:"type"`
 		Name  string `json:"name"`
 		Value string `json:"value"`
 	} `json:"localizedAspects"`
-	ShortDescription string `json:"shortDescription"`
+	ShortDescription     string `json:"shortDescription"`
+	ConditionDescription string `json:"conditionDescription"`
+	Image                struct {
+		ImageURL string `json:"imageUrl"`
+	} `json:"image"`
+	AdditionalImages []struct {
+		ImageURL string `json:"imageUrl"`
+	} `json:"additionalImages"`
+	ShippingOptions []struct {
+		ShippingCost *Amount `json:"shippingCost"`
+	} `json:"shippingOptions"`
+}
+
+// CountryOfOrigin extracts Country of Origin from localizedAspects, normalized
+// with calculator.NormalizeCountry
+func (b *BrowseAPIItemResponse) CountryOfOrigin() (coo, aspectName string) {
+	// Look for the various field names sellers use for COO (see cooSpecPatterns)
+	names := make([]string, len(b.LocalizedAspects))
+	for i, aspect := range b.LocalizedAspects {
+		names[i] = aspect.Name
+	}
+	if i := findCOOSpec(names); i >= 0 {
+		return calculator.NormalizeCountry(b.LocalizedAspects[i].Value), b.LocalizedAspects[i].Name
+	}
+	return "", ""
+}
+
+// ImageURLs returns the primary image followed by any additional images
+func (b *BrowseAPIItemResponse) ImageURLs() []string {
+	images := make([]string, 0, 1+len(b.AdditionalImages))
+	if b.Image.ImageURL != "" {
+		images = append(images, b.Image.ImageURL)
+	}
+	for _, img := range b.AdditionalImages {
+		if img.ImageURL != "" {
+			images = append(images, img.ImageURL)
+		}
+	}
+	return images
 }
 
 // GetItemFromBrowseAPI fetches item details using the Browse API (REST/JSON)
 // This is used as a fallback to get Country of Origin when Trading API doesn't return it
 func (c *Client) GetItemFromBrowseAPI(ctx context.Context, itemID string) (coo string, err error) {
+	browseResp, err := c.GetBrowseItem(ctx, itemID)
+	if err != nil {
+		return "", err
+	}
+
+	coo, aspectName := browseResp.CountryOfOrigin()
+	if coo != "" {
+		log.Printf("[BROWSE-API-DEBUG] Item %s: Found COO = %s (aspect: %s)", itemID, coo, aspectName)
+		return coo, nil
+	}
+
+	// Log all aspects if COO not found (for debugging)
+	var allAspects []string
+	for _, aspect := range browseResp.LocalizedAspects {
+		allAspects = append(allAspects, aspect.Name)
+	}
+	log.Printf("[BROWSE-API-DEBUG] Item %s: COO not found in localizedAspects. All aspects: %v", itemID, allAspects)
+
+	return "", nil
+}
+
+// GetBrowseItem fetches the raw Browse API item. Works with either a user token
+// or an application token from FetchApplicationToken, since Browse is read-only.
+func (c *Client) GetBrowseItem(ctx context.Context, itemID string) (*BrowseAPIItemResponse, error) {
 	if !c.IsAuthenticated() {
-		return "", fmt.Errorf("client not authenticated")
+		return nil, fmt.Errorf("client not authenticated")
 	}
 
-	// Ensure token is fresh
-	src := c.oauthConfig.TokenSource(ctx, c.token)
-	token, err := src.Token()
+	token, err := c.freshToken(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to get valid token: %w", err)
+		return nil, err
 	}
-	c.token = token
 
 	// Browse API uses the legacy item ID format: v1|{itemId}|0
 	browseItemID := fmt.Sprintf("v1|%s|0", itemID)
@@ -778,7 +1281,7 @@ func (c *Client) GetItemFromBrowseAPI(ctx context.Context, itemID string) (coo s
 	// Create HTTP request
 	req, err := http.NewRequestWithContext(ctx, "GET", browseURL, nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// Set headers for Browse API (RESTful, uses Bearer token)
@@ -786,74 +1289,245 @@ func (c *Client) GetItemFromBrowseAPI(ctx context.Context, itemID string) (coo s
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("X-EBAY-C-MARKETPLACE-ID", "EBAY_AU")
+	// Quote shipping options as seen by a US buyer (matches the Trading API US preference)
+	req.Header.Set("X-EBAY-C-ENDUSERCTX", "contextualLocation=country%3DUS")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		log.Printf("[BROWSE-API-ERROR] Request failed for item %s: %v", itemID, err)
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	log.Printf("[BROWSE-API-DEBUG] Response status: %d", resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("[BROWSE-API-ERROR] Non-200 response for item %s: %s", itemID, string(body))
-		return "", fmt.Errorf("Browse API error %d: %s", resp.StatusCode, string(body))
+		return nil, newRESTAPIError(resp.StatusCode, body)
 	}
 
 	// Parse JSON response
 	var browseResp BrowseAPIItemResponse
 	if err := json.Unmarshal(body, &browseResp); err != nil {
 		log.Printf("[BROWSE-API-ERROR] Failed to parse JSON for item %s: %v", itemID, err)
-		return "", fmt.Errorf("failed to parse Browse API response: %w", err)
+		return nil, fmt.Errorf("failed to parse Browse API response: %w", err)
 	}
 
-	// Extract Country of Origin from localizedAspects
-	// Look for various field names that eBay uses for COO
-	for _, aspect := range browseResp.LocalizedAspects {
-		aspectNameLower := strings.ToLower(strings.TrimSpace(aspect.Name))
-
-		if aspectNameLower == "country of origin" ||
-			aspectNameLower == "country/region of manufacture" ||
-			aspectNameLower == "country of manufacture" ||
-			aspectNameLower == "country/region of origin" ||
-			aspectNameLower == "materials sourced from" ||
-			strings.Contains(aspectNameLower, "country") && strings.Contains(aspectNameLower, "origin") ||
-			strings.Contains(aspectNameLower, "country") && strings.Contains(aspectNameLower, "manufacture") {
-			coo = aspect.Value
-			log.Printf("[BROWSE-API-DEBUG] Item %s: Found COO = %s (aspect: %s)", itemID, coo, aspect.Name)
-			return coo, nil
+	return &browseResp, nil
+}
+
+// GetItem fetches full details for a single item by ItemID.
+// The Trading API is the primary source; the Browse API fills in any brand, COO,
+// shipping, images or condition note it doesn't return, and is used instead when
+// the Trading call fails with a retryable error.
+func (c *Client) GetItem(ctx context.Context, itemID string) (*ItemDetails, error) {
+	item, err := c.getItemTrading(ctx, itemID)
+	if err != nil {
+		if !IsRetryable(err) {
+			return nil, err
+		}
+		log.Printf("[GET-ITEM-WARN] Item %s: Trading API failed (%v). Trying Browse API fallback...", itemID, err)
+		browseItem, browseErr := c.GetItemBrowse(ctx, itemID)
+		if browseErr != nil {
+			log.Printf("[GET-ITEM-WARN] Item %s: Browse API fallback failed: %v", itemID, browseErr)
+			return nil, err
 		}
+		return browseItem, nil
 	}
 
-	// Log all aspects if COO not found (for debugging)
-	var allAspects []string
-	for _, aspect := range browseResp.LocalizedAspects {
-		allAspects = append(allAspects, aspect.Name)
+	if item.Brand != "" && item.CountryOfOrigin != "" && item.ShippingCost != "" && len(item.Images) > 0 {
+		return item, nil
 	}
-	log.Printf("[BROWSE-API-DEBUG] Item %s: COO not found in localizedAspects. All aspects: %v", itemID, allAspects)
 
-	return "", nil
+	// Browse API returns localizedAspects and shipping options which may include data the Trading API doesn't return
+	log.Printf("[GET-ITEM-DEBUG] Item %s: Incomplete Trading API data (brand=%q, coo=%q, shipping=%q). Trying Browse API fallback...",
+		itemID, item.Brand, item.CountryOfOrigin, item.ShippingCost)
+
+	browseItem, browseErr := c.GetItemBrowse(ctx, itemID)
+	if browseErr != nil {
+		log.Printf("[GET-ITEM-WARN] Item %s: Browse API fallback failed: %v", itemID, browseErr)
+		return item, nil
+	}
+
+	if item.Brand == "" {
+		item.Brand = browseItem.Brand
+	}
+	if item.CountryOfOrigin == "" {
+		item.CountryOfOrigin = browseItem.CountryOfOrigin
+		if item.CountryOfOrigin != "" {
+			log.Printf("[GET-ITEM-DEBUG] Item %s: COO found via Browse API fallback: %s", itemID, item.CountryOfOrigin)
+		} else {
+			log.Printf("[GET-ITEM-WARN] Item %s: COO not found in either Trading API or Browse API", itemID)
+		}
+	}
+	if item.ShippingCost == "" {
+		item.ShippingCost, item.ShippingCurrency = browseItem.ShippingCost, browseItem.ShippingCurrency
+	}
+	if len(item.Images) == 0 {
+		item.Images = browseItem.Images
+	}
+	if item.ConditionDescription == "" {
+		item.ConditionDescription = browseItem.ConditionDescription
+	}
+
+	return item, nil
+}
+
+// ItemDetails holds the enrichment fields returned by GetItem
+type ItemDetails struct {
+	ItemID               string
+	SKU                  string // Seller's SKU; only the Trading API returns it
+	Brand                string
+	ShippingCost         string // US (or Worldwide) international shipping, else the first domestic service
+	ShippingCurrency     string
+	CountryOfOrigin      string
+	Images               []string
+	ConditionDescription string // Truncated to MaxConditionDescriptionLen
+
+	// International shipping cost (in ShippingCurrency) by destination; see
+	// shippingByDestination. Only the Trading API returns it.
+	ShippingByDestination map[string]string
+}
+
+// MaxConditionDescriptionLen caps the seller's condition note (in characters).
+// eBay allows up to 1000; anything past a few sentences is noise in the listings table.
+const MaxConditionDescriptionLen = 500
+
+// truncateText shortens s to at most max characters, marking the cut with an ellipsis
+func truncateText(s string, max int) string {
+	s = strings.TrimSpace(s)
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:max-1])) + "…"
+}
+
+// GetItems fetches details for many items with bounded concurrency.
+// The Trading API has no batch GetItem call (GetMultipleItems was part of the
+// retired Shopping API), so calls are coalesced here instead. Retryable errors
+// are retried with exponential backoff; an item that still fails is reported in
+// the returned error map and doesn't affect the rest of the batch.
+func (c *Client) GetItems(ctx context.Context, itemIDs []string) (map[string]ItemDetails, map[string]error) {
+	const maxConcurrent = 30
+	const maxRetries = 3
+
+	results := make(map[string]ItemDetails, len(itemIDs))
+	failures := make(map[string]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrent)
+
+	for _, itemID := range itemIDs {
+		wg.Add(1)
+		sem <- struct{}{} // Acquire semaphore
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }() // Release semaphore
+
+			var lastErr error
+			for attempt := 1; attempt <= maxRetries; attempt++ {
+				attemptCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+				item, err := c.GetItem(attemptCtx, itemID)
+				cancel()
+
+				if err == nil {
+					mu.Lock()
+					results[itemID] = *item
+					mu.Unlock()
+					return
+				}
+
+				lastErr = err
+				if !IsRetryable(err) || attempt == maxRetries || ctx.Err() != nil {
+					break
+				}
+
+				// Exponential backoff: 1s, 2s, 4s
+				backoff := time.Duration(1<<(attempt-1)) * time.Second
+				log.Printf("[GET-ITEMS] Retrying item %s in %v (attempt %d/%d): %v", itemID, backoff, attempt, maxRetries, err)
+				select {
+				case <-ctx.Done():
+				case <-time.After(backoff):
+				}
+			}
+
+			log.Printf("[GET-ITEMS] Failed to fetch item %s: %v", itemID, lastErr)
+			mu.Lock()
+			failures[itemID] = lastErr
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	return results, failures
+}
+
+// GetItemBrowse fetches item details from the Browse API (REST/JSON) and
+// normalizes them into the same ItemDetails returned by GetItem
+func (c *Client) GetItemBrowse(ctx context.Context, itemID string) (*ItemDetails, error) {
+	item, err := c.GetBrowseItem(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &ItemDetails{
+		ItemID:               itemID,
+		Brand:                item.Brand,
+		ConditionDescription: truncateText(item.ConditionDescription, MaxConditionDescriptionLen),
+	}
+	details.CountryOfOrigin, _ = item.CountryOfOrigin()
+	if len(item.ShippingOptions) > 0 && item.ShippingOptions[0].ShippingCost != nil {
+		details.ShippingCost = item.ShippingOptions[0].ShippingCost.Value
+		details.ShippingCurrency = item.ShippingOptions[0].ShippingCost.Currency
+	}
+
+	details.Images = make([]string, 0, 1+len(item.AdditionalImages))
+	for _, imageURL := range item.ImageURLs() {
+		details.Images = append(details.Images, c.fullSizeImageURL(imageURL))
+	}
+
+	return details, nil
+}
+
+// DefaultImageSize is the s-lNNN size token image URLs are upscaled to when
+// Config.ImageSize is unset (1600px max dimension, eBay's largest)
+const DefaultImageSize = 1600
+
+// imageSizeToken matches the size token in an eBay image URL, e.g. "/s-l64." or "/s-l1000."
+var imageSizeToken = regexp.MustCompile(`/s-l\d+\.`)
+
+// UpscaleImageURL rewrites any s-lNNN size token in an eBay image URL to size.
+// URLs without a size token are returned unchanged.
+func UpscaleImageURL(imageURL string, size int) string {
+	return imageSizeToken.ReplaceAllLiteralString(imageURL, fmt.Sprintf("/s-l%d.", size))
+}
+
+// fullSizeImageURL upscales an image URL to the configured image size
+func (c *Client) fullSizeImageURL(imageURL string) string {
+	size := c.config.ImageSize
+	if size <= 0 {
+		size = DefaultImageSize
+	}
+	return UpscaleImageURL(imageURL, size)
 }
 
-// GetItem fetches full details for a single item by ItemID
-func (c *Client) GetItem(ctx context.Context, itemID string) (brand, shippingCost, shippingCurrency, coo string, images []string, err error) {
+// getItemTrading fetches item details using the Trading API GetItem call (XML)
+func (c *Client) getItemTrading(ctx context.Context, itemID string) (*ItemDetails, error) {
 	if !c.IsAuthenticated() {
-		return "", "", "", "", nil, fmt.Errorf("client not authenticated")
+		return nil, fmt.Errorf("client not authenticated")
 	}
 
-	// Ensure token is fresh
-	src := c.oauthConfig.TokenSource(ctx, c.token)
-	token, err := src.Token()
+	token, err := c.freshToken(ctx)
 	if err != nil {
-		return "", "", "", "", nil, fmt.Errorf("failed to get valid token: %w", err)
+		return nil, err
 	}
-	c.token = token
 
 	// Build XML request for GetItem
 	xmlRequest := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
@@ -868,7 +1542,7 @@ func (c *Client) GetItem(ctx context.Context, itemID string) (brand, shippingCos
 	// Create HTTP request
 	req, err := http.NewRequestWithContext(ctx, "POST", c.tradingAPIURL, strings.NewReader(xmlRequest))
 	if err != nil {
-		return "", "", "", "", nil, err
+		return nil, err
 	}
 
 	// Set headers for Trading API
@@ -881,73 +1555,57 @@ func (c *Client) GetItem(ctx context.Context, itemID string) (brand, shippingCos
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		log.Printf("[GET-ITEM-ERROR] Request failed for item %s: %v", itemID, err)
-		return "", "", "", "", nil, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", "", "", "", nil, err
+		return nil, err
+	}
+	if c.config.ResponseRecorder != nil {
+		c.config.ResponseRecorder.RecordResponse(itemID, "GetItem", resp.StatusCode, body)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: string(body)}
 	}
 
 	// Parse XML response
 	var xmlResp GetItemResponse
 	if err := xml.Unmarshal(body, &xmlResp); err != nil {
 		log.Printf("[GET-ITEM-ERROR] Failed to parse XML for item %s: %v", itemID, err)
-		return "", "", "", "", nil, fmt.Errorf("failed to parse XML response: %w", err)
+		return nil, fmt.Errorf("failed to parse XML response: %w", err)
 	}
 
 	// Check for API errors
-	if xmlResp.Ack != "Success" && xmlResp.Ack != "Warning" {
-		if len(xmlResp.Errors) > 0 {
-			errMsg := fmt.Sprintf("eBay API error %s: %s", xmlResp.Errors[0].ErrorCode, xmlResp.Errors[0].LongMessage)
-			log.Printf("[GET-ITEM-ERROR] %s", errMsg)
-			return "", "", "", "", nil, fmt.Errorf("%s", errMsg)
-		}
-		return "", "", "", "", nil, fmt.Errorf("API returned Ack=%s", xmlResp.Ack)
+	if err := checkTradingAck(resp.StatusCode, xmlResp.Ack, xmlResp.Errors, c.config.StrictAck); err != nil {
+		log.Printf("[GET-ITEM-ERROR] %v", err)
+		return nil, err
 	}
 
+	var brand, shippingCost, shippingCurrency, coo string
+
 	// Extract Brand and Country of Origin from ItemSpecifics
 	// Log all specs for debugging COO detection issues
 	var allSpecNames []string
 	for _, spec := range xmlResp.Item.ItemSpecifics.NameValueList {
 		allSpecNames = append(allSpecNames, spec.Name)
-		specNameLower := strings.ToLower(strings.TrimSpace(spec.Name))
 
 		if spec.Name == "Brand" {
 			brand = spec.Value
 			log.Printf("[GET-ITEM-DEBUG] Item %s: Brand = %s", itemID, brand)
 		}
-		// Look for Country of Origin (can be stored as various names in eBay)
-		// Use case-insensitive matching to catch variations
-		// Common field names: "Country/Region of Manufacture", "Country of Manufacture", "Country of Origin"
-		// eBay also uses "Materials sourced from" for COO in some listings
-		if specNameLower == "country/region of manufacture" ||
-			specNameLower == "country of manufacture" ||
-			specNameLower == "country of origin" ||
-			specNameLower == "country/region of origin" ||
-			specNameLower == "materials sourced from" ||
-			strings.Contains(specNameLower, "country") && strings.Contains(specNameLower, "origin") ||
-			strings.Contains(specNameLower, "country") && strings.Contains(specNameLower, "manufacture") {
-			coo = spec.Value
-			log.Printf("[GET-ITEM-DEBUG] Item %s: Country of Origin = %s (field: %s)", itemID, coo, spec.Name)
-		}
 	}
-	// If COO not found from Trading API, try Browse API as fallback
-	// Browse API returns localizedAspects which may include COO data that Trading API doesn't return
+	// Country of Origin is stored under many names ("Country/Region of
+	// Manufacture", "Made In", "Materials sourced from", ...); see cooSpecPatterns
+	if i := findCOOSpec(allSpecNames); i >= 0 {
+		spec := xmlResp.Item.ItemSpecifics.NameValueList[i]
+		coo = calculator.NormalizeCountry(spec.Value)
+		log.Printf("[GET-ITEM-DEBUG] Item %s: Country of Origin = %s (field: %s)", itemID, coo, spec.Name)
+	}
 	if coo == "" {
-		log.Printf("[GET-ITEM-DEBUG] Item %s: COO NOT FOUND in Trading API. Trying Browse API fallback...", itemID)
-		log.Printf("[GET-ITEM-DEBUG] Item %s: All ItemSpecifics from Trading API: %v", itemID, allSpecNames)
-
-		browseCOO, browseErr := c.GetItemFromBrowseAPI(ctx, itemID)
-		if browseErr != nil {
-			log.Printf("[GET-ITEM-WARN] Item %s: Browse API fallback failed: %v", itemID, browseErr)
-		} else if browseCOO != "" {
-			coo = browseCOO
-			log.Printf("[GET-ITEM-DEBUG] Item %s: COO found via Browse API fallback: %s", itemID, coo)
-		} else {
-			log.Printf("[GET-ITEM-WARN] Item %s: COO not found in either Trading API or Browse API", itemID)
-		}
+		log.Printf("[GET-ITEM-DEBUG] Item %s: COO NOT FOUND in Trading API. All ItemSpecifics: %v", itemID, allSpecNames)
 	}
 
 	// Extract US international shipping cost
@@ -974,20 +1632,31 @@ func (c *Client) GetItem(ctx context.Context, itemID string) (brand, shippingCos
 		log.Printf("[GET-ITEM-DEBUG] Item %s: No US shipping, using domestic = %s %s", itemID, shippingCost, shippingCurrency)
 	}
 
-	// Extract all image URLs and convert to full-size (s-l1600)
-	images = make([]string, 0, len(xmlResp.Item.PictureDetails.PictureURL))
+	// Every international destination, so other zones can be audited too
+	byDestination := shippingByDestination(xmlResp.Item.ShippingDetails.InternationalShippingServiceOption)
+	if len(byDestination) > 0 {
+		log.Printf("[GET-ITEM-DEBUG] Item %s: International shipping by destination = %v", itemID, byDestination)
+	}
+
+	// Extract all image URLs and convert to full-size (Config.ImageSize)
+	images := make([]string, 0, len(xmlResp.Item.PictureDetails.PictureURL))
 	for _, imageURL := range xmlResp.Item.PictureDetails.PictureURL {
-		// Convert eBay image URLs to full-size (1600px max dimension)
-		// eBay URLs typically have size parameters like s-l64, s-l140, s-l225, s-l500
-		fullSizeURL := strings.ReplaceAll(imageURL, "/s-l64.", "/s-l1600.")
-		fullSizeURL = strings.ReplaceAll(fullSizeURL, "/s-l140.", "/s-l1600.")
-		fullSizeURL = strings.ReplaceAll(fullSizeURL, "/s-l225.", "/s-l1600.")
-		fullSizeURL = strings.ReplaceAll(fullSizeURL, "/s-l500.", "/s-l1600.")
-		images = append(images, fullSizeURL)
+		images = append(images, c.fullSizeImageURL(imageURL))
 	}
 	log.Printf("[GET-ITEM-DEBUG] Item %s: Found %d image(s)", itemID, len(images))
 
-	return brand, shippingCost, shippingCurrency, coo, images, nil
+	return &ItemDetails{
+		ItemID:               itemID,
+		SKU:                  xmlResp.Item.SKU,
+		Brand:                brand,
+		ShippingCost:         shippingCost,
+		ShippingCurrency:     shippingCurrency,
+		CountryOfOrigin:      coo,
+		Images:               images,
+		ConditionDescription: truncateText(xmlResp.Item.ConditionDescription, MaxConditionDescriptionLen),
+
+		ShippingByDestination: byDestination,
+	}, nil
 }
 
 // GetMyeBaySelling fetches active listings using the Trading API (XML)
@@ -996,13 +1665,10 @@ func (c *Client) GetMyeBaySelling(ctx context.Context, pageNumber, entriesPerPag
 		return nil, 0, fmt.Errorf("client not authenticated")
 	}
 
-	// Ensure token is fresh
-	src := c.oauthConfig.TokenSource(ctx, c.token)
-	token, err := src.Token()
+	token, err := c.freshToken(ctx)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get valid token: %w", err)
+		return nil, 0, err
 	}
-	c.token = token
 
 	// Build XML request
 	xmlRequest := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
@@ -1049,6 +1715,10 @@ func (c *Client) GetMyeBaySelling(ctx context.Context, pageNumber, entriesPerPag
 	log.Printf("[TRADING-API-DEBUG] Response status: %d", resp.StatusCode)
 	log.Printf("[TRADING-API-DEBUG] Response body (first 1000 chars): %s", string(body)[:min(1000, len(body))])
 
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return nil, 0, &APIError{StatusCode: resp.StatusCode, Message: string(body)}
+	}
+
 	// Parse XML response
 	var xmlResp GetMyeBaySellingResponse
 	if err := xml.Unmarshal(body, &xmlResp); err != nil {
@@ -1058,13 +1728,9 @@ func (c *Client) GetMyeBaySelling(ctx context.Context, pageNumber, entriesPerPag
 	}
 
 	// Check for API errors
-	if xmlResp.Ack != "Success" && xmlResp.Ack != "Warning" {
-		if len(xmlResp.Errors) > 0 {
-			errMsg := fmt.Sprintf("eBay API error %s: %s", xmlResp.Errors[0].ErrorCode, xmlResp.Errors[0].LongMessage)
-			log.Printf("[TRADING-API-ERROR] %s", errMsg)
-			return nil, 0, fmt.Errorf("%s", errMsg)
-		}
-		return nil, 0, fmt.Errorf("API returned Ack=%s", xmlResp.Ack)
+	if err := checkTradingAck(resp.StatusCode, xmlResp.Ack, xmlResp.Errors, c.config.StrictAck); err != nil {
+		log.Printf("[TRADING-API-ERROR] %v", err)
+		return nil, 0, err
 	}
 
 	// Convert XML items to TradingItem structs
@@ -1159,3 +1825,103 @@ func (c *Client) GetMyeBaySelling(ctx context.Context, pageNumber, entriesPerPag
 
 	return items, totalEntries, nil
 }
+
+// EndReasons are the EndReasonCodeType values eBay accepts when ending a listing early
+var EndReasons = []string{
+	"Incorrect",         // Start price, reserve or other listing detail was wrong
+	"LostOrBroken",      // Item was lost or broken
+	"NotAvailable",      // Item is no longer available for sale
+	"OtherListingError", // Listing contained some other error
+	"SellToHighBidder",  // Auction only: sell to the current high bidder
+	"Sold",              // Item was sold outside eBay
+}
+
+// IsValidEndReason reports whether reason is one of EndReasons
+func IsValidEndReason(reason string) bool {
+	for _, r := range EndReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// EndItemResponse represents the XML response from EndItem
+type EndItemResponse struct {
+	XMLName xml.Name       `xml:"EndItemResponse"`
+	Ack     string         `xml:"Ack"`
+	EndTime string         `xml:"EndTime"`
+	Errors  []TradingError `xml:"Errors>Error"`
+}
+
+// EndItem ends (withdraws) an active listing using the Trading API.
+// reason must be one of EndReasons.
+func (c *Client) EndItem(ctx context.Context, itemID, reason string) error {
+	if !c.IsAuthenticated() {
+		return fmt.Errorf("client not authenticated")
+	}
+	if !IsValidEndReason(reason) {
+		return fmt.Errorf("invalid end reason %q (valid: %s)", reason, strings.Join(EndReasons, ", "))
+	}
+
+	token, err := c.freshToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var escapedID strings.Builder
+	if err := xml.EscapeText(&escapedID, []byte(itemID)); err != nil {
+		return err
+	}
+
+	// Build XML request for EndItem
+	xmlRequest := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<EndItemRequest xmlns="urn:ebay:apis:eBLBaseComponents">
+  <ItemID>%s</ItemID>
+  <EndingReason>%s</EndingReason>
+</EndItemRequest>`, escapedID.String(), reason)
+
+	log.Printf("[END-ITEM] Ending item %s (reason: %s)", itemID, reason)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.tradingAPIURL, strings.NewReader(xmlRequest))
+	if err != nil {
+		return err
+	}
+
+	// Set headers for Trading API
+	req.Header.Set("X-EBAY-API-COMPATIBILITY-LEVEL", "967")
+	req.Header.Set("X-EBAY-API-CALL-NAME", "EndItem")
+	req.Header.Set("X-EBAY-API-SITEID", "15") // Australia
+	req.Header.Set("X-EBAY-API-IAF-TOKEN", token.AccessToken)
+	req.Header.Set("Content-Type", "text/xml")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		log.Printf("[END-ITEM-ERROR] Request failed for item %s: %v", itemID, err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return &APIError{StatusCode: resp.StatusCode, Message: string(body)}
+	}
+
+	var xmlResp EndItemResponse
+	if err := xml.Unmarshal(body, &xmlResp); err != nil {
+		log.Printf("[END-ITEM-ERROR] Failed to parse XML for item %s: %v", itemID, err)
+		return fmt.Errorf("failed to parse XML response: %w", err)
+	}
+
+	if err := checkTradingAck(resp.StatusCode, xmlResp.Ack, xmlResp.Errors, c.config.StrictAck); err != nil {
+		log.Printf("[END-ITEM-ERROR] %v", err)
+		return err
+	}
+
+	log.Printf("[END-ITEM] Item %s ended at %s", itemID, xmlResp.EndTime)
+	return nil
+}
@@ -0,0 +1,269 @@
+package ebay
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestShippingByDestination(t *testing.T) {
+	const body = `<GetItemResponse>
+  <Ack>Success</Ack>
+  <Item>
+    <ShippingDetails>
+      <InternationalShippingServiceOption>
+        <ShippingServiceCost currencyID="AUD">25.00</ShippingServiceCost>
+        <ShipToLocation>US</ShipToLocation>
+        <ShipToLocation>GB</ShipToLocation>
+      </InternationalShippingServiceOption>
+      <InternationalShippingServiceOption>
+        <ShippingServiceCost currencyID="AUD">30.00</ShippingServiceCost>
+        <ShipToLocation>UK</ShipToLocation>
+        <ShipToLocation>NZ</ShipToLocation>
+      </InternationalShippingServiceOption>
+      <InternationalShippingServiceOption>
+        <ShippingServiceCost currencyID="AUD"></ShippingServiceCost>
+        <ShipToLocation>Worldwide</ShipToLocation>
+      </InternationalShippingServiceOption>
+    </ShippingDetails>
+  </Item>
+</GetItemResponse>`
+
+	var resp GetItemResponse
+	if err := xml.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	got := shippingByDestination(resp.Item.ShippingDetails.InternationalShippingServiceOption)
+	// GB and UK are the same destination, so the first option's cost wins;
+	// an option without a cost is skipped
+	want := map[string]string{
+		"United States":  "25.00",
+		"United Kingdom": "25.00",
+		"New Zealand":    "30.00",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("shippingByDestination = %v, want %v", got, want)
+	}
+}
+
+func TestEndItem(t *testing.T) {
+	tests := []struct {
+		name      string
+		reason    string
+		status    int
+		response  string
+		wantCalls int
+		wantCode  string // EbayErrorCode of the returned APIError; "" for success
+		wantErr   bool
+	}{
+		{name: "ended", reason: "Incorrect", status: http.StatusOK, wantCalls: 1,
+			response: `<EndItemResponse><Ack>Success</Ack><EndTime>2026-10-18T01:00:00.000Z</EndTime></EndItemResponse>`},
+		{name: "already ended", reason: "NotAvailable", status: http.StatusOK, wantCalls: 1, wantErr: true, wantCode: "1047",
+			response: `<EndItemResponse><Ack>Failure</Ack><Errors><Error><ShortMessage>Ended</ShortMessage><LongMessage>The auction has already been closed.</LongMessage><ErrorCode>1047</ErrorCode></Error></Errors></EndItemResponse>`},
+		{name: "server error", reason: "Incorrect", status: http.StatusServiceUnavailable, wantCalls: 1, wantErr: true},
+		{name: "invalid reason", reason: "Bored", wantCalls: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if got := r.Header.Get("X-EBAY-API-CALL-NAME"); got != "EndItem" {
+					t.Errorf("call name = %q, want EndItem", got)
+				}
+				var req struct {
+					ItemID       string `xml:"ItemID"`
+					EndingReason string `xml:"EndingReason"`
+				}
+				body, _ := io.ReadAll(r.Body)
+				if err := xml.Unmarshal(body, &req); err != nil || req.ItemID != "1234" || req.EndingReason != tt.reason {
+					t.Errorf("request = %s, want item 1234 ending for %s", body, tt.reason)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.response))
+			})
+
+			err := c.EndItem(context.Background(), "1234", tt.reason)
+			if calls != tt.wantCalls {
+				t.Errorf("Trading API calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EndItem error = %v, want error %v", err, tt.wantErr)
+			}
+			var apiErr *APIError
+			if tt.wantCode != "" && (!errors.As(err, &apiErr) || apiErr.EbayErrorCode != tt.wantCode) {
+				t.Errorf("error = %v, want eBay error %s", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestBulkUpdateOfferShipping(t *testing.T) {
+	var mu sync.Mutex
+	applied := make(map[string][]ShippingCostOverride) // Offer ID -> overrides PUT
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		offerID := path.Base(r.URL.Path)
+		switch {
+		case r.Method == http.MethodGet && offerID == "missing":
+			http.Error(w, `{"errors":[{"errorId":25713}]}`, http.StatusNotFound)
+		case r.Method == http.MethodGet:
+			fmt.Fprintf(w, `{"offerId":%q,"sku":"SKU-%s","listingPolicies":{"fulfillmentPolicyId":"fp-1"}}`, offerID, offerID)
+		case r.Method == http.MethodPut && offerID == "rejected":
+			http.Error(w, `{"errors":[{"errorId":25002}]}`, http.StatusBadRequest)
+		case r.Method == http.MethodPut:
+			var offer Offer
+			json.NewDecoder(r.Body).Decode(&offer)
+			if offer.SKU != "SKU-"+offerID || offer.ListingPolicies.FulfillmentPolicyID != "fp-1" {
+				t.Errorf("PUT %s = %+v, want the fetched offer with new overrides", offerID, offer)
+			}
+			mu.Lock()
+			applied[offerID] = offer.ListingPolicies.ShippingCostOverrides
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	overrides := []ShippingCostOverride{{ShippingServiceType: ShippingServiceDomestic, Priority: 1, ShippingCost: &Amount{Value: "9.95", Currency: "AUD"}}}
+	var updates []OfferShippingUpdate
+	for _, id := range []string{"1", "missing", "2", "rejected", "3", "4", "5", "6"} {
+		updates = append(updates, OfferShippingUpdate{OfferID: id, Overrides: overrides})
+	}
+	results := c.BulkUpdateOfferShipping(context.Background(), updates)
+
+	if len(results) != len(updates) {
+		t.Fatalf("got %d results, want %d", len(results), len(updates))
+	}
+	for _, u := range updates {
+		failed := u.OfferID == "missing" || u.OfferID == "rejected"
+		if err := results[u.OfferID]; (err != nil) != failed {
+			t.Errorf("offer %s error = %v, want failure %v", u.OfferID, err, failed)
+		}
+		if got, ok := applied[u.OfferID]; !failed && (!ok || !reflect.DeepEqual(got, overrides)) {
+			t.Errorf("offer %s overrides = %+v, want %+v", u.OfferID, got, overrides)
+		}
+	}
+	if len(applied) != len(updates)-2 {
+		t.Errorf("%d offers updated, want %d", len(applied), len(updates)-2)
+	}
+}
+
+func TestGetOrders(t *testing.T) {
+	const body = `{
+  "href": "https://api.ebay.com/sell/fulfillment/v1/order?limit=2&offset=4",
+  "total": 7,
+  "limit": 2,
+  "offset": 4,
+  "next": "https://api.ebay.com/sell/fulfillment/v1/order?limit=2&offset=6",
+  "orders": [
+    {
+      "orderId": "12-34567-89012",
+      "legacyOrderId": "110123456789-0",
+      "creationDate": "2026-09-01T10:00:00.000Z",
+      "orderFulfillmentStatus": "FULFILLED",
+      "orderPaymentStatus": "PAID",
+      "buyer": {"username": "buyer_us"},
+      "pricingSummary": {
+        "priceSubtotal": {"value": "120.00", "currency": "AUD"},
+        "deliveryCost": {"value": "35.50", "currency": "AUD"},
+        "total": {"value": "155.50", "currency": "AUD"}
+      },
+      "fulfillmentStartInstructions": [
+        {"shippingStep": {"shippingServiceCode": "AU_StandardInternational",
+          "shipTo": {"contactAddress": {"stateOrProvince": "CA", "postalCode": "90210", "countryCode": "US"}}}}
+      ],
+      "lineItems": [
+        {"lineItemId": "li-1", "legacyItemId": "110123456789", "sku": "SKU-1", "title": "Silk dress",
+         "quantity": 1, "lineItemCost": {"value": "120.00", "currency": "AUD"},
+         "deliveryCost": {"shippingCost": {"value": "35.50", "currency": "AUD"}},
+         "total": {"value": "155.50", "currency": "AUD"}}
+      ]
+    },
+    {"orderId": "12-34567-89013"}
+  ]
+}`
+	var gotQuery string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sell/fulfillment/v1/order" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		gotQuery = r.URL.RawQuery
+		w.Write([]byte(body))
+	})
+
+	orders, err := client.GetOrders(context.Background(), "orderfulfillmentstatus:{FULFILLED}", 2, 4)
+	if err != nil {
+		t.Fatalf("GetOrders: %v", err)
+	}
+	if want := "limit=2&offset=4&filter=orderfulfillmentstatus%3A%7BFULFILLED%7D"; gotQuery != want {
+		t.Errorf("query = %s, want %s", gotQuery, want)
+	}
+	if orders.Total != 7 || orders.Limit != 2 || orders.Offset != 4 || orders.Next == "" || len(orders.Orders) != 2 {
+		t.Fatalf("orders = %+v", orders)
+	}
+
+	order := orders.Orders[0]
+	if order.OrderID != "12-34567-89012" || order.Buyer == nil || order.Buyer.Username != "buyer_us" {
+		t.Errorf("order = %+v", order)
+	}
+	if got := order.BuyerCountry(); got != "US" {
+		t.Errorf("BuyerCountry() = %q, want US", got)
+	}
+	if order.PricingSummary == nil || *order.PricingSummary.DeliveryCost != (Amount{Value: "35.50", Currency: "AUD"}) {
+		t.Errorf("pricing summary = %+v", order.PricingSummary)
+	}
+	if len(order.LineItems) != 1 {
+		t.Fatalf("line items = %+v", order.LineItems)
+	}
+	line := order.LineItems[0]
+	if line.LegacyItemID != "110123456789" || line.SKU != "SKU-1" || line.Quantity != 1 ||
+		line.DeliveryCost == nil || line.DeliveryCost.ShippingCost.Value != "35.50" {
+		t.Errorf("line item = %+v", line)
+	}
+
+	// An order with no shipping instructions has no buyer country
+	if got := orders.Orders[1].BuyerCountry(); got != "" {
+		t.Errorf("BuyerCountry() without instructions = %q", got)
+	}
+
+	// No filter: none sent
+	client.GetOrders(context.Background(), "", 50, 0)
+	if gotQuery != "limit=50&offset=0" {
+		t.Errorf("query without filter = %s", gotQuery)
+	}
+}
+
+func TestGetOrdersAPIError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"errors":[{"errorId":30700,"message":"Invalid filter"}]}`))
+	})
+	if _, err := client.GetOrders(context.Background(), "bogus", 50, 0); err == nil {
+		t.Error("GetOrders succeeded on a 400")
+	}
+}
+
+func TestOfferPrice(t *testing.T) {
+	tests := []struct {
+		name  string
+		offer Offer
+		want  Amount
+	}{
+		{"no pricing summary", Offer{}, Amount{}},
+		{"no price", Offer{PricingSummary: &PricingSummary{}}, Amount{}},
+		{"priced", Offer{PricingSummary: &PricingSummary{Price: &Amount{Value: "80.00", Currency: "AUD"}}}, Amount{Value: "80.00", Currency: "AUD"}},
+	}
+	for _, tt := range tests {
+		if got := tt.offer.Price(); got != tt.want {
+			t.Errorf("%s: Price() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package ebay
+
+import (
+	"strings"
+	"unicode"
+)
+
+// cooSpecPatterns are the item specific names sellers use for country of
+// origin, most specific first. A spec name matches a pattern if it contains
+// it once both are normalized by specNameKey, so "Country/Region of
+// Manufacture" and "COUNTRY OF MANUFACTURE" both contain
+// "country region of manufacture" or "country of manufacture".
+var cooSpecPatterns = []string{
+	"country of origin",
+	"country region of origin",
+	"country of manufacture",
+	"country region of manufacture",
+	"manufacturer country",
+	"country of manufacturer",
+	"country of production",
+	"place of origin",
+	"made in",
+	"manufactured in",
+	"materials sourced from",
+	"country region",
+}
+
+// specNameKey lower-cases an item specific name and reduces everything that
+// isn't a letter to single spaces: "Country/Region of Manufacture" becomes
+// "country region of manufacture"
+func specNameKey(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}), " ")
+}
+
+// findCOOSpec returns the index of the item specific naming the country of
+// origin, or -1. Patterns are tried in cooSpecPatterns order and the first
+// spec matching the earliest pattern wins (then any name mentioning a country
+// and origin or manufacture), so "Country of Origin" beats
+// "Materials sourced from" whatever order the seller listed them in.
+func findCOOSpec(names []string) int {
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = " " + specNameKey(name) + " "
+	}
+	for _, pattern := range cooSpecPatterns {
+		for i, key := range keys {
+			if strings.Contains(key, " "+pattern+" ") {
+				return i
+			}
+		}
+	}
+	// Anything else mentioning a country and origin or manufacture, e.g.
+	// "Manufacture Country" or "Origin Country"
+	for i, key := range keys {
+		if strings.Contains(key, "country") && (strings.Contains(key, "origin") || strings.Contains(key, "manufactur")) {
+			return i
+		}
+	}
+	return -1
+}
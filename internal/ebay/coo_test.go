@@ -0,0 +1,72 @@
+package ebay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFindCOOSpec(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  int
+	}{
+		{[]string{"Brand", "Country/Region of Manufacture"}, 1},
+		{[]string{"Brand", "COUNTRY OF MANUFACTURE"}, 1},
+		{[]string{"Made In", "Size"}, 0},
+		{[]string{"Size", "Manufacturer Country"}, 1},
+		{[]string{"Country/Region"}, 0},
+		{[]string{"Place of Origin"}, 0},
+		{[]string{"Origin Country"}, 0},
+		// The more specific pattern wins whatever the listing order
+		{[]string{"Materials sourced from", "Country of Origin"}, 1},
+		{[]string{"Country/Region", "Made In"}, 1},
+		// Names that merely contain the letters of a pattern don't match
+		{[]string{"Homemade Instructions", "Region"}, -1},
+		{[]string{"Brand", "Style"}, -1},
+		{nil, -1},
+	}
+	for _, tt := range tests {
+		if got := findCOOSpec(tt.names); got != tt.want {
+			t.Errorf("findCOOSpec(%q) = %d, want %d", tt.names, got, tt.want)
+		}
+	}
+}
+
+func TestGetItemCOOSpecNames(t *testing.T) {
+	tests := []struct {
+		specs map[string]string
+		want  string
+	}{
+		{map[string]string{"Country/Region of Manufacture": "China"}, "China"},
+		{map[string]string{"made in": "India"}, "India"},
+		{map[string]string{"Manufacturer Country": "PRC"}, "China"},
+		{map[string]string{"Country/Region": "Hong Kong (SAR)"}, "Hong Kong"},
+		{map[string]string{"Materials sourced from": "Italy", "Country of Origin": "Vietnam"}, "Vietnam"},
+		{map[string]string{"Style": "Maxi"}, ""},
+	}
+	for _, tt := range tests {
+		var specs strings.Builder
+		specs.WriteString(`<NameValueList><Name>Brand</Name><Value>Aje</Value></NameValueList>`)
+		for name, value := range tt.specs {
+			fmt.Fprintf(&specs, `<NameValueList><Name>%s</Name><Value>%s</Value></NameValueList>`, name, value)
+		}
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-EBAY-API-CALL-NAME") != "GetItem" {
+				http.NotFound(w, r) // Browse API fallback
+				return
+			}
+			fmt.Fprintf(w, `<GetItemResponse><Ack>Success</Ack><Item><ItemID>123</ItemID><ItemSpecifics>%s</ItemSpecifics></Item></GetItemResponse>`, specs.String())
+		})
+
+		item, err := client.GetItem(context.Background(), "123")
+		if err != nil {
+			t.Fatalf("%v: GetItem: %v", tt.specs, err)
+		}
+		if item.CountryOfOrigin != tt.want || item.Brand != "Aje" {
+			t.Errorf("%v: COO = %q, brand %q; want %q, Aje", tt.specs, item.CountryOfOrigin, item.Brand, tt.want)
+		}
+	}
+}
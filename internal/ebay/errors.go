@@ -0,0 +1,124 @@
+package ebay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// APIError is returned when eBay rejects a request, either with a non-2xx HTTP
+// status (REST APIs) or a failed Ack (Trading API)
+type APIError struct {
+	StatusCode    int    // HTTP status code (200 for Trading API Ack failures)
+	EbayErrorCode string // eBay's errorId / ErrorCode, if one was returned
+	Message       string
+}
+
+func (e *APIError) Error() string {
+	if e.EbayErrorCode != "" {
+		return fmt.Sprintf("eBay API error %s (HTTP %d): %s", e.EbayErrorCode, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Message)
+}
+
+// restErrorResponse is the error envelope used by eBay's REST APIs
+type restErrorResponse struct {
+	Errors []struct {
+		ErrorID     int    `json:"errorId"`
+		Message     string `json:"message"`
+		LongMessage string `json:"longMessage"`
+	} `json:"errors"`
+}
+
+// newRESTAPIError builds an APIError from a REST API error response, falling
+// back to the raw body when it isn't eBay's standard error envelope
+func newRESTAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode, Message: string(body)}
+
+	var errResp restErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && len(errResp.Errors) > 0 {
+		first := errResp.Errors[0]
+		apiErr.EbayErrorCode = strconv.Itoa(first.ErrorID)
+		apiErr.Message = first.Message
+		if first.LongMessage != "" {
+			apiErr.Message = first.LongMessage
+		}
+	}
+	return apiErr
+}
+
+// TradingError is an entry in a Trading API response's Errors list. eBay uses
+// the same list for warnings when Ack is "Warning".
+type TradingError struct {
+	ShortMessage string `xml:"ShortMessage"`
+	LongMessage  string `xml:"LongMessage"`
+	ErrorCode    string `xml:"ErrorCode"`
+}
+
+// checkTradingAck turns a Trading API Ack into an error. "Success" is always
+// fine and "Warning" is only an error when strict is set; anything else fails.
+func checkTradingAck(statusCode int, ack string, errs []TradingError, strict bool) error {
+	switch ack {
+	case "Success":
+		return nil
+	case "Warning":
+		if !strict {
+			return nil
+		}
+	}
+
+	if len(errs) > 0 {
+		msg := errs[0].LongMessage
+		if msg == "" {
+			msg = errs[0].ShortMessage
+		}
+		if ack == "Warning" {
+			msg = "warning treated as error: " + msg
+		}
+		return &APIError{StatusCode: statusCode, EbayErrorCode: errs[0].ErrorCode, Message: msg}
+	}
+	return &APIError{StatusCode: statusCode, Message: "API returned Ack=" + ack}
+}
+
+// retryableTradingCodes are Trading API error codes for transient server-side failures
+var retryableTradingCodes = map[string]bool{
+	"10007": true, // Internal error to the application
+}
+
+// IsRetryable reports whether err is likely transient: rate limiting (429),
+// server errors (500/502/503/504), or a timeout
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+		return retryableTradingCodes[apiErr.EbayErrorCode]
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsNotFound reports whether err is eBay answering 404, e.g. for an unknown
+// SKU or offer
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
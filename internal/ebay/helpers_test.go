@@ -0,0 +1,29 @@
+package ebay
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// newTestClient returns an authenticated sandbox client whose REST, Commerce
+// and Trading requests are all served by handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c := NewClient(Config{Sandbox: true})
+	c.baseURL = server.URL
+	c.commerceBaseURL = server.URL
+	c.tradingAPIURL = server.URL
+	c.SetToken(&oauth2.Token{AccessToken: "test", Expiry: time.Now().Add(time.Hour)})
+	return c
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,46 @@
+package ebay
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidMarketplaces is the set of marketplace IDs the Sell APIs accept
+var ValidMarketplaces = map[string]bool{
+	"EBAY_AT":        true,
+	"EBAY_AU":        true,
+	"EBAY_BE":        true,
+	"EBAY_CA":        true,
+	"EBAY_CH":        true,
+	"EBAY_DE":        true,
+	"EBAY_ES":        true,
+	"EBAY_FR":        true,
+	"EBAY_GB":        true,
+	"EBAY_HK":        true,
+	"EBAY_IE":        true,
+	"EBAY_IN":        true,
+	"EBAY_IT":        true,
+	"EBAY_MOTORS_US": true,
+	"EBAY_MY":        true,
+	"EBAY_NL":        true,
+	"EBAY_PH":        true,
+	"EBAY_PL":        true,
+	"EBAY_SG":        true,
+	"EBAY_TW":        true,
+	"EBAY_US":        true,
+}
+
+// ValidateMarketplace returns an error listing the valid IDs if marketplaceID
+// isn't one of ValidMarketplaces. IDs are case-sensitive, as they are to eBay.
+func ValidateMarketplace(marketplaceID string) error {
+	if ValidMarketplaces[marketplaceID] {
+		return nil
+	}
+	valid := make([]string, 0, len(ValidMarketplaces))
+	for id := range ValidMarketplaces {
+		valid = append(valid, id)
+	}
+	sort.Strings(valid)
+	return fmt.Errorf("unsupported marketplace %q (valid: %s)", marketplaceID, strings.Join(valid, ", "))
+}
@@ -0,0 +1,27 @@
+package ebay
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateMarketplace(t *testing.T) {
+	for _, id := range []string{"EBAY_AU", "EBAY_US", "EBAY_GB", "EBAY_MOTORS_US"} {
+		if err := ValidateMarketplace(id); err != nil {
+			t.Errorf("ValidateMarketplace(%q) = %v, want nil", id, err)
+		}
+	}
+
+	for _, id := range []string{"", "EBAY_AUS", "ebay_au", " EBAY_AU", "EBAY_XX"} {
+		err := ValidateMarketplace(id)
+		if err == nil {
+			t.Errorf("ValidateMarketplace(%q) = nil, want an error", id)
+			continue
+		}
+		// The error lists the valid IDs, sorted
+		if msg := err.Error(); !strings.Contains(msg, "unsupported marketplace") ||
+			!strings.Contains(msg, "EBAY_AT, EBAY_AU, EBAY_BE") || !strings.HasSuffix(msg, "EBAY_US)") {
+			t.Errorf("ValidateMarketplace(%q) = %q", id, msg)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package ebay
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Shipping service types a ShippingCostOverride can target
+const (
+	ShippingServiceDomestic      = "DOMESTIC"
+	ShippingServiceInternational = "INTERNATIONAL"
+)
+
+// decimalAmount matches the plain non-negative decimals eBay accepts in
+// Amount.Value, e.g. "12" or "12.50" (no sign, exponent or separators)
+var decimalAmount = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// ValidateShippingCostOverrides checks overrides against the constraints eBay
+// enforces, so bad values get a specific error instead of eBay's opaque one:
+// a DOMESTIC or INTERNATIONAL service type (case-sensitive, as to eBay), a
+// non-negative priority, and costs that are non-negative decimals. The error
+// names the first invalid override by index.
+func ValidateShippingCostOverrides(overrides []ShippingCostOverride) error {
+	for i, o := range overrides {
+		switch o.ShippingServiceType {
+		case ShippingServiceDomestic, ShippingServiceInternational:
+		default:
+			return fmt.Errorf("override %d: shippingServiceType must be %s or %s, got %q",
+				i, ShippingServiceDomestic, ShippingServiceInternational, o.ShippingServiceType)
+		}
+		if o.Priority < 0 {
+			return fmt.Errorf("override %d: priority must be 0 or more, got %d", i, o.Priority)
+		}
+		if err := validateOverrideAmount("shippingCost", o.ShippingCost); err != nil {
+			return fmt.Errorf("override %d: %w", i, err)
+		}
+		if err := validateOverrideAmount("additionalShippingCost", o.AdditionalShippingCost); err != nil {
+			return fmt.Errorf("override %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validateOverrideAmount checks an optional override cost
+func validateOverrideAmount(field string, amount *Amount) error {
+	if amount == nil {
+		return nil
+	}
+	if !decimalAmount.MatchString(amount.Value) {
+		return fmt.Errorf("%s.value must be a non-negative decimal, got %q", field, amount.Value)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package ebay
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateShippingCostOverrides(t *testing.T) {
+	valid := func() ShippingCostOverride {
+		return ShippingCostOverride{
+			ShippingServiceType:    ShippingServiceInternational,
+			Priority:               1,
+			ShippingCost:           &Amount{Value: "25.00", Currency: "AUD"},
+			AdditionalShippingCost: &Amount{Value: "5", Currency: "AUD"},
+		}
+	}
+
+	ok := []ShippingCostOverride{
+		valid(),
+		{ShippingServiceType: ShippingServiceDomestic}, // Costs are optional, priority 0 is allowed
+		{ShippingServiceType: ShippingServiceDomestic, ShippingCost: &Amount{Value: "0", Currency: "AUD"}},
+	}
+	if err := ValidateShippingCostOverrides(ok); err != nil {
+		t.Errorf("valid overrides rejected: %v", err)
+	}
+	if err := ValidateShippingCostOverrides(nil); err != nil {
+		t.Errorf("no overrides rejected: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*ShippingCostOverride)
+		wantErr string
+	}{
+		{"missing service type", func(o *ShippingCostOverride) { o.ShippingServiceType = "" }, "shippingServiceType"},
+		{"unknown service type", func(o *ShippingCostOverride) { o.ShippingServiceType = "EXPRESS" }, `"EXPRESS"`},
+		{"lowercase service type", func(o *ShippingCostOverride) { o.ShippingServiceType = "domestic" }, "shippingServiceType"},
+		{"negative priority", func(o *ShippingCostOverride) { o.Priority = -1 }, "priority"},
+		{"negative cost", func(o *ShippingCostOverride) { o.ShippingCost.Value = "-5.00" }, "shippingCost.value"},
+		{"blank cost", func(o *ShippingCostOverride) { o.ShippingCost.Value = "" }, "shippingCost.value"},
+		{"non-numeric cost", func(o *ShippingCostOverride) { o.ShippingCost.Value = "free" }, "shippingCost.value"},
+		{"cost with separator", func(o *ShippingCostOverride) { o.ShippingCost.Value = "1,000.00" }, "shippingCost.value"},
+		{"cost with exponent", func(o *ShippingCostOverride) { o.ShippingCost.Value = "1e3" }, "shippingCost.value"},
+		{"cost with trailing dot", func(o *ShippingCostOverride) { o.ShippingCost.Value = "12." }, "shippingCost.value"},
+		{"negative additional cost", func(o *ShippingCostOverride) { o.AdditionalShippingCost.Value = "-1" }, "additionalShippingCost.value"},
+	}
+	for _, tt := range tests {
+		bad := valid()
+		tt.modify(&bad)
+		// The invalid override is the second, so the error must name index 1
+		err := ValidateShippingCostOverrides([]ShippingCostOverride{valid(), bad})
+		if err == nil {
+			t.Errorf("%s: accepted", tt.name)
+			continue
+		}
+		if msg := err.Error(); !strings.HasPrefix(msg, "override 1: ") || !strings.Contains(msg, tt.wantErr) {
+			t.Errorf("%s: error %q should name override 1 and %s", tt.name, msg, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package ebay
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Default outbound rate limit, shared by every client built from the same Config.
+// eBay enforces daily call quotas per app, so this smooths out bursts from the
+// concurrent listing fetch and enrichment rather than letting them spike.
+const (
+	DefaultRateLimit = 10.0 // Calls per second
+	DefaultRateBurst = 20
+)
+
+// RateLimiter is a token-bucket limiter. Tokens refill continuously at rate
+// per second up to burst; each outbound call takes one token.
+// A nil *RateLimiter never blocks.
+type RateLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     int
+	tokens    float64
+	last      time.Time
+	calls     int64
+	throttled int64
+}
+
+// RateLimiterStats reports the limiter's configuration and remaining budget
+type RateLimiterStats struct {
+	RatePerSecond float64 `json:"ratePerSecond"`
+	Burst         int     `json:"burst"`
+	Available     float64 `json:"available"`  // Tokens currently available (calls that can fire immediately)
+	TotalCalls    int64   `json:"totalCalls"` // Calls admitted since startup
+	Throttled     int64   `json:"throttled"`  // Calls that had to wait for a token
+}
+
+// NewRateLimiter creates a limiter allowing ratePerSecond calls with bursts of up to burst.
+// Returns nil (no limiting) if ratePerSecond is not positive.
+func NewRateLimiter(ratePerSecond float64, burst int) *RateLimiter {
+	if ratePerSecond <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   ratePerSecond,
+		burst:  burst,
+		tokens: float64(burst), // Start full so the first burst isn't delayed
+		last:   time.Now(),
+	}
+}
+
+// refill adds tokens for the time elapsed since the last call. Caller must hold mu.
+func (l *RateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+	l.tokens += elapsed * l.rate
+	if l.tokens > float64(l.burst) {
+		l.tokens = float64(l.burst)
+	}
+}
+
+// Wait blocks until a token is available or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	waited := false
+	for {
+		l.mu.Lock()
+		l.refill(time.Now())
+		if l.tokens >= 1 {
+			l.tokens--
+			l.calls++
+			if waited {
+				l.throttled++
+			}
+			l.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		waited = true
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Stats returns the current limiter state
+func (l *RateLimiter) Stats() RateLimiterStats {
+	if l == nil {
+		return RateLimiterStats{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	return RateLimiterStats{
+		RatePerSecond: l.rate,
+		Burst:         l.burst,
+		Available:     l.tokens,
+		TotalCalls:    l.calls,
+		Throttled:     l.throttled,
+	}
+}
+
+// rateLimitedTransport makes every request through the client's http.Client
+// acquire a token first, so REST, Browse and Trading calls share one budget.
+// Admitted calls are reported to counter, if set.
+type rateLimitedTransport struct {
+	limiter *RateLimiter
+	counter CallCounter
+	base    http.RoundTripper
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	if t.counter != nil {
+		t.counter.CountCall()
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,81 @@
+package ebay
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultHTTPTimeout bounds a whole call, including any retries, when
+// Config.HTTPTimeout is unset
+const DefaultHTTPTimeout = 30 * time.Second
+
+// retryBaseDelay is the first backoff; each further retry doubles it
+const retryBaseDelay = 500 * time.Millisecond
+
+// maxRetryAfter caps how long a Retry-After header can make us wait
+const maxRetryAfter = 30 * time.Second
+
+// retryTransport retries idempotent requests (GET/HEAD without a body) that
+// fail with 429 or a 5xx, with exponential backoff. Writes are never retried
+// here since eBay may already have applied them. Waiting stops as soon as the
+// request's context is done.
+type retryTransport struct {
+	maxRetries int
+	base       http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.maxRetries <= 0 || !isIdempotent(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || !isRetryableStatus(resp.StatusCode) || attempt == t.maxRetries {
+			return resp, err
+		}
+
+		delay := retryDelay(resp, attempt)
+		log.Printf("[HTTP-RETRY] %s %s returned %d, retrying in %v (attempt %d/%d)",
+			req.Method, req.URL.Path, resp.StatusCode, delay, attempt+1, t.maxRetries)
+
+		// Drain so the connection can be reused
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func isIdempotent(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody
+}
+
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// retryDelay honours a Retry-After header in seconds, otherwise backs off
+// exponentially from retryBaseDelay
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+		delay := time.Duration(secs) * time.Second
+		if delay > maxRetryAfter {
+			delay = maxRetryAfter
+		}
+		return delay
+	}
+	return retryBaseDelay << attempt
+}
@@ -0,0 +1,106 @@
+package ebay
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// statusSequence answers with statuses in turn, repeating the last, and
+// counts the calls. Retry-After: 0 keeps the retries immediate.
+func statusSequence(calls *int, statuses ...int) roundTripFunc {
+	return func(r *http.Request) (*http.Response, error) {
+		status := statuses[min(*calls, len(statuses)-1)]
+		*calls++
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Retry-After": {"0"}},
+			Body:       io.NopCloser(strings.NewReader("")),
+		}, nil
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		maxRetries int
+		statuses   []int
+		wantCalls  int
+		wantStatus int
+	}{
+		{"recovers", http.MethodGet, 3, []int{503, 429, 200}, 3, 200},
+		{"gives up after max retries", http.MethodGet, 2, []int{503}, 3, 503},
+		{"retries disabled", http.MethodGet, 0, []int{503, 200}, 1, 503},
+		{"client errors aren't retried", http.MethodGet, 3, []int{404, 200}, 1, 404},
+		{"writes aren't retried", http.MethodPut, 3, []int{503, 200}, 1, 503},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			transport := &retryTransport{maxRetries: tt.maxRetries, base: statusSequence(&calls, tt.statuses...)}
+			req, _ := http.NewRequest(tt.method, "https://api.ebay.test/sell/inventory/v1/offer/1", nil)
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("RoundTrip: %v", err)
+			}
+			if calls != tt.wantCalls || resp.StatusCode != tt.wantStatus {
+				t.Errorf("calls = %d, status = %d; want %d, %d", calls, resp.StatusCode, tt.wantCalls, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRetryTransportStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	transport := &retryTransport{maxRetries: 5, base: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		cancel() // Cancelled during the backoff that follows
+		return &http.Response{StatusCode: 503, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.ebay.test/", nil)
+
+	if _, err := transport.RoundTrip(req); !errors.Is(err, context.Canceled) {
+		t.Errorf("RoundTrip error = %v, want context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	withRetryAfter := func(v string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": {v}}}
+	}
+	tests := []struct {
+		resp    *http.Response
+		attempt int
+		want    time.Duration
+	}{
+		{&http.Response{Header: http.Header{}}, 0, retryBaseDelay},
+		{&http.Response{Header: http.Header{}}, 2, 4 * retryBaseDelay},
+		{withRetryAfter("3"), 2, 3 * time.Second},
+		{withRetryAfter("3600"), 0, maxRetryAfter},
+		{withRetryAfter("Wed, 21 Oct 2026 07:28:00 GMT"), 1, 2 * retryBaseDelay}, // Dates fall back to backoff
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.resp, tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(Retry-After %q, attempt %d) = %v, want %v", tt.resp.Header.Get("Retry-After"), tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestClientHTTPTimeout(t *testing.T) {
+	if got := NewClient(Config{Sandbox: true}).httpClient.Timeout; got != DefaultHTTPTimeout {
+		t.Errorf("default timeout = %v, want %v", got, DefaultHTTPTimeout)
+	}
+	if got := NewClient(Config{Sandbox: true, HTTPTimeout: 5 * time.Second}).httpClient.Timeout; got != 5*time.Second {
+		t.Errorf("configured timeout = %v, want 5s", got)
+	}
+}
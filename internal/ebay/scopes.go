@@ -0,0 +1,45 @@
+package ebay
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ScopePrefix starts every eBay OAuth scope, in production and sandbox alike
+const ScopePrefix = "https://api.ebay.com/oauth/api_scope"
+
+// DefaultScopes are requested when Config.Scopes is empty: inventory,
+// account and fulfillment management plus the User API identity scope
+var DefaultScopes = []string{
+	"https://api.ebay.com/oauth/api_scope",
+	"https://api.ebay.com/oauth/api_scope/sell.inventory",
+	"https://api.ebay.com/oauth/api_scope/sell.inventory.readonly",
+	"https://api.ebay.com/oauth/api_scope/sell.account",
+	"https://api.ebay.com/oauth/api_scope/sell.account.readonly",
+	"https://api.ebay.com/oauth/api_scope/sell.fulfillment",
+	"https://api.ebay.com/oauth/api_scope/sell.fulfillment.readonly",
+	"https://api.ebay.com/oauth/api_scope/commerce.identity.readonly", // For User API
+}
+
+// ParseScopes reads a space- or comma-separated scope list, such as the
+// EBAY_SCOPES environment variable, dropping duplicates. Every scope must be
+// an eBay scope URL (ScopePrefix or a path under it). A blank list returns
+// nil, meaning DefaultScopes.
+func ParseScopes(list string) ([]string, error) {
+	fields := strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+
+	var scopes []string
+	seen := make(map[string]bool, len(fields))
+	for _, scope := range fields {
+		if scope != ScopePrefix && !strings.HasPrefix(scope, ScopePrefix+"/") {
+			return nil, fmt.Errorf("%q is not an eBay OAuth scope (expected %s or %s/...)", scope, ScopePrefix, ScopePrefix)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
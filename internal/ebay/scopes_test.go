@@ -0,0 +1,53 @@
+package ebay
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseScopes(t *testing.T) {
+	inventory := ScopePrefix + "/sell.inventory"
+	marketing := ScopePrefix + "/sell.marketing"
+
+	tests := []struct {
+		list    string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"  \t ", nil, false},
+		{inventory, []string{inventory}, false},
+		{ScopePrefix + " " + inventory, []string{ScopePrefix, inventory}, false},
+		{inventory + "," + marketing, []string{inventory, marketing}, false},
+		{inventory + ", \n" + marketing + ",", []string{inventory, marketing}, false},
+		{inventory + " " + marketing + " " + inventory, []string{inventory, marketing}, false},
+		{"sell.inventory", nil, true},
+		{"https://api.ebay.com/oauth/api_scopes", nil, true},
+		{inventory + " https://example.com/oauth/api_scope", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseScopes(tt.list)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseScopes(%q) error = %v, wantErr %v", tt.list, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseScopes(%q) = %q, want %q", tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestNewClientDefaultScopes(t *testing.T) {
+	if got := NewClient(Config{}).oauthConfig.Scopes; !reflect.DeepEqual(got, DefaultScopes) {
+		t.Errorf("scopes without Config.Scopes = %q, want DefaultScopes", got)
+	}
+	custom := []string{ScopePrefix + "/sell.marketing"}
+	if got := NewClient(Config{Scopes: custom}).oauthConfig.Scopes; !reflect.DeepEqual(got, custom) {
+		t.Errorf("scopes = %q, want %q", got, custom)
+	}
+	for _, scope := range DefaultScopes {
+		if _, err := ParseScopes(scope); err != nil {
+			t.Errorf("default scope %q doesn't parse: %v", scope, err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package ebay
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// TestTokenSharedAcrossGoroutines refreshes a Client's token while other
+// goroutines make requests with it, as concurrent requests in one session do.
+// Run with -race to catch unguarded token access.
+func TestTokenSharedAcrossGoroutines(t *testing.T) {
+	var refreshes atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/token") {
+			n := refreshes.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"fresh-` + strconv.Itoa(int(n)) + `","token_type":"Bearer","expires_in":7200}`))
+			return
+		}
+		w.Write([]byte(`{"userId":"u-1","username":"seller"}`))
+	})
+	c.oauthConfig.Endpoint.TokenURL = c.baseURL + "/token"
+	c.SetToken(&oauth2.Token{AccessToken: "first", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetUser(context.Background()); err != nil {
+				errs <- err
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := c.RefreshToken(context.Background()); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if n := refreshes.Load(); n != 10 {
+		t.Errorf("token refreshed %d times, want 10", n)
+	}
+	token := c.GetToken()
+	if !strings.HasPrefix(token.AccessToken, "fresh-") || token.RefreshToken != "refresh" {
+		t.Errorf("token = %q (refresh %q), want a refreshed one keeping its refresh token", token.AccessToken, token.RefreshToken)
+	}
+}
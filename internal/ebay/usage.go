@@ -0,0 +1,38 @@
+package ebay
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata" // Quota days need America/Los_Angeles even without system tzdata
+)
+
+// CallCounter is told about every outbound eBay API call, including retries,
+// e.g. to track usage against eBay's daily call quotas. Implementations must
+// be safe for concurrent use.
+type CallCounter interface {
+	CountCall()
+}
+
+// quotaLocation is the time zone eBay's daily call quotas reset in (midnight)
+var quotaLocation = loadQuotaLocation()
+
+func loadQuotaLocation() *time.Location {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		// Can't happen with time/tzdata embedded. A fixed offset would put
+		// calls on the wrong day for half the year, so don't guess.
+		panic(fmt.Sprintf("ebay: failed to load quota time zone: %v", err))
+	}
+	return loc
+}
+
+// QuotaDay returns the eBay quota day containing t as YYYY-MM-DD in Pacific time
+func QuotaDay(t time.Time) string {
+	return t.In(quotaLocation).Format("2006-01-02")
+}
+
+// NextQuotaReset returns the midnight Pacific time that ends the quota day containing t
+func NextQuotaReset(t time.Time) time.Time {
+	year, month, day := t.In(quotaLocation).Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, quotaLocation)
+}
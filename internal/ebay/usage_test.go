@@ -0,0 +1,41 @@
+package ebay
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuotaDay(t *testing.T) {
+	tests := []struct {
+		utc  string
+		want string
+	}{
+		{"2026-01-15T07:59:00Z", "2026-01-14"}, // PST (UTC-8): 23:59
+		{"2026-01-15T08:00:00Z", "2026-01-15"}, // PST: midnight
+		{"2026-07-15T06:59:00Z", "2026-07-14"}, // PDT (UTC-7): 23:59
+		{"2026-07-15T07:00:00Z", "2026-07-15"}, // PDT: midnight, still 23:00 the day before in PST
+	}
+	for _, tc := range tests {
+		at, _ := time.Parse(time.RFC3339, tc.utc)
+		if got := QuotaDay(at); got != tc.want {
+			t.Errorf("QuotaDay(%s) = %s, want %s", tc.utc, got, tc.want)
+		}
+	}
+}
+
+func TestNextQuotaReset(t *testing.T) {
+	tests := []struct {
+		utc  string
+		want string
+	}{
+		{"2026-01-15T12:00:00Z", "2026-01-16T08:00:00Z"},
+		{"2026-07-15T12:00:00Z", "2026-07-16T07:00:00Z"},
+		{"2026-03-08T06:00:00Z", "2026-03-08T08:00:00Z"}, // Day before DST starts: the reset is still at PST midnight
+	}
+	for _, tc := range tests {
+		at, _ := time.Parse(time.RFC3339, tc.utc)
+		if got := NextQuotaReset(at).UTC().Format(time.RFC3339); got != tc.want {
+			t.Errorf("NextQuotaReset(%s) = %s, want %s", tc.utc, got, tc.want)
+		}
+	}
+}
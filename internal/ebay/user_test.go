@@ -0,0 +1,74 @@
+package ebay
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetUserWithRetry(t *testing.T) {
+	backoff := getUserBackoff
+	getUserBackoff = time.Millisecond
+	t.Cleanup(func() { getUserBackoff = backoff })
+
+	tests := []struct {
+		name      string
+		failures  int // Leading failures before the user is returned
+		status    int // Status of each failure
+		attempts  int
+		wantCalls int32
+		wantErr   bool
+	}{
+		{"fails twice then succeeds", 2, http.StatusServiceUnavailable, 3, 3, false},
+		{"out of attempts", 3, http.StatusServiceUnavailable, 3, 3, true},
+		{"rejected token is not retried", 3, http.StatusUnauthorized, 3, 1, true},
+		{"single attempt", 1, http.StatusServiceUnavailable, 1, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if int(calls.Add(1)) <= tt.failures {
+					w.WriteHeader(tt.status)
+					w.Write([]byte(`{"errors":[{"errorId":1,"message":"nope"}]}`))
+					return
+				}
+				w.Write([]byte(`{"userId":"u-1","username":"seller"}`))
+			})
+
+			user, err := c.GetUserWithRetry(context.Background(), tt.attempts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && user.UserID != "u-1" {
+				t.Errorf("user = %+v", user)
+			}
+			if n := calls.Load(); n != tt.wantCalls {
+				t.Errorf("User API called %d times, want %d", n, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGetUserWithRetryStopsWhenCancelled(t *testing.T) {
+	var calls atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		cancel() // Cancelled during the first attempt's backoff
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	start := time.Now()
+	if _, err := c.GetUserWithRetry(ctx, 3); err == nil {
+		t.Fatal("expected an error")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("User API called %d times, want 1", n)
+	}
+	if elapsed := time.Since(start); elapsed > getUserBackoff/2 {
+		t.Errorf("took %v, want no backoff wait", elapsed)
+	}
+}
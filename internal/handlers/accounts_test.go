@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/database"
+)
+
+func TestHydrateCurrentAccountSharesLookup(t *testing.T) {
+	h := &Handler{}
+	var lookups atomic.Int32
+	release := make(chan struct{})
+	lookup := func() (*database.Account, error) {
+		lookups.Add(1)
+		<-release // Hold the lookup open until every caller is waiting on it
+		return &database.Account{ID: 7, AccountKey: "seller_sandbox"}, nil
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make([]*database.Account, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			account, err := h.hydrateCurrentAccount("token", lookup)
+			if err != nil {
+				t.Errorf("hydrateCurrentAccount: %v", err)
+			}
+			results[i] = account
+		}(i)
+	}
+
+	// Give the callers time to join the in-flight lookup, then let it finish
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := lookups.Load(); n != 1 {
+		t.Errorf("lookup ran %d times, want 1", n)
+	}
+	for i, account := range results {
+		if account == nil || account.ID != 7 {
+			t.Errorf("caller %d got %+v, want account 7", i, account)
+		}
+	}
+	if h.currentAccount == nil || h.currentAccount.ID != 7 {
+		t.Errorf("currentAccount = %+v, want account 7", h.currentAccount)
+	}
+
+	// Once hydrated, later calls don't look the account up again
+	if _, err := h.hydrateCurrentAccount("token", lookup); err != nil {
+		t.Fatalf("hydrateCurrentAccount: %v", err)
+	}
+	if n := lookups.Load(); n != 1 {
+		t.Errorf("lookup ran %d times after hydration, want 1", n)
+	}
+}
+
+func TestAccountListingsRoute(t *testing.T) {
+	h := newTestHandler(t)
+	signIn(t, h, "alice")
+	alice, _ := h.db.GetAccountByKey("alice")
+	if _, err := h.db.Exec(`INSERT INTO offers (account_id, offer_id, sku, listing_id, data) VALUES (?, '1', 'SKU-1', 'item-1', '{}')`, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path      string
+		want      int
+		wantTotal int
+	}{
+		{"/api/accounts/alice/listings", http.StatusOK, 1},
+		{"/api/accounts/alice/listings/?search=item-1", http.StatusOK, 1},
+		{"/api/accounts/alice/listings?search=nothing", http.StatusOK, 0},
+		{"/api/accounts/nobody/listings", http.StatusNotFound, 0},
+		{"/api/accounts//listings", http.StatusNotFound, 0},
+		{"/api/accounts/alice/offers", http.StatusNotFound, 0},
+		{"/api/accounts/alice/x/listings", http.StatusNotFound, 0},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.AccountListings(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.path, rec.Code, tt.want)
+			continue
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+		var result database.ListingsResult
+		if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		if result.Total != tt.wantTotal {
+			t.Errorf("%s: total = %d, want %d", tt.path, result.Total, tt.wantTotal)
+		}
+	}
+}
+
+func TestSoftDeleteAndReactivateAccount(t *testing.T) {
+	h := newTestHandler(t)
+	alice := signIn(t, h, "alice")
+	bob, err := h.db.GetOrCreateAccount("bob", "bob", "sandbox", "EBAY_AU")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.db.CreateSyncHistory(&database.SyncHistory{AccountID: bob.ID, SyncType: "export", Status: "success", StartedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	listed := func(target string) map[string]*time.Time {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.GetAccounts(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var resp struct {
+			Accounts []database.Account `json:"accounts"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: decode: %v", target, err)
+		}
+		keys := map[string]*time.Time{}
+		for _, acc := range resp.Accounts {
+			keys[acc.AccountKey] = acc.DeletedAt
+		}
+		return keys
+	}
+	call := func(handler http.HandlerFunc, method, target string) int {
+		rec := httptest.NewRecorder()
+		handler(rec, sessionRequest(method, target, "", alice))
+		return rec.Code
+	}
+
+	if code := call(h.DeleteAccount, http.MethodDelete, "/api/accounts/bob"); code != http.StatusOK {
+		t.Fatalf("delete bob = %d, want 200", code)
+	}
+	if _, ok := listed("/api/accounts")["bob"]; ok {
+		t.Error("deleted bob is still listed")
+	}
+	if deletedAt, ok := listed("/api/accounts?includeDeleted=true")["bob"]; !ok || deletedAt == nil {
+		t.Errorf("includeDeleted: bob listed %v with deletedAt %v, want listed as deleted", ok, deletedAt)
+	}
+	if _, ok := listed("/api/accounts")["alice"]; !ok {
+		t.Error("alice is no longer listed")
+	}
+	if _, total, err := h.db.GetSyncHistory(bob.ID, database.SyncHistoryQuery{}); err != nil || total != 1 {
+		t.Errorf("bob has %d sync history entries after delete (%v), want 1", total, err)
+	}
+
+	if code := call(h.DeleteAccount, http.MethodDelete, "/api/accounts/alice"); code != http.StatusConflict {
+		t.Errorf("delete the signed-in account = %d, want 409", code)
+	}
+	if code := call(h.DeleteAccount, http.MethodDelete, "/api/accounts/nobody"); code != http.StatusNotFound {
+		t.Errorf("delete an unknown account = %d, want 404", code)
+	}
+
+	if code := call(h.ReactivateAccount, http.MethodPost, "/api/accounts/bob/reactivate"); code != http.StatusOK {
+		t.Fatalf("reactivate bob = %d, want 200", code)
+	}
+	if deletedAt, ok := listed("/api/accounts")["bob"]; !ok || deletedAt != nil {
+		t.Errorf("reactivated bob listed %v with deletedAt %v, want listed and not deleted", ok, deletedAt)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestRefreshAuth(t *testing.T) {
+	tokenStatus := http.StatusOK
+	var grants []string
+	serveEbay(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		grants = append(grants, r.PostForm.Get("grant_type")+":"+r.PostForm.Get("refresh_token"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(tokenStatus)
+		if tokenStatus != http.StatusOK {
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"the provided authorization refresh token is invalid"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"refreshed","expires_in":7200,"token_type":"User Access Token"}`))
+	})
+	h := newTestHandler(t)
+	alice := signIn(t, h, "alice")
+
+	refresh := func(method string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.RefreshAuth(rec, sessionRequest(method, "/api/auth/refresh", "", alice))
+		return rec
+	}
+
+	if rec := refresh(http.MethodGet); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status = %d, want 405", rec.Code)
+	}
+
+	// signIn's token has no refresh token
+	if rec := refresh(http.MethodPost); rec.Code != http.StatusUnauthorized || len(grants) != 0 {
+		t.Errorf("without a refresh token: status = %d with %d token calls, want 401 and none", rec.Code, len(grants))
+	}
+
+	rec := httptest.NewRecorder()
+	token := &oauth2.Token{AccessToken: "token-alice", RefreshToken: "refresh-alice", Expiry: time.Now().Add(time.Minute)}
+	if err := h.saveTokenToSession(rec, sessionRequest(http.MethodGet, "/", "", alice), token); err != nil {
+		t.Fatalf("saveTokenToSession: %v", err)
+	}
+
+	// A still-valid token is refreshed early
+	rec = refresh(http.MethodPost)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh: status = %d: %s", rec.Code, rec.Body)
+	}
+	if len(grants) != 1 || grants[0] != "refresh_token:refresh-alice" {
+		t.Errorf("token requests = %v, want one refresh_token grant", grants)
+	}
+	var resp struct {
+		ExpiresAt time.Time `json:"expiresAt"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if until := time.Until(resp.ExpiresAt); until < 110*time.Minute || until > 2*time.Hour {
+		t.Errorf("expiresAt = %v, want about two hours from now", resp.ExpiresAt)
+	}
+
+	// The session now holds the new access token, and keeps the refresh token
+	client, err := h.getEbayClient(sessionRequest(http.MethodGet, "/", "", alice))
+	if err != nil {
+		t.Fatalf("getEbayClient: %v", err)
+	}
+	if saved := client.GetToken(); saved.AccessToken != "refreshed" || saved.RefreshToken != "refresh-alice" {
+		t.Errorf("session token = %q / %q, want refreshed / refresh-alice", saved.AccessToken, saved.RefreshToken)
+	}
+
+	tokenStatus = http.StatusBadRequest
+	if rec := refresh(http.MethodPost); rec.Code != http.StatusUnauthorized {
+		t.Errorf("rejected refresh token: status = %d, want 401", rec.Code)
+	}
+	tokenStatus = http.StatusServiceUnavailable
+	if rec := refresh(http.MethodPost); rec.Code != http.StatusBadGateway {
+		t.Errorf("token endpoint down: status = %d, want 502", rec.Code)
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/database"
+	syncpkg "github.com/julienbonastre/ebay-helpers/internal/sync"
+)
+
+func TestAdminBackupDownload(t *testing.T) {
+	h := newTestHandler(t)
+	if err := h.db.SaveEnrichedItem(&database.EnrichedItem{ItemID: "item-1", Brand: "Aje", EnrichedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.db.SetItemWeight("item-1", 1500); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.AdminBackup(rec, httptest.NewRequest(http.MethodGet, "/api/admin/backup", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("backup without a session = %d, want 401", rec.Code)
+	}
+
+	alice := signIn(t, h, "alice")
+	rec = httptest.NewRecorder()
+	h.AdminBackup(rec, sessionRequest(http.MethodGet, "/api/admin/backup", "", alice))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("backup = %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(got, "attachment; filename=") || !strings.HasSuffix(got, `.db"`) {
+		t.Errorf("Content-Disposition = %q, want a .db attachment", got)
+	}
+	if got := rec.Header().Get("Content-Length"); got != strconv.Itoa(rec.Body.Len()) {
+		t.Errorf("Content-Length = %s, body is %d bytes", got, rec.Body.Len())
+	}
+
+	// The download is a database holding the data, without the session it was taken with
+	path := filepath.Join(t.TempDir(), "download.db")
+	if err := os.WriteFile(path, rec.Body.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := database.Open(path)
+	if err != nil {
+		t.Fatalf("Open backup: %v", err)
+	}
+	defer backup.Close()
+	if item, err := backup.GetEnrichedItem("item-1", 30); err != nil || item == nil || item.Brand != "Aje" {
+		t.Errorf("enriched item in backup = %+v, %v; want Aje", item, err)
+	}
+	if weights, err := backup.GetItemWeights(); err != nil || len(weights) != 1 || weights[0].WeightGrams != 1500 {
+		t.Errorf("item weights in backup = %+v, %v; want item-1's 1500g", weights, err)
+	}
+	var sessions int
+	if err := backup.QueryRow(`SELECT COUNT(*) FROM sessions`).Scan(&sessions); err != nil || sessions != 0 {
+		t.Errorf("backup has %d sessions (%v), want none", sessions, err)
+	}
+}
+
+func TestSyncBackupAndRestoreRequireSession(t *testing.T) {
+	h := newTestHandler(t)
+	alice := signIn(t, h, "alice")
+	signIn(t, h, "bob")
+	aliceAccount, _ := h.db.GetAccountByKey("alice")
+	bobAccount, _ := h.db.GetAccountByKey("bob")
+	if _, err := h.db.Exec(`INSERT INTO inventory_items (account_id, sku, data) VALUES (?, 'SKU-1', '{"sku":"SKU-1"}')`, aliceAccount.ID); err != nil {
+		t.Fatal(err)
+	}
+	h.mu.Lock()
+	h.currentAccount = bobAccount // The last account to sign in on this instance
+	h.mu.Unlock()
+
+	rec := httptest.NewRecorder()
+	h.SyncBackup(rec, httptest.NewRequest(http.MethodGet, "/api/sync/backup", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("backup without a session = %d, want 401", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.SyncBackup(rec, sessionRequest(http.MethodGet, "/api/sync/backup", "", alice))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("backup = %d: %s", rec.Code, rec.Body)
+	}
+	body := rec.Body.String()
+	var backup syncpkg.Backup
+	if err := json.Unmarshal([]byte(body), &backup); err != nil {
+		t.Fatal(err)
+	}
+	if backup.Manifest.AccountKey != "alice" || backup.Manifest.Counts[syncpkg.ResourceInventoryItems] != 1 {
+		t.Errorf("manifest = %+v, want alice's account with 1 inventory item", backup.Manifest)
+	}
+
+	rec = httptest.NewRecorder()
+	h.SyncRestore(rec, httptest.NewRequest(http.MethodPost, "/api/sync/restore", strings.NewReader(body)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("restore without a session = %d, want 401", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	h.SyncRestore(rec, sessionRequest(http.MethodPost, "/api/sync/restore", body, alice))
+	if rec.Code != http.StatusOK {
+		t.Errorf("restore = %d: %s", rec.Code, rec.Body)
+	}
+}
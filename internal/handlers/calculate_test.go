@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalculateShippingByWeight(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBand   string
+	}{
+		{"just under small", `{"weightGrams":249}`, http.StatusOK, "XSmall"},
+		{"small boundary", `{"weightGrams":250}`, http.StatusOK, "Small"},
+		{"large boundary", `{"weightGrams":1000}`, http.StatusOK, "Large"},
+		{"maximum", `{"weightGrams":2000}`, http.StatusOK, "XLarge"},
+		// 20×20×10 cm is 800g volumetric, heavier than the 300g parcel
+		{"volumetric", `{"weightGrams":300,"lengthCm":20,"widthCm":20,"heightCm":10}`, http.StatusOK, "Medium"},
+		{"zero", `{"weightGrams":0}`, http.StatusBadRequest, ""},
+		{"over the maximum", `{"weightGrams":2001}`, http.StatusBadRequest, ""},
+	}
+
+	h := newTestHandler(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := strings.Replace(tt.body, "{", `{"itemValueAUD":100,"brandName":"Aje",`, 1)
+			rec := httptest.NewRecorder()
+			h.CalculateShippingByWeight(rec, httptest.NewRequest(http.MethodPost, "/api/calculate/weight", strings.NewReader(body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				WeightBand string `json:"weightBand"`
+				Result     struct {
+					Inputs struct {
+						WeightBand string `json:"weightBand"`
+					} `json:"inputs"`
+				} `json:"result"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.WeightBand != tt.wantBand || resp.Result.Inputs.WeightBand != tt.wantBand {
+				t.Errorf("weightBand = %q (result %q), want %q", resp.WeightBand, resp.Result.Inputs.WeightBand, tt.wantBand)
+			}
+		})
+	}
+}
+
+func TestCalculateShippingByWeights(t *testing.T) {
+	h := newTestHandler(t)
+	// 20×20×10 cm is 800g volumetric: it sets the band of the lighter weights
+	body := `{"itemValueAUD":150,"brandName":"Aje","lengthCm":20,"widthCm":20,"heightCm":10,"weightsGrams":[400,700,1200]}`
+	rec := httptest.NewRecorder()
+	h.CalculateShippingByWeights(rec, httptest.NewRequest(http.MethodPost, "/api/calculate/weights", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	var resp struct {
+		Quotes   []WeightQuote `json:"quotes"`
+		MinTotal float64       `json:"minTotal"`
+		MaxTotal float64       `json:"maxTotal"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	wantBands := []string{"Medium", "Medium", "Large"}
+	if len(resp.Quotes) != len(wantBands) {
+		t.Fatalf("got %d quotes, want %d", len(resp.Quotes), len(wantBands))
+	}
+	for i, q := range resp.Quotes {
+		if q.WeightBand != wantBands[i] {
+			t.Errorf("%dg band = %q, want %q", q.WeightGrams, q.WeightBand, wantBands[i])
+		}
+	}
+	if resp.Quotes[0].Total != resp.Quotes[1].Total {
+		t.Errorf("400g and 700g totals = %v and %v, want the same Medium total", resp.Quotes[0].Total, resp.Quotes[1].Total)
+	}
+	if resp.MinTotal != resp.Quotes[0].Total || resp.MaxTotal != resp.Quotes[2].Total {
+		t.Errorf("min/max = %v/%v, want %v/%v", resp.MinTotal, resp.MaxTotal, resp.Quotes[0].Total, resp.Quotes[2].Total)
+	}
+}
+
+func TestCalculateShippingByWeightsRejectsBadWeights(t *testing.T) {
+	h := newTestHandler(t)
+	for _, weights := range []string{`[]`, `[500,0]`, `[500,2001]`} {
+		body := `{"itemValueAUD":150,"brandName":"Aje","weightsGrams":` + weights + `}`
+		rec := httptest.NewRecorder()
+		h.CalculateShippingByWeights(rec, httptest.NewRequest(http.MethodPost, "/api/calculate/weights", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("weights %s: status = %d, want %d", weights, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestGetBrandCOOs(t *testing.T) {
+	h := newTestHandler(t)
+	tests := []struct {
+		path          string
+		wantStatus    int
+		wantBrand     string
+		wantPrimary   string
+		wantSecondary []string
+	}{
+		{"/api/brands/Aje/coos", http.StatusOK, "Aje", "China", []string{"India", "Malaysia"}},
+		{"/api/brands/innika%20choo%20%5Bbali%5D/coos/", http.StatusOK, "Innika Choo [Bali]", "Indonesia", []string{"Vietnam", "Malaysia"}},
+		{"/api/brands/Auguste/coos", http.StatusOK, "Auguste", "China", []string{}},
+		{"/api/brands/Unmapped/coos", http.StatusNotFound, "", "", nil},
+		{"/api/brands/Aje", http.StatusNotFound, "", "", nil},
+		{"/api/brands//coos", http.StatusNotFound, "", "", nil},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.GetBrandCOOs(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.path, rec.Code, tt.wantStatus)
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		var resp struct {
+			Brand     string   `json:"brand"`
+			Primary   string   `json:"primary"`
+			Secondary []string `json:"secondary"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		if resp.Brand != tt.wantBrand || resp.Primary != tt.wantPrimary || !reflect.DeepEqual(resp.Secondary, tt.wantSecondary) {
+			t.Errorf("%s = %+v, want %s %s %v", tt.path, resp, tt.wantBrand, tt.wantPrimary, tt.wantSecondary)
+		}
+	}
+}
+
+func TestBatchCalculateExplicitCOO(t *testing.T) {
+	h := newTestHandler(t)
+	h.cacheEnrichment(publicEnrichment, &EnrichedItemData{
+		ItemID: "item-1", Brand: "Aje", CountryOfOrigin: "China",
+		ShippingCost: "30.00", ShippingCurrency: "AUD", EnrichedAt: time.Now(),
+	})
+
+	tests := []struct {
+		coo         string
+		wantCOO     string
+		wantUnknown bool
+	}{
+		{"", "China", false},
+		{"India", "India", false},
+		{"malaysia", "Malaysia", false},
+		{"Vietnam", "Vietnam", true},
+	}
+	costs := map[string]float64{}
+	for _, tt := range tests {
+		body, _ := json.Marshal([]BatchCalculateItem{{ItemID: "item-1", Price: 300, CountryOfOrigin: tt.coo}})
+		rec := httptest.NewRecorder()
+		h.BatchCalculate(rec, httptest.NewRequest(http.MethodPost, "/api/calculate/batch", strings.NewReader(string(body))))
+		var resp map[string]BatchCalculateResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%q: decode: %v", tt.coo, err)
+		}
+		got := resp["item-1"]
+		if got.CalculatedCOO != tt.wantCOO || got.COONotKnownForBrand != tt.wantUnknown {
+			t.Errorf("%q: calculated with %q (unknown %v), want %q (unknown %v)",
+				tt.coo, got.CalculatedCOO, got.COONotKnownForBrand, tt.wantCOO, tt.wantUnknown)
+		}
+		costs[got.CalculatedCOO] = got.CalculatedCost
+	}
+	if costs["India"] <= costs["China"] {
+		t.Errorf("India cost %.2f should exceed China's %.2f", costs["India"], costs["China"])
+	}
+}
+
+func TestBatchCalculateBreakdownSumsToTotal(t *testing.T) {
+	h := newTestHandler(t)
+	h.cacheEnrichment(publicEnrichment, &EnrichedItemData{
+		ItemID: "item-1", Brand: "Aje", CountryOfOrigin: "China",
+		ShippingCost: "30.00", ShippingCurrency: "AUD", EnrichedAt: time.Now(),
+	})
+
+	// A high enough price to attract extra cover, so every component is non-zero
+	rec := httptest.NewRecorder()
+	h.BatchCalculate(rec, httptest.NewRequest(http.MethodPost, "/api/calculate/batch", strings.NewReader(`[{"itemId":"item-1","price":500}]`)))
+	var resp map[string]BatchCalculateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	got, ok := resp["item-1"]
+	if !ok {
+		t.Fatalf("no result for item-1: %v", resp)
+	}
+	b := got.Breakdown
+	if b.AusPostShipping <= 0 || b.ExtraCover <= 0 || b.TariffDuties <= 0 || b.ZonosFees <= 0 {
+		t.Errorf("breakdown %+v is missing a component", b)
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 0.01 }
+	if !near(b.AusPostShipping+b.ExtraCover, b.ShippingSubtotal) {
+		t.Errorf("shipping %.2f + extra cover %.2f != subtotal %.2f", b.AusPostShipping, b.ExtraCover, b.ShippingSubtotal)
+	}
+	if !near(b.TariffDuties+b.ZonosFees, b.DutiesSubtotal) {
+		t.Errorf("tariff %.2f + Zonos %.2f != duties subtotal %.2f", b.TariffDuties, b.ZonosFees, b.DutiesSubtotal)
+	}
+	if !near(b.ShippingSubtotal+b.DutiesSubtotal, got.CalculatedCost) {
+		t.Errorf("subtotals %.2f + %.2f != calculatedCost %.2f", b.ShippingSubtotal, b.DutiesSubtotal, got.CalculatedCost)
+	}
+}
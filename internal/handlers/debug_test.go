@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienbonastre/ebay-helpers/internal/database"
+)
+
+func TestRawResponsesStoredOnlyWhenDebugOn(t *testing.T) {
+	served := 0
+	serveEbay(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-EBAY-API-CALL-NAME") != "GetItem" {
+			http.NotFound(w, r) // Browse API fallback
+			return
+		}
+		served++
+		fmt.Fprintf(w, `<GetItemResponse><Ack>Success</Ack><Item><ItemID>123</ItemID><Title>Response %d</Title>
+			<ItemSpecifics><NameValueList><Name>Country/Region of Manufacture</Name><Value>China</Value></NameValueList></ItemSpecifics>
+		</Item></GetItemResponse>`, served)
+	})
+	h := newTestHandler(t)
+	alice := signIn(t, h, "alice")
+
+	getItem := func() {
+		t.Helper()
+		client, err := h.getEbayClient(sessionRequest(http.MethodGet, "/", "", alice))
+		if err != nil {
+			t.Fatalf("getEbayClient: %v", err)
+		}
+		if _, err := client.GetItem(context.Background(), "123"); err != nil {
+			t.Fatalf("GetItem: %v", err)
+		}
+	}
+	debug := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.DebugItemResponses(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	// Off by default: nothing stored
+	getItem()
+	if rec := debug("/api/debug/item/123"); rec.Code != http.StatusNotFound {
+		t.Errorf("debug off: status = %d, want 404", rec.Code)
+	}
+
+	if err := h.db.UpdateSetting("debug_store_raw_responses", "true"); err != nil {
+		t.Fatal(err)
+	}
+	getItem()
+	getItem() // The latest response replaces the earlier one
+
+	rec := debug("/api/debug/item/123")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("debug on: status = %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		ItemID    string                    `json:"itemId"`
+		Responses []database.RawAPIResponse `json:"responses"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Responses) != 1 || resp.Responses[0].CallName != "GetItem" || resp.Responses[0].StatusCode != http.StatusOK ||
+		!strings.Contains(resp.Responses[0].Response, "Response 3") {
+		t.Errorf("stored responses = %+v, want the third GetItem response only", resp.Responses)
+	}
+
+	rec = debug("/api/debug/item/123?call=GetItem")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/xml") ||
+		!strings.Contains(rec.Body.String(), "<Name>Country/Region of Manufacture</Name>") {
+		t.Errorf("raw GetItem: status %d, type %q, body %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+
+	for target, want := range map[string]int{
+		"/api/debug/item/123?call=GetMyeBaySelling": http.StatusNotFound,
+		"/api/debug/item/456":                       http.StatusNotFound,
+		"/api/debug/item/":                          http.StatusBadRequest,
+		"/api/debug/item/123/extra":                 http.StatusBadRequest,
+	} {
+		if rec := debug(target); rec.Code != want {
+			t.Errorf("%s: status = %d, want %d", target, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/database"
+)
+
+func TestEnrichmentCachePerAccount(t *testing.T) {
+	h := newTestHandler(t)
+	h.cacheEnrichment("alice", &EnrichedItemData{ItemID: "item-1", SKU: "ALICE-SKU", Brand: "Acme"})
+	h.cacheEnrichment(publicEnrichment, &EnrichedItemData{ItemID: "item-2", Brand: "Acme"})
+
+	if data, ok := h.cachedEnrichment("alice", "item-1"); !ok || data.SKU != "ALICE-SKU" {
+		t.Errorf("alice's item-1 = %+v, %v; want her own enrichment", data, ok)
+	}
+	if data, ok := h.cachedEnrichment("bob", "item-1"); ok {
+		t.Errorf("bob sees alice's item-1: %+v", data)
+	}
+	if _, ok := h.cachedEnrichment("", "item-1"); ok {
+		t.Error("a session without an account sees alice's item-1")
+	}
+	for _, account := range []string{"alice", "bob", ""} {
+		if _, ok := h.cachedEnrichment(account, "item-2"); !ok {
+			t.Errorf("%q doesn't see the public item-2", account)
+		}
+	}
+
+	// The account's own enrichment wins over the public one
+	h.cacheEnrichment(publicEnrichment, &EnrichedItemData{ItemID: "item-1", Brand: "Acme"})
+	if data, _ := h.cachedEnrichment("alice", "item-1"); data.SKU != "ALICE-SKU" {
+		t.Errorf("alice's item-1 SKU = %q after a public refresh, want ALICE-SKU", data.SKU)
+	}
+
+	// Purging an item removes it for every account
+	h.uncacheEnrichment([]string{"item-1"})
+	for _, account := range []string{"alice", "bob"} {
+		if _, ok := h.cachedEnrichment(account, "item-1"); ok {
+			t.Errorf("%s still sees purged item-1", account)
+		}
+	}
+	if _, ok := h.cachedEnrichment("bob", "item-2"); !ok {
+		t.Error("purging item-1 also removed item-2")
+	}
+}
+
+func TestBatchCalculateUsesSessionAccountEnrichment(t *testing.T) {
+	h := newTestHandler(t)
+	alice := signIn(t, h, "alice")
+	bob := signIn(t, h, "bob")
+	h.cacheEnrichment("alice", &EnrichedItemData{
+		ItemID: "item-1", Brand: "Acme", CountryOfOrigin: "China",
+		ShippingCost: "25.00", ShippingCurrency: "AUD", EnrichedAt: time.Now(),
+	})
+
+	calculate := func(cookies []*http.Cookie) map[string]BatchCalculateResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.BatchCalculate(rec, sessionRequest(http.MethodPost, "/api/calculate/batch", `[{"itemId":"item-1","price":100}]`, cookies))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		var results map[string]BatchCalculateResponse
+		if err := json.NewDecoder(rec.Body).Decode(&results); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return results
+	}
+
+	if _, ok := calculate(alice)["item-1"]; !ok {
+		t.Error("alice gets no result for her enriched item")
+	}
+	if result, ok := calculate(bob)["item-1"]; ok {
+		t.Errorf("bob gets a result from alice's enrichment: %+v", result)
+	}
+}
+
+func TestEmptyItemIDsRejected(t *testing.T) {
+	h := newTestHandler(t)
+	for _, itemIDs := range []string{"", "   ", ",,,", " , ,\t"} {
+		requests := map[string]func() *httptest.ResponseRecorder{
+			"GetEnrichedData": func() *httptest.ResponseRecorder {
+				rec := httptest.NewRecorder()
+				h.GetEnrichedData(rec, httptest.NewRequest(http.MethodGet, "/api/offers/enriched?itemIds="+url.QueryEscape(itemIDs), nil))
+				return rec
+			},
+			"QueueEnrichment": func() *httptest.ResponseRecorder {
+				body, _ := json.Marshal(map[string][]string{"itemIds": strings.Split(itemIDs, ",")})
+				rec := httptest.NewRecorder()
+				h.QueueEnrichment(rec, httptest.NewRequest(http.MethodPost, "/api/enrich/queue", strings.NewReader(string(body))))
+				return rec
+			},
+		}
+		for name, request := range requests {
+			rec := request()
+			var resp struct {
+				Code string `json:"code"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &resp)
+			if rec.Code != http.StatusBadRequest || resp.Code != errCodeMissingItemIDs {
+				t.Errorf("%s(%q) = %d %s, want 400 with code %s", name, itemIDs, rec.Code, rec.Body, errCodeMissingItemIDs)
+			}
+		}
+	}
+}
+
+func TestCleanItemIDs(t *testing.T) {
+	got := cleanItemIDs([]string{" 123 ", "", "\t", "456"})
+	if want := []string{"123", "456"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cleanItemIDs = %q, want %q", got, want)
+	}
+}
+
+func TestPurgeEnrichmentCache(t *testing.T) {
+	h := newTestHandler(t)
+	for _, id := range []string{"item-1", "item-2"} {
+		h.cacheEnrichment(publicEnrichment, &EnrichedItemData{ItemID: id, Brand: "Acme"})
+		if err := h.db.SaveEnrichedItem(&database.EnrichedItem{ItemID: id, Brand: "Acme", EnrichedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	purge := func(target string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.PurgeEnrichmentCache(rec, sessionRequest(http.MethodDelete, target, "", cookies))
+		return rec
+	}
+
+	if rec := purge("/api/enrich/cache?itemIds=item-1", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("purge without a session = %d, want 401", rec.Code)
+	}
+	if _, ok := h.cachedEnrichment(publicEnrichment, "item-1"); !ok {
+		t.Fatal("an unauthenticated purge removed item-1")
+	}
+
+	cookies := signIn(t, h, "alice")
+	rec := purge("/api/enrich/cache?itemIds=item-1", cookies)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("purge item-1 = %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Deleted int64    `json:"deleted"`
+		ItemIDs []string `json:"itemIds"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Deleted != 1 || !reflect.DeepEqual(resp.ItemIDs, []string{"item-1"}) {
+		t.Errorf("response = %+v, want 1 deleted for item-1", resp)
+	}
+
+	if _, ok := h.cachedEnrichment(publicEnrichment, "item-1"); ok {
+		t.Error("item-1 is still cached in memory")
+	}
+	if item, err := h.db.GetEnrichedItem("item-1", 30); err != nil || item != nil {
+		t.Errorf("stored item-1 = %+v, %v; want it deleted", item, err)
+	}
+	if _, ok := h.cachedEnrichment(publicEnrichment, "item-2"); !ok {
+		t.Error("purging item-1 removed item-2 from memory")
+	}
+	if item, err := h.db.GetEnrichedItem("item-2", 30); err != nil || item == nil {
+		t.Errorf("stored item-2 = %+v, %v; want it kept", item, err)
+	}
+
+	if rec := purge("/api/enrich/cache?itemIds=,,", cookies); rec.Code != http.StatusBadRequest {
+		t.Errorf("purge with blank itemIds = %d, want 400", rec.Code)
+	}
+	if rec := purge("/api/enrich/cache", cookies); rec.Code != http.StatusOK {
+		t.Fatalf("purge all = %d: %s", rec.Code, rec.Body)
+	}
+	if _, ok := h.cachedEnrichment(publicEnrichment, "item-2"); ok {
+		t.Error("item-2 is still cached after purging everything")
+	}
+	if item, _ := h.db.GetEnrichedItem("item-2", 30); item != nil {
+		t.Error("item-2 is still stored after purging everything")
+	}
+}
+
+func TestRecomputeCountsTariffEditMadeThroughAPI(t *testing.T) {
+	h := newTestHandler(t)
+	err := h.db.SaveEnrichedItem(&database.EnrichedItem{ItemID: "3001", Brand: "Acme", CountryOfOrigin: "China", EnrichedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recompute := func() database.RecomputeResult {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.RecomputeEnrichment(rec, httptest.NewRequest(http.MethodPost, "/api/enrich/recompute", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("recompute = %d: %s", rec.Code, rec.Body)
+		}
+		var result database.RecomputeResult
+		if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	if got := recompute(); got.Computed != 1 || got.Changed != 0 {
+		t.Fatalf("first recompute = %+v, want 1 computed", got)
+	}
+
+	tariffs, err := h.db.GetAllTariffRates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chinaID int64
+	for _, r := range tariffs {
+		if r.CountryName == "China" {
+			chinaID = r.ID
+		}
+	}
+	if chinaID == 0 {
+		t.Fatal("no seeded China tariff")
+	}
+	// The PUT reloads the calculator itself, so recompute must diff against
+	// the stored values rather than the config in memory
+	rec := httptest.NewRecorder()
+	h.ReferenceTariffByID(rec, httptest.NewRequest(http.MethodPut, "/api/reference/tariffs/"+strconv.FormatInt(chinaID, 10),
+		strings.NewReader(`{"countryName":"China","tariffRate":0.9}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT tariff = %d: %s", rec.Code, rec.Body)
+	}
+
+	if got := recompute(); got.Changed != 1 || got.TariffRateChanged != 1 {
+		t.Errorf("recompute after tariff PUT = %+v, want 1 item with a changed tariff", got)
+	}
+	if got := recompute(); got.Changed != 0 {
+		t.Errorf("recompute without edits = %+v, want nothing changed", got)
+	}
+}
+
+// enrichmentSyncMap is the sync.Map alternative to Handler.enrichmentCache
+// measured in BenchmarkEnrichmentCache, keyed by account key and item ID
+type enrichmentSyncMap struct{ m sync.Map }
+
+func (c *enrichmentSyncMap) cached(accountKey, itemID string) (*EnrichedItemData, bool) {
+	if data, ok := c.m.Load(accountKey + "\x00" + itemID); ok {
+		return data.(*EnrichedItemData), true
+	}
+	data, ok := c.m.Load(publicEnrichment + "\x00" + itemID)
+	if !ok {
+		return nil, false
+	}
+	return data.(*EnrichedItemData), true
+}
+
+func (c *enrichmentSyncMap) cache(accountKey string, data *EnrichedItemData) {
+	c.m.Store(accountKey+"\x00"+data.ItemID, data)
+}
+
+// BenchmarkEnrichmentCache enriches a 200-item page with 30 workers, as
+// EnrichItems does: each worker looks the item up, caches it on a miss and
+// reads it back, while the others do the same
+func BenchmarkEnrichmentCache(b *testing.B) {
+	const items, workers = 200, 30
+	ids := make([]string, items)
+	for i := range ids {
+		ids[i] = strconv.Itoa(100000 + i)
+	}
+	run := func(b *testing.B, reset func(), cached func(string, string) (*EnrichedItemData, bool), cache func(string, *EnrichedItemData)) {
+		for i := 0; i < b.N; i++ {
+			reset()
+			work := make(chan string)
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for id := range work {
+						if _, ok := cached("alice", id); !ok {
+							cache("alice", &EnrichedItemData{ItemID: id, Brand: "Acme"})
+						}
+						cached("alice", id)
+					}
+				}()
+			}
+			for _, id := range ids {
+				work <- id
+			}
+			close(work)
+			wg.Wait()
+		}
+	}
+
+	b.Run("RWMutexMap", func(b *testing.B) {
+		h := &Handler{}
+		reset := func() { h.enrichmentCache = make(map[string]map[string]*EnrichedItemData) }
+		run(b, reset, h.cachedEnrichment, h.cacheEnrichment)
+	})
+	b.Run("SyncMap", func(b *testing.B) {
+		c := &enrichmentSyncMap{}
+		reset := func() { c.m = sync.Map{} }
+		run(b, reset, c.cached, c.cache)
+	})
+}
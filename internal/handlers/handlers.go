@@ -1,18 +1,28 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/csv"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/julienbonastre/ebay-helpers/internal/calculator"
@@ -20,12 +30,14 @@ import (
 	"github.com/julienbonastre/ebay-helpers/internal/ebay"
 	syncpkg "github.com/julienbonastre/ebay-helpers/internal/sync"
 	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
 )
 
 // EnrichedItemData holds enriched item details from GetItem API
 // Now includes server-calculated postage to keep business logic on backend
 type EnrichedItemData struct {
 	ItemID           string    `json:"itemId"`
+	SKU              string    `json:"sku,omitempty"`
 	Brand            string    `json:"brand"`
 	CountryOfOrigin  string    `json:"countryOfOrigin"`
 	ExpectedCOO      string    `json:"expectedCoo"` // From brand mapping
@@ -37,18 +49,41 @@ type EnrichedItemData struct {
 	DiffStatus       string    `json:"diffStatus"`     // "ok" (green) or "bad" (red)
 	Images           []string  `json:"images"`
 	EnrichedAt       time.Time `json:"enrichedAt"`
+
+	ConditionDescription string `json:"conditionDescription,omitempty"` // Seller's note on wear, truncated
+
+	// International shipping cost (in ShippingCurrency) by destination, keyed
+	// by normalized country; ShippingCost stays the US cost
+	ShippingByDestination map[string]string `json:"shippingByDestination,omitempty"`
+
+	// Shipping currency vs. the audit currency at enrichment time (see
+	// database.CheckShippingCurrency): "pass", "fail" or "unknown"
+	CurrencyCheck string `json:"currencyCheck,omitempty"`
+
+	// Provenance, set on responses only (see withSource)
+	Source     string `json:"source,omitempty"` // database.SourceFetched or database.SourceMemoryCache
+	AgeSeconds int64  `json:"ageSeconds"`       // Time since EnrichedAt
+}
+
+// withSource returns a copy of d labelled with where it came from and its age
+func (d EnrichedItemData) withSource(source string) EnrichedItemData {
+	d.Source = source
+	d.AgeSeconds = int64(time.Since(d.EnrichedAt).Seconds())
+	return d
 }
 
 // Handler holds dependencies for HTTP handlers
 type Handler struct {
 	db                *database.DB
 	ebayConfig        ebay.Config              // eBay configuration (no shared client)
+	callCounter       *ebayCallCounter         // Counts ebayConfig's calls, unless the caller supplied its own CallCounter
 	sessionStore      *database.DBSessionStore // Session store for per-user tokens
-	currentAccount    *database.Account        // Current instance's account (can be nil until OAuth)
+	currentAccount    *database.Account        // Current instance's account (can be nil until OAuth); guarded by mu
+	accountHydration  singleflight.Group       // One eBay user lookup per session token; see hydrateCurrentAccount
 	syncService       *syncpkg.Service
-	calcConfig        *calculator.CalculatorConfig // Calculator configuration loaded from database
+	calcConfig        *calculator.CalculatorConfig // Calculator configuration loaded from database; use calculator()
+	calcMutex         sync.RWMutex                 // Protects calcConfig, which is swapped by reloadCalculator
 	mu                sync.RWMutex
-	oauthState        string
 	verificationToken string // eBay verification token for account deletion notifications
 	endpoint          string // Public endpoint URL for this server
 	environment       string // "production" or "sandbox"
@@ -56,14 +91,159 @@ type Handler struct {
 	encryptionKey     []byte // AES-256 key for credential encryption
 
 	// Item enrichment cache and background worker
-	enrichmentCache map[string]*EnrichedItemData // ItemID -> EnrichedItemData
-	enrichmentMutex sync.RWMutex                 // Protects enrichmentCache
-	enrichmentQueue chan string                  // Queue of ItemIDs to enrich
+	enrichmentCache map[string]map[string]*EnrichedItemData // Account key -> ItemID -> EnrichedItemData; use cachedEnrichment/cacheEnrichment
+	enrichmentMutex sync.RWMutex                            // Protects enrichmentCache
+	enrichmentQueue chan string                             // Queue of ItemIDs to enrich
+	appToken        *oauth2.Token                           // Client-credentials token for background Browse API calls
+	appTokenMutex   sync.Mutex                              // Protects appToken
 
 	// Listings cache - avoids re-fetching from eBay on every page load
-	listingsCache     []map[string]interface{} // Cached offer listings
-	listingsCacheTime time.Time                // When cache was last updated
-	listingsMutex     sync.RWMutex             // Protects listingsCache
+	listingsCache map[string]*listingsCacheEntry // listingsCacheKey -> cached offer listings
+	listingsMutex sync.RWMutex                   // Protects listingsCache
+
+	// Public keys eBay signs deletion notifications with, by key ID
+	notificationKeys       map[string]*cachedNotificationKey
+	notificationKeysMutex  sync.Mutex         // Protects notificationKeys; not held while fetching
+	notificationKeyFetches singleflight.Group // One getPublicKey call per key ID at a time
+}
+
+// calculator returns the current calculator configuration, reloading it
+// first if a future-dated tariff rate has taken effect since it was loaded
+func (h *Handler) calculator() *calculator.CalculatorConfig {
+	h.calcMutex.RLock()
+	calc := h.calcConfig
+	h.calcMutex.RUnlock()
+	if calc == nil || !calc.TariffsStale(time.Now()) {
+		return calc
+	}
+
+	if err := h.reloadCalculator(); err != nil {
+		// Keep pricing with the old rates rather than failing the request
+		log.Printf("Failed to reload calculator for a new tariff rate: %v", err)
+		return calc
+	}
+	h.calcMutex.RLock()
+	defer h.calcMutex.RUnlock()
+	return h.calcConfig
+}
+
+// reloadCalculator reloads the calculator configuration from the database so
+// edits to reference data apply without a restart
+func (h *Handler) reloadCalculator() error {
+	calcConfig, err := h.db.GetCalculatorConfig()
+	if err != nil {
+		return err
+	}
+	h.calcMutex.Lock()
+	h.calcConfig = calcConfig
+	h.calcMutex.Unlock()
+	return nil
+}
+
+// listingsCacheEntry is one account's cached offer listings
+type listingsCacheEntry struct {
+	accountKey  string
+	offers      []map[string]interface{}
+	failedPages []int // GetMyeBaySelling pages that failed, so offers is incomplete
+	cachedAt    time.Time
+}
+
+// listingsCacheKey scopes cached listings to one account and marketplace
+func listingsCacheKey(accountKey, marketplaceID string) string {
+	return accountKey + ":" + marketplaceID
+}
+
+// cachedListings returns the cached listings for key, or nil
+func (h *Handler) cachedListings(key string) *listingsCacheEntry {
+	h.listingsMutex.RLock()
+	defer h.listingsMutex.RUnlock()
+	return h.listingsCache[key]
+}
+
+// invalidateListings drops every cached marketplace for accountKey
+func (h *Handler) invalidateListings(accountKey string) {
+	h.listingsMutex.Lock()
+	defer h.listingsMutex.Unlock()
+	for key, entry := range h.listingsCache {
+		if entry.accountKey == accountKey {
+			delete(h.listingsCache, key)
+		}
+	}
+}
+
+// clearListingsCache drops cached listings for every account
+func (h *Handler) clearListingsCache() {
+	h.listingsMutex.Lock()
+	h.listingsCache = make(map[string]*listingsCacheEntry)
+	h.listingsMutex.Unlock()
+}
+
+// listingsETag identifies one page of a cached listings snapshot
+func listingsETag(key string, cachedAt time.Time, limit, offset int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d|%d", key, cachedAt.UnixNano(), limit, offset)))
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// listingsNotModified reports whether the request's conditional headers match
+// the snapshot, in which case the client's copy is still current. If-None-Match
+// takes precedence over If-Modified-Since, as in RFC 9110.
+func listingsNotModified(r *http.Request, etag string, cachedAt time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == etag || tag == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !cachedAt.Truncate(time.Second).After(ims)
+	}
+	return false
+}
+
+// writeListingsPage sends one page of a listings snapshot with validators so
+// browsers can revalidate instead of re-downloading an unchanged page
+func writeListingsPage(w http.ResponseWriter, r *http.Request, key string, entry *listingsCacheEntry, limit, offset int, cached bool) {
+	etag := listingsETag(key, entry.cachedAt, limit, offset)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", entry.cachedAt.UTC().Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", "private, no-cache") // Always revalidate; listings are per account
+
+	if cached && listingsNotModified(r, etag, entry.cachedAt) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	total := len(entry.offers)
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	var offers []map[string]interface{}
+	if offset < total {
+		offers = entry.offers[offset:end]
+	}
+
+	source := database.SourceLive
+	if cached {
+		source = database.SourceMemoryCache
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"offers":     offers,
+		"total":      total,
+		"limit":      limit,
+		"offset":     offset,
+		"cached":     cached,
+		"source":     source,
+		"ageSeconds": int64(time.Since(entry.cachedAt).Seconds()),
+
+		// Pages that failed to load; the next request retries them
+		"partial":     len(entry.failedPages) > 0,
+		"failedPages": entry.failedPages,
+	})
 }
 
 // NewHandler creates a new handler
@@ -89,27 +269,70 @@ func NewHandler(db *database.DB, config ebay.Config, sessionStore *database.DBSe
 		environment:       environment,
 		marketplaceID:     marketplaceID,
 		encryptionKey:     encryptionKey,
-		enrichmentCache:   make(map[string]*EnrichedItemData),
 		enrichmentQueue:   make(chan string, 1000), // Buffer up to 1000 items
+		enrichmentCache:   make(map[string]map[string]*EnrichedItemData),
+		listingsCache:     make(map[string]*listingsCacheEntry),
+		notificationKeys:  make(map[string]*cachedNotificationKey),
+	}
+	if h.ebayConfig.CallCounter == nil {
+		h.callCounter = &ebayCallCounter{db: db}
+		h.ebayConfig.CallCounter = h.callCounter
 	}
 
-	// TODO: Background enrichment worker disabled for session-based auth
-	// The enrichment worker ran in a background goroutine without HTTP request context,
-	// which means it couldn't access session-based OAuth tokens.
-	// To re-enable, refactor to either:
-	// 1. Make enrichment on-demand per request, or
-	// 2. Store a reference to the current user's token (complex with multi-user sessions)
-	// go h.enrichmentWorker()
+	// Background enrichment uses an application token rather than a user session,
+	// so it can only call read-only APIs (Browse). See getAppClient.
+	go h.enrichmentWorker()
 
 	return h
 }
 
+// oauthStateTTL is how long a user has to complete the eBay login
+const oauthStateTTL = 10 * time.Minute
+
 // Session constants
 const (
-	sessionName = "ebay-helper-session"
-	tokenKey    = "oauth_token"
+	sessionName   = "ebay-helper-session"
+	tokenKey      = "oauth_token"
+	accountKeyKey = "account_key" // AccountKey of the eBay account this session authenticated as
 )
 
+// applyClientSettings copies client behaviour settings from the database into
+// config. Read per client so changes apply without a restart.
+func (h *Handler) applyClientSettings(config *ebay.Config) {
+	strictAck, err := h.db.GetSettingBool("trading_strict_ack", false)
+	if err != nil {
+		log.Printf("Failed to read trading_strict_ack setting: %v - using lenient Ack handling", err)
+	}
+	config.StrictAck = strictAck
+
+	imageSize, err := h.db.GetSettingInt("image_target_size", ebay.DefaultImageSize)
+	if err != nil {
+		log.Printf("Failed to read image_target_size setting: %v - using %d", err, ebay.DefaultImageSize)
+	}
+	config.ImageSize = imageSize
+
+	storeRaw, err := h.db.GetSettingBool("debug_store_raw_responses", false)
+	if err != nil {
+		log.Printf("Failed to read debug_store_raw_responses setting: %v - not storing raw responses", err)
+	}
+	if storeRaw {
+		config.ResponseRecorder = &rawResponseRecorder{db: h.db}
+	}
+}
+
+// rawResponseRecorder stores raw Trading API responses in raw_api_responses
+type rawResponseRecorder struct {
+	db *database.DB
+}
+
+// RecordResponse implements ebay.ResponseRecorder. A failed write is logged
+// rather than failing the eBay call it was recording.
+func (rec *rawResponseRecorder) RecordResponse(itemID, callName string, statusCode int, body []byte) {
+	if err := rec.db.SaveRawAPIResponse(itemID, callName, statusCode, body); err != nil {
+		log.Printf("[DEBUG-STORE] Failed to store %s response for item %s: %v", callName, itemID, err)
+	}
+}
+
 // getEbayClient creates a client for this request using session token
 // Hybrid approach: loads credentials from database if available, falls back to env vars
 func (h *Handler) getEbayClient(r *http.Request) (*ebay.Client, error) {
@@ -140,6 +363,11 @@ func (h *Handler) getEbayClient(r *http.Request) (*ebay.Client, error) {
 				RedirectURI:  cred.RedirectURI,
 				Sandbox:      environment == "sandbox",
 				Scopes:       h.ebayConfig.Scopes, // Use same scopes
+				DebugOAuth:   h.ebayConfig.DebugOAuth,
+				RateLimiter:  h.ebayConfig.RateLimiter, // Share one budget across credentials
+				CallCounter:  h.ebayConfig.CallCounter,
+				HTTPTimeout:  h.ebayConfig.HTTPTimeout,
+				MaxRetries:   h.ebayConfig.MaxRetries,
 			}
 			log.Printf("Using DB credentials: %s (%s)", cred.Name, environment)
 		} else {
@@ -156,6 +384,8 @@ func (h *Handler) getEbayClient(r *http.Request) (*ebay.Client, error) {
 		config = h.ebayConfig
 	}
 
+	h.applyClientSettings(&config)
+
 	client := ebay.NewClient(config)
 
 	// Load token from session if it exists
@@ -199,6 +429,62 @@ func (h *Handler) saveTokenToSession(w http.ResponseWriter, r *http.Request, tok
 	return session.Save(r, w)
 }
 
+// saveAccountToSession records which account the session's token belongs to,
+// so per-account caches are scoped to the session rather than the process
+func (h *Handler) saveAccountToSession(w http.ResponseWriter, r *http.Request, accountKey string) error {
+	session, err := h.sessionStore.Get(r, sessionName)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	session.Values[accountKeyKey] = accountKey
+	return session.Save(r, w)
+}
+
+// sessionAccountKey returns the account key saved in the session, or "" if none
+func (h *Handler) sessionAccountKey(r *http.Request) string {
+	session, err := h.sessionStore.Get(r, sessionName)
+	if err != nil {
+		return ""
+	}
+	accountKey, _ := session.Values[accountKeyKey].(string)
+	return accountKey
+}
+
+// sessionAccount returns the account saved in the session, or nil if there's none
+func (h *Handler) sessionAccount(r *http.Request) *database.Account {
+	accountKey := h.sessionAccountKey(r)
+	if accountKey == "" {
+		return nil
+	}
+	account, err := h.db.GetAccountByKey(accountKey)
+	if err != nil {
+		log.Printf("Failed to look up session account %s: %v", accountKey, err)
+		return nil
+	}
+	return account
+}
+
+// sessionAccountID returns the ID of the account saved in the session, or 0
+// if there's none
+func (h *Handler) sessionAccountID(r *http.Request) int64 {
+	if account := h.sessionAccount(r); account != nil {
+		return account.ID
+	}
+	return 0
+}
+
+// exportAccount returns the account an export from this session's eBay
+// login is stored under: the session's own account, or currentAccount for
+// sessions that predate accounts being saved in the session
+func (h *Handler) exportAccount(r *http.Request) *database.Account {
+	if account := h.sessionAccount(r); account != nil {
+		return account
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.currentAccount
+}
+
 // clearSession removes all session data
 func (h *Handler) clearSession(w http.ResponseWriter, r *http.Request) error {
 	session, err := h.sessionStore.Get(r, sessionName)
@@ -209,49 +495,47 @@ func (h *Handler) clearSession(w http.ResponseWriter, r *http.Request) error {
 	return session.Save(r, w)
 }
 
-// TODO: enrichmentWorker disabled for session-based auth
-// The enrichmentWorker ran in a background goroutine without HTTP request context,
-// which means it couldn't access session-based OAuth tokens.
-// To re-enable, refactor to either:
-// 1. Make enrichment on-demand per request, or
-// 2. Store a reference to the current user's token (complex with multi-user sessions)
-/*
+// getAppClient returns a client authenticated with an application (client-credentials)
+// token. The token is cached on the handler and refreshed when it expires, so
+// background work can call read-only APIs without a user session.
+func (h *Handler) getAppClient(ctx context.Context) (*ebay.Client, error) {
+	config := h.ebayConfig
+	h.applyClientSettings(&config)
+	client := ebay.NewClient(config)
+
+	h.appTokenMutex.Lock()
+	defer h.appTokenMutex.Unlock()
+
+	if !h.appToken.Valid() {
+		token, err := client.FetchApplicationToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		h.appToken = token
+		log.Printf("[ENRICHMENT] Obtained application token (expires %s)", token.Expiry.Format(time.RFC3339))
+	}
+
+	client.SetToken(h.appToken)
+	return client, nil
+}
+
+// enrichmentWorker drains enrichmentQueue using a pool of workers. Items are
+// fetched from the Browse API with an application token, cached in memory and
+// written to enriched_items.
 func (h *Handler) enrichmentWorker() {
-	const numWorkers = 25 // Process 25 items concurrently
+	const numWorkers = 5 // Browse API calls are cheap but rate limited per app
 	log.Printf("[ENRICHMENT] Background worker started with %d concurrent workers", numWorkers)
 
 	// Create worker pool
 	var wg sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
-		go func(workerID int) {
+		go func() {
 			defer wg.Done()
-
 			for itemID := range h.enrichmentQueue {
-				// Check if already enriched
-				h.enrichmentMutex.RLock()
-				_, exists := h.enrichmentCache[itemID]
-				h.enrichmentMutex.RUnlock()
-
-				if exists {
-					continue // Already enriched
-				}
-
-				// Fetch item details using GetItem
-				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-				// NOTE: Can't use h.ebayClient anymore with session-based auth
-				// brand, shippingCost, shippingCurrency, coo, images, err := h.ebayClient.GetItem(ctx, itemID)
-				cancel()
-
-				// Store empty entry to avoid retrying failed items
-				h.enrichmentMutex.Lock()
-				h.enrichmentCache[itemID] = &EnrichedItemData{
-					ItemID:     itemID,
-					EnrichedAt: time.Now(),
-				}
-				h.enrichmentMutex.Unlock()
+				h.enrichInBackground(itemID)
 			}
-		}(i)
+		}()
 	}
 
 	// Wait for all workers to finish (this won't happen until channel is closed)
@@ -259,660 +543,938 @@ func (h *Handler) enrichmentWorker() {
 	log.Printf("[ENRICHMENT] All workers stopped")
 }
 
-func (h *Handler) queueItemsForEnrichment(itemIDs []string) {
-	for _, itemID := range itemIDs {
-		select {
-		case h.enrichmentQueue <- itemID:
-			// Queued successfully
-		default:
-			// Queue is full, skip this item
-			log.Printf("[ENRICHMENT] Queue full, skipping item %s", itemID)
-		}
-	}
+// publicEnrichment is the enrichment cache's account key for items fetched
+// from the Browse API with the application token. That data is public, so
+// every session can use it; what a session fetches with its own token (SKU,
+// per-destination shipping) is cached under its account only.
+const publicEnrichment = ""
+
+// cachedEnrichment returns the in-memory enrichment for itemID that
+// accountKey's sessions may see: the account's own, else the public one
+func (h *Handler) cachedEnrichment(accountKey, itemID string) (*EnrichedItemData, bool) {
+	h.enrichmentMutex.RLock()
+	defer h.enrichmentMutex.RUnlock()
+	if data, ok := h.enrichmentCache[accountKey][itemID]; ok {
+		return data, true
+	}
+	data, ok := h.enrichmentCache[publicEnrichment][itemID]
+	return data, ok
 }
-*/
 
-// JSON response helper
-func jsonResponse(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Printf("Error encoding JSON: %v", err)
+// cacheEnrichment stores data in accountKey's in-memory enrichment cache,
+// replacing any earlier enrichment of the item (e.g. after a refresh)
+func (h *Handler) cacheEnrichment(accountKey string, data *EnrichedItemData) {
+	h.enrichmentMutex.Lock()
+	defer h.enrichmentMutex.Unlock()
+	items := h.enrichmentCache[accountKey]
+	if items == nil {
+		items = make(map[string]*EnrichedItemData)
+		h.enrichmentCache[accountKey] = items
 	}
+	items[data.ItemID] = data
 }
 
-// Error response helper
-func errorResponse(w http.ResponseWriter, status int, message string) {
-	jsonResponse(w, status, map[string]string{"error": message})
-}
-
-// HealthCheck returns API health status
-func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	client, err := h.getEbayClient(r)
-	authenticated := false
-	if err == nil {
-		authenticated = client.IsAuthenticated()
+// uncacheEnrichment removes itemIDs from every account's in-memory
+// enrichment cache, or every item when itemIDs is nil
+func (h *Handler) uncacheEnrichment(itemIDs []string) {
+	h.enrichmentMutex.Lock()
+	defer h.enrichmentMutex.Unlock()
+	if itemIDs == nil {
+		h.enrichmentCache = make(map[string]map[string]*EnrichedItemData)
+		return
+	}
+	for _, items := range h.enrichmentCache {
+		for _, id := range itemIDs {
+			delete(items, id)
+		}
 	}
-
-	jsonResponse(w, http.StatusOK, map[string]interface{}{
-		"status":        "ok",
-		"authenticated": authenticated,
-		"configured":    h.ebayConfig.ClientID != "",
-		"hasAccount":    h.currentAccount != nil,
-	})
 }
 
-// GetCurrentAccount returns the current instance's account info
-func (h *Handler) GetCurrentAccount(w http.ResponseWriter, r *http.Request) {
-	h.mu.RLock()
-	account := h.currentAccount
-	h.mu.RUnlock()
-
-	// If no account in memory but user has valid session, hydrate from eBay
-	if account == nil {
-		client, err := h.getEbayClient(r)
-		if err == nil && client.IsAuthenticated() {
+// enrichInBackground fetches and stores a single queued item
+func (h *Handler) enrichInBackground(itemID string) {
+	// Check if already enriched
+	if _, exists := h.cachedEnrichment(publicEnrichment, itemID); exists {
+		return
+	}
 
-			// Fetch user info from eBay
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			user, err := client.GetUser(ctx)
-			cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-			if err == nil && user != nil {
-				// Create/update account in database
-				accountKey := fmt.Sprintf("%s_%s", user.UserID, h.environment)
-				dbAccount, err := h.db.GetOrCreateAccountFromEbay(accountKey, user.Username, h.environment, h.marketplaceID)
-				if err == nil {
-					h.mu.Lock()
-					h.currentAccount = dbAccount
-					account = dbAccount
-					h.mu.Unlock()
-				} else {
-				}
-			} else {
-			}
-		}
+	client, err := h.getAppClient(ctx)
+	if err != nil {
+		log.Printf("[ENRICHMENT] Cannot enrich item %s without application token: %v", itemID, err)
+		return
 	}
 
-	if account == nil {
-		jsonResponse(w, http.StatusOK, map[string]interface{}{
-			"configured": false,
-			"message":    "Not connected to an eBay account. Authenticate to continue.",
-		})
+	enrichedData, err := h.fetchEnrichment(ctx, client, itemID)
+	if err != nil {
+		// Not cached, so the item can be queued again later
+		log.Printf("[ENRICHMENT] Failed to enrich item %s: %v", itemID, err)
 		return
 	}
 
-	jsonResponse(w, http.StatusOK, map[string]interface{}{
-		"configured": true,
-		"account":    account,
-	})
+	log.Printf("[ENRICHMENT] Background enriched item %s (Brand: %s, COO: %s, Images: %d)",
+		itemID, enrichedData.Brand, enrichedData.CountryOfOrigin, len(enrichedData.Images))
 }
 
-// GetAccounts returns all accounts that have data in the database
-func (h *Handler) GetAccounts(w http.ResponseWriter, r *http.Request) {
-	accounts, err := h.db.GetAccounts()
+// auditCurrency returns the currency listings' shipping is expected in,
+// falling back to the default if the setting can't be read
+func (h *Handler) auditCurrency() string {
+	currency, err := h.db.GetAuditCurrency()
 	if err != nil {
-		log.Printf("GetAccounts error: %v", err)
-		errorResponse(w, http.StatusInternalServerError, err.Error())
-		return
+		log.Printf("Failed to read audit_shipping_currency, using %s: %v", currency, err)
 	}
-
-	jsonResponse(w, http.StatusOK, map[string]interface{}{
-		"accounts": accounts,
-		"total":    len(accounts),
-	})
+	return currency
 }
 
-// GetAuthURL returns the OAuth authorization URL
-func (h *Handler) GetAuthURL(w http.ResponseWriter, r *http.Request) {
-	h.mu.Lock()
-	h.oauthState = generateState()
-	state := h.oauthState
-	h.mu.Unlock()
-
-	client := ebay.NewClient(h.ebayConfig)
-	url := client.GetAuthURL(state)
-	jsonResponse(w, http.StatusOK, map[string]string{"url": url})
+// fetchEnrichment fetches an item from the Browse API, replacing any cached
+// enrichment and writing it to enriched_items
+func (h *Handler) fetchEnrichment(ctx context.Context, client *ebay.Client, itemID string) (*EnrichedItemData, error) {
+	item, err := client.GetItemBrowse(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	enrichedData := &EnrichedItemData{
+		ItemID:               itemID,
+		Brand:                item.Brand,
+		CountryOfOrigin:      item.CountryOfOrigin,
+		ShippingCost:         item.ShippingCost,
+		ShippingCurrency:     item.ShippingCurrency,
+		Images:               item.Images,
+		ConditionDescription: item.ConditionDescription,
+		EnrichedAt:           time.Now(),
+	}
+	enrichedData.CurrencyCheck = database.CheckShippingCurrency(item.ShippingCurrency, h.auditCurrency())
+
+	h.cacheEnrichment(publicEnrichment, enrichedData)
+
+	if err := h.db.SaveEnrichedItem(&database.EnrichedItem{
+		ItemID:           itemID,
+		Brand:            enrichedData.Brand,
+		CountryOfOrigin:  enrichedData.CountryOfOrigin,
+		ShippingCost:     enrichedData.ShippingCost,
+		ShippingCurrency: enrichedData.ShippingCurrency,
+		Images:           enrichedData.Images,
+		EnrichedAt:       enrichedData.EnrichedAt,
+
+		ConditionDescription: enrichedData.ConditionDescription,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save to database: %w", err)
+	}
+	h.autoMapBrand(item.Brand, item.CountryOfOrigin, itemID)
+	return enrichedData, nil
 }
 
-// OAuthCallback handles the OAuth callback
-func (h *Handler) OAuthCallback(w http.ResponseWriter, r *http.Request) {
-	code := r.URL.Query().Get("code")
-	state := r.URL.Query().Get("state")
-	errParam := r.URL.Query().Get("error")
-	errDesc := r.URL.Query().Get("error_description")
+// autoMapBrand adds a brand-COO mapping, flagged for review, for a brand
+// that enrichment found without one, when enrichment_auto_create_brands is
+// on. The item's declared COO is used if it's a tariff country, otherwise
+// the default COO. Failures are only logged: enrichment itself succeeded.
+func (h *Handler) autoMapBrand(brand, declaredCOO, itemID string) {
+	brand = strings.TrimSpace(brand)
+	if brand == "" {
+		return
+	}
+	enabled, err := h.db.GetSettingBool("enrichment_auto_create_brands", false)
+	if err != nil {
+		log.Printf("[ENRICHMENT] Failed to read enrichment_auto_create_brands: %v", err)
+		return
+	}
+	if !enabled {
+		return
+	}
 
-	log.Printf("OAuth callback received - code: %v, state: %s, error: %s", code != "", state, errParam)
+	coo := strings.TrimSpace(declaredCOO)
+	notes := fmt.Sprintf("auto-created from item %s", itemID)
+	if coo != "" {
+		exists, err := h.db.TariffCountryExists(coo)
+		if err != nil {
+			log.Printf("[ENRICHMENT] Failed to check tariff country %s: %v", coo, err)
+			return
+		}
+		if !exists {
+			notes += fmt.Sprintf("; declared COO %s has no tariff rate", coo)
+			coo = ""
+		}
+	}
+	if coo == "" {
+		coo = h.calculator().DefaultCOO
+		notes += fmt.Sprintf("; default COO %s assumed", coo)
+	}
 
-	if errParam != "" {
-		log.Printf("OAuth error from eBay: %s - %s", errParam, errDesc)
-		http.Error(w, "eBay OAuth error: "+errDesc, http.StatusBadRequest)
+	created, err := h.db.CreateAutoBrandMapping(brand, coo, notes)
+	if err != nil {
+		log.Printf("[ENRICHMENT] Failed to auto-create mapping for brand %s: %v", brand, err)
 		return
 	}
+	if created {
+		log.Printf("[ENRICHMENT] Auto-created mapping %s -> %s from item %s", brand, coo, itemID)
+		h.reloadCalculatorAfterEdit()
+	}
+}
 
-	h.mu.RLock()
-	expectedState := h.oauthState
-	h.mu.RUnlock()
+// enrichmentRefreshInterval is how often RunEnrichmentRefresh looks for
+// stale enrichment
+const enrichmentRefreshInterval = 15 * time.Minute
 
-	log.Printf("State check - received: %s, expected: %s", state, expectedState)
+// enrichmentRefreshBatch caps how many items one refresh pass re-fetches,
+// so a large sync doesn't eat the Browse API quota in one go
+const enrichmentRefreshBatch = 50
 
-	if state != expectedState {
-		log.Printf("State mismatch!")
-		http.Error(w, "Invalid state parameter", http.StatusBadRequest)
-		return
+// RunEnrichmentRefresh periodically re-enriches items whose offer or
+// inventory item changed in a sync since they were last enriched, until ctx
+// is done. It only does work while the enrichment_auto_refresh setting is on;
+// the setting is read every tick so it can be toggled without a restart.
+func (h *Handler) RunEnrichmentRefresh(ctx context.Context) {
+	ticker := time.NewTicker(enrichmentRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.refreshStaleEnrichment(ctx)
+		}
 	}
+}
 
-	if code == "" {
-		log.Printf("Missing authorization code")
-		http.Error(w, "Missing authorization code", http.StatusBadRequest)
+// refreshStaleEnrichment runs one refresh pass. Items are fetched one at a
+// time, on top of the client's shared rate limiter.
+func (h *Handler) refreshStaleEnrichment(ctx context.Context) {
+	enabled, err := h.db.GetSettingBool("enrichment_auto_refresh", false)
+	if err != nil {
+		log.Printf("[ENRICHMENT-REFRESH] Failed to read setting: %v", err)
 		return
 	}
-
-	log.Printf("Exchanging code for token...")
-	client := ebay.NewClient(h.ebayConfig)
-	if err := client.ExchangeCode(r.Context(), code); err != nil {
-		log.Printf("OAuth exchange error: %v", err)
-		http.Error(w, "Failed to authenticate: "+err.Error(), http.StatusInternalServerError)
+	if !enabled {
 		return
 	}
-
-	// Get the token from the client and save it to session
-	token := client.GetToken()
-	if token == nil {
-		log.Printf("ERROR: Token is nil after exchange")
-		http.Error(w, "Failed to obtain token", http.StatusInternalServerError)
+	if usage, err := h.ebayUsageToday(); err == nil && usage.Warning {
+		log.Printf("[ENRICHMENT-REFRESH] Skipping: %d/%d of today's eBay call budget used", usage.Calls, usage.Budget)
 		return
 	}
 
-	// Save token to session
-	if err := h.saveTokenToSession(w, r, token); err != nil {
-		log.Printf("Failed to save token to session: %v", err)
-		http.Error(w, "Failed to save authentication", http.StatusInternalServerError)
+	itemIDs, err := h.db.GetStaleEnrichedItemIDs(h.environment, enrichmentRefreshBatch)
+	if err != nil {
+		log.Printf("[ENRICHMENT-REFRESH] Failed to find changed items: %v", err)
+		return
+	}
+	if len(itemIDs) == 0 {
 		return
 	}
 
-	log.Printf("OAuth success! Token obtained and saved to session.")
+	client, err := h.getAppClient(ctx)
+	if err != nil {
+		log.Printf("[ENRICHMENT-REFRESH] Cannot refresh without application token: %v", err)
+		return
+	}
 
-	// Fetch eBay username using Commerce Identity API with retry logic
-	// No useless fallbacks - if this fails, we show a proper error to the user
-	var username, userID string
-	var userErr error
-
-	// Retry logic: 3 attempts with increasing timeout
-	maxAttempts := 3
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		timeout := time.Duration(5+attempt*5) * time.Second // 10s, 15s, 20s
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-
-		user, err := client.GetUser(ctx)
-		cancel()
-
-		if err == nil && user != nil {
-			username = user.Username
-			userID = user.UserID
-			log.Printf("SUCCESS: Authenticated as eBay user: %s (ID: %s)", username, userID)
-			userErr = nil
+	refreshed := 0
+	for _, itemID := range itemIDs {
+		if ctx.Err() != nil {
 			break
 		}
+		if _, err := h.fetchEnrichment(ctx, client, itemID); err != nil {
+			log.Printf("[ENRICHMENT-REFRESH] Failed to refresh item %s: %v", itemID, err)
+			continue
+		}
+		refreshed++
+	}
+	log.Printf("[ENRICHMENT-REFRESH] Refreshed %d/%d changed items", refreshed, len(itemIDs))
+}
+
+// queueItemsForEnrichment adds items to the background queue without blocking.
+// Returns how many were queued and how many were skipped because the queue was full.
+func (h *Handler) queueItemsForEnrichment(itemIDs []string) (queued, skipped int) {
+	for _, itemID := range itemIDs {
+		select {
+		case h.enrichmentQueue <- itemID:
+			queued++
+		default:
+			// Queue is full, skip this item
+			log.Printf("[ENRICHMENT] Queue full, skipping item %s", itemID)
+			skipped++
+		}
+	}
+	return queued, skipped
+}
+
+// JSON response helper
+func jsonResponse(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("Error encoding JSON: %v", err)
+	}
+}
+
+// Error response helper
+func errorResponse(w http.ResponseWriter, status int, message string) {
+	jsonResponse(w, status, map[string]string{"error": message})
+}
+
+// Error codes for errorCodeResponse, so clients can branch without matching message text
+const (
+	errCodeMissingItemIDs   = "missing_item_ids"
+	errCodeBackupInvalid    = "backup_invalid"
+	errCodeReferenceInvalid = "reference_invalid"
+	errCodeCallBudget       = "call_budget_exceeded"
+)
 
-		userErr = err
-		log.Printf("WARNING: Attempt %d failed: %v", attempt, err)
+// errorCodeResponse is errorResponse with a machine-readable error code
+func errorCodeResponse(w http.ResponseWriter, status int, code, message string) {
+	jsonResponse(w, status, map[string]string{"error": message, "code": code})
+}
 
-		if attempt < maxAttempts {
-			backoff := time.Duration(attempt) * time.Second
-			time.Sleep(backoff)
+// cleanItemIDs trims item IDs and drops empty ones
+func cleanItemIDs(ids []string) []string {
+	var cleaned []string
+	for _, id := range ids {
+		if trimmed := strings.TrimSpace(id); trimmed != "" {
+			cleaned = append(cleaned, trimmed)
 		}
 	}
+	return cleaned
+}
 
-	if userErr != nil {
-		log.Printf("ERROR: Failed to fetch eBay user info after %d attempts: %v", maxAttempts, userErr)
-		http.Error(w, "Unable to connect to eBay to verify your account. Please try again later.", http.StatusServiceUnavailable)
-		return
+// maxDeletionNotificationsLimit caps ?limit= on the deletion notification lists
+const maxDeletionNotificationsLimit = 500
+
+// parsePagination reads ?limit= and ?offset= for list endpoints. A missing,
+// invalid or non-positive limit gets defaultLimit and a larger one is capped
+// at maxLimit; a missing, invalid or negative offset becomes 0.
+func parsePagination(r *http.Request, defaultLimit, maxLimit int) (limit, offset int) {
+	limit = clampPageSize(r.URL.Query().Get("limit"), defaultLimit, maxLimit)
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
 	}
+	return limit, offset
+}
 
-	// Use a unique identifier based on the actual eBay user ID
-	accountKey := fmt.Sprintf("%s_%s", userID, h.environment)
+// parsePage is parsePagination for endpoints paged by ?page= (zero-based)
+// and ?pageSize= rather than limit/offset
+func parsePage(r *http.Request, defaultSize, maxSize int) (page, pageSize int) {
+	pageSize = clampPageSize(r.URL.Query().Get("pageSize"), defaultSize, maxSize)
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 0 {
+		page = 0
+	}
+	return page, pageSize
+}
 
-	// Create or update account with real eBay username
-	account, err := h.db.GetOrCreateAccountFromEbay(accountKey, username, h.environment, h.marketplaceID)
-	if err != nil {
-		log.Printf("ERROR: Failed to create/update account: %v", err)
-		http.Error(w, "Unable to create account. Please try again.", http.StatusInternalServerError)
-		return
+func clampPageSize(param string, defaultSize, maxSize int) int {
+	size, err := strconv.Atoi(param)
+	if err != nil || size <= 0 {
+		return defaultSize
+	}
+	if size > maxSize {
+		return maxSize
 	}
+	return size
+}
 
-	h.mu.Lock()
-	h.currentAccount = account
-	h.mu.Unlock()
-	log.Printf("SUCCESS: Account created/updated: %s (AccountKey: %s)", account.DisplayName, account.AccountKey)
+// marketplaceParam reads ?marketplace_id=, falling back to defaultID when it's
+// absent. An unsupported ID gets a 400 listing the valid ones, rather than
+// being passed on to eBay; ok is false when that response has been written.
+func marketplaceParam(w http.ResponseWriter, r *http.Request, defaultID string) (marketplaceID string, ok bool) {
+	marketplaceID = r.URL.Query().Get("marketplace_id")
+	if marketplaceID == "" {
+		return defaultID, true
+	}
+	if err := ebay.ValidateMarketplace(marketplaceID); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	return marketplaceID, true
+}
 
-	// Redirect to the main app
-	http.Redirect(w, r, "/?auth=success", http.StatusFound)
+func missingItemIDsResponse(w http.ResponseWriter) {
+	errorCodeResponse(w, http.StatusBadRequest, errCodeMissingItemIDs, "itemIds must contain at least one non-empty item ID")
 }
 
-// GetAuthStatus returns current auth status
-func (h *Handler) GetAuthStatus(w http.ResponseWriter, r *http.Request) {
+// HealthCheck returns API health status
+func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	client, err := h.getEbayClient(r)
 	authenticated := false
+	tokenValid := false
+	var tokenExpiresAt *time.Time
 	if err == nil {
 		authenticated = client.IsAuthenticated()
-	} else {
+		// Only the expiry is reported - never any part of the token itself
+		if token := client.GetToken(); token != nil {
+			tokenValid = token.Valid()
+			if !token.Expiry.IsZero() {
+				tokenExpiresAt = &token.Expiry
+			}
+		}
 	}
 
-	configured := h.ebayConfig.ClientID != ""
+	status := http.StatusOK
+	health := map[string]interface{}{
+		"status":            "ok",
+		"authenticated":     authenticated,
+		"configured":        h.ebayConfig.ClientID != "",
+		"tokenValid":        tokenValid,
+		"tokenExpiresAt":    tokenExpiresAt,
+		"rateLimit":         h.ebayConfig.RateLimiter.Stats(),
+		"apiCallsRemaining": nil, // Null when no daily budget is configured
+		"hasAccount":        h.exportAccount(r) != nil,
+		"database":          "ok",
+	}
 
-	jsonResponse(w, http.StatusOK, map[string]interface{}{
-		"authenticated": authenticated,
-		"configured":    configured,
-	})
+	if err := h.db.CheckConnection(); err != nil {
+		log.Printf("[HEALTH] %v", err)
+		status = http.StatusServiceUnavailable
+		health["status"] = "degraded"
+		health["database"] = "error"
+	} else if usage, err := h.ebayUsageToday(); err != nil {
+		log.Printf("[HEALTH] Failed to read eBay usage: %v", err)
+	} else if usage.Budget > 0 {
+		health["apiCallsRemaining"] = usage.Remaining
+	}
+
+	jsonResponse(w, status, health)
 }
 
-// Logout clears the session and logs the user out
-func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
-	if err := h.clearSession(w, r); err != nil {
-		log.Printf("Failed to clear session: %v", err)
-		errorResponse(w, http.StatusInternalServerError, "Failed to logout")
-		return
-	}
+// ebayCallCounter counts outbound eBay calls in memory and persists them per
+// quota day on flush, so counting never puts a database write in the path of
+// an eBay call. Calls are attributed to the quota day they're flushed in,
+// which can be up to callCountFlushInterval after midnight.
+type ebayCallCounter struct {
+	db      *database.DB
+	pending atomic.Int64 // Calls counted since the last flush
+	flushMu sync.Mutex   // Serializes flushes, so a failed one can't double-count
+}
 
-	// Also clear currentAccount on logout
-	h.mu.Lock()
-	h.currentAccount = nil
-	h.mu.Unlock()
+// callCountFlushInterval is how often counted eBay calls are written out
+const callCountFlushInterval = 10 * time.Second
 
-	http.Redirect(w, r, "/", http.StatusSeeOther)
+// CountCall implements ebay.CallCounter
+func (c *ebayCallCounter) CountCall() {
+	c.pending.Add(1)
 }
 
-// GetInventoryItems returns paginated inventory items
-func (h *Handler) GetInventoryItems(w http.ResponseWriter, r *http.Request) {
-	client, err := h.getEbayClient(r)
-	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Session error")
-		return
+// Flush adds the calls counted since the last flush to the current quota
+// day. If the write fails they're kept for the next flush.
+func (c *ebayCallCounter) Flush() error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	n := c.pending.Swap(0)
+	if n == 0 {
+		return nil
+	}
+	if err := c.db.AddEbayCallCount(ebay.QuotaDay(time.Now()), n); err != nil {
+		c.pending.Add(n)
+		return err
 	}
+	return nil
+}
 
-	if !client.IsAuthenticated() {
-		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
+// RunCallCountFlush writes counted eBay calls to the database every
+// callCountFlushInterval until ctx is done. Call FlushCallCounts after
+// shutting down to write the last ones.
+func (h *Handler) RunCallCountFlush(ctx context.Context) {
+	ticker := time.NewTicker(callCountFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.FlushCallCounts()
+		}
+	}
+}
+
+// FlushCallCounts writes counted eBay calls to the database now. A failure
+// is logged; the calls stay counted for the next flush.
+func (h *Handler) FlushCallCounts() {
+	if h.callCounter == nil {
 		return
 	}
+	if err := h.callCounter.Flush(); err != nil {
+		log.Printf("[EBAY-USAGE] Failed to save call counts: %v", err)
+	}
+}
 
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+// callBudgetWarnFraction is the share of the daily call budget at which usage
+// is flagged, and bulk operations refused if ebay_budget_block_bulk is on
+const callBudgetWarnFraction = 0.8
+
+// EbayUsage reports today's eBay calls against the daily budget
+type EbayUsage struct {
+	Day         string    `json:"day"` // Quota day, YYYY-MM-DD in Pacific time
+	Calls       int64     `json:"calls"`
+	Budget      int       `json:"budget"`    // 0 = no budget configured
+	Remaining   int64     `json:"remaining"` // 0 once the budget is used up, or with no budget
+	PercentUsed float64   `json:"percentUsed"`
+	Warning     bool      `json:"warning"`   // At least callBudgetWarnFraction of the budget used
+	BlockBulk   bool      `json:"blockBulk"` // Bulk operations are refused while Warning is set
+	ResetsAt    time.Time `json:"resetsAt"`
+}
 
-	if limit <= 0 || limit > 100 {
-		limit = 25
+func (h *Handler) ebayUsageToday() (*EbayUsage, error) {
+	h.FlushCallCounts() // Include calls not yet written out
+	now := time.Now()
+	day := ebay.QuotaDay(now)
+	calls, err := h.db.GetEbayCallCount(day)
+	if err != nil {
+		return nil, err
 	}
-	if offset < 0 {
-		offset = 0
+	budget, err := h.db.GetSettingInt("ebay_daily_call_budget", 0)
+	if err != nil {
+		return nil, err
+	}
+	blockBulk, err := h.db.GetSettingBool("ebay_budget_block_bulk", false)
+	if err != nil {
+		return nil, err
 	}
 
-	items, err := client.GetInventoryItems(r.Context(), limit, offset)
+	usage := &EbayUsage{
+		Day:       day,
+		Calls:     calls,
+		Budget:    budget,
+		BlockBulk: blockBulk,
+		ResetsAt:  ebay.NextQuotaReset(now),
+	}
+	if budget > 0 {
+		usage.Remaining = max(int64(budget)-calls, 0)
+		usage.PercentUsed = math.Round(float64(calls)/float64(budget)*1000) / 10
+		usage.Warning = float64(calls) >= float64(budget)*callBudgetWarnFraction
+	}
+	return usage, nil
+}
+
+// GetEbayUsage returns today's eBay call count against the daily budget
+func (h *Handler) GetEbayUsage(w http.ResponseWriter, r *http.Request) {
+	usage, err := h.ebayUsageToday()
 	if err != nil {
-		log.Printf("GetInventoryItems error: %v", err)
+		log.Printf("GetEbayUsage error: %v", err)
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-
-	jsonResponse(w, http.StatusOK, items)
+	jsonResponse(w, http.StatusOK, usage)
 }
 
-// GetOffers returns paginated offers
-// This endpoint uses the Trading API to fetch traditional eBay listings
-func (h *Handler) GetOffers(w http.ResponseWriter, r *http.Request) {
-	client, err := h.getEbayClient(r)
+// bulkCallsAllowed checks the daily eBay call budget before a bulk operation.
+// Near the budget it logs a warning and, if ebay_budget_block_bulk is on,
+// responds 429 and returns false. Usage lookup errors don't block anything.
+func (h *Handler) bulkCallsAllowed(w http.ResponseWriter) bool {
+	usage, err := h.ebayUsageToday()
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Session error")
-		return
+		log.Printf("[EBAY-USAGE] Failed to check call budget: %v", err)
+		return true
+	}
+	if !usage.Warning {
+		return true
 	}
 
-	if !client.IsAuthenticated() {
-		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
-		return
+	log.Printf("[EBAY-USAGE] %d/%d of today's eBay call budget used (%.1f%%)", usage.Calls, usage.Budget, usage.PercentUsed)
+	if !usage.BlockBulk {
+		return true
 	}
+	errorCodeResponse(w, http.StatusTooManyRequests, errCodeCallBudget,
+		fmt.Sprintf("Used %d of today's %d-call eBay budget; bulk operations resume when it resets at midnight Pacific (%s)",
+			usage.Calls, usage.Budget, usage.ResetsAt.Format(time.RFC3339)))
+	return false
+}
 
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-	forceRefresh := r.URL.Query().Get("force") == "true"
+// hydrateCurrentAccount sets currentAccount from lookup, unless another
+// request has set it first. Concurrent calls for the same session token
+// share one lookup, so a page firing several requests at startup makes a
+// single eBay GetUser call and a single GetOrCreateAccountFromEbay.
+func (h *Handler) hydrateCurrentAccount(token string, lookup func() (*database.Account, error)) (*database.Account, error) {
+	sum := sha256.Sum256([]byte(token))
+	v, err, _ := h.accountHydration.Do(hex.EncodeToString(sum[:]), func() (interface{}, error) {
+		h.mu.RLock()
+		account := h.currentAccount
+		h.mu.RUnlock()
+		if account != nil {
+			return account, nil // Hydrated by a call that finished just before this one
+		}
 
-	if limit <= 0 || limit > 100 {
-		limit = 50
-	}
-	if offset < 0 {
-		offset = 0
+		account, err := lookup()
+		if err != nil {
+			return nil, err
+		}
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.currentAccount == nil {
+			h.currentAccount = account
+		}
+		return h.currentAccount, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return v.(*database.Account), nil
+}
 
-	// Check if we have cached listings and not forcing refresh
-	h.listingsMutex.RLock()
-	hasCachedListings := len(h.listingsCache) > 0
-	cacheAge := time.Since(h.listingsCacheTime)
-	h.listingsMutex.RUnlock()
-
-	// Cache TTL: 8 hours (only Refresh button or server restart triggers re-fetch)
-	const cacheTTL = 8 * time.Hour
+// getUserAttempts is how many times the OAuth callback tries the User API
+// before giving up. GetCurrentAccount is polled on page load, so it tries
+// once and leaves the retries to the next poll.
+const getUserAttempts = 3
 
-	// Use cache if available, not forcing, and cache is within TTL
-	if hasCachedListings && !forceRefresh && cacheAge < cacheTTL {
-		log.Printf("[CACHE] Returning cached listings (age: %v, total: %d)", cacheAge.Round(time.Second), len(h.listingsCache))
+// GetCurrentAccount returns the current instance's account info
+func (h *Handler) GetCurrentAccount(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	account := h.currentAccount
+	h.mu.RUnlock()
 
-		h.listingsMutex.RLock()
-		total := len(h.listingsCache)
+	// If no account in memory but user has valid session, hydrate from eBay
+	if account == nil {
+		client, err := h.getEbayClient(r)
+		if err == nil && client.IsAuthenticated() {
+			dbAccount, err := h.hydrateCurrentAccount(client.GetToken().AccessToken, func() (*database.Account, error) {
+				// Fetch user info from eBay. Not tied to r's context, since
+				// concurrent requests share this call.
+				user, err := client.GetUserWithRetry(context.Background(), 1)
+				if err != nil {
+					return nil, err
+				}
 
-		// Paginate from cache
-		end := offset + limit
-		if end > total {
-			end = total
-		}
-		var offers []map[string]interface{}
-		if offset < total {
-			offers = h.listingsCache[offset:end]
+				// Create/update account in database
+				accountKey := fmt.Sprintf("%s_%s", user.UserID, h.environment)
+				return h.db.GetOrCreateAccountFromEbay(accountKey, user.Username, h.environment, h.marketplaceID)
+			})
+			if err == nil {
+				account = dbAccount
+				if err := h.saveAccountToSession(w, r, dbAccount.AccountKey); err != nil {
+					log.Printf("Failed to save account to session: %v", err)
+				}
+			} else {
+				log.Printf("Failed to hydrate current account: %v", err)
+			}
 		}
-		h.listingsMutex.RUnlock()
+	}
 
+	if account == nil {
 		jsonResponse(w, http.StatusOK, map[string]interface{}{
-			"offers": offers,
-			"total":  total,
-			"limit":  limit,
-			"offset": offset,
-			"cached": true,
+			"configured": false,
+			"message":    "Not connected to an eBay account. Authenticate to continue.",
 		})
 		return
 	}
 
-	// Need to fetch from eBay - fetch ALL listings CONCURRENTLY and cache them
-	log.Printf("[CACHE] Fetching all listings from eBay CONCURRENTLY (force=%v, cacheAge=%v)", forceRefresh, cacheAge.Round(time.Second))
-
-	startTime := time.Now()
-	pageSize := 100 // Max allowed by Trading API
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"configured": true,
+		"account":    account,
+	})
+}
 
-	// First, fetch page 1 to get total count
-	log.Printf("[CACHE] Fetching page 1 to get total count...")
-	firstPageItems, totalItems, err := client.GetMyeBaySelling(r.Context(), 1, pageSize)
+// GetAccounts returns all accounts that have data in the database
+func (h *Handler) GetAccounts(w http.ResponseWriter, r *http.Request) {
+	includeDeleted := r.URL.Query().Get("includeDeleted") == "true"
+	accounts, err := h.db.GetAccounts(includeDeleted)
 	if err != nil {
-		log.Printf("GetMyeBaySelling error: %v", err)
-		errorResponse(w, http.StatusInternalServerError, "Failed to fetch listings: "+err.Error())
+		log.Printf("GetAccounts error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	totalPages := (totalItems + pageSize - 1) / pageSize
-	log.Printf("[CACHE] Total items: %d, pages: %d", totalItems, totalPages)
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"accounts": accounts,
+		"total":    len(accounts),
+	})
+}
 
-	// Convert first page items
-	convertItems := func(items []ebay.TradingItem) []map[string]interface{} {
-		offers := make([]map[string]interface{}, 0, len(items))
-		for _, item := range items {
-			offer := map[string]interface{}{
-				"offerId": item.ItemID,
-				"sku":     item.SKU,
-				"title":   item.Title,
-				"pricingSummary": map[string]interface{}{
-					"price": map[string]interface{}{
-						"value":    item.Price,
-						"currency": item.Currency,
-					},
-				},
-			}
-			if item.ImageURL != "" {
-				offer["image"] = map[string]interface{}{
-					"imageUrl": item.ImageURL,
-				}
-			}
-			if item.Brand != "" {
-				offer["brand"] = item.Brand
-			}
-			if item.ShippingCost != "" {
-				offer["shippingCost"] = map[string]interface{}{
-					"value":    item.ShippingCost,
-					"currency": item.ShippingCurrency,
-				}
-			}
-			offers = append(offers, offer)
-		}
-		return offers
+// DeleteAccount soft-deletes an account: /api/accounts/:key. It's hidden from
+// GetAccounts but its sync history and synced data are kept, so it can be
+// reactivated. The account this session is signed in as can't be deleted.
+func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/accounts/"), "/")
+	if key == "" || strings.Contains(key, "/") {
+		errorResponse(w, http.StatusNotFound, "Not found")
+		return
 	}
+	if key == h.sessionAccountKey(r) {
+		errorResponse(w, http.StatusConflict, "Cannot delete the account you are signed in as")
+		return
+	}
+	h.setAccountDeleted(w, key, true)
+}
 
-	// Start with first page results
-	allOffers := convertItems(firstPageItems)
+// ReactivateAccount undoes a soft delete: /api/accounts/:key/reactivate
+func (h *Handler) ReactivateAccount(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/accounts/")
+	key, ok := strings.CutSuffix(strings.TrimSuffix(rest, "/"), "/reactivate")
+	if !ok || key == "" || strings.Contains(key, "/") {
+		errorResponse(w, http.StatusNotFound, "Not found")
+		return
+	}
+	h.setAccountDeleted(w, key, false)
+}
 
-	// If more pages, fetch them concurrently
-	if totalPages > 1 {
-		const maxWorkers = 5 // Concurrent requests to eBay (be nice, don't DDoS them!)
-
-		type pageResult struct {
-			pageNum int
-			items   []ebay.TradingItem
-			err     error
-		}
-
-		// Channel for page numbers to fetch
-		pageChan := make(chan int, totalPages-1)
-		// Channel for results
-		resultChan := make(chan pageResult, totalPages-1)
-
-		// Start worker goroutines
-		var wg sync.WaitGroup
-		for i := 0; i < maxWorkers; i++ {
-			wg.Add(1)
-			go func(workerID int) {
-				defer wg.Done()
-				for pageNum := range pageChan {
-					log.Printf("[CACHE-WORKER-%d] Fetching page %d...", workerID, pageNum)
-					items, _, err := client.GetMyeBaySelling(r.Context(), pageNum, pageSize)
-					resultChan <- pageResult{pageNum: pageNum, items: items, err: err}
-				}
-			}(i)
-		}
+// AccountListings browses one account's exported offers with the same
+// search, sort, filter and pagination params as GetListings:
+// GET /api/accounts/:key/listings. Soft-deleted accounts can still be browsed.
+func (h *Handler) AccountListings(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/accounts/")
+	key, ok := strings.CutSuffix(strings.TrimSuffix(rest, "/"), "/listings")
+	if !ok || key == "" || strings.Contains(key, "/") {
+		errorResponse(w, http.StatusNotFound, "Not found")
+		return
+	}
 
-		// Queue remaining pages (2 to totalPages)
-		for p := 2; p <= totalPages; p++ {
-			pageChan <- p
-		}
-		close(pageChan)
-
-		// Wait for all workers to finish, then close results channel
-		go func() {
-			wg.Wait()
-			close(resultChan)
-		}()
+	query, ok := h.parseListingsQuery(w, r)
+	if !ok {
+		return
+	}
+	query.Page, query.PageSize = parsePage(r, 50, 100)
 
-		// Collect results into a map (to preserve order)
-		pageResults := make(map[int][]map[string]interface{})
-		for result := range resultChan {
-			if result.err != nil {
-				log.Printf("[CACHE-ERROR] Page %d failed: %v", result.pageNum, result.err)
-				continue // Skip failed pages rather than failing entirely
-			}
-			log.Printf("[CACHE] Page %d: got %d items", result.pageNum, len(result.items))
-			pageResults[result.pageNum] = convertItems(result.items)
-		}
+	account, err := h.db.GetAccountByKey(key)
+	if err != nil {
+		log.Printf("GetAccountByKey error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if account == nil {
+		errorResponse(w, http.StatusNotFound, "Account not found")
+		return
+	}
 
-		// Append results in order (page 2, 3, 4, ...)
-		for p := 2; p <= totalPages; p++ {
-			if offers, ok := pageResults[p]; ok {
-				allOffers = append(allOffers, offers...)
-			}
-		}
+	result, err := h.db.GetAccountListings(account.ID, query, h.calculator())
+	if err != nil {
+		log.Printf("GetAccountListings error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	elapsed := time.Since(startTime)
-	log.Printf("[CACHE] Fetched %d listings in %v (concurrent mode)", len(allOffers), elapsed.Round(time.Millisecond))
+	jsonResponse(w, http.StatusOK, result)
+}
 
-	// Update cache
-	h.listingsMutex.Lock()
-	h.listingsCache = allOffers
-	h.listingsCacheTime = time.Now()
-	h.listingsMutex.Unlock()
+// setAccountDeleted applies a soft delete or reactivation and responds with
+// the updated account. Repeating either is harmless.
+func (h *Handler) setAccountDeleted(w http.ResponseWriter, key string, deleted bool) {
+	account, err := h.db.GetAccountByKey(key)
+	if err != nil {
+		log.Printf("GetAccountByKey error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if account == nil {
+		errorResponse(w, http.StatusNotFound, "Account not found")
+		return
+	}
 
-	log.Printf("[CACHE] Cached %d listings", len(allOffers))
+	if err := h.db.SetAccountDeleted(key, deleted); err != nil {
+		log.Printf("SetAccountDeleted error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
-	// Return paginated results
-	total := len(allOffers)
-	end := offset + limit
-	if end > total {
-		end = total
+	account, err = h.db.GetAccountByKey(key)
+	if err != nil {
+		log.Printf("GetAccountByKey error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
 	}
-	var offers []map[string]interface{}
-	if offset < total {
-		offers = allOffers[offset:end]
+	jsonResponse(w, http.StatusOK, account)
+}
+
+// GetAuthURL returns the OAuth authorization URL
+func (h *Handler) GetAuthURL(w http.ResponseWriter, r *http.Request) {
+	// States live in the database so the callback can be served by any instance
+	state := generateState()
+	if err := h.db.CreateOAuthState(state, oauthStateTTL); err != nil {
+		log.Printf("Failed to store OAuth state: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to start authentication")
+		return
 	}
 
-	jsonResponse(w, http.StatusOK, map[string]interface{}{
-		"offers": offers,
-		"total":  total,
-		"limit":  limit,
-		"offset": offset,
-		"cached": false,
-	})
+	client := ebay.NewClient(h.ebayConfig)
+	url := client.GetAuthURL(state)
+	jsonResponse(w, http.StatusOK, map[string]string{"url": url})
 }
 
-// GetEnrichedData returns enriched item data, fetching on-demand using session-based OAuth
-// This implements request-based enrichment with parallel fetching for better performance
-func (h *Handler) GetEnrichedData(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		errorResponse(w, http.StatusMethodNotAllowed, "GET required")
+// OAuthCallback handles the OAuth callback
+func (h *Handler) OAuthCallback(w http.ResponseWriter, r *http.Request) {
+	code := r.URL.Query().Get("code")
+	state := r.URL.Query().Get("state")
+	errParam := r.URL.Query().Get("error")
+	errDesc := r.URL.Query().Get("error_description")
+
+	log.Printf("OAuth callback received - code: %v, state: %s, error: %s", code != "", state, errParam)
+
+	if errParam != "" {
+		log.Printf("OAuth error from eBay: %s - %s", errParam, errDesc)
+		http.Error(w, "eBay OAuth error: "+errDesc, http.StatusBadRequest)
 		return
 	}
 
-	// Parse itemIds from query parameters
-	// Frontend sends: ?itemIds=id1,id2,id3
-	itemIDsParam := r.URL.Query().Get("itemIds")
-	if itemIDsParam == "" {
-		errorResponse(w, http.StatusBadRequest, "No itemIds provided")
+	// One-time use: a valid state is deleted as it's checked
+	valid, err := h.db.ConsumeOAuthState(state)
+	if err != nil {
+		log.Printf("Failed to check OAuth state: %v", err)
+		http.Error(w, "Failed to validate state", http.StatusInternalServerError)
 		return
 	}
 
-	// Split comma-separated item IDs
-	var itemIDs []string
-	for _, id := range strings.Split(itemIDsParam, ",") {
-		trimmed := strings.TrimSpace(id)
-		if trimmed != "" {
-			itemIDs = append(itemIDs, trimmed)
-		}
+	if h.ebayConfig.DebugOAuth {
+		log.Printf("[OAUTH-DEBUG] State check - received: %s, valid: %v", state, valid)
 	}
 
-	if len(itemIDs) == 0 {
-		errorResponse(w, http.StatusBadRequest, "No valid itemIds provided")
+	if !valid {
+		log.Printf("State mismatch! (unknown, expired or already used)")
+		http.Error(w, "Invalid state parameter", http.StatusBadRequest)
 		return
 	}
 
-	// Get eBay client using session-based auth (same as listings)
-	client, err := h.getEbayClient(r)
+	if code == "" {
+		log.Printf("Missing authorization code")
+		http.Error(w, "Missing authorization code", http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Exchanging code for token...")
+	client := ebay.NewClient(h.ebayConfig)
+	if err := client.ExchangeCode(r.Context(), code); err != nil {
+		log.Printf("OAuth exchange error: %v", err)
+		http.Error(w, "Failed to authenticate: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Get the token from the client and save it to session
+	token := client.GetToken()
+	if token == nil {
+		log.Printf("ERROR: Token is nil after exchange")
+		http.Error(w, "Failed to obtain token", http.StatusInternalServerError)
+		return
+	}
+
+	// Save token to session
+	if err := h.saveTokenToSession(w, r, token); err != nil {
+		log.Printf("Failed to save token to session: %v", err)
+		http.Error(w, "Failed to save authentication", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("OAuth success! Token obtained and saved to session.")
+
+	// Fetch eBay username using Commerce Identity API with retry logic
+	// No useless fallbacks - if this fails, we show a proper error to the user
+	user, err := client.GetUserWithRetry(r.Context(), getUserAttempts)
 	if err != nil {
-		errorResponse(w, http.StatusInternalServerError, "Session error")
+		log.Printf("ERROR: Failed to fetch eBay user info: %v", err)
+		http.Error(w, "Unable to connect to eBay to verify your account. Please try again later.", http.StatusServiceUnavailable)
 		return
 	}
+	username, userID := user.Username, user.UserID
+	log.Printf("SUCCESS: Authenticated as eBay user: %s (ID: %s)", username, userID)
 
-	if !client.IsAuthenticated() {
-		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
+	// Use a unique identifier based on the actual eBay user ID
+	accountKey := fmt.Sprintf("%s_%s", userID, h.environment)
+
+	// Create or update account with real eBay username
+	account, err := h.db.GetOrCreateAccountFromEbay(accountKey, username, h.environment, h.marketplaceID)
+	if err != nil {
+		log.Printf("ERROR: Failed to create/update account: %v", err)
+		http.Error(w, "Unable to create account. Please try again.", http.StatusInternalServerError)
 		return
 	}
 
-	// Prepare result map with mutex for concurrent writes
-	result := make(map[string]EnrichedItemData)
-	var resultMutex sync.Mutex
+	h.mu.Lock()
+	h.currentAccount = account
+	h.mu.Unlock()
+	log.Printf("SUCCESS: Account created/updated: %s (AccountKey: %s)", account.DisplayName, account.AccountKey)
 
-	// Separate items into cached and to-fetch
-	var toFetch []string
-	for _, itemID := range itemIDs {
-		h.enrichmentMutex.RLock()
-		cachedData, exists := h.enrichmentCache[itemID]
-		h.enrichmentMutex.RUnlock()
-
-		if exists && cachedData != nil {
-			resultMutex.Lock()
-			result[itemID] = *cachedData
-			resultMutex.Unlock()
-			log.Printf("[ENRICHMENT] Using cached data for item %s", itemID)
-		} else {
-			toFetch = append(toFetch, itemID)
-		}
+	if err := h.saveAccountToSession(w, r, account.AccountKey); err != nil {
+		log.Printf("Failed to save account to session: %v", err)
 	}
 
-	// Fetch uncached items in parallel (limit concurrency to 30)
-	// eBay Trading API rate limits are typically 5000 calls/day for production
-	// Each item = 1-2 API calls (Trading API + potential Browse API fallback)
-	if len(toFetch) > 0 {
-		const maxConcurrent = 30
-		sem := make(chan struct{}, maxConcurrent)
-		var wg sync.WaitGroup
-
-		log.Printf("[ENRICHMENT] Fetching %d items in parallel (max %d concurrent)", len(toFetch), maxConcurrent)
-
-		for _, itemID := range toFetch {
-			wg.Add(1)
-			sem <- struct{}{} // Acquire semaphore
-
-			go func(id string) {
-				defer wg.Done()
-				defer func() { <-sem }() // Release semaphore
-
-				// Retry with exponential backoff
-				var enrichedData *EnrichedItemData
-				maxRetries := 3
-				for attempt := 1; attempt <= maxRetries; attempt++ {
-					log.Printf("[ENRICHMENT] Fetching item %s (attempt %d/%d)", id, attempt, maxRetries)
-					ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
-					brand, shippingCost, shippingCurrency, coo, images, err := client.GetItem(ctx, id)
-					cancel()
-
-					if err == nil {
-						enrichedData = &EnrichedItemData{
-							ItemID:           id,
-							Brand:            brand,
-							CountryOfOrigin:  coo,
-							ShippingCost:     shippingCost,
-							ShippingCurrency: shippingCurrency,
-							Images:           images,
-							EnrichedAt:       time.Now(),
-						}
-						log.Printf("[ENRICHMENT] Successfully enriched item %s (Brand: %s, COO: %s, Images: %d)",
-							id, brand, coo, len(images))
-						break
-					}
-
-					// Check for rate limiting (HTTP 429) or server errors (5xx)
-					errMsg := err.Error()
-					isRetryable := strings.Contains(errMsg, "429") ||
-						strings.Contains(errMsg, "500") ||
-						strings.Contains(errMsg, "502") ||
-						strings.Contains(errMsg, "503") ||
-						strings.Contains(errMsg, "timeout")
-
-					if !isRetryable || attempt == maxRetries {
-						log.Printf("[ENRICHMENT] Failed to fetch item %s after %d attempts: %v", id, attempt, err)
-						enrichedData = &EnrichedItemData{
-							ItemID:     id,
-							EnrichedAt: time.Now(),
-						}
-						break
-					}
-
-					// Exponential backoff: 1s, 2s, 4s
-					backoff := time.Duration(1<<(attempt-1)) * time.Second
-					log.Printf("[ENRICHMENT] Retrying item %s in %v...", id, backoff)
-					time.Sleep(backoff)
-				}
+	// Redirect to the main app
+	http.Redirect(w, r, "/?auth=success", http.StatusFound)
+}
 
-				// Cache the result
-				h.enrichmentMutex.Lock()
-				h.enrichmentCache[id] = enrichedData
-				h.enrichmentMutex.Unlock()
+// GetAuthStatus returns current auth status
+func (h *Handler) GetAuthStatus(w http.ResponseWriter, r *http.Request) {
+	client, err := h.getEbayClient(r)
+	authenticated := false
+	if err == nil {
+		authenticated = client.IsAuthenticated()
+	} else {
+	}
 
-				// Add to result
-				resultMutex.Lock()
-				result[id] = *enrichedData
-				resultMutex.Unlock()
-			}(itemID)
-		}
+	configured := h.ebayConfig.ClientID != ""
 
-		wg.Wait()
-		log.Printf("[ENRICHMENT] Completed fetching %d items", len(toFetch))
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"authenticated": authenticated,
+		"configured":    configured,
+	})
+}
+
+// RefreshAuth exchanges the session's refresh token for a new access token
+// and saves it, so a session close to expiry can carry on without logging in
+// again. Returns 401 when the session has no refresh token or eBay rejects it.
+func (h *Handler) RefreshAuth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
+		return
 	}
 
-	jsonResponse(w, http.StatusOK, result)
+	client, err := h.getEbayClient(r)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Session error")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+	err = client.RefreshToken(ctx)
+	var retrieveErr *oauth2.RetrieveError
+	switch {
+	case errors.Is(err, ebay.ErrNoRefreshToken):
+		errorResponse(w, http.StatusUnauthorized, "No refresh token in session; log in again")
+		return
+	case errors.As(err, &retrieveErr) && retrieveErr.Response != nil && retrieveErr.Response.StatusCode < 500:
+		log.Printf("Token refresh rejected: %v", err)
+		errorResponse(w, http.StatusUnauthorized, "eBay rejected the refresh token; log in again")
+		return
+	case err != nil:
+		log.Printf("Token refresh error: %v", err)
+		errorResponse(w, http.StatusBadGateway, "Failed to refresh token")
+		return
+	}
+
+	token := client.GetToken()
+	if err := h.saveTokenToSession(w, r, token); err != nil {
+		log.Printf("Failed to save refreshed token to session: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to save refreshed token")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"expiresAt": token.Expiry,
+	})
 }
 
-// GetFulfillmentPolicies returns shipping policies
-func (h *Handler) GetFulfillmentPolicies(w http.ResponseWriter, r *http.Request) {
+// Logout clears the session and logs the user out
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	// Drop this account's cached listings along with the session
+	if accountKey := h.sessionAccountKey(r); accountKey != "" {
+		h.invalidateListings(accountKey)
+	}
+
+	if err := h.clearSession(w, r); err != nil {
+		log.Printf("Failed to clear session: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to logout")
+		return
+	}
+
+	// Also clear currentAccount on logout
+	h.mu.Lock()
+	h.currentAccount = nil
+	h.mu.Unlock()
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// GetInventoryItems returns paginated inventory items
+func (h *Handler) GetInventoryItems(w http.ResponseWriter, r *http.Request) {
 	client, err := h.getEbayClient(r)
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "Session error")
@@ -924,382 +1486,2213 @@ func (h *Handler) GetFulfillmentPolicies(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	marketplaceID := r.URL.Query().Get("marketplace_id")
-	if marketplaceID == "" {
-		marketplaceID = "EBAY_AU" // Default to eBay Australia
-	}
+	limit, offset := parsePagination(r, 25, 100)
 
-	policies, err := client.GetFulfillmentPolicies(r.Context(), marketplaceID)
+	items, err := client.GetInventoryItems(r.Context(), limit, offset)
 	if err != nil {
-		log.Printf("GetFulfillmentPolicies error: %v", err)
+		log.Printf("GetInventoryItems error: %v", err)
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	jsonResponse(w, http.StatusOK, policies)
+	jsonResponse(w, http.StatusOK, items)
 }
 
-// CalculateRequest is the request body for calculate endpoint
-type CalculateRequest struct {
-	ItemValueAUD      float64 `json:"itemValueAUD"`
-	WeightBand        string  `json:"weightBand"`
-	BrandName         string  `json:"brandName"`
-	CountryOfOrigin   string  `json:"countryOfOrigin,omitempty"`
-	IncludeExtraCover bool    `json:"includeExtraCover"`
-	DiscountBand      int     `json:"discountBand"`
-}
+// GetInventoryItem returns the inventory item for one SKU from eBay:
+// /api/inventory/:sku
+func (h *Handler) GetInventoryItem(w http.ResponseWriter, r *http.Request) {
+	client, err := h.getEbayClient(r)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Session error")
+		return
+	}
 
-// CalculateShipping calculates shipping costs
-func (h *Handler) CalculateShipping(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
+	if !client.IsAuthenticated() {
+		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
 		return
 	}
 
-	var req CalculateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+	sku := strings.TrimPrefix(r.URL.Path, "/api/inventory/")
+	if strings.TrimSpace(sku) == "" {
+		errorResponse(w, http.StatusBadRequest, "SKU required")
 		return
 	}
 
-	result, err := h.calcConfig.CalculateUSAShipping(calculator.CalculateUSAShippingParams{
-		ItemValueAUD:      req.ItemValueAUD,
-		WeightBand:        req.WeightBand,
-		BrandName:         req.BrandName,
-		CountryOfOrigin:   req.CountryOfOrigin,
-		IncludeExtraCover: req.IncludeExtraCover,
-		DiscountBand:      req.DiscountBand,
-	})
+	item, err := client.GetInventoryItem(r.Context(), sku)
+	if ebay.IsNotFound(err) {
+		errorResponse(w, http.StatusNotFound, "No inventory item for SKU "+sku)
+		return
+	}
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, err.Error())
+		log.Printf("GetInventoryItem error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, item)
+}
+
+// GetOfferShippingOverrides lists exported offers with custom domestic or
+// international postage, for every account or just ?account=:key
+func (h *Handler) GetOfferShippingOverrides(w http.ResponseWriter, r *http.Request) {
+	var accountID int64
+	if key := r.URL.Query().Get("account"); key != "" {
+		account, err := h.db.GetAccountByKey(key)
+		if err != nil {
+			log.Printf("GetAccountByKey error: %v", err)
+			errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if account == nil {
+			errorResponse(w, http.StatusNotFound, "Account not found: "+key)
+			return
+		}
+		accountID = account.ID
+	}
+
+	overrides, err := h.db.GetOfferShippingOverrides(accountID)
+	if err != nil {
+		log.Printf("GetOfferShippingOverrides error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"offers": overrides,
+		"total":  len(overrides),
+	})
+}
+
+// GetOrders returns the seller's recent orders from the Fulfillment API.
+// ?filter= is passed through to eBay (e.g. creationdate:[2024-01-01T00:00:00.000Z..]).
+func (h *Handler) GetOrders(w http.ResponseWriter, r *http.Request) {
+	client, err := h.getEbayClient(r)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Session error")
+		return
+	}
+
+	if !client.IsAuthenticated() {
+		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
+		return
+	}
+
+	limit, offset := parsePagination(r, 50, 200)
+
+	orders, err := client.GetOrders(r.Context(), r.URL.Query().Get("filter"), limit, offset)
+	if err != nil {
+		log.Printf("GetOrders error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, orders)
+}
+
+// OrderReconciliation compares the postage a buyer paid with what the
+// calculator says it should have cost. Amounts are in AUD.
+type OrderReconciliation struct {
+	OrderID            string  `json:"orderId"`
+	CreationDate       string  `json:"creationDate"`
+	BuyerCountry       string  `json:"buyerCountry"`
+	ZoneID             string  `json:"zoneId,omitempty"`
+	PaidShipping       float64 `json:"paidShipping"`
+	CalculatedShipping float64 `json:"calculatedShipping"`
+	Diff               float64 `json:"diff"`   // PaidShipping - CalculatedShipping
+	Status             string  `json:"status"` // See reconcileStatus* constants
+
+	// Line items with no enriched data (by item ID or SKU), left out of
+	// CalculatedShipping
+	UnmatchedItems []string `json:"unmatchedItems,omitempty"`
+
+	// Currencies of the order's amounts that have no AUD rate; those amounts
+	// count as 0
+	MissingRates []string `json:"missingRates,omitempty"`
+}
+
+// Reconciliation statuses
+const (
+	reconcileStatusOK           = "ok"           // Paid at least the calculated postage
+	reconcileStatusUndercharged = "undercharged" // Paid less than the calculated postage
+	reconcileStatusUnmatched    = "unmatched"    // No line item could be matched to enriched data
+	reconcileStatusPartial      = "partial"      // Some line items unmatched, so CalculatedShipping is too low to judge
+	reconcileStatusUnknownZone  = "unknown_zone" // Domestic, or no postal zone for the buyer's country
+	reconcileStatusNoRate       = "no_rate"      // An amount's currency has no AUD rate (see MissingRates)
+)
+
+// reconcileEnrichmentMaxAgeDays is how old enrichment used for reconciling
+// may be. Brand and COO rarely change, so this is far past the listings TTL.
+const reconcileEnrichmentMaxAgeDays = 365
+
+// ReconcileOrders compares paid with calculated postage for recent orders:
+// GET /api/reconcile, paged and filtered like GetOrders
+func (h *Handler) ReconcileOrders(w http.ResponseWriter, r *http.Request) {
+	client, err := h.getEbayClient(r)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Session error")
+		return
+	}
+
+	if !client.IsAuthenticated() {
+		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
+		return
+	}
+
+	limit, offset := parsePagination(r, 50, 200)
+
+	orders, err := client.GetOrders(r.Context(), r.URL.Query().Get("filter"), limit, offset)
+	if err != nil {
+		log.Printf("ReconcileOrders error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Load enrichment for every line item up front
+	var itemIDs []string
+	for _, order := range orders.Orders {
+		for _, line := range order.LineItems {
+			if line.LegacyItemID != "" {
+				itemIDs = append(itemIDs, line.LegacyItemID)
+			}
+		}
+	}
+	enriched, err := h.db.GetEnrichedItemsBatch(itemIDs, reconcileEnrichmentMaxAgeDays)
+	if err != nil {
+		log.Printf("ReconcileOrders error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	weights, err := h.itemWeights()
+	if err != nil {
+		log.Printf("ReconcileOrders error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to load item weights")
+		return
+	}
+
+	calc := h.calculator()
+	results := make([]OrderReconciliation, 0, len(orders.Orders))
+	summary := map[string]int{}
+	for i := range orders.Orders {
+		result := h.reconcileOrder(calc, &orders.Orders[i], enriched, weights)
+		summary[result.Status]++
+		results = append(results, result)
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"orders":  results,
+		"summary": summary,
+		"total":   orders.Total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// reconcileOrder calculates postage for each line item in the buyer's zone
+// with the same assumptions as BatchCalculate (the band of the item's stored
+// weight, else one guessed from the brand; the default discount band; extra
+// cover over $100), one parcel per unit. weights holds the stored item weights
+// in grams, by item ID.
+func (h *Handler) reconcileOrder(calc *calculator.CalculatorConfig, order *ebay.Order, enriched map[string]*database.EnrichedItem, weights map[string]int) OrderReconciliation {
+	result := OrderReconciliation{
+		OrderID:      order.OrderID,
+		CreationDate: order.CreationDate,
+		BuyerCountry: order.BuyerCountry(),
+	}
+
+	// Postage paid for the whole order, falling back to the line items' share
+	if order.PricingSummary != nil && order.PricingSummary.DeliveryCost != nil {
+		result.PaidShipping = result.amountAUD(calc, order.PricingSummary.DeliveryCost)
+	} else {
+		for _, line := range order.LineItems {
+			if line.DeliveryCost != nil && line.DeliveryCost.ShippingCost != nil {
+				result.PaidShipping += result.amountAUD(calc, line.DeliveryCost.ShippingCost)
+			}
+		}
+	}
+	result.PaidShipping = math.Round(result.PaidShipping*100) / 100
+
+	zoneID, ok := calculator.ZoneForCountry(result.BuyerCountry)
+	if !ok {
+		result.Status = reconcileStatusUnknownZone
+		return result
+	}
+	result.ZoneID = zoneID
+
+	// Duties are charged at the tariff rates in effect when the order was placed
+	orderDate, err := time.Parse(time.RFC3339, order.CreationDate)
+	if err != nil {
+		orderDate = time.Now().UTC()
+	}
+
+	matched := 0
+	for _, line := range order.LineItems {
+		item := enriched[line.LegacyItemID]
+		if item == nil && line.SKU != "" {
+			if bySKU, err := h.db.GetEnrichedItemsBySKU(line.SKU); err != nil {
+				log.Printf("[RECONCILE] SKU lookup failed for %s: %v", line.SKU, err)
+			} else if len(bySKU) > 0 {
+				item = &bySKU[0]
+			}
+		}
+		if item == nil {
+			result.UnmatchedItems = append(result.UnmatchedItems, line.LegacyItemID)
+			continue
+		}
+
+		quantity := line.Quantity
+		if quantity < 1 {
+			quantity = 1
+		}
+		var unitValue float64
+		if line.LineItemCost != nil {
+			unitValue = result.amountAUD(calc, line.LineItemCost) / float64(quantity)
+		}
+
+		coo, _ := calc.ResolveCOO(item.Brand, item.CountryOfOrigin)
+		tariffRate, err := h.db.GetTariffRateAsOf(calc.TariffCountry(coo), orderDate)
+		if err != nil {
+			log.Printf("[RECONCILE] Tariff lookup failed for %s (order %s): %v", coo, order.OrderID, err)
+			tariffRate = calc.GetTariffRate(coo)
+		}
+
+		weightBand, _ := calc.ItemWeightBand(item.Brand, weights[item.ItemID])
+		zones, err := calc.CalculateAllZones(calculator.CalculateAllZonesParams{
+			ItemValueAUD:      unitValue,
+			WeightBand:        weightBand,
+			BrandName:         item.Brand,
+			CountryOfOrigin:   item.CountryOfOrigin,
+			IncludeExtraCover: unitValue > 100,
+			DiscountBand:      calc.FallbackDiscountBand,
+
+			TariffRateOverride: &tariffRate,
+		})
+		if err != nil {
+			log.Printf("[RECONCILE] Error calculating item %s (order %s): %v", line.LegacyItemID, order.OrderID, err)
+			result.UnmatchedItems = append(result.UnmatchedItems, line.LegacyItemID)
+			continue
+		}
+		zone := zones.Zone(zoneID)
+		if zone == nil {
+			result.Status = reconcileStatusUnknownZone // Zone has no rates configured
+			return result
+		}
+		result.CalculatedShipping += zone.Total * float64(quantity)
+		matched++
+	}
+
+	result.CalculatedShipping = math.Round(result.CalculatedShipping*100) / 100
+	result.Diff = math.Round((result.PaidShipping-result.CalculatedShipping)*100) / 100
+
+	switch {
+	case len(result.MissingRates) > 0:
+		result.Status = reconcileStatusNoRate
+	case matched == 0:
+		result.Status = reconcileStatusUnmatched
+	case len(result.UnmatchedItems) > 0:
+		result.Status = reconcileStatusPartial
+	case result.PaidShipping < result.CalculatedShipping:
+		result.Status = reconcileStatusUndercharged
+	default:
+		result.Status = reconcileStatusOK
+	}
+	return result
+}
+
+// amountAUD parses an eBay amount and converts it to AUD. An amount in a
+// currency with no rate counts as 0 and its currency is added to
+// r.MissingRates.
+func (r *OrderReconciliation) amountAUD(calc *calculator.CalculatorConfig, amount *ebay.Amount) float64 {
+	var value float64
+	fmt.Sscanf(amount.Value, "%f", &value)
+	aud, converted := calc.ToAUD(value, amount.Currency)
+	if !converted {
+		log.Printf("[RECONCILE] No AUD rate for %s (order %s)", amount.Currency, r.OrderID)
+		currency := calculator.NormalizeCurrency(amount.Currency)
+		if !slices.Contains(r.MissingRates, currency) {
+			r.MissingRates = append(r.MissingRates, currency)
+		}
+	}
+	return aud
+}
+
+// GetOffers returns paginated offers
+// This endpoint uses the Trading API to fetch traditional eBay listings
+func (h *Handler) GetOffers(w http.ResponseWriter, r *http.Request) {
+	client, err := h.getEbayClient(r)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Session error")
+		return
+	}
+
+	if !client.IsAuthenticated() {
+		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
+		return
+	}
+
+	limit, offset := parsePagination(r, 50, 100)
+	forceRefresh := r.URL.Query().Get("force") == "true"
+
+	// Listings are cached per account and marketplace, using the account this
+	// session authenticated as (not h.currentAccount, which is shared by every
+	// session). Without one there's nothing safe to key on, so don't cache.
+	accountKey := h.sessionAccountKey(r)
+	var cacheKey string
+	var cached *listingsCacheEntry
+	if accountKey != "" {
+		cacheKey = listingsCacheKey(accountKey, h.marketplaceID)
+		cached = h.cachedListings(cacheKey)
+	}
+
+	// Cache TTL: 8 hours (only Refresh button or server restart triggers re-fetch)
+	const cacheTTL = 8 * time.Hour
+
+	var cacheAge time.Duration
+	if cached != nil {
+		cacheAge = time.Since(cached.cachedAt)
+	}
+
+	// Use cache if available, not forcing, and cache is within TTL
+	if cached != nil && len(cached.offers) > 0 && !forceRefresh && cacheAge < cacheTTL {
+		log.Printf("[CACHE] Returning cached listings for %s (age: %v, total: %d)", cacheKey, cacheAge.Round(time.Second), len(cached.offers))
+		writeListingsPage(w, r, cacheKey, cached, limit, offset, true)
+		return
+	}
+
+	// Need to fetch from eBay - fetch ALL listings CONCURRENTLY and cache them
+	log.Printf("[CACHE] Fetching all listings from eBay CONCURRENTLY (force=%v, cacheAge=%v)", forceRefresh, cacheAge.Round(time.Second))
+
+	startTime := time.Now()
+	pageSize := 100 // Max allowed by Trading API
+
+	// First, fetch page 1 to get total count
+	log.Printf("[CACHE] Fetching page 1 to get total count...")
+	firstPageItems, totalItems, err := client.GetMyeBaySelling(r.Context(), 1, pageSize)
+	if err != nil {
+		log.Printf("GetMyeBaySelling error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to fetch listings: "+err.Error())
+		return
+	}
+
+	totalPages := (totalItems + pageSize - 1) / pageSize
+	log.Printf("[CACHE] Total items: %d, pages: %d", totalItems, totalPages)
+
+	// Convert first page items
+	convertItems := func(items []ebay.TradingItem) []map[string]interface{} {
+		offers := make([]map[string]interface{}, 0, len(items))
+		for _, item := range items {
+			offer := map[string]interface{}{
+				"offerId":      item.ItemID,
+				"sku":          item.SKU,
+				"title":        item.Title,
+				"quantity":     item.Quantity,
+				"quantitySold": item.QuantitySold,
+				"pricingSummary": map[string]interface{}{
+					"price": map[string]interface{}{
+						"value":    item.Price,
+						"currency": item.Currency,
+					},
+				},
+			}
+			if item.ImageURL != "" {
+				offer["image"] = map[string]interface{}{
+					"imageUrl": item.ImageURL,
+				}
+			}
+			if item.Brand != "" {
+				offer["brand"] = item.Brand
+			}
+			if item.ShippingCost != "" {
+				offer["shippingCost"] = map[string]interface{}{
+					"value":    item.ShippingCost,
+					"currency": item.ShippingCurrency,
+				}
+			}
+			offers = append(offers, offer)
+		}
+		return offers
+	}
+
+	// Start with first page results
+	allOffers := convertItems(firstPageItems)
+	failedPages := []int{}
+
+	// If more pages, fetch them concurrently
+	if totalPages > 1 {
+		var pages map[int][]ebay.TradingItem
+		pages, failedPages = fetchListingPages(r.Context(), totalPages, h.listingsFetchConcurrency(), func(pageNum int) ([]ebay.TradingItem, error) {
+			items, _, err := client.GetMyeBaySelling(r.Context(), pageNum, pageSize)
+			return items, err
+		})
+
+		// Nobody is waiting for an incomplete load, so don't cache it
+		if err := r.Context().Err(); err != nil {
+			log.Printf("[CACHE] Listings fetch for %s abandoned after %v: %v", cacheKey, time.Since(startTime).Round(time.Millisecond), err)
+			return
+		}
+
+		// Append results in order (page 2, 3, 4, ...)
+		for p := 2; p <= totalPages; p++ {
+			if items, ok := pages[p]; ok {
+				allOffers = append(allOffers, convertItems(items)...)
+			}
+		}
+	}
+
+	elapsed := time.Since(startTime)
+	log.Printf("[CACHE] Fetched %d listings in %v (concurrent mode)", len(allOffers), elapsed.Round(time.Millisecond))
+	if len(failedPages) > 0 {
+		log.Printf("WARNING: [CACHE] Listings for %s are incomplete - pages %v of %d failed", cacheKey, failedPages, totalPages)
+	}
+
+	// Record SKUs on already-enriched items so /api/item/by-sku can find them
+	skus := make(map[string]string, len(allOffers))
+	for _, offer := range allOffers {
+		itemID, _ := offer["offerId"].(string)
+		sku, _ := offer["sku"].(string)
+		skus[itemID] = sku
+	}
+	if n, err := h.db.SetEnrichedItemSKUs(skus); err != nil {
+		log.Printf("[CACHE] Failed to record listing SKUs: %v", err)
+	} else if n > 0 {
+		log.Printf("[CACHE] Recorded SKUs for %d enriched items", n)
+	}
+
+	// Likewise quantities, so GET /api/listings can sort by units sold
+	quantities := make(map[string]database.ListingQuantity, len(allOffers))
+	for _, offer := range allOffers {
+		itemID, _ := offer["offerId"].(string)
+		quantity, _ := offer["quantity"].(int)
+		sold, _ := offer["quantitySold"].(int)
+		quantities[itemID] = database.ListingQuantity{Quantity: quantity, QuantitySold: sold}
+	}
+	if n, err := h.db.SetEnrichedItemQuantities(quantities); err != nil {
+		log.Printf("[CACHE] Failed to record listing quantities: %v", err)
+	} else if n > 0 {
+		log.Printf("[CACHE] Recorded quantities for %d enriched items", n)
+	}
+
+	entry := &listingsCacheEntry{accountKey: accountKey, offers: allOffers, failedPages: failedPages, cachedAt: time.Now()}
+
+	// Update cache. An incomplete load isn't cached, so the next request
+	// retries the failed pages instead of serving the gap for the whole TTL.
+	if accountKey != "" && len(failedPages) == 0 {
+		h.listingsMutex.Lock()
+		h.listingsCache[cacheKey] = entry
+		h.listingsMutex.Unlock()
+		log.Printf("[CACHE] Cached %d listings for %s", len(allOffers), cacheKey)
+	}
+
+	// Return paginated results
+	writeListingsPage(w, r, cacheKey, entry, limit, offset, false)
+}
+
+// Bounds of the listings_fetch_concurrency setting
+const (
+	defaultListingsFetchConcurrency = 5
+	maxListingsFetchConcurrency     = 10
+)
+
+// listingsFetchConcurrency returns the listings_fetch_concurrency setting,
+// clamped to 1-maxListingsFetchConcurrency
+func (h *Handler) listingsFetchConcurrency() int {
+	workers, err := h.db.GetSettingInt("listings_fetch_concurrency", defaultListingsFetchConcurrency)
+	if err != nil {
+		log.Printf("Failed to read listings_fetch_concurrency setting: %v - using %d", err, defaultListingsFetchConcurrency)
+		workers = defaultListingsFetchConcurrency
+	}
+	return min(max(workers, 1), maxListingsFetchConcurrency)
+}
+
+// fetchListingPages fetches pages 2 to totalPages with at most workers
+// fetches in flight, returning the items by page number. Failed pages are
+// logged and returned in failed, in order, rather than failing the whole load.
+// Once ctx is done no more pages are requested; it returns as soon as the
+// fetches already in flight finish, without the pages never requested.
+func fetchListingPages(ctx context.Context, totalPages, workers int, fetch func(pageNum int) ([]ebay.TradingItem, error)) (pages map[int][]ebay.TradingItem, failed []int) {
+	type pageResult struct {
+		pageNum int
+		items   []ebay.TradingItem
+		err     error
+	}
+
+	// Channel for page numbers to fetch
+	pageChan := make(chan int, totalPages-1)
+	// Channel for results
+	resultChan := make(chan pageResult, totalPages-1)
+
+	// Start worker goroutines
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			for pageNum := range pageChan {
+				// Stop early if the client has gone away; eBay calls count against the daily budget
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("[CACHE-WORKER-%d] Fetching page %d...", workerID, pageNum)
+				items, err := fetch(pageNum)
+				resultChan <- pageResult{pageNum: pageNum, items: items, err: err}
+			}
+		}(i)
+	}
+
+	// Queue remaining pages (2 to totalPages)
+	for p := 2; p <= totalPages; p++ {
+		pageChan <- p
+	}
+	close(pageChan)
+
+	// Wait for all workers to finish, then close results channel
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	// Collect results into a map (to preserve order)
+	pages = make(map[int][]ebay.TradingItem)
+	failed = []int{}
+	for result := range resultChan {
+		if result.err != nil {
+			log.Printf("[CACHE-ERROR] Page %d failed: %v", result.pageNum, result.err)
+			failed = append(failed, result.pageNum)
+			continue // Skip failed pages rather than failing entirely
+		}
+		log.Printf("[CACHE] Page %d: got %d items", result.pageNum, len(result.items))
+		pages[result.pageNum] = result.items
+	}
+	sort.Ints(failed)
+	return pages, failed
+}
+
+// GetEnrichedData returns enriched item data, fetching on-demand using session-based OAuth
+// This implements request-based enrichment with parallel fetching for better performance
+func (h *Handler) GetEnrichedData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "GET required")
+		return
+	}
+
+	// Parse itemIds from query parameters
+	// Frontend sends: ?itemIds=id1,id2,id3
+	// Missing, blank and comma-only values (e.g. ",,,") are all the same error
+	itemIDs := cleanItemIDs(strings.Split(r.URL.Query().Get("itemIds"), ","))
+	if len(itemIDs) == 0 {
+		missingItemIDsResponse(w)
+		return
+	}
+
+	// Get eBay client using session-based auth (same as listings)
+	client, err := h.getEbayClient(r)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Session error")
+		return
+	}
+
+	if !client.IsAuthenticated() {
+		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
+		return
+	}
+
+	// Items fetched with this session's token are cached for its account
+	// only. Without one there's nothing safe to key on, so don't cache.
+	accountKey := h.sessionAccountKey(r)
+	cacheFetched := func(data *EnrichedItemData) {
+		if accountKey != "" {
+			h.cacheEnrichment(accountKey, data)
+		}
+	}
+
+	result := make(map[string]EnrichedItemData)
+
+	// Separate items into cached and to-fetch
+	var toFetch []string
+	for _, itemID := range itemIDs {
+		if cachedData, exists := h.cachedEnrichment(accountKey, itemID); exists {
+			result[itemID] = cachedData.withSource(database.SourceMemoryCache)
+			log.Printf("[ENRICHMENT] Using cached data for item %s", itemID)
+		} else {
+			toFetch = append(toFetch, itemID)
+		}
+	}
+
+	if len(toFetch) > 0 && !h.bulkCallsAllowed(w) {
+		return
+	}
+
+	// Fetch uncached items in parallel; GetItems bounds concurrency and retries
+	// rate limiting/server errors so one bad item doesn't sink the batch.
+	// Each item = 1-2 API calls (Trading API + potential Browse API fallback)
+	if len(toFetch) > 0 {
+		log.Printf("[ENRICHMENT] Fetching %d items", len(toFetch))
+		fetched, failures := client.GetItems(r.Context(), toFetch)
+		auditCurrency := h.auditCurrency()
+		toSave := make([]*database.EnrichedItem, 0, len(fetched))
+
+		for _, id := range toFetch {
+			item, ok := fetched[id]
+			if !ok {
+				var apiErr *ebay.APIError
+				if errors.As(failures[id], &apiErr) {
+					log.Printf("[ENRICHMENT] Item %s: eBay returned HTTP %d (code %q)", id, apiErr.StatusCode, apiErr.EbayErrorCode)
+				}
+				// Store empty entry to avoid retrying failed items
+				enrichedData := &EnrichedItemData{
+					ItemID:     id,
+					EnrichedAt: time.Now(),
+				}
+				cacheFetched(enrichedData)
+				result[id] = enrichedData.withSource(database.SourceFetched)
+				continue
+			}
+
+			enrichedData := &EnrichedItemData{
+				ItemID:               id,
+				SKU:                  item.SKU,
+				Brand:                item.Brand,
+				CountryOfOrigin:      item.CountryOfOrigin,
+				ShippingCost:         item.ShippingCost,
+				ShippingCurrency:     item.ShippingCurrency,
+				Images:               item.Images,
+				ConditionDescription: item.ConditionDescription,
+				EnrichedAt:           time.Now(),
+				CurrencyCheck:        database.CheckShippingCurrency(item.ShippingCurrency, auditCurrency),
+
+				ShippingByDestination: item.ShippingByDestination,
+			}
+			log.Printf("[ENRICHMENT] Successfully enriched item %s (Brand: %s, COO: %s, Images: %d)",
+				id, item.Brand, item.CountryOfOrigin, len(item.Images))
+
+			// Written through to the database below, so GetListings can serve it
+			toSave = append(toSave, &database.EnrichedItem{
+				ItemID:           id,
+				SKU:              item.SKU,
+				Brand:            item.Brand,
+				CountryOfOrigin:  item.CountryOfOrigin,
+				ShippingCost:     item.ShippingCost,
+				ShippingCurrency: item.ShippingCurrency,
+				Images:           item.Images,
+				EnrichedAt:       enrichedData.EnrichedAt,
+
+				ConditionDescription:  item.ConditionDescription,
+				ShippingByDestination: item.ShippingByDestination,
+			})
+
+			// Cache the result
+			cacheFetched(enrichedData)
+			result[id] = enrichedData.withSource(database.SourceFetched)
+		}
+
+		// One transaction for the whole batch rather than one per item
+		if err := h.db.SaveEnrichedItemsBatch(toSave); err != nil {
+			log.Printf("[ENRICHMENT] Failed to save %d items to database: %v", len(toSave), err)
+		} else {
+			for _, item := range toSave {
+				h.autoMapBrand(item.Brand, item.CountryOfOrigin, item.ItemID)
+			}
+		}
+
+		log.Printf("[ENRICHMENT] Completed fetching %d items (%d failed)", len(toFetch), len(failures))
+	}
+
+	jsonResponse(w, http.StatusOK, result)
+}
+
+// GetItemBySKU returns the enriched items for a seller SKU:
+// GET /api/item/by-sku/:sku. The SKU is recorded when an item is enriched
+// through the Trading API or appears in a listings fetch, so items that have
+// been neither are not found.
+func (h *Handler) GetItemBySKU(w http.ResponseWriter, r *http.Request) {
+	// Everything after the prefix is the SKU; SKUs may contain slashes
+	sku := strings.TrimPrefix(r.URL.Path, "/api/item/by-sku/")
+	if sku == "" {
+		errorResponse(w, http.StatusBadRequest, "SKU required")
+		return
+	}
+
+	items, err := h.db.GetEnrichedItemsBySKU(sku)
+	if err != nil {
+		log.Printf("GetItemBySKU error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(items) == 0 {
+		errorResponse(w, http.StatusNotFound, "No item found for SKU")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"sku":   sku,
+		"items": items,
+	})
+}
+
+// QueueEnrichment queues item IDs for background enrichment.
+// Does not require a user session - the worker uses an application token.
+func (h *Handler) QueueEnrichment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+
+	var req struct {
+		ItemIDs []string `json:"itemIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	itemIDs := cleanItemIDs(req.ItemIDs)
+	if len(itemIDs) == 0 {
+		missingItemIDsResponse(w)
+		return
+	}
+
+	if h.ebayConfig.ClientID == "" || h.ebayConfig.ClientSecret == "" {
+		errorResponse(w, http.StatusServiceUnavailable, "eBay credentials not configured")
+		return
+	}
+	if !h.bulkCallsAllowed(w) {
+		return
+	}
+
+	queued, skipped := h.queueItemsForEnrichment(itemIDs)
+	jsonResponse(w, http.StatusAccepted, map[string]interface{}{
+		"queued":  queued,
+		"skipped": skipped,
+	})
+}
+
+// RecomputeEnrichment reloads reference data from the database, re-derives
+// every enriched item's expected COO, tariff rate, calculated cost and diff
+// status, and stores them. It reports how many differ from the values stored
+// by the previous recompute, so edits made through the API or directly in
+// the database since then are both counted. eBay is not called.
+func (h *Handler) RecomputeEnrichment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+
+	if err := h.reloadCalculator(); err != nil {
+		log.Printf("[RECOMPUTE] Failed to reload calculator config: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to load reference data")
+		return
+	}
+
+	result, err := h.db.RecomputeEnrichedItems(h.sessionAccountID(r), h.calculator())
+	if err != nil {
+		log.Printf("[RECOMPUTE] Error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to recompute enriched items")
+		return
+	}
+
+	log.Printf("[RECOMPUTE] %d of %d enriched items changed (%d diff status), %d computed for the first time", result.Changed, result.Items, result.DiffStatusChanged, result.Computed)
+	jsonResponse(w, http.StatusOK, result)
+}
+
+// PurgeEnrichmentCache forgets enrichment so it is fetched from eBay again,
+// e.g. after a seller corrects a listing's COO: DELETE /api/enrich/cache
+// clears every item, ?itemIds=id1,id2 only those. Both the in-memory cache
+// and enriched_items are cleared. Requires a signed-in eBay session.
+func (h *Handler) PurgeEnrichmentCache(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		errorResponse(w, http.StatusMethodNotAllowed, "DELETE required")
+		return
+	}
+
+	client, err := h.getEbayClient(r)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Session error")
+		return
+	}
+	if !client.IsAuthenticated() {
+		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
+		return
+	}
+
+	var itemIDs []string
+	if r.URL.Query().Has("itemIds") {
+		itemIDs = cleanItemIDs(strings.Split(r.URL.Query().Get("itemIds"), ","))
+		if len(itemIDs) == 0 {
+			missingItemIDsResponse(w)
+			return
+		}
+	}
+
+	var deleted int64
+	if itemIDs == nil {
+		deleted, err = h.db.DeleteAllEnrichedItems()
+	} else {
+		deleted, err = h.db.DeleteEnrichedItems(itemIDs)
+	}
+	if err != nil {
+		log.Printf("[PURGE] Failed to delete enriched items: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to purge enrichment cache")
+		return
+	}
+
+	// The database is cleared first so a failure can't leave memory emptied
+	// but rows that would be reloaded
+	h.uncacheEnrichment(itemIDs)
+	if itemIDs == nil {
+		log.Printf("[PURGE] Cleared all enrichment (%d stored items)", deleted)
+	} else {
+		log.Printf("[PURGE] Cleared enrichment for %d items (%d stored)", len(itemIDs), deleted)
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"deleted": deleted,
+		"itemIds": itemIDs, // null when everything was purged
+	})
+}
+
+// GetFulfillmentPolicies returns shipping policies
+func (h *Handler) GetFulfillmentPolicies(w http.ResponseWriter, r *http.Request) {
+	client, err := h.getEbayClient(r)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Session error")
+		return
+	}
+
+	if !client.IsAuthenticated() {
+		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
+		return
+	}
+
+	marketplaceID, ok := marketplaceParam(w, r, "EBAY_AU") // Default to eBay Australia
+	if !ok {
+		return
+	}
+
+	policies, err := client.GetFulfillmentPolicies(r.Context(), marketplaceID)
+	if err != nil {
+		log.Printf("GetFulfillmentPolicies error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, policies)
+}
+
+// GetPaymentPolicies returns payment policies
+func (h *Handler) GetPaymentPolicies(w http.ResponseWriter, r *http.Request) {
+	client, err := h.getEbayClient(r)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Session error")
+		return
+	}
+
+	if !client.IsAuthenticated() {
+		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
+		return
+	}
+
+	marketplaceID, ok := marketplaceParam(w, r, "EBAY_AU") // Default to eBay Australia
+	if !ok {
+		return
+	}
+
+	policies, err := client.GetPaymentPolicies(r.Context(), marketplaceID)
+	if err != nil {
+		log.Printf("GetPaymentPolicies error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, policies)
+}
+
+// GetReturnPolicies returns return policies
+func (h *Handler) GetReturnPolicies(w http.ResponseWriter, r *http.Request) {
+	client, err := h.getEbayClient(r)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Session error")
+		return
+	}
+
+	if !client.IsAuthenticated() {
+		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
+		return
+	}
+
+	marketplaceID, ok := marketplaceParam(w, r, "EBAY_AU") // Default to eBay Australia
+	if !ok {
+		return
+	}
+
+	policies, err := client.GetReturnPolicies(r.Context(), marketplaceID)
+	if err != nil {
+		log.Printf("GetReturnPolicies error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, policies)
+}
+
+// CalculateRequest is the request body for calculate endpoint
+type CalculateRequest struct {
+	ItemValueAUD      float64 `json:"itemValueAUD"`
+	WeightBand        string  `json:"weightBand"`
+	BrandName         string  `json:"brandName"`
+	CountryOfOrigin   string  `json:"countryOfOrigin,omitempty"`
+	IncludeExtraCover bool    `json:"includeExtraCover"`
+	DiscountBand      int     `json:"discountBand"`
+	LengthCm          float64 `json:"lengthCm,omitempty"` // Optional dimensions for volumetric weight
+	WidthCm           float64 `json:"widthCm,omitempty"`
+	HeightCm          float64 `json:"heightCm,omitempty"`
+
+	// Optional 0-1 rate used instead of the tariff table (USA calculations only)
+	TariffRateOverride *float64 `json:"tariffRateOverride,omitempty"`
+}
+
+// CalculateShipping calculates shipping costs
+func (h *Handler) CalculateShipping(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+
+	var req CalculateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	result, err := h.calculator().CalculateUSAShipping(calculator.CalculateUSAShippingParams{
+		ItemValueAUD:      req.ItemValueAUD,
+		WeightBand:        req.WeightBand,
+		BrandName:         req.BrandName,
+		CountryOfOrigin:   req.CountryOfOrigin,
+		IncludeExtraCover: req.IncludeExtraCover,
+		DiscountBand:      req.DiscountBand,
+		LengthCm:          req.LengthCm,
+		WidthCm:           req.WidthCm,
+		HeightCm:          req.HeightCm,
+
+		TariffRateOverride: req.TariffRateOverride,
+	})
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, result)
+}
+
+// CalculateByWeightRequest is the request body for the calculate/weight
+// endpoint: the usual calculate fields with a measured weight instead of a band
+type CalculateByWeightRequest struct {
+	CalculateRequest
+	WeightGrams int `json:"weightGrams"`
+}
+
+// CalculateShippingByWeight calculates USA shipping from a weight in grams,
+// resolving the weight band first
+func (h *Handler) CalculateShippingByWeight(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+
+	var req CalculateByWeightRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.WeightGrams <= 0 {
+		errorResponse(w, http.StatusBadRequest, "weightGrams must be greater than 0")
+		return
+	}
+	if req.WeightGrams > calculator.MaxParcelGrams {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("weightGrams %d is over the %dg limit of the largest weight band (XLarge)", req.WeightGrams, calculator.MaxParcelGrams))
+		return
+	}
+
+	result, err := h.calculator().CalculateUSAShipping(calculator.CalculateUSAShippingParams{
+		ItemValueAUD:      req.ItemValueAUD,
+		WeightBand:        calculator.GetWeightBandFromGrams(req.WeightGrams),
+		BrandName:         req.BrandName,
+		CountryOfOrigin:   req.CountryOfOrigin,
+		IncludeExtraCover: req.IncludeExtraCover,
+		DiscountBand:      req.DiscountBand,
+		LengthCm:          req.LengthCm,
+		WidthCm:           req.WidthCm,
+		HeightCm:          req.HeightCm,
+
+		TariffRateOverride: req.TariffRateOverride,
+	})
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The band charged, which is the volumetric band when that's heavier
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"weightGrams": req.WeightGrams,
+		"weightBand":  result.Inputs.WeightBand,
+		"result":      result,
+	})
+}
+
+// maxBracketWeights caps how many weights one calculate/weights request may price
+const maxBracketWeights = 20
+
+// CalculateByWeightsRequest is the request body for the calculate/weights
+// endpoint: the usual calculate fields with candidate weights instead of a band
+type CalculateByWeightsRequest struct {
+	CalculateRequest
+	WeightsGrams []int `json:"weightsGrams"`
+}
+
+// WeightQuote is the calculated shipping for one candidate weight.
+// WeightBand is the band charged, which is the volumetric band when that's
+// heavier.
+type WeightQuote struct {
+	WeightGrams int                        `json:"weightGrams"`
+	WeightBand  string                     `json:"weightBand"`
+	Total       float64                    `json:"totalShipping"`
+	Result      *calculator.ShippingResult `json:"result"`
+}
+
+// CalculateShippingByWeights brackets an item of uncertain weight: it prices
+// the same item at each of several weights so the spread can be compared
+func (h *Handler) CalculateShippingByWeights(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+
+	var req CalculateByWeightsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if len(req.WeightsGrams) == 0 {
+		errorResponse(w, http.StatusBadRequest, "weightsGrams must list at least one weight")
+		return
+	}
+	if len(req.WeightsGrams) > maxBracketWeights {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("weightsGrams has %d weights; at most %d are allowed", len(req.WeightsGrams), maxBracketWeights))
+		return
+	}
+	for _, grams := range req.WeightsGrams {
+		if grams <= 0 {
+			errorResponse(w, http.StatusBadRequest, "weightsGrams must all be greater than 0")
+			return
+		}
+		if grams > calculator.MaxParcelGrams {
+			errorResponse(w, http.StatusBadRequest, fmt.Sprintf("weight %dg is over the %dg limit of the largest weight band (XLarge)", grams, calculator.MaxParcelGrams))
+			return
+		}
+	}
+
+	// One calculator for every weight, so a concurrent reload can't make
+	// the quotes disagree for reasons other than weight
+	calc := h.calculator()
+	quotes := make([]WeightQuote, 0, len(req.WeightsGrams))
+	for _, grams := range req.WeightsGrams {
+		result, err := calc.CalculateUSAShipping(calculator.CalculateUSAShippingParams{
+			ItemValueAUD:      req.ItemValueAUD,
+			WeightBand:        calculator.GetWeightBandFromGrams(grams),
+			BrandName:         req.BrandName,
+			CountryOfOrigin:   req.CountryOfOrigin,
+			IncludeExtraCover: req.IncludeExtraCover,
+			DiscountBand:      req.DiscountBand,
+			LengthCm:          req.LengthCm,
+			WidthCm:           req.WidthCm,
+			HeightCm:          req.HeightCm,
+
+			TariffRateOverride: req.TariffRateOverride,
+		})
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		quotes = append(quotes, WeightQuote{
+			WeightGrams: grams,
+			WeightBand:  result.Inputs.WeightBand,
+			Total:       result.Total,
+			Result:      result,
+		})
+	}
+
+	minTotal, maxTotal := quotes[0].Total, quotes[0].Total
+	for _, q := range quotes[1:] {
+		minTotal = min(minTotal, q.Total)
+		maxTotal = max(maxTotal, q.Total)
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"quotes":   quotes,
+		"minTotal": minTotal,
+		"maxTotal": maxTotal,
+		"spread":   math.Round((maxTotal-minTotal)*100) / 100,
+	})
+}
+
+// GetBrands returns available brands
+func (h *Handler) GetBrands(w http.ResponseWriter, r *http.Request) {
+	brands := h.calculator().GetAvailableBrands()
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"brands": brands,
+		"total":  len(brands),
+	})
+}
+
+// maxBrandSuggestions caps ?limit= on /api/brands/suggest
+const maxBrandSuggestions = 20
+
+// SuggestBrands returns the known brands closest to an item's brand, for
+// names that don't exactly match a mapping: GET /api/brands/suggest?q=name
+// (&limit=, default 5)
+func (h *Handler) SuggestBrands(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		errorResponse(w, http.StatusBadRequest, "q required")
+		return
+	}
+	limit := clampPageSize(r.URL.Query().Get("limit"), 5, maxBrandSuggestions)
+
+	suggestions := h.calculator().SuggestBrands(q, limit)
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"query":       q,
+		"suggestions": suggestions,
+		"total":       len(suggestions),
+	})
+}
+
+// GetBrandCOOs returns the countries of origin a brand is known to
+// manufacture in: /api/brands/:name/coos
+func (h *Handler) GetBrandCOOs(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/brands/")
+	name, ok := strings.CutSuffix(strings.TrimSuffix(rest, "/"), "/coos")
+	if !ok || name == "" {
+		errorResponse(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	brandName := ""
+	for _, b := range h.calculator().GetAvailableBrands() {
+		if strings.EqualFold(b, name) {
+			brandName = b
+			break
+		}
+	}
+	if brandName == "" {
+		errorResponse(w, http.StatusNotFound, "Brand not found")
+		return
+	}
+
+	primary, secondary := h.calculator().BrandCOOs(brandName)
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"brand":     brandName,
+		"primary":   primary,
+		"secondary": secondary,
+	})
+}
+
+// GetWeightBands returns available weight bands
+func (h *Handler) GetWeightBands(w http.ResponseWriter, r *http.Request) {
+	bands := h.calculator().GetWeightBands()
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"weightBands": bands,
+	})
+}
+
+// GetTariffCountries returns countries with tariff rates
+func (h *Handler) GetTariffCountries(w http.ResponseWriter, r *http.Request) {
+	countries := h.calculator().GetTariffCountries()
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"countries": countries,
+	})
+}
+
+// CalculateAllZones calculates shipping costs for all zones
+func (h *Handler) CalculateAllZones(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+
+	var req CalculateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	result, err := h.calculator().CalculateAllZones(calculator.CalculateAllZonesParams{
+		ItemValueAUD:      req.ItemValueAUD,
+		WeightBand:        req.WeightBand,
+		BrandName:         req.BrandName,
+		CountryOfOrigin:   req.CountryOfOrigin,
+		IncludeExtraCover: req.IncludeExtraCover,
+		DiscountBand:      req.DiscountBand,
+		LengthCm:          req.LengthCm,
+		WidthCm:           req.WidthCm,
+		HeightCm:          req.HeightCm,
+	})
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, result)
+}
+
+// Reference Data CRUD Endpoints
+
+// ReferenceTariffs handles CRUD operations for tariff rates
+func (h *Handler) ReferenceTariffs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listTariffs(w, r)
+	case http.MethodPost:
+		h.createTariff(w, r)
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// ReferenceTariffByID handles CRUD operations for a specific tariff rate
+func (h *Handler) ReferenceTariffByID(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from path: /api/reference/tariffs/:id
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/reference/tariffs/"), "/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid tariff ID")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		h.updateTariff(w, r, id)
+	case http.MethodDelete:
+		h.deleteTariff(w, r, id)
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *Handler) listTariffs(w http.ResponseWriter, r *http.Request) {
+	tariffs, err := h.db.GetAllTariffRates()
+	if err != nil {
+		log.Printf("Error fetching tariffs: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to fetch tariffs")
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"tariffs": tariffs,
+		"total":   len(tariffs),
+	})
+}
+
+func (h *Handler) createTariff(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CountryName   string  `json:"countryName"`
+		TariffRate    float64 `json:"tariffRate"`
+		Notes         string  `json:"notes"`
+		EffectiveDate string  `json:"effectiveDate"` // YYYY-MM-DD, optional
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.CountryName == "" {
+		errorResponse(w, http.StatusBadRequest, "Country name required")
+		return
+	}
+	if req.TariffRate < 0 || req.TariffRate > 1 {
+		errorResponse(w, http.StatusBadRequest, "Tariff rate must be between 0 and 1")
+		return
+	}
+	if req.EffectiveDate != "" {
+		if _, err := time.Parse("2006-01-02", req.EffectiveDate); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Effective date must be YYYY-MM-DD")
+			return
+		}
+	}
+
+	id, err := h.db.CreateTariffRate(req.CountryName, req.TariffRate, req.Notes, req.EffectiveDate)
+	if errors.Is(err, database.ErrTariffDateExists) {
+		errorResponse(w, http.StatusConflict, fmt.Sprintf("%s already has a tariff rate taking effect on that date", req.CountryName))
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating tariff: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to create tariff")
+		return
+	}
+	h.reloadCalculatorAfterEdit()
+
+	jsonResponse(w, http.StatusCreated, map[string]interface{}{
+		"id":      id,
+		"message": "Tariff created successfully",
+	})
+}
+
+func (h *Handler) updateTariff(w http.ResponseWriter, r *http.Request, id int64) {
+	var req struct {
+		CountryName   string  `json:"countryName"`
+		TariffRate    float64 `json:"tariffRate"`
+		Notes         string  `json:"notes"`
+		EffectiveDate string  `json:"effectiveDate"` // YYYY-MM-DD, optional
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.CountryName == "" {
+		errorResponse(w, http.StatusBadRequest, "Country name required")
+		return
+	}
+	if req.TariffRate < 0 || req.TariffRate > 1 {
+		errorResponse(w, http.StatusBadRequest, "Tariff rate must be between 0 and 1")
+		return
+	}
+	if req.EffectiveDate != "" {
+		if _, err := time.Parse("2006-01-02", req.EffectiveDate); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Effective date must be YYYY-MM-DD")
+			return
+		}
+	}
+
+	err := h.db.UpdateTariffRate(id, req.CountryName, req.TariffRate, req.Notes, req.EffectiveDate)
+	if errors.Is(err, database.ErrTariffDateExists) {
+		errorResponse(w, http.StatusConflict, fmt.Sprintf("%s already has a tariff rate taking effect on that date", req.CountryName))
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating tariff: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to update tariff")
+		return
+	}
+	h.reloadCalculatorAfterEdit()
+
+	jsonResponse(w, http.StatusOK, map[string]string{"message": "Tariff updated successfully"})
+}
+
+func (h *Handler) deleteTariff(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := h.db.DeleteTariffRate(id); err != nil {
+		log.Printf("Error deleting tariff: %v", err)
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.reloadCalculatorAfterEdit()
+
+	jsonResponse(w, http.StatusOK, map[string]string{"message": "Tariff deleted successfully"})
+}
+
+// PreviewTariff shows how a tariff rate change would shift total calculated
+// postage across enriched items from that country, without saving the rate
+func (h *Handler) PreviewTariff(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CountryName string   `json:"countryName"`
+		NewRate     *float64 `json:"newRate"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if strings.TrimSpace(req.CountryName) == "" {
+		errorResponse(w, http.StatusBadRequest, "Country name required")
+		return
+	}
+	if req.NewRate == nil {
+		errorResponse(w, http.StatusBadRequest, "newRate required")
+		return
+	}
+	if *req.NewRate < 0 || *req.NewRate > 1 {
+		errorResponse(w, http.StatusBadRequest, "Tariff rate must be between 0 and 1")
+		return
+	}
+
+	preview, err := h.db.PreviewTariffChange(h.sessionAccountID(r), h.calculator(), req.CountryName, *req.NewRate)
+	if err != nil {
+		log.Printf("Error previewing tariff: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to preview tariff")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, preview)
+}
+
+// ReferenceBrands handles CRUD operations for brand COO mappings
+func (h *Handler) ReferenceBrands(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listBrands(w, r)
+	case http.MethodPost:
+		h.createBrand(w, r)
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// ReferenceBrandByID handles CRUD operations for a specific brand mapping
+func (h *Handler) ReferenceBrandByID(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from path: /api/reference/brands/:id
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/reference/brands/"), "/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid brand ID")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		h.updateBrand(w, r, id)
+	case http.MethodDelete:
+		h.deleteBrand(w, r, id)
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *Handler) listBrands(w http.ResponseWriter, r *http.Request) {
+	brands, err := h.db.GetAllBrandCOOMappings()
+	if err != nil {
+		log.Printf("Error fetching brands: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to fetch brands")
+		return
+	}
+	// ?autoCreated=true narrows the list to mappings awaiting review
+	if r.URL.Query().Get("autoCreated") == "true" {
+		pending := []database.BrandCOOMapping{}
+		for _, b := range brands {
+			if b.AutoCreated {
+				pending = append(pending, b)
+			}
+		}
+		brands = pending
+	}
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"brands": brands,
+		"total":  len(brands),
+	})
+}
+
+func (h *Handler) createBrand(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		BrandName  string `json:"brandName"`
+		PrimaryCOO string `json:"primaryCoo"`
+		Notes      string `json:"notes"`
+		BrandType  string `json:"brandType"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.BrandName == "" {
+		errorResponse(w, http.StatusBadRequest, "Brand name required")
+		return
+	}
+	if req.PrimaryCOO == "" {
+		errorResponse(w, http.StatusBadRequest, "Primary COO required")
+		return
+	}
+
+	// SECURITY FIX: Validate foreign key - ensure country exists in tariff_rates
+	exists, err := h.db.TariffCountryExists(req.PrimaryCOO)
+	if err != nil {
+		log.Printf("Error checking tariff country: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to validate country")
+		return
+	}
+	if !exists {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid country: %s does not exist in tariff rates", req.PrimaryCOO))
+		return
+	}
+
+	id, err := h.db.CreateBrandCOOMapping(req.BrandName, req.PrimaryCOO, req.Notes, req.BrandType)
+	if err != nil {
+		log.Printf("Error creating brand: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to create brand")
+		return
+	}
+	h.reloadCalculatorAfterEdit()
+
+	jsonResponse(w, http.StatusCreated, map[string]interface{}{
+		"id":      id,
+		"message": "Brand created successfully",
+	})
+}
+
+func (h *Handler) updateBrand(w http.ResponseWriter, r *http.Request, id int64) {
+	var req struct {
+		BrandName  string `json:"brandName"`
+		PrimaryCOO string `json:"primaryCoo"`
+		Notes      string `json:"notes"`
+		BrandType  string `json:"brandType"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.BrandName == "" {
+		errorResponse(w, http.StatusBadRequest, "Brand name required")
+		return
+	}
+	if req.PrimaryCOO == "" {
+		errorResponse(w, http.StatusBadRequest, "Primary COO required")
+		return
+	}
+
+	// SECURITY FIX: Validate foreign key - ensure country exists in tariff_rates
+	exists, err := h.db.TariffCountryExists(req.PrimaryCOO)
+	if err != nil {
+		log.Printf("Error checking tariff country: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to validate country")
+		return
+	}
+	if !exists {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid country: %s does not exist in tariff rates", req.PrimaryCOO))
+		return
+	}
+
+	if err := h.db.UpdateBrandCOOMapping(id, req.BrandName, req.PrimaryCOO, req.Notes, req.BrandType); err != nil {
+		log.Printf("Error updating brand: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to update brand")
+		return
+	}
+	h.reloadCalculatorAfterEdit()
+
+	jsonResponse(w, http.StatusOK, map[string]string{"message": "Brand updated successfully"})
+}
+
+func (h *Handler) deleteBrand(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := h.db.DeleteBrandCOOMapping(id); err != nil {
+		log.Printf("Error deleting brand: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to delete brand")
+		return
+	}
+	h.reloadCalculatorAfterEdit()
+
+	jsonResponse(w, http.StatusOK, map[string]string{"message": "Brand deleted successfully"})
+}
+
+// ReferenceWeightBands handles CRUD operations for postal weight bands
+func (h *Handler) ReferenceWeightBands(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listWeightBands(w, r)
+	case http.MethodPost:
+		h.createWeightBand(w, r)
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// ReferenceWeightBandByID handles CRUD operations for a specific weight band
+func (h *Handler) ReferenceWeightBandByID(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from path: /api/reference/weight-bands/:id
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/reference/weight-bands/"), "/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid weight band ID")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		h.updateWeightBand(w, r, id)
+	case http.MethodDelete:
+		h.deleteWeightBand(w, r, id)
+	default:
+		errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// weightBandRequest is the body for creating or updating a weight band.
+// ZoneID and WeightBand are only used on create.
+type weightBandRequest struct {
+	ZoneID         string  `json:"zoneId"`
+	WeightBand     string  `json:"weightBand"`
+	Label          string  `json:"label"`
+	MaxWeightGrams int     `json:"maxWeightGrams"`
+	BasePriceAUD   float64 `json:"basePriceAud"`
+}
+
+// validate checks the editable fields shared by create and update
+func (req *weightBandRequest) validate() string {
+	if req.MaxWeightGrams <= 0 {
+		return "Max weight must be greater than 0"
+	}
+	if req.BasePriceAUD < 0 {
+		return "Base price cannot be negative"
+	}
+	return ""
+}
+
+func (h *Handler) listWeightBands(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.db.GetPostalRates(r.URL.Query().Get("zone"))
+	if err != nil {
+		log.Printf("Error fetching weight bands: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to fetch weight bands")
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"weightBands": rates,
+		"total":       len(rates),
+	})
+}
+
+func (h *Handler) createWeightBand(w http.ResponseWriter, r *http.Request) {
+	var req weightBandRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.ZoneID == "" {
+		errorResponse(w, http.StatusBadRequest, "Zone ID required")
+		return
+	}
+	if !calculator.IsWeightBand(req.WeightBand) {
+		errorResponse(w, http.StatusBadRequest, "Weight band must be one of XSmall, Small, Medium, Large, XLarge")
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		errorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	exists, err := h.db.PostalZoneExists(req.ZoneID)
+	if err != nil {
+		log.Printf("Error checking postal zone: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to validate zone")
+		return
+	}
+	if !exists {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid zone: %s does not exist", req.ZoneID))
+		return
+	}
+
+	existing, err := h.db.GetPostalRates(req.ZoneID)
+	if err != nil {
+		log.Printf("Error fetching weight bands: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to validate weight band")
+		return
+	}
+	for _, rate := range existing {
+		if rate.WeightBand == req.WeightBand {
+			errorResponse(w, http.StatusConflict, fmt.Sprintf("%s already has a %s band", req.ZoneID, req.WeightBand))
+			return
+		}
+	}
+
+	id, err := h.db.CreatePostalRate(req.ZoneID, req.WeightBand, req.Label, req.MaxWeightGrams, req.BasePriceAUD)
+	if err != nil {
+		log.Printf("Error creating weight band: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to create weight band")
+		return
+	}
+	h.reloadCalculatorAfterEdit()
+
+	jsonResponse(w, http.StatusCreated, map[string]interface{}{
+		"id":      id,
+		"message": "Weight band created successfully",
+	})
+}
+
+func (h *Handler) updateWeightBand(w http.ResponseWriter, r *http.Request, id int64) {
+	var req weightBandRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		errorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	rate, err := h.db.GetPostalRateByID(id)
+	if err != nil {
+		log.Printf("Error fetching weight band: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to fetch weight band")
+		return
+	}
+	if rate == nil {
+		errorResponse(w, http.StatusNotFound, "Weight band not found")
 		return
 	}
 
-	jsonResponse(w, http.StatusOK, result)
-}
+	if err := h.db.UpdatePostalRate(id, req.Label, req.MaxWeightGrams, req.BasePriceAUD); err != nil {
+		log.Printf("Error updating weight band: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to update weight band")
+		return
+	}
+	h.reloadCalculatorAfterEdit()
 
-// GetBrands returns available brands
-func (h *Handler) GetBrands(w http.ResponseWriter, r *http.Request) {
-	brands := h.calcConfig.GetAvailableBrands()
-	jsonResponse(w, http.StatusOK, map[string]interface{}{
-		"brands": brands,
-		"total":  len(brands),
-	})
+	jsonResponse(w, http.StatusOK, map[string]string{"message": "Weight band updated successfully"})
 }
 
-// GetWeightBands returns available weight bands
-func (h *Handler) GetWeightBands(w http.ResponseWriter, r *http.Request) {
-	bands := h.calcConfig.GetWeightBands()
-	jsonResponse(w, http.StatusOK, map[string]interface{}{
-		"weightBands": bands,
-	})
+func (h *Handler) deleteWeightBand(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := h.db.DeletePostalRate(id); err != nil {
+		log.Printf("Error deleting weight band: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to delete weight band")
+		return
+	}
+	h.reloadCalculatorAfterEdit()
+
+	jsonResponse(w, http.StatusOK, map[string]string{"message": "Weight band deleted successfully"})
 }
 
-// GetTariffCountries returns countries with tariff rates
-func (h *Handler) GetTariffCountries(w http.ResponseWriter, r *http.Request) {
-	countries := h.calcConfig.GetTariffCountries()
+// ReferenceZones lists each postal zone's import duty and Zonos settings
+func (h *Handler) ReferenceZones(w http.ResponseWriter, r *http.Request) {
+	zones, err := h.db.GetPostalZoneDuties()
+	if err != nil {
+		log.Printf("Error fetching postal zones: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to fetch postal zones")
+		return
+	}
 	jsonResponse(w, http.StatusOK, map[string]interface{}{
-		"countries": countries,
+		"zones": zones,
+		"total": len(zones),
 	})
 }
 
-// CalculateAllZones calculates shipping costs for all zones
-func (h *Handler) CalculateAllZones(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
+// ReferenceZoneByID updates a zone's import duty and Zonos settings:
+// PUT /api/reference/zones/:zoneId. Zonos fields sent as null (or left out)
+// go back to their defaults: on when the zone has tariffs, at the fees in the
+// zonos_* settings.
+func (h *Handler) ReferenceZoneByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
+	zoneID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/reference/zones/"), "/")
 
-	var req CalculateRequest
+	var req struct {
+		HasTariffs                   bool     `json:"hasTariffs"`
+		ZonosEnabled                 *bool    `json:"zonosEnabled"`
+		ZonosProcessingChargePercent *float64 `json:"zonosProcessingChargePercent"`
+		ZonosFlatFeeAUD              *float64 `json:"zonosFlatFeeAud"`
+	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		errorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	if p := req.ZonosProcessingChargePercent; p != nil && (*p < 0 || *p > 1) {
+		errorResponse(w, http.StatusBadRequest, "zonosProcessingChargePercent must be between 0 and 1")
+		return
+	}
+	if f := req.ZonosFlatFeeAUD; f != nil && *f < 0 {
+		errorResponse(w, http.StatusBadRequest, "zonosFlatFeeAud cannot be negative")
+		return
+	}
 
-	result, err := h.calcConfig.CalculateAllZones(calculator.CalculateAllZonesParams{
-		ItemValueAUD:      req.ItemValueAUD,
-		WeightBand:        req.WeightBand,
-		BrandName:         req.BrandName,
-		CountryOfOrigin:   req.CountryOfOrigin,
-		IncludeExtraCover: req.IncludeExtraCover,
-		DiscountBand:      req.DiscountBand,
-	})
+	zone, err := h.db.GetPostalZoneDutiesByID(zoneID)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, err.Error())
+		log.Printf("Error fetching postal zone: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to fetch postal zone")
+		return
+	}
+	if zone == nil {
+		errorResponse(w, http.StatusNotFound, "Postal zone not found")
 		return
 	}
 
-	jsonResponse(w, http.StatusOK, result)
-}
+	zone.HasTariffs = req.HasTariffs
+	zone.ZonosEnabled = req.ZonosEnabled
+	zone.ZonosProcessingChargePercent = req.ZonosProcessingChargePercent
+	zone.ZonosFlatFeeAUD = req.ZonosFlatFeeAUD
+	if err := h.db.UpdatePostalZoneDuties(zone); err != nil {
+		log.Printf("Error updating postal zone: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to update postal zone")
+		return
+	}
+	h.reloadCalculatorAfterEdit()
 
-// Reference Data CRUD Endpoints
+	jsonResponse(w, http.StatusOK, zone)
+}
 
-// ReferenceTariffs handles CRUD operations for tariff rates
-func (h *Handler) ReferenceTariffs(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		h.listTariffs(w, r)
-	case http.MethodPost:
-		h.createTariff(w, r)
-	default:
-		errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+// ReferenceItemWeights lists the stored per-listing weights
+func (h *Handler) ReferenceItemWeights(w http.ResponseWriter, r *http.Request) {
+	weights, err := h.db.GetItemWeights()
+	if err != nil {
+		log.Printf("Error fetching item weights: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to fetch item weights")
+		return
 	}
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"weights": weights,
+		"total":   len(weights),
+	})
 }
 
-// ReferenceTariffByID handles CRUD operations for a specific tariff rate
-func (h *Handler) ReferenceTariffByID(w http.ResponseWriter, r *http.Request) {
-	// Extract ID from path: /api/reference/tariffs/:id
-	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/reference/tariffs/"), "/")
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid tariff ID")
+// ReferenceItemWeightByID gets, sets or removes one listing's weight:
+// /api/reference/item-weights/:itemId. Listings and batch calculations use
+// the band for a stored weight instead of guessing one from the brand type.
+func (h *Handler) ReferenceItemWeightByID(w http.ResponseWriter, r *http.Request) {
+	itemID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/reference/item-weights/"), "/")
+	if itemID == "" {
+		errorResponse(w, http.StatusBadRequest, "Item ID required")
 		return
 	}
 
 	switch r.Method {
+	case http.MethodGet:
+		weight, err := h.db.GetItemWeight(itemID)
+		if err != nil {
+			log.Printf("Error fetching item weight: %v", err)
+			errorResponse(w, http.StatusInternalServerError, "Failed to fetch item weight")
+			return
+		}
+		if weight == nil {
+			errorResponse(w, http.StatusNotFound, "No weight stored for item")
+			return
+		}
+		jsonResponse(w, http.StatusOK, weight)
+
 	case http.MethodPut:
-		h.updateTariff(w, r, id)
+		var req struct {
+			WeightGrams int `json:"weightGrams"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.WeightGrams <= 0 {
+			errorResponse(w, http.StatusBadRequest, "weightGrams must be greater than 0")
+			return
+		}
+		if req.WeightGrams > calculator.MaxParcelGrams {
+			errorResponse(w, http.StatusBadRequest, fmt.Sprintf("weightGrams %d is over the %dg limit of the largest weight band (XLarge)", req.WeightGrams, calculator.MaxParcelGrams))
+			return
+		}
+		if err := h.db.SetItemWeight(itemID, req.WeightGrams); err != nil {
+			log.Printf("Error saving item weight: %v", err)
+			errorResponse(w, http.StatusInternalServerError, "Failed to save item weight")
+			return
+		}
+		weight, err := h.db.GetItemWeight(itemID)
+		if err != nil || weight == nil {
+			log.Printf("Error fetching saved item weight: %v", err)
+			errorResponse(w, http.StatusInternalServerError, "Failed to fetch item weight")
+			return
+		}
+		jsonResponse(w, http.StatusOK, weight)
+
 	case http.MethodDelete:
-		h.deleteTariff(w, r, id)
+		deleted, err := h.db.DeleteItemWeight(itemID)
+		if err != nil {
+			log.Printf("Error deleting item weight: %v", err)
+			errorResponse(w, http.StatusInternalServerError, "Failed to delete item weight")
+			return
+		}
+		if !deleted {
+			errorResponse(w, http.StatusNotFound, "No weight stored for item")
+			return
+		}
+		jsonResponse(w, http.StatusOK, map[string]string{"message": "Item weight deleted successfully"})
+
 	default:
 		errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
 }
 
-func (h *Handler) listTariffs(w http.ResponseWriter, r *http.Request) {
-	tariffs, err := h.db.GetAllTariffRates()
+// reloadCalculatorAfterEdit reloads the calculator after a reference data
+// edit. The edit itself has been saved, so a failed reload is only logged;
+// the previous configuration stays in use until the next reload or restart.
+func (h *Handler) reloadCalculatorAfterEdit() {
+	if err := h.reloadCalculator(); err != nil {
+		log.Printf("Failed to reload calculator config: %v", err)
+	}
+}
+
+// ExportReferenceData downloads the brand-COO mappings and tariff rates as a
+// versioned JSON package that another installation can import
+func (h *Handler) ExportReferenceData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "GET required")
+		return
+	}
+
+	data, err := h.db.ExportReferenceData()
 	if err != nil {
-		log.Printf("Error fetching tariffs: %v", err)
-		errorResponse(w, http.StatusInternalServerError, "Failed to fetch tariffs")
+		log.Printf("Error exporting reference data: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to export reference data")
 		return
 	}
-	jsonResponse(w, http.StatusOK, map[string]interface{}{
-		"tariffs": tariffs,
-		"total":   len(tariffs),
-	})
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="reference-data-%s.json"`, time.Now().Format("2006-01-02")))
+	jsonResponse(w, http.StatusOK, data)
 }
 
-func (h *Handler) createTariff(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		CountryName string  `json:"countryName"`
-		TariffRate  float64 `json:"tariffRate"`
-		Notes       string  `json:"notes"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+// ImportReferenceData upserts a package produced by ExportReferenceData.
+// Nothing is written unless the whole package is valid; with
+// ?skipInvalid=true the valid rows are written and the malformed ones
+// skipped and reported instead.
+func (h *Handler) ImportReferenceData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
 		return
 	}
 
-	if req.CountryName == "" {
-		errorResponse(w, http.StatusBadRequest, "Country name required")
+	var data database.ReferenceData
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid reference data file: "+err.Error())
 		return
 	}
-	if req.TariffRate < 0 || req.TariffRate > 1 {
-		errorResponse(w, http.StatusBadRequest, "Tariff rate must be between 0 and 1")
+
+	if r.URL.Query().Get("skipInvalid") == "true" {
+		h.importReferenceDataSkippingInvalid(w, &data)
 		return
 	}
 
-	id, err := h.db.CreateTariffRate(req.CountryName, req.TariffRate, req.Notes)
+	tariffs, brands, err := h.db.ImportReferenceData(&data)
 	if err != nil {
-		log.Printf("Error creating tariff: %v", err)
-		errorResponse(w, http.StatusInternalServerError, "Failed to create tariff")
+		if errors.Is(err, database.ErrReferenceInvalid) {
+			errorCodeResponse(w, http.StatusBadRequest, errCodeReferenceInvalid, err.Error())
+			return
+		}
+		log.Printf("Error importing reference data: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to import reference data")
 		return
 	}
 
-	jsonResponse(w, http.StatusCreated, map[string]interface{}{
-		"id":      id,
-		"message": "Tariff created successfully",
+	log.Printf("Imported reference data: %d tariffs, %d brands", tariffs, brands)
+	h.reloadCalculatorAfterEdit()
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status":  "imported",
+		"tariffs": tariffs,
+		"brands":  brands,
 	})
 }
 
-func (h *Handler) updateTariff(w http.ResponseWriter, r *http.Request, id int64) {
-	var req struct {
-		CountryName string  `json:"countryName"`
-		TariffRate  float64 `json:"tariffRate"`
-		Notes       string  `json:"notes"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+// importReferenceDataSkippingInvalid upserts the valid rows of a reference
+// package, skipping malformed ones and reporting them in the response
+func (h *Handler) importReferenceDataSkippingInvalid(w http.ResponseWriter, data *database.ReferenceData) {
+	result, err := h.db.ImportReferenceDataSkippingInvalid(data)
+	if err != nil {
+		if errors.Is(err, database.ErrReferenceInvalid) {
+			errorCodeResponse(w, http.StatusBadRequest, errCodeReferenceInvalid, err.Error())
+			return
+		}
+		log.Printf("Error importing reference data: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to import reference data")
 		return
 	}
 
-	if req.CountryName == "" {
-		errorResponse(w, http.StatusBadRequest, "Country name required")
-		return
+	log.Printf("Imported reference data: %d tariffs, %d brands (skipped %d tariffs, %d brands)",
+		result.Tariffs, result.Brands, result.SkippedTariffs, result.SkippedBrands)
+	if result.Tariffs > 0 || result.Brands > 0 {
+		h.reloadCalculatorAfterEdit()
 	}
-	if req.TariffRate < 0 || req.TariffRate > 1 {
-		errorResponse(w, http.StatusBadRequest, "Tariff rate must be between 0 and 1")
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status":         "imported",
+		"tariffs":        result.Tariffs,
+		"brands":         result.Brands,
+		"skippedTariffs": result.SkippedTariffs,
+		"skippedBrands":  result.SkippedBrands,
+		"skipped":        result.Skipped,
+	})
+}
+
+// UpdateShippingRequest is the request for updating shipping
+type UpdateShippingRequest struct {
+	OfferID   string                      `json:"offerId"`
+	Overrides []ebay.ShippingCostOverride `json:"overrides"`
+}
+
+// UpdateOfferShipping updates shipping cost overrides
+func (h *Handler) UpdateOfferShipping(w http.ResponseWriter, r *http.Request) {
+	client, err := h.getEbayClient(r)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Session error")
 		return
 	}
 
-	if err := h.db.UpdateTariffRate(id, req.CountryName, req.TariffRate, req.Notes); err != nil {
-		log.Printf("Error updating tariff: %v", err)
-		errorResponse(w, http.StatusInternalServerError, "Failed to update tariff")
+	if !client.IsAuthenticated() {
+		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
 		return
 	}
 
-	jsonResponse(w, http.StatusOK, map[string]string{"message": "Tariff updated successfully"})
-}
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
 
-func (h *Handler) deleteTariff(w http.ResponseWriter, r *http.Request, id int64) {
-	if err := h.db.DeleteTariffRate(id); err != nil {
-		log.Printf("Error deleting tariff: %v", err)
+	var req UpdateShippingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := ebay.ValidateShippingCostOverrides(req.Overrides); err != nil {
 		errorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	jsonResponse(w, http.StatusOK, map[string]string{"message": "Tariff deleted successfully"})
+	if err := client.UpdateOfferShipping(r.Context(), req.OfferID, req.Overrides); err != nil {
+		log.Printf("UpdateOfferShipping error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "updated"})
 }
 
-// ReferenceBrands handles CRUD operations for brand COO mappings
-func (h *Handler) ReferenceBrands(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		h.listBrands(w, r)
-	case http.MethodPost:
-		h.createBrand(w, r)
-	default:
-		errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-	}
+// maxBulkShippingUpdates caps a single batch so one request can't tie up the
+// eBay rate limit for minutes
+const maxBulkShippingUpdates = 500
+
+// BulkShippingResult is the per-offer outcome of a batch shipping update
+type BulkShippingResult struct {
+	Status string `json:"status"` // "updated" or "failed"
+	Error  string `json:"error,omitempty"`
 }
 
-// ReferenceBrandByID handles CRUD operations for a specific brand mapping
-func (h *Handler) ReferenceBrandByID(w http.ResponseWriter, r *http.Request) {
-	// Extract ID from path: /api/reference/brands/:id
-	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/reference/brands/"), "/")
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid brand ID")
+// UpdateOfferShippingBatch updates shipping cost overrides for many offers.
+// The response maps each offerId to its result; a failed offer doesn't stop
+// the rest from being applied.
+func (h *Handler) UpdateOfferShippingBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
 		return
 	}
 
-	switch r.Method {
-	case http.MethodPut:
-		h.updateBrand(w, r, id)
-	case http.MethodDelete:
-		h.deleteBrand(w, r, id)
-	default:
-		errorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	if !h.bulkCallsAllowed(w) {
+		return
 	}
-}
 
-func (h *Handler) listBrands(w http.ResponseWriter, r *http.Request) {
-	brands, err := h.db.GetAllBrandCOOMappings()
+	client, err := h.getEbayClient(r)
 	if err != nil {
-		log.Printf("Error fetching brands: %v", err)
-		errorResponse(w, http.StatusInternalServerError, "Failed to fetch brands")
+		errorResponse(w, http.StatusInternalServerError, "Session error")
 		return
 	}
-	jsonResponse(w, http.StatusOK, map[string]interface{}{
-		"brands": brands,
-		"total":  len(brands),
-	})
-}
 
-func (h *Handler) createBrand(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		BrandName  string `json:"brandName"`
-		PrimaryCOO string `json:"primaryCoo"`
-		Notes      string `json:"notes"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+	if !client.IsAuthenticated() {
+		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
 		return
 	}
 
-	if req.BrandName == "" {
-		errorResponse(w, http.StatusBadRequest, "Brand name required")
+	var updates []ebay.OfferShippingUpdate
+	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid request body: expected an array of {offerId, overrides}")
 		return
 	}
-	if req.PrimaryCOO == "" {
-		errorResponse(w, http.StatusBadRequest, "Primary COO required")
+	if len(updates) == 0 {
+		errorResponse(w, http.StatusBadRequest, "At least one update is required")
+		return
+	}
+	if len(updates) > maxBulkShippingUpdates {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Too many updates: %d (max %d)", len(updates), maxBulkShippingUpdates))
+		return
+	}
+	seen := make(map[string]bool, len(updates))
+	for i, u := range updates {
+		if strings.TrimSpace(u.OfferID) == "" {
+			errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Update %d has no offerId", i))
+			return
+		}
+		if seen[u.OfferID] {
+			errorResponse(w, http.StatusBadRequest, "Duplicate offerId: "+u.OfferID)
+			return
+		}
+		seen[u.OfferID] = true
+		if err := ebay.ValidateShippingCostOverrides(u.Overrides); err != nil {
+			errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Update %d (offer %s): %v", i, u.OfferID, err))
+			return
+		}
+	}
+
+	errs := client.BulkUpdateOfferShipping(r.Context(), updates)
+
+	results := make(map[string]BulkShippingResult, len(errs))
+	failed := 0
+	for offerID, err := range errs {
+		if err != nil {
+			results[offerID] = BulkShippingResult{Status: "failed", Error: err.Error()}
+			failed++
+			continue
+		}
+		results[offerID] = BulkShippingResult{Status: "updated"}
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"results": results,
+		"updated": len(results) - failed,
+		"failed":  failed,
+	})
+}
+
+// EndListingRequest is the request body for ending a listing
+type EndListingRequest struct {
+	ItemID string `json:"itemId"`
+	Reason string `json:"reason"` // One of ebay.EndReasons
+}
+
+// EndListing ends (withdraws) an active eBay listing
+func (h *Handler) EndListing(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
 		return
 	}
 
-	// SECURITY FIX: Validate foreign key - ensure country exists in tariff_rates
-	exists, err := h.db.TariffCountryExists(req.PrimaryCOO)
+	client, err := h.getEbayClient(r)
 	if err != nil {
-		log.Printf("Error checking tariff country: %v", err)
-		errorResponse(w, http.StatusInternalServerError, "Failed to validate country")
-		return
-	}
-	if !exists {
-		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid country: %s does not exist in tariff rates", req.PrimaryCOO))
+		errorResponse(w, http.StatusInternalServerError, "Session error")
 		return
 	}
 
-	id, err := h.db.CreateBrandCOOMapping(req.BrandName, req.PrimaryCOO, req.Notes)
-	if err != nil {
-		log.Printf("Error creating brand: %v", err)
-		errorResponse(w, http.StatusInternalServerError, "Failed to create brand")
+	if !client.IsAuthenticated() {
+		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
 		return
 	}
 
-	jsonResponse(w, http.StatusCreated, map[string]interface{}{
-		"id":      id,
-		"message": "Brand created successfully",
-	})
-}
-
-func (h *Handler) updateBrand(w http.ResponseWriter, r *http.Request, id int64) {
-	var req struct {
-		BrandName  string `json:"brandName"`
-		PrimaryCOO string `json:"primaryCoo"`
-		Notes      string `json:"notes"`
-	}
+	var req EndListingRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		errorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	if req.BrandName == "" {
-		errorResponse(w, http.StatusBadRequest, "Brand name required")
+	req.ItemID = strings.TrimSpace(req.ItemID)
+	if req.ItemID == "" {
+		errorResponse(w, http.StatusBadRequest, "itemId is required")
 		return
 	}
-	if req.PrimaryCOO == "" {
-		errorResponse(w, http.StatusBadRequest, "Primary COO required")
+	if !ebay.IsValidEndReason(req.Reason) {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("reason must be one of: %s", strings.Join(ebay.EndReasons, ", ")))
 		return
 	}
 
-	// SECURITY FIX: Validate foreign key - ensure country exists in tariff_rates
-	exists, err := h.db.TariffCountryExists(req.PrimaryCOO)
-	if err != nil {
-		log.Printf("Error checking tariff country: %v", err)
-		errorResponse(w, http.StatusInternalServerError, "Failed to validate country")
-		return
-	}
-	if !exists {
-		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid country: %s does not exist in tariff rates", req.PrimaryCOO))
+	if err := client.EndItem(r.Context(), req.ItemID, req.Reason); err != nil {
+		log.Printf("EndListing error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	if err := h.db.UpdateBrandCOOMapping(id, req.BrandName, req.PrimaryCOO, req.Notes); err != nil {
-		log.Printf("Error updating brand: %v", err)
-		errorResponse(w, http.StatusInternalServerError, "Failed to update brand")
-		return
+	// The ended listing would otherwise linger in the cached offers list
+	if accountKey := h.sessionAccountKey(r); accountKey != "" {
+		h.invalidateListings(accountKey)
 	}
 
-	jsonResponse(w, http.StatusOK, map[string]string{"message": "Brand updated successfully"})
+	jsonResponse(w, http.StatusOK, map[string]string{"status": "ended", "itemId": req.ItemID})
 }
 
-func (h *Handler) deleteBrand(w http.ResponseWriter, r *http.Request, id int64) {
-	if err := h.db.DeleteBrandCOOMapping(id); err != nil {
-		log.Printf("Error deleting brand: %v", err)
-		errorResponse(w, http.StatusInternalServerError, "Failed to delete brand")
+// SyncExport exports the session's eBay account data to the database
+func (h *Handler) SyncExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
 		return
 	}
 
-	jsonResponse(w, http.StatusOK, map[string]string{"message": "Brand deleted successfully"})
-}
-
-// UpdateShippingRequest is the request for updating shipping
-type UpdateShippingRequest struct {
-	OfferID   string                      `json:"offerId"`
-	Overrides []ebay.ShippingCostOverride `json:"overrides"`
-}
+	if !h.bulkCallsAllowed(w) {
+		return
+	}
 
-// UpdateOfferShipping updates shipping cost overrides
-func (h *Handler) UpdateOfferShipping(w http.ResponseWriter, r *http.Request) {
 	client, err := h.getEbayClient(r)
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "Session error")
@@ -1311,30 +3704,60 @@ func (h *Handler) UpdateOfferShipping(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if r.Method != http.MethodPost {
-		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
+	account := h.exportAccount(r)
+	if account == nil {
+		errorResponse(w, http.StatusBadRequest, "Not connected to an eBay account. Please authenticate first.")
 		return
 	}
 
-	var req UpdateShippingRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		errorResponse(w, http.StatusBadRequest, "Invalid request body")
+	marketplaceID, ok := marketplaceParam(w, r, account.MarketplaceID)
+	if !ok {
 		return
 	}
 
-	if err := client.UpdateOfferShipping(r.Context(), req.OfferID, req.Overrides); err != nil {
-		log.Printf("UpdateOfferShipping error: %v", err)
-		errorResponse(w, http.StatusInternalServerError, err.Error())
+	resources, err := syncpkg.ParseResources(r.URL.Query().Get("resources"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	opts := syncpkg.ExportOptions{
+		Resources: resources,
+		Resume:    r.URL.Query().Get("resume") == "true",
+	}
 
-	jsonResponse(w, http.StatusOK, map[string]string{"status": "updated"})
+	log.Printf("Starting export for account: %s", account.DisplayName)
+
+	result, err := h.syncService.ExportFromEbay(r.Context(), client, account.ID, marketplaceID, opts, nil)
+	if err != nil {
+		log.Printf("Export failed: %v", err)
+		syncErrorResponse(w, err, result)
+		return
+	}
+
+	// Update last export time
+	if err := h.db.UpdateLastExport(account.ID); err != nil {
+		log.Printf("Failed to update last export time: %v", err)
+	}
+	h.invalidateListings(account.AccountKey)
+
+	log.Printf("Export completed successfully")
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status":  result.Status,
+		"message": fmt.Sprintf("Exported %d items from %s", result.TotalItems, account.DisplayName),
+		"result":  result,
+	})
 }
 
-// SyncExport exports current eBay account data to database
-func (h *Handler) SyncExport(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
+// SyncExportStream runs an export and streams progress as Server-Sent Events:
+// "phase" when a resource starts, "count"/"error" when it finishes, and a final
+// "done" (or "error" without a resource) carrying the SyncResult
+func (h *Handler) SyncExportStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "GET required")
+		return
+	}
+
+	if !h.bulkCallsAllowed(w) {
 		return
 	}
 
@@ -1349,40 +3772,74 @@ func (h *Handler) SyncExport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.currentAccount == nil {
+	account := h.exportAccount(r)
+	if account == nil {
 		errorResponse(w, http.StatusBadRequest, "Not connected to an eBay account. Please authenticate first.")
 		return
 	}
 
-	marketplaceID := r.URL.Query().Get("marketplace_id")
-	if marketplaceID == "" {
-		marketplaceID = h.currentAccount.MarketplaceID
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errorResponse(w, http.StatusInternalServerError, "Streaming not supported")
+		return
 	}
 
-	log.Printf("Starting export for account: %s", h.currentAccount.DisplayName)
+	marketplaceID, ok := marketplaceParam(w, r, account.MarketplaceID)
+	if !ok {
+		return
+	}
 
-	err = h.syncService.ExportFromEbay(r.Context(), client, h.currentAccount.ID, marketplaceID)
+	resources, err := syncpkg.ParseResources(r.URL.Query().Get("resources"))
 	if err != nil {
-		log.Printf("Export failed: %v", err)
-		errorResponse(w, http.StatusInternalServerError, err.Error())
+		errorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	opts := syncpkg.ExportOptions{
+		Resources: resources,
+		Resume:    r.URL.Query().Get("resume") == "true",
+	}
 
-	// Update last export time
-	if err := h.db.UpdateLastExport(h.currentAccount.ID); err != nil {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	sendEvent := func(event syncpkg.ProgressEvent) {
+		data, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("SSE marshal error: %v", err)
+			return
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		flusher.Flush()
+	}
+
+	log.Printf("Starting streamed export for account: %s", account.DisplayName)
+
+	result, err := h.syncService.ExportFromEbay(r.Context(), client, account.ID, marketplaceID, opts, sendEvent)
+	if err != nil {
+		log.Printf("Streamed export failed: %v", err)
+		event := syncpkg.ProgressEvent{Type: syncpkg.EventError, Error: err.Error(), Result: result}
+		if result != nil && result.Summary != "" {
+			event.Error = result.Summary
+		}
+		sendEvent(event)
+		return
+	}
+
+	if err := h.db.UpdateLastExport(account.ID); err != nil {
 		log.Printf("Failed to update last export time: %v", err)
 	}
+	h.invalidateListings(account.AccountKey)
 
-	log.Printf("Export completed successfully")
-	jsonResponse(w, http.StatusOK, map[string]string{
-		"status":  "success",
-		"message": "Exported data from " + h.currentAccount.DisplayName,
-	})
+	sendEvent(syncpkg.ProgressEvent{Type: syncpkg.EventDone, Result: result})
 }
 
 // SyncImportRequest is the request body for import
 type SyncImportRequest struct {
 	SourceAccountKey string `json:"sourceAccountKey"` // Which account's data to import from
+	DryRun           bool   `json:"dryRun"`           // Preview what would be created without changing eBay
 }
 
 // SyncImport imports data from database to current eBay account
@@ -1392,6 +3849,10 @@ func (h *Handler) SyncImport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.bulkCallsAllowed(w) {
+		return
+	}
+
 	client, err := h.getEbayClient(r)
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "Session error")
@@ -1403,7 +3864,9 @@ func (h *Handler) SyncImport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if h.currentAccount == nil {
+	// Read once: the shared account can change while the import runs
+	target := h.exportAccount(r)
+	if target == nil {
 		errorResponse(w, http.StatusBadRequest, "Not connected to an eBay account. Please authenticate first.")
 		return
 	}
@@ -1427,38 +3890,134 @@ func (h *Handler) SyncImport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("Starting import from %s to %s", sourceAccount.DisplayName, h.currentAccount.DisplayName)
+	resources, err := syncpkg.ParseResources(r.URL.Query().Get("resources"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	log.Printf("Starting import from %s to %s", sourceAccount.DisplayName, target.DisplayName)
 
-	err = h.syncService.ImportToEbay(r.Context(), client, sourceAccount.ID, h.currentAccount.ID)
+	result, err := h.syncService.ImportToEbay(r.Context(), client, sourceAccount.ID, target.ID, syncpkg.ImportOptions{DryRun: req.DryRun, Resources: resources})
 	if err != nil {
 		log.Printf("Import failed: %v", err)
-		errorResponse(w, http.StatusInternalServerError, err.Error())
+		syncErrorResponse(w, err, result)
+		return
+	}
+
+	if req.DryRun {
+		jsonResponse(w, http.StatusOK, map[string]interface{}{
+			"status":  "dry-run",
+			"message": fmt.Sprintf("Dry run: %d actions planned importing %s into %s", len(result.Actions), sourceAccount.DisplayName, target.DisplayName),
+			"result":  result,
+		})
 		return
 	}
 
 	log.Printf("Import completed successfully")
-	jsonResponse(w, http.StatusOK, map[string]string{
-		"status":  "success",
-		"message": "Imported data from " + sourceAccount.DisplayName + " to " + h.currentAccount.DisplayName,
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status":  result.Status,
+		"message": fmt.Sprintf("Imported %d items from %s to %s", result.TotalItems, sourceAccount.DisplayName, target.DisplayName),
+		"result":  result,
+	})
+}
+
+// syncErrorResponse reports a failed sync, including the partial result when
+// one is available so the user can see which resources did succeed
+func syncErrorResponse(w http.ResponseWriter, err error, result *syncpkg.SyncResult) {
+	if result == nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	message := err.Error()
+	if result.Summary != "" {
+		// Per-phase summary tells the user which resources need retrying
+		message = result.Summary
+	}
+	jsonResponse(w, http.StatusInternalServerError, map[string]interface{}{
+		"error":  message,
+		"result": result,
+	})
+}
+
+// SyncDiff compares two accounts' exported inventory items and offers
+// (?source=KEY_A&target=KEY_B): SKUs only in the source (added), only in the
+// target (removed), and differing titles, prices or policy names (changed)
+func (h *Handler) SyncDiff(w http.ResponseWriter, r *http.Request) {
+	sourceKey := r.URL.Query().Get("source")
+	targetKey := r.URL.Query().Get("target")
+	if sourceKey == "" || targetKey == "" {
+		errorResponse(w, http.StatusBadRequest, "source and target account keys are required")
+		return
+	}
+
+	sourceAccount, err := h.db.GetAccountByKey(sourceKey)
+	if err != nil {
+		log.Printf("Failed to get source account: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if sourceAccount == nil {
+		errorResponse(w, http.StatusNotFound, "Source account not found: "+sourceKey)
+		return
+	}
+
+	targetAccount, err := h.db.GetAccountByKey(targetKey)
+	if err != nil {
+		log.Printf("Failed to get target account: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if targetAccount == nil {
+		errorResponse(w, http.StatusNotFound, "Target account not found: "+targetKey)
+		return
+	}
+
+	diff, err := h.syncService.DiffAccounts(sourceAccount.ID, targetAccount.ID)
+	if err != nil {
+		log.Printf("DiffAccounts error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"source":  sourceKey,
+		"target":  targetKey,
+		"added":   diff.Added,
+		"removed": diff.Removed,
+		"changed": diff.Changed,
 	})
 }
 
 // GetSyncHistory returns sync history
 func (h *Handler) GetSyncHistory(w http.ResponseWriter, r *http.Request) {
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	if limit <= 0 || limit > 100 {
-		limit = 20
+	query := database.SyncHistoryQuery{
+		SyncType: r.URL.Query().Get("syncType"),
+		Status:   r.URL.Query().Get("status"),
+	}
+	query.Limit, query.Offset = parsePagination(r, 20, 100)
+
+	switch query.SyncType {
+	case "", "export", "import":
+	default:
+		errorResponse(w, http.StatusBadRequest, "syncType must be export or import")
+		return
+	}
+	switch query.Status {
+	case "", "success", "partial", "failed":
+	default:
+		errorResponse(w, http.StatusBadRequest, "status must be success, partial or failed")
+		return
 	}
 
-	var history []database.SyncHistory
+	history := []database.SyncHistory{}
+	total := 0
 	var err error
 
-	if h.currentAccount != nil {
-		history, err = h.db.GetSyncHistory(h.currentAccount.ID, limit)
-	} else {
-		// If no current account, return empty
-		history = []database.SyncHistory{}
+	if account := h.exportAccount(r); account != nil {
+		history, total, err = h.db.GetSyncHistory(account.ID, query)
 	}
+	// If no current account, return empty
 
 	if err != nil {
 		log.Printf("GetSyncHistory error: %v", err)
@@ -1467,8 +4026,119 @@ func (h *Handler) GetSyncHistory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	jsonResponse(w, http.StatusOK, map[string]interface{}{
-		"history": history,
-		"total":   len(history),
+		"history": history,
+		"total":   total, // All entries matching the filters, not just this page
+		"limit":   query.Limit,
+		"offset":  query.Offset,
+	})
+}
+
+// SyncBackup downloads a JSON backup of an account's exported data, with a
+// manifest of record counts and a checksum. Defaults to the session's
+// account; ?account=<accountKey> selects another. Requires a signed-in eBay
+// session.
+func (h *Handler) SyncBackup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "GET required")
+		return
+	}
+
+	// Backups hold the account's exported listings and policies, so only a
+	// signed-in session may download them
+	client, err := h.getEbayClient(r)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Session error")
+		return
+	}
+	if !client.IsAuthenticated() {
+		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
+		return
+	}
+
+	account := h.exportAccount(r)
+	if key := r.URL.Query().Get("account"); key != "" {
+		account, err = h.db.GetAccountByKey(key)
+		if err != nil {
+			log.Printf("SyncBackup account lookup error: %v", err)
+			errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if account == nil {
+			errorResponse(w, http.StatusNotFound, "Account not found: "+key)
+			return
+		}
+	}
+	if account == nil {
+		errorResponse(w, http.StatusBadRequest, "Not connected to an eBay account. Please authenticate first.")
+		return
+	}
+
+	backup, err := h.syncService.CreateBackup(r.Context(), account)
+	if err != nil {
+		log.Printf("SyncBackup error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="backup-%s-%s.json"`, account.AccountKey, time.Now().Format("2006-01-02")))
+	jsonResponse(w, http.StatusOK, backup)
+}
+
+// SyncRestore restores a backup produced by SyncBackup into the account named
+// in its manifest. The manifest counts and checksum are verified first and
+// nothing is written if they don't match. Requires a signed-in eBay session.
+func (h *Handler) SyncRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		errorResponse(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+
+	// A restore overwrites the account's exported listings and policies, so
+	// only a signed-in session may run one
+	client, err := h.getEbayClient(r)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Session error")
+		return
+	}
+	if !client.IsAuthenticated() {
+		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
+		return
+	}
+
+	var backup syncpkg.Backup
+	if err := json.NewDecoder(r.Body).Decode(&backup); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid backup file: "+err.Error())
+		return
+	}
+
+	if err := backup.Verify(); err != nil {
+		log.Printf("SyncRestore rejected backup: %v", err)
+		errorCodeResponse(w, http.StatusBadRequest, errCodeBackupInvalid, err.Error())
+		return
+	}
+
+	account, err := h.db.GetAccountByKey(backup.Manifest.AccountKey)
+	if err != nil {
+		log.Printf("SyncRestore account lookup error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if account == nil {
+		errorResponse(w, http.StatusNotFound, "Account not found: "+backup.Manifest.AccountKey)
+		return
+	}
+
+	counts, err := h.syncService.RestoreBackup(r.Context(), account, &backup)
+	if err != nil {
+		log.Printf("SyncRestore error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status":  "restored",
+		"account": account.AccountKey,
+		"counts":  counts,
 	})
 }
 
@@ -1544,9 +4214,29 @@ type EbayDeletionNotification struct {
 
 // handleDeletionNotification handles actual account deletion notifications
 func (h *Handler) handleDeletionNotification(w http.ResponseWriter, r *http.Request) {
+	// The signature covers the raw bytes, so read them before decoding
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxNotificationBytes))
+	if err != nil {
+		log.Printf("Failed to read deletion notification: %v", err)
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.verifyNotification(r.Context(), r.Header.Get("X-EBAY-SIGNATURE"), payload); err != nil {
+		if errors.Is(err, ebay.ErrInvalidNotificationSignature) {
+			log.Printf("Rejected deletion notification: %v", err)
+			http.Error(w, "Signature verification failed", http.StatusPreconditionFailed)
+			return
+		}
+		// Couldn't get the key: fail so eBay retries later
+		log.Printf("Failed to verify deletion notification: %v", err)
+		http.Error(w, "Unable to verify signature", http.StatusInternalServerError)
+		return
+	}
+
 	// Parse the notification payload
 	var notification EbayDeletionNotification
-	if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+	if err := json.Unmarshal(payload, &notification); err != nil {
 		log.Printf("Failed to parse deletion notification: %v", err)
 		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
 		return
@@ -1581,48 +4271,379 @@ func (h *Handler) handleDeletionNotification(w http.ResponseWriter, r *http.Requ
 		RawPayload:     string(rawPayload),
 	}
 
-	if err := h.db.CreateDeletionNotification(dn); err != nil {
+	created, err := h.db.CreateDeletionNotification(dn)
+	if err != nil {
 		log.Printf("Failed to store deletion notification: %v", err)
 		// Still return success to eBay to avoid retries
-	} else {
+	} else if created {
 		log.Printf("Stored deletion notification: %s", dn.NotificationID)
+	} else {
+		// A redelivery: the stored copy is kept, and only processed if an
+		// earlier attempt failed
+		processed, err := h.db.IsDeletionNotificationProcessed(dn.NotificationID)
+		if err != nil {
+			log.Printf("Failed to check deletion notification %s: %v", dn.NotificationID, err)
+		} else if processed {
+			log.Printf("Deletion notification %s already processed; ignoring redelivery", dn.NotificationID)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+
+	// A failure leaves the notification unprocessed; it can be replayed from
+	// POST /api/admin/deletion-notifications/:id/reprocess
+	if err := h.processDeletionNotification(dn); err != nil {
+		log.Printf("Failed to process deletion notification %s: %v", dn.NotificationID, err)
+	}
+
+	// Respond with 200 OK (or 201/202/204 as per eBay docs)
+	w.WriteHeader(http.StatusOK)
+}
+
+// maxNotificationBytes caps the size of a deletion notification body
+const maxNotificationBytes = 64 << 10
+
+// notificationKeyTTL is how long a notification public key is reused before
+// being fetched again; eBay recommends caching them for an hour
+const notificationKeyTTL = time.Hour
+
+// notificationUnknownKeyTTL is how long a key ID eBay doesn't know is
+// remembered, so made-up key IDs don't each cost a Notification API call
+const notificationUnknownKeyTTL = 10 * time.Minute
+
+// cachedNotificationKey is a notification public key and when it was fetched.
+// key is nil for a key ID eBay reported as unknown.
+type cachedNotificationKey struct {
+	key       *ebay.NotificationPublicKey
+	fetchedAt time.Time
+}
+
+// fresh reports whether the entry can still be used
+func (c *cachedNotificationKey) fresh() bool {
+	ttl := notificationKeyTTL
+	if c.key == nil {
+		ttl = notificationUnknownKeyTTL
+	}
+	return time.Since(c.fetchedAt) < ttl
+}
+
+// verifyNotification checks an X-EBAY-SIGNATURE header against the raw
+// notification payload. A bad or missing signature, or one made with a key
+// eBay doesn't know, is reported as ebay.ErrInvalidNotificationSignature;
+// any other error means the key couldn't be fetched.
+func (h *Handler) verifyNotification(ctx context.Context, header string, payload []byte) error {
+	sig, err := ebay.ParseNotificationSignature(header)
+	if err != nil {
+		return err
 	}
+	key, err := h.notificationKey(ctx, sig.KeyID)
+	if err != nil {
+		return err
+	}
+	return ebay.VerifyNotificationSignature(key, sig, payload)
+}
+
+// notificationKey returns the public key with keyID, from the cache if it was
+// fetched within notificationKeyTTL. Keys are fetched with an application
+// token, since notifications arrive without a user session. Concurrent
+// requests for the same key ID share one fetch.
+func (h *Handler) notificationKey(ctx context.Context, keyID string) (*ebay.NotificationPublicKey, error) {
+	if cached := h.cachedNotificationKey(keyID); cached != nil {
+		if cached.key == nil {
+			return nil, unknownNotificationKeyError(keyID)
+		}
+		return cached.key, nil
+	}
+
+	v, err, _ := h.notificationKeyFetches.Do(keyID, func() (interface{}, error) {
+		// The fetch is shared, so one caller giving up mustn't fail the others
+		fetchCtx := context.WithoutCancel(ctx)
+		client, err := h.getAppClient(fetchCtx)
+		if err != nil {
+			return nil, err
+		}
+		key, err := client.GetNotificationPublicKey(fetchCtx, keyID)
+		if isUnknownNotificationKey(err) {
+			h.cacheNotificationKey(keyID, nil)
+			return nil, unknownNotificationKeyError(keyID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch notification public key %s: %w", keyID, err)
+		}
+		h.cacheNotificationKey(keyID, key)
+		return key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*ebay.NotificationPublicKey), nil
+}
+
+// cachedNotificationKey returns the fresh cache entry for keyID, or nil
+func (h *Handler) cachedNotificationKey(keyID string) *cachedNotificationKey {
+	h.notificationKeysMutex.Lock()
+	defer h.notificationKeysMutex.Unlock()
+
+	if cached, ok := h.notificationKeys[keyID]; ok && cached.fresh() {
+		return cached
+	}
+	return nil
+}
+
+// cacheNotificationKey stores key under keyID; nil records an unknown key ID
+func (h *Handler) cacheNotificationKey(keyID string, key *ebay.NotificationPublicKey) {
+	h.notificationKeysMutex.Lock()
+	defer h.notificationKeysMutex.Unlock()
+
+	h.notificationKeys[keyID] = &cachedNotificationKey{key: key, fetchedAt: time.Now()}
+}
 
+// isUnknownNotificationKey reports whether getPublicKey rejected the key ID
+// itself, rather than failing for some other reason
+func isUnknownNotificationKey(err error) bool {
+	var apiErr *ebay.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusBadRequest
+}
+
+func unknownNotificationKeyError(keyID string) error {
+	return fmt.Errorf("%w: unknown key %s", ebay.ErrInvalidNotificationSignature, keyID)
+}
+
+// processDeletionNotification carries out a deletion notification and marks it
+// processed. Safe to run more than once for the same notification.
+func (h *Handler) processDeletionNotification(dn *database.DeletionNotification) error {
 	// NOTE: This application uses memory-only OAuth token storage (tokens lost on restart).
 	// No persistent user credentials are stored, so there is no user data to delete.
 	// The notification is logged for eBay compliance and audit trail purposes.
 	//
 	// If OAuth token persistence is implemented in the future, token deletion logic
-	// must be added here to match on notification.Notification.Data.UserID.
+	// must be added here to match on dn.UserID.
 
 	log.Printf("Notification logged. No persistent user data to delete (memory-only OAuth tokens).")
 
-	// Mark as processed immediately
 	if err := h.db.MarkDeletionNotificationProcessed(dn.NotificationID); err != nil {
-		log.Printf("Failed to mark notification as processed: %v", err)
+		return fmt.Errorf("failed to mark notification as processed: %w", err)
 	}
-
-	// Respond with 200 OK (or 201/202/204 as per eBay docs)
-	w.WriteHeader(http.StatusOK)
+	return nil
 }
 
-// GetDeletionNotifications returns deletion notifications for admin viewing
+// GetDeletionNotifications returns deletion notifications for admin viewing,
+// newest first, paged with ?limit=&offset= and filtered by ?processed=
 func (h *Handler) GetDeletionNotifications(w http.ResponseWriter, r *http.Request) {
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	if limit <= 0 {
-		limit = 50
+	h.listDeletionNotifications(w, r, false, "GetDeletionNotifications")
+}
+
+// listDeletionNotifications serves a page of deletion notifications with the
+// total matching ?processed=. oldestFirst lists them in arrival order.
+func (h *Handler) listDeletionNotifications(w http.ResponseWriter, r *http.Request, oldestFirst bool, name string) {
+	limit, offset := parsePagination(r, 50, maxDeletionNotificationsLimit)
+	query := database.DeletionNotificationsQuery{Limit: limit, Offset: offset, OldestFirst: oldestFirst}
+	if param := r.URL.Query().Get("processed"); param != "" {
+		processed, err := strconv.ParseBool(param)
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "processed must be true or false")
+			return
+		}
+		query.Processed = &processed
 	}
 
-	notifications, err := h.db.GetDeletionNotifications(limit)
+	notifications, total, err := h.db.QueryDeletionNotifications(query)
 	if err != nil {
-		log.Printf("GetDeletionNotifications error: %v", err)
+		log.Printf("%s error: %v", name, err)
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if notifications == nil {
+		notifications = []database.DeletionNotification{}
+	}
 
 	jsonResponse(w, http.StatusOK, map[string]interface{}{
 		"notifications": notifications,
-		"total":         len(notifications),
+		"total":         total,
+		"limit":         limit,
+		"offset":        offset,
+	})
+}
+
+// GetDeletionNotificationByID returns one deletion notification with its raw
+// payload pretty-printed: /api/deletion-notifications/:id
+func (h *Handler) GetDeletionNotificationByID(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/deletion-notifications/"), "/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid notification ID")
+		return
+	}
+
+	notification, err := h.db.GetDeletionNotificationByID(id)
+	if err != nil {
+		log.Printf("GetDeletionNotificationByID error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if notification == nil {
+		errorResponse(w, http.StatusNotFound, "Notification not found")
+		return
+	}
+
+	// Payloads are stored as received; leave anything that isn't valid JSON as-is
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, []byte(notification.RawPayload), "", "  "); err == nil {
+		notification.RawPayload = pretty.String()
+	}
+
+	jsonResponse(w, http.StatusOK, notification)
+}
+
+// DebugItemResponses returns the raw Trading API responses stored for an
+// item while debug_store_raw_responses is on: /api/debug/item/:id. With
+// ?call=GetItem the raw XML of that call is returned as is.
+func (h *Handler) DebugItemResponses(w http.ResponseWriter, r *http.Request) {
+	itemID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/debug/item/"), "/")
+	if itemID == "" || strings.Contains(itemID, "/") {
+		errorResponse(w, http.StatusBadRequest, "Item ID required: /api/debug/item/:id")
+		return
+	}
+
+	if callName := r.URL.Query().Get("call"); callName != "" {
+		response, err := h.db.GetRawAPIResponse(itemID, callName)
+		if err != nil {
+			log.Printf("DebugItemResponses error: %v", err)
+			errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if response == nil {
+			errorResponse(w, http.StatusNotFound, fmt.Sprintf("No stored %s response for item %s", callName, itemID))
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		w.Write([]byte(response.Response))
+		return
+	}
+
+	responses, err := h.db.GetRawAPIResponses(itemID)
+	if err != nil {
+		log.Printf("DebugItemResponses error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(responses) == 0 {
+		errorResponse(w, http.StatusNotFound, fmt.Sprintf("No stored responses for item %s (is debug_store_raw_responses on?)", itemID))
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"itemId":    itemID,
+		"responses": responses,
+	})
+}
+
+// AdminBackup streams a consistent copy of the SQLite database as a download.
+// The copy is made with VACUUM INTO in a temp directory rather than by reading
+// the live file, which may be mid-write.
+func (h *Handler) AdminBackup(w http.ResponseWriter, r *http.Request) {
+	// The copy holds every account's data and the encrypted eBay credentials,
+	// so only a signed-in session may download it
+	client, err := h.getEbayClient(r)
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Session error")
+		return
+	}
+	if !client.IsAuthenticated() {
+		errorResponse(w, http.StatusUnauthorized, "Not authenticated with eBay")
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "ebay-helpers-backup-")
+	if err != nil {
+		log.Printf("AdminBackup error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to create backup")
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "backup.db")
+	if err := h.db.BackupTo(path); err != nil {
+		log.Printf("AdminBackup error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to create backup")
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Printf("AdminBackup error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to read backup")
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		log.Printf("AdminBackup error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to read backup")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="ebay-helpers-%s.db"`, time.Now().Format("2006-01-02-150405")))
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	if _, err := io.Copy(w, f); err != nil {
+		log.Printf("AdminBackup write error: %v", err)
+		return
+	}
+	log.Printf("Database backup downloaded (%d bytes)", info.Size())
+}
+
+// AdminDeletionNotifications lists deletion notifications for recovery, with
+// ?processed=false for the ones that still need replaying. Filtered lists are
+// oldest first, so they page in replay order.
+func (h *Handler) AdminDeletionNotifications(w http.ResponseWriter, r *http.Request) {
+	oldestFirst := r.URL.Query().Get("processed") != ""
+	h.listDeletionNotifications(w, r, oldestFirst, "AdminDeletionNotifications")
+}
+
+// ReprocessDeletionNotification re-runs processing for one notification:
+// /api/admin/deletion-notifications/:id/reprocess
+func (h *Handler) ReprocessDeletionNotification(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/admin/deletion-notifications/")
+	idStr, ok := strings.CutSuffix(strings.TrimSuffix(rest, "/"), "/reprocess")
+	if !ok {
+		errorResponse(w, http.StatusNotFound, "Not found")
+		return
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Invalid notification ID")
+		return
+	}
+
+	notification, err := h.db.GetDeletionNotificationByID(id)
+	if err != nil {
+		log.Printf("ReprocessDeletionNotification error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if notification == nil {
+		errorResponse(w, http.StatusNotFound, "Notification not found")
+		return
+	}
+
+	wasProcessed := notification.Processed
+	log.Printf("Reprocessing deletion notification %s (previously processed: %v)", notification.NotificationID, wasProcessed)
+	if err := h.processDeletionNotification(notification); err != nil {
+		log.Printf("Reprocess of deletion notification %s failed: %v", notification.NotificationID, err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"status":              "processed",
+		"id":                  notification.ID,
+		"notificationId":      notification.NotificationID,
+		"previouslyProcessed": wasProcessed,
 	})
 }
 
@@ -1630,6 +4651,10 @@ func (h *Handler) GetDeletionNotifications(w http.ResponseWriter, r *http.Reques
 type BatchCalculateItem struct {
 	ItemID string  `json:"itemId"`
 	Price  float64 `json:"price"`
+
+	// CountryOfOrigin, when set, is used for the calculation instead of the
+	// enriched COO, e.g. to pick one of the brand's secondary countries
+	CountryOfOrigin string `json:"countryOfOrigin,omitempty"`
 }
 
 // BatchCalculateResponse holds calculated data for an item
@@ -1639,7 +4664,31 @@ type BatchCalculateResponse struct {
 	COOStatus      string  `json:"cooStatus"` // "match", "mismatch", "missing"
 	CalculatedCost float64 `json:"calculatedCost"`
 	Diff           float64 `json:"diff"`
-	DiffStatus     string  `json:"diffStatus"` // "ok" or "bad"
+	DiffStatus     string  `json:"diffStatus"` // "ok", "bad" or "unknown" (no AUD rate for the shipping currency)
+
+	ShippingCostAUD float64 `json:"shippingCostAUD"` // eBay shipping cost converted to AUD for the diff
+
+	CalculatedCOO       string `json:"calculatedCoo"`       // COO the cost was calculated with
+	COONotKnownForBrand bool   `json:"cooNotKnownForBrand"` // CalculatedCOO isn't one of the brand's primary/secondary countries
+
+	WeightBand   string `json:"weightBand"`
+	WeightSource string `json:"weightSource"` // "itemWeight", "brandType" or "default"
+
+	// Components of CalculatedCost: ShippingSubtotal + DutiesSubtotal
+	Breakdown calculator.ShippingBreakdown `json:"breakdown"`
+}
+
+// itemWeights returns every stored item weight in grams, by item ID
+func (h *Handler) itemWeights() (map[string]int, error) {
+	stored, err := h.db.GetItemWeights()
+	if err != nil {
+		return nil, err
+	}
+	weights := make(map[string]int, len(stored))
+	for _, sw := range stored {
+		weights[sw.ItemID] = sw.WeightGrams
+	}
+	return weights, nil
 }
 
 // BatchCalculate calculates postage for multiple items using server-side logic
@@ -1657,41 +4706,50 @@ func (h *Handler) BatchCalculate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Items with a stored weight use its band rather than a guessed one
+	weights, err := h.itemWeights()
+	if err != nil {
+		log.Printf("[BATCH-CALC] Failed to load item weights: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Failed to load item weights")
+		return
+	}
+
 	results := make(map[string]BatchCalculateResponse)
+	accountKey := h.sessionAccountKey(r)
 
 	for _, item := range items {
-		// Get enrichment data from cache (brand, COO, shipping)
-		h.enrichmentMutex.RLock()
-		enriched, exists := h.enrichmentCache[item.ItemID]
-		h.enrichmentMutex.RUnlock()
-
-		if !exists || enriched == nil {
+		// Get enrichment data from cache (brand, COO, shipping), as this
+		// session's account sees it
+		enriched, exists := h.cachedEnrichment(accountKey, item.ItemID)
+		if !exists {
 			continue // Skip items not yet enriched
 		}
 
 		// Get expected COO from brand mapping
-		expectedCOO := h.calcConfig.GetCountryOfOrigin(enriched.Brand)
+		expectedCOO := h.calculator().GetCountryOfOrigin(enriched.Brand)
 
-		// Determine COO status
-		var cooStatus string
+		// Determine COO status; secondary COOs match when coo_honor_secondary is on
+		cooStatus := h.calculator().COOStatus(enriched.Brand, enriched.CountryOfOrigin)
 		coo := enriched.CountryOfOrigin
 		if coo == "" {
-			cooStatus = "missing"
 			coo = expectedCOO // Use expected for calculation
-		} else if coo == expectedCOO {
-			cooStatus = "match"
-		} else {
-			cooStatus = "mismatch"
+		}
+		if item.CountryOfOrigin != "" {
+			coo = item.CountryOfOrigin // Explicit choice wins for the calculation
 		}
 
-		// Calculate postage using backend calculator
-		result, err := h.calcConfig.CalculateUSAShipping(calculator.CalculateUSAShippingParams{
+		// Calculate postage using backend calculator. Without a stored weight
+		// or a known discount band, assume the seller's typical parcel
+		// (default_weight_band / default_discount_band settings).
+		calc := h.calculator()
+		weightBand, weightSource := calc.ItemWeightBand(enriched.Brand, weights[item.ItemID])
+		result, err := calc.CalculateUSAShipping(calculator.CalculateUSAShippingParams{
 			ItemValueAUD:      item.Price,
-			WeightBand:        "Medium", // Default - TODO: make configurable
+			WeightBand:        weightBand, // Guessed from brand type when no weight is stored
 			BrandName:         enriched.Brand,
 			CountryOfOrigin:   coo,
 			IncludeExtraCover: item.Price > 100,
-			DiscountBand:      3, // Default band 3 - TODO: make configurable
+			DiscountBand:      calc.FallbackDiscountBand,
 		})
 
 		if err != nil {
@@ -1699,19 +4757,27 @@ func (h *Handler) BatchCalculate(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		// Calculate diff
+		// Calculate diff in AUD - eBay may report shipping in the buyer's currency
 		shippingCost := 0.0
 		if enriched.ShippingCost != "" {
 			fmt.Sscanf(enriched.ShippingCost, "%f", &shippingCost)
 		}
-		diff := shippingCost - result.Total
+		shippingCost, converted := h.calculator().ToAUD(shippingCost, enriched.ShippingCurrency)
 
-		// Determine diff status (5% threshold)
+		// Determine diff status (5% threshold); without an AUD rate the
+		// listed cost can't be compared, as on the listings page
+		var diff float64
 		var diffStatus string
 		threshold := result.Total * 1.05
-		if shippingCost >= threshold {
+		switch {
+		case !converted:
+			log.Printf("[BATCH-CALC] No AUD rate for %s (item %s)", enriched.ShippingCurrency, item.ItemID)
+			diffStatus = database.DiffStatusUnknown
+		case shippingCost >= threshold:
+			diff = shippingCost - result.Total
 			diffStatus = "ok"
-		} else {
+		default:
+			diff = shippingCost - result.Total
 			diffStatus = "bad"
 		}
 
@@ -1722,6 +4788,16 @@ func (h *Handler) BatchCalculate(w http.ResponseWriter, r *http.Request) {
 			CalculatedCost: result.Total,
 			Diff:           diff,
 			DiffStatus:     diffStatus,
+
+			ShippingCostAUD: shippingCost,
+
+			CalculatedCOO:       result.Inputs.CountryOfOrigin,
+			COONotKnownForBrand: result.Warnings.COONotKnownForBrand,
+
+			WeightBand:   result.Inputs.WeightBand,
+			WeightSource: weightSource,
+
+			Breakdown: result.Breakdown,
 		}
 	}
 
@@ -1770,11 +4846,29 @@ func (h *Handler) UpdateSetting(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Only seeded settings can be changed; their declared type says what's valid
+	setting, err := h.db.GetSetting(key)
+	if err != nil {
+		log.Printf("UpdateSetting error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if setting == nil {
+		errorResponse(w, http.StatusNotFound, fmt.Sprintf("Unknown setting: %s", key))
+		return
+	}
+	if msg := validateSetting(setting, req.Value); msg != "" {
+		errorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
 	if err := h.db.UpdateSetting(key, req.Value); err != nil {
 		log.Printf("UpdateSetting error: %v", err)
 		errorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	// Many settings feed the calculator (e.g. coo_honor_secondary); apply them now
+	h.reloadCalculatorAfterEdit()
 
 	jsonResponse(w, http.StatusOK, map[string]string{
 		"status": "updated",
@@ -1783,33 +4877,64 @@ func (h *Handler) UpdateSetting(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetListings returns enriched listings from database with server-side sort/filter/pagination
-// This is the proper backend-driven approach - frontend just renders what API returns
-func (h *Handler) GetListings(w http.ResponseWriter, r *http.Request) {
-	// Parse query parameters
-	query := database.ListingsQuery{
-		Search:    r.URL.Query().Get("search"),
-		SortBy:    r.URL.Query().Get("sort"),
-		SortOrder: r.URL.Query().Get("order"),
+// validateSetting checks a new value for setting against its declared data
+// type, then against the valid values of settings that only accept certain
+// ones, returning a message if it's invalid
+func validateSetting(setting *database.Setting, value string) string {
+	switch setting.DataType {
+	case "int":
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Sprintf("%s must be a whole number", setting.Key)
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(value, 64); err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Sprintf("%s must be a number", setting.Key)
+		}
+	case "bool":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Sprintf("%s must be true or false", setting.Key)
+		}
+	case "json":
+		if !json.Valid([]byte(value)) {
+			return fmt.Sprintf("%s must be valid JSON", setting.Key)
+		}
 	}
 
-	// Parse page number
-	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
-		if page, err := strconv.Atoi(pageStr); err == nil {
-			query.Page = page
+	switch setting.Key {
+	case "default_weight_band":
+		if !calculator.IsWeightBand(value) {
+			return "default_weight_band must be one of XSmall, Small, Medium, Large, XLarge"
+		}
+	case "default_discount_band":
+		if band, err := strconv.Atoi(value); err != nil || band < 0 || band > calculator.MaxDiscountBand {
+			return fmt.Sprintf("default_discount_band must be a whole number from 0 to %d", calculator.MaxDiscountBand)
+		}
+	case "brand_type_weight_bands":
+		var bands map[string]string
+		if err := json.Unmarshal([]byte(value), &bands); err != nil {
+			return `brand_type_weight_bands must be a JSON object of brand type to weight band, e.g. {"Hats": "XSmall"}`
+		}
+		for brandType, band := range bands {
+			if !calculator.IsWeightBand(band) {
+				return fmt.Sprintf("brand_type_weight_bands: %q for %s is not a weight band", band, brandType)
+			}
 		}
 	}
+	return ""
+}
 
-	// Parse page size
-	query.PageSize = 50 // Default
-	if sizeStr := r.URL.Query().Get("pageSize"); sizeStr != "" {
-		if size, err := strconv.Atoi(sizeStr); err == nil && size > 0 && size <= 100 {
-			query.PageSize = size
-		}
+// GetListings returns enriched listings from database with server-side sort/filter/pagination
+// This is the proper backend-driven approach - frontend just renders what API returns
+func (h *Handler) GetListings(w http.ResponseWriter, r *http.Request) {
+	query, ok := h.parseListingsQuery(w, r)
+	if !ok {
+		return
 	}
 
+	query.Page, query.PageSize = parsePage(r, 50, 100)
+
 	// Query database
-	result, err := h.db.GetListings(query)
+	result, err := h.db.GetListings(query, h.calculator())
 	if err != nil {
 		log.Printf("GetListings error: %v", err)
 		errorResponse(w, http.StatusInternalServerError, err.Error())
@@ -1819,6 +4944,121 @@ func (h *Handler) GetListings(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusOK, result)
 }
 
+// ListingsSummary returns diff status and COO match counts, and the total
+// underpriced postage, over the listings matching GetListings' search and
+// currencyCheck params
+func (h *Handler) ListingsSummary(w http.ResponseWriter, r *http.Request) {
+	query, ok := h.parseListingsQuery(w, r)
+	if !ok {
+		return
+	}
+
+	summary, err := h.db.GetListingsSummary(query, h.calculator())
+	if err != nil {
+		log.Printf("ListingsSummary error: %v", err)
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, summary)
+}
+
+// parseListingsQuery reads the search, sort and filter params shared by
+// GetListings, AccountListings, ListingsSummary and ExportListingsCSV, and
+// prices listings from the session's account. ok is false when a 400 has
+// been written.
+func (h *Handler) parseListingsQuery(w http.ResponseWriter, r *http.Request) (query database.ListingsQuery, ok bool) {
+	query = database.ListingsQuery{
+		Search:        r.URL.Query().Get("search"),
+		SortBy:        r.URL.Query().Get("sort"),
+		SortOrder:     r.URL.Query().Get("order"),
+		CurrencyCheck: r.URL.Query().Get("currencyCheck"),
+		AccountID:     h.sessionAccountID(r),
+	}
+	switch query.CurrencyCheck {
+	case "", database.CurrencyCheckPass, database.CurrencyCheckFail:
+	default:
+		errorResponse(w, http.StatusBadRequest, "currencyCheck must be pass or fail")
+		return query, false
+	}
+	return query, true
+}
+
+// ExportListingsCSV streams listings as a CSV download, honoring the same
+// search/sort params as GetListings but without pagination
+func (h *Handler) ExportListingsCSV(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		errorResponse(w, http.StatusMethodNotAllowed, "GET required")
+		return
+	}
+
+	query, ok := h.parseListingsQuery(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="listings-%s.csv"`, time.Now().Format("2006-01-02")))
+
+	cw := csv.NewWriter(w)
+	flusher, _ := w.(http.Flusher)
+
+	if err := cw.Write([]string{
+		"itemId", "title", "brand", "countryOfOrigin", "expectedCoo", "cooMatch",
+		"shippingCost", "calculatedCost", "diff", "diffStatus",
+	}); err != nil {
+		log.Printf("ExportListingsCSV write error: %v", err)
+		return
+	}
+
+	rowCount := 0
+	err := h.db.EachListing(query, h.calculator(), func(item *database.ListingItem) error {
+		if err := cw.Write([]string{
+			csvSafe(item.ItemID),
+			csvSafe(item.Title),
+			csvSafe(item.Brand),
+			csvSafe(item.CountryOfOrigin),
+			csvSafe(item.ExpectedCOO),
+			item.COOMatch,
+			strconv.FormatFloat(item.ShippingCost, 'f', 2, 64),
+			strconv.FormatFloat(item.CalculatedCost, 'f', 2, 64),
+			strconv.FormatFloat(item.Diff, 'f', 2, 64),
+			item.DiffStatus,
+		}); err != nil {
+			return err
+		}
+
+		// Push rows to the client periodically rather than buffering the whole file
+		rowCount++
+		if rowCount%100 == 0 {
+			cw.Flush()
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return cw.Error()
+	})
+	cw.Flush()
+
+	// Headers are already sent, so errors can only be logged at this point
+	if err != nil {
+		log.Printf("ExportListingsCSV error after %d rows: %v", rowCount, err)
+		return
+	}
+	if err := cw.Error(); err != nil {
+		log.Printf("ExportListingsCSV flush error: %v", err)
+	}
+}
+
+// csvSafe neutralises spreadsheet formula injection in free-text CSV fields
+// (titles and brands come from eBay listings, not from us)
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
 // GetCredentials returns all eBay credentials (without decrypted secrets)
 func (h *Handler) GetCredentials(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -2104,14 +5344,8 @@ func (h *Handler) SwitchEnvironment(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Clear caches
-	h.listingsMutex.Lock()
-	h.listingsCache = nil
-	h.listingsCacheTime = time.Time{}
-	h.listingsMutex.Unlock()
-
-	h.enrichmentMutex.Lock()
-	h.enrichmentCache = make(map[string]*EnrichedItemData)
-	h.enrichmentMutex.Unlock()
+	h.clearListingsCache()
+	h.uncacheEnrichment(nil)
 
 	// Log with safe value - req.Environment already validated to be "production" or "sandbox"
 	// CodeQL: This is safe because validation at line 2084 ensures only whitelisted values
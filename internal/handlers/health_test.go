@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/ebay"
+)
+
+func TestHealthCheckReportsToken(t *testing.T) {
+	h := newTestHandler(t)
+	alice := signIn(t, h, "alice")
+
+	health := func(r *http.Request) (int, map[string]json.RawMessage) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.HealthCheck(rec, r)
+		var resp map[string]json.RawMessage
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode health: %v", err)
+		}
+		// Only the token's expiry is reported
+		for _, raw := range resp {
+			if strings.Contains(string(raw), "token-alice") {
+				t.Errorf("health response leaks the access token: %s", raw)
+			}
+		}
+		return rec.Code, resp
+	}
+
+	// Without a session there's no token to report
+	code, resp := health(httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	if code != http.StatusOK || string(resp["tokenValid"]) != "false" || string(resp["tokenExpiresAt"]) != "null" {
+		t.Errorf("signed out: status %d, tokenValid %s, tokenExpiresAt %s", code, resp["tokenValid"], resp["tokenExpiresAt"])
+	}
+
+	code, resp = health(sessionRequest(http.MethodGet, "/api/health", "", alice))
+	if code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if string(resp["authenticated"]) != "true" || string(resp["tokenValid"]) != "true" || string(resp["database"]) != `"ok"` {
+		t.Errorf("authenticated %s, tokenValid %s, database %s", resp["authenticated"], resp["tokenValid"], resp["database"])
+	}
+	var expiresAt time.Time
+	if err := json.Unmarshal(resp["tokenExpiresAt"], &expiresAt); err != nil {
+		t.Fatalf("tokenExpiresAt = %s: %v", resp["tokenExpiresAt"], err)
+	}
+	if until := time.Until(expiresAt); until < 50*time.Minute || until > time.Hour {
+		t.Errorf("tokenExpiresAt = %v, want about an hour from now", expiresAt)
+	}
+	if _, ok := resp["rateLimit"]; !ok {
+		t.Error("no rateLimit in health response")
+	}
+	if err := h.db.UpdateSetting("ebay_daily_call_budget", "0"); err != nil {
+		t.Fatalf("UpdateSetting: %v", err)
+	}
+	if _, resp = health(sessionRequest(http.MethodGet, "/api/health", "", alice)); string(resp["apiCallsRemaining"]) != "null" {
+		t.Errorf("apiCallsRemaining = %s without a budget, want null", resp["apiCallsRemaining"])
+	}
+
+	// With a daily budget the remaining calls are reported
+	if err := h.db.UpdateSetting("ebay_daily_call_budget", "100"); err != nil {
+		t.Fatalf("UpdateSetting: %v", err)
+	}
+	if err := h.db.AddEbayCallCount(ebay.QuotaDay(time.Now()), 30); err != nil {
+		t.Fatalf("AddEbayCallCount: %v", err)
+	}
+	_, resp = health(sessionRequest(http.MethodGet, "/api/health", "", alice))
+	if string(resp["apiCallsRemaining"]) != "70" {
+		t.Errorf("apiCallsRemaining = %s, want 70", resp["apiCallsRemaining"])
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/database"
+	"github.com/julienbonastre/ebay-helpers/internal/ebay"
+	"golang.org/x/oauth2"
+)
+
+// newTestHandler returns a sandbox Handler over a fresh database seeded with
+// the calculator's reference data
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.SeedInitialData(); err != nil {
+		t.Fatalf("SeedInitialData: %v", err)
+	}
+	store := database.NewDBSessionStore(db, []byte("test-session-secret-0123456789ab"))
+	return NewHandler(db, ebay.Config{Sandbox: true}, store, "", "", "sandbox", "EBAY_AU", nil)
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// signIn creates accountKey's account and returns the cookies of a session
+// signed in to it, as the OAuth callback leaves them
+func signIn(t *testing.T, h *Handler, accountKey string) []*http.Cookie {
+	t.Helper()
+	if _, err := h.db.GetOrCreateAccount(accountKey, accountKey, "sandbox", "EBAY_AU"); err != nil {
+		t.Fatalf("GetOrCreateAccount(%s): %v", accountKey, err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/auth/callback", nil)
+	rec := httptest.NewRecorder()
+	token := &oauth2.Token{AccessToken: "token-" + accountKey, Expiry: time.Now().Add(time.Hour)}
+	if err := h.saveTokenToSession(rec, r, token); err != nil {
+		t.Fatalf("saveTokenToSession: %v", err)
+	}
+	// The second save must find the session the first one created
+	for _, c := range rec.Result().Cookies() {
+		r.AddCookie(c)
+	}
+	rec = httptest.NewRecorder()
+	if err := h.saveAccountToSession(rec, r, accountKey); err != nil {
+		t.Fatalf("saveAccountToSession: %v", err)
+	}
+	return rec.Result().Cookies()
+}
+
+// sessionRequest is httptest.NewRequest carrying a signIn session
+func sessionRequest(method, target, body string, cookies []*http.Cookie) *http.Request {
+	var r *http.Request
+	if body == "" {
+		r = httptest.NewRequest(method, target, nil)
+	} else {
+		r = httptest.NewRequest(method, target, strings.NewReader(body))
+	}
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	return r
+}
+
+// serveEbay sends every eBay call made during the test to handler. Clients
+// capture http.DefaultTransport when created, so create them afterwards.
+func serveEbay(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	target, _ := url.Parse(server.URL)
+	orig := http.DefaultTransport
+	http.DefaultTransport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r.URL.Scheme, r.URL.Host = target.Scheme, target.Host
+		return orig.RoundTrip(r)
+	})
+	t.Cleanup(func() { http.DefaultTransport = orig })
+}
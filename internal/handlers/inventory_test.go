@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienbonastre/ebay-helpers/internal/ebay"
+)
+
+func TestGetInventoryItem(t *testing.T) {
+	var requested []string
+	serveEbay(t, func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.EscapedPath())
+		switch strings.TrimPrefix(r.URL.Path, "/sell/inventory/v1/inventory_item/") {
+		case "WOOL HAT":
+			w.Write([]byte(`{"sku":"WOOL HAT","condition":"USED_EXCELLENT","product":{"title":"Wool hat"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"errorId":25710,"message":"We didn't find the entity you are requesting."}]}`))
+		}
+	})
+	h := newTestHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.GetInventoryItem(rec, httptest.NewRequest(http.MethodGet, "/api/inventory/WOOL%20HAT", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("signed out: status = %d, want 401", rec.Code)
+	}
+
+	alice := signIn(t, h, "alice")
+	rec = httptest.NewRecorder()
+	h.GetInventoryItem(rec, sessionRequest(http.MethodGet, "/api/inventory/WOOL%20HAT", "", alice))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("found: status = %d: %s", rec.Code, rec.Body)
+	}
+	var item ebay.InventoryItem
+	if err := json.NewDecoder(rec.Body).Decode(&item); err != nil {
+		t.Fatal(err)
+	}
+	if item.SKU != "WOOL HAT" || item.Product == nil || item.Product.Title != "Wool hat" {
+		t.Errorf("item = %+v, want the wool hat", item)
+	}
+	if len(requested) != 1 || requested[0] != "/sell/inventory/v1/inventory_item/WOOL%20HAT" {
+		t.Errorf("eBay requests = %v, want the escaped SKU", requested)
+	}
+
+	rec = httptest.NewRecorder()
+	h.GetInventoryItem(rec, sessionRequest(http.MethodGet, "/api/inventory/MISSING", "", alice))
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "MISSING") {
+		t.Errorf("not found: %d %s, want 404 naming the SKU", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.GetInventoryItem(rec, sessionRequest(http.MethodGet, "/api/inventory/", "", alice))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("no SKU: status = %d, want 400", rec.Code)
+	}
+}
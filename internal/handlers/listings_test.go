@@ -0,0 +1,579 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/database"
+	"github.com/julienbonastre/ebay-helpers/internal/ebay"
+)
+
+func TestExportListingsCSV(t *testing.T) {
+	h := newTestHandler(t)
+	acc, err := h.db.GetOrCreateAccount("seller", "seller", "sandbox", "EBAY_AU")
+	if err != nil {
+		t.Fatalf("GetOrCreateAccount: %v", err)
+	}
+	for _, id := range []string{"item-1", "item-2"} {
+		err := h.db.SaveEnrichedItem(&database.EnrichedItem{
+			ItemID: id, Brand: "Acme", CountryOfOrigin: "China",
+			ShippingCost: "25.00", ShippingCurrency: "AUD", EnrichedAt: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("SaveEnrichedItem(%s): %v", id, err)
+		}
+	}
+	if _, err := h.db.Exec(`INSERT INTO offers (account_id, offer_id, sku, listing_id, data) VALUES (?, '1', 'SKU-1', 'item-1', '{}')`, acc.ID); err != nil {
+		t.Fatalf("insert offer: %v", err)
+	}
+	if _, err := h.db.Exec(`INSERT INTO inventory_items (account_id, sku, title, data) VALUES (?, 'SKU-1', 'Blue teapot', '{}')`, acc.ID); err != nil {
+		t.Fatalf("insert inventory item: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ExportListingsCSV(rec, httptest.NewRequest(http.MethodGet, "/api/listings/export.csv?sort=brand", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Content-Type = %q, want text/csv", ct)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment;") {
+		t.Errorf("Content-Disposition = %q, want attachment", cd)
+	}
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want header and 2 rows", len(records))
+	}
+	if got := strings.Join(records[0], ","); got != "itemId,title,brand,countryOfOrigin,expectedCoo,cooMatch,shippingCost,calculatedCost,diff,diffStatus" {
+		t.Errorf("header = %s", got)
+	}
+	rows := map[string][]string{records[1][0]: records[1], records[2][0]: records[2]}
+	if got := rows["item-1"][1]; got != "Blue teapot" {
+		t.Errorf("item-1 title = %q, want Blue teapot", got)
+	}
+	if got := rows["item-2"][1]; got != "" {
+		t.Errorf("item-2 title = %q, want empty (never synced)", got)
+	}
+	if got := rows["item-1"][6]; got != "25.00" {
+		t.Errorf("item-1 shippingCost = %q, want 25.00", got)
+	}
+}
+
+func TestSyncExportInvalidatesSessionAccountListings(t *testing.T) {
+	// Every resource comes back empty
+	serveEbay(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	h := newTestHandler(t)
+	alice := signIn(t, h, "alice")
+	signIn(t, h, "bob")
+
+	// Another session hydrated currentAccount as bob
+	bobAccount, _ := h.db.GetAccountByKey("bob")
+	h.currentAccount = bobAccount
+
+	for _, account := range []string{"alice", "bob"} {
+		key := listingsCacheKey(account, h.marketplaceID)
+		h.listingsCache[key] = &listingsCacheEntry{accountKey: account, offers: []map[string]interface{}{{"itemId": "1"}}, cachedAt: time.Now()}
+	}
+
+	rec := httptest.NewRecorder()
+	h.SyncExport(rec, sessionRequest(http.MethodPost, "/api/sync/export", "", alice))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+
+	if h.cachedListings(listingsCacheKey("alice", h.marketplaceID)) != nil {
+		t.Error("alice's cached listings survived her export")
+	}
+	if h.cachedListings(listingsCacheKey("bob", h.marketplaceID)) == nil {
+		t.Error("alice's export dropped bob's cached listings")
+	}
+
+	aliceAccount, _ := h.db.GetAccountByKey("alice")
+	history, _, err := h.db.GetSyncHistory(aliceAccount.ID, database.SyncHistoryQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("GetSyncHistory: %v", err)
+	}
+	if len(history) != 1 {
+		t.Errorf("alice has %d exports recorded, want 1 (the export belongs to her session)", len(history))
+	}
+}
+
+// TestListingsMatchBatchCalculate checks /api/listings and
+// /api/calculate/batch price the same listing identically
+func TestListingsMatchBatchCalculate(t *testing.T) {
+	h := newTestHandler(t)
+	acc, err := h.db.GetOrCreateAccount("alice", "alice", "sandbox", "EBAY_AU")
+	if err != nil {
+		t.Fatalf("GetOrCreateAccount: %v", err)
+	}
+
+	items := []struct {
+		itemID, brand, coo, shipping, currency string
+		price                                  float64
+		weightGrams                            int
+	}{
+		{"item-1", "Aje", "China", "30.00", "AUD", 80, 0},
+		{"item-2", "Lack of Color", "China", "20.00", "USD", 150, 0}, // Brand type band, converted shipping
+		{"item-3", "Camilla Franks", "India", "60.00", "AUD", 320, 1200},
+		{"item-4", "Unmapped Label", "", "25.00", "AUD", 45, 0},
+	}
+	var batch []BatchCalculateItem
+	for i, it := range items {
+		enriched := &database.EnrichedItem{
+			ItemID: it.itemID, Brand: it.brand, CountryOfOrigin: it.coo,
+			ShippingCost: it.shipping, ShippingCurrency: it.currency, EnrichedAt: time.Now(),
+		}
+		if err := h.db.SaveEnrichedItem(enriched); err != nil {
+			t.Fatalf("SaveEnrichedItem: %v", err)
+		}
+		h.cacheEnrichment(publicEnrichment, &EnrichedItemData{
+			ItemID: it.itemID, Brand: it.brand, CountryOfOrigin: it.coo,
+			ShippingCost: it.shipping, ShippingCurrency: it.currency, EnrichedAt: enriched.EnrichedAt,
+		})
+		if it.weightGrams > 0 {
+			if err := h.db.SetItemWeight(it.itemID, it.weightGrams); err != nil {
+				t.Fatalf("SetItemWeight: %v", err)
+			}
+		}
+		data := fmt.Sprintf(`{"pricingSummary":{"price":{"value":"%.2f","currency":"AUD"}}}`, it.price)
+		if _, err := h.db.Exec(`INSERT INTO offers (account_id, offer_id, sku, listing_id, data) VALUES (?, ?, ?, ?, ?)`,
+			acc.ID, fmt.Sprint(i+1), "SKU-"+it.itemID, it.itemID, data); err != nil {
+			t.Fatalf("insert offer: %v", err)
+		}
+		batch = append(batch, BatchCalculateItem{ItemID: it.itemID, Price: it.price})
+	}
+
+	listings, err := h.db.GetListings(database.ListingsQuery{PageSize: 100}, h.calculator())
+	if err != nil {
+		t.Fatalf("GetListings: %v", err)
+	}
+	body, _ := json.Marshal(batch)
+	rec := httptest.NewRecorder()
+	h.BatchCalculate(rec, httptest.NewRequest(http.MethodPost, "/api/calculate/batch", strings.NewReader(string(body))))
+	var calculated map[string]BatchCalculateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&calculated); err != nil {
+		t.Fatalf("decode batch: %v", err)
+	}
+
+	if len(listings.Items) != len(items) {
+		t.Fatalf("got %d listings, want %d", len(listings.Items), len(items))
+	}
+	for _, listing := range listings.Items {
+		want, ok := calculated[listing.ItemID]
+		if !ok {
+			t.Errorf("%s: no batch result", listing.ItemID)
+			continue
+		}
+		if listing.CalculatedCost != want.CalculatedCost || listing.DiffStatus != want.DiffStatus ||
+			listing.WeightBand != want.WeightBand || listing.WeightSource != want.WeightSource ||
+			math.Abs(listing.Diff-want.Diff) > 0.005 || listing.ShippingCostAUD != want.ShippingCostAUD {
+			t.Errorf("%s: listings = {cost %.2f diff %.2f %s band %s/%s shipping %.2f}, batch = {cost %.2f diff %.2f %s band %s/%s shipping %.2f}",
+				listing.ItemID,
+				listing.CalculatedCost, listing.Diff, listing.DiffStatus, listing.WeightBand, listing.WeightSource, listing.ShippingCostAUD,
+				want.CalculatedCost, want.Diff, want.DiffStatus, want.WeightBand, want.WeightSource, want.ShippingCostAUD)
+		}
+	}
+}
+
+// serveListingPages answers GetMyeBaySelling with one item per page out of
+// totalPages. failPage reports whether a page should fail with a 500.
+func serveListingPages(t *testing.T, totalPages int, failPage func(pageNum int) bool) {
+	t.Helper()
+	serveEbay(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			PageNumber int `xml:"ActiveList>Pagination>PageNumber"`
+		}
+		if err := xml.Unmarshal(body, &req); err != nil {
+			t.Errorf("decode GetMyeBaySelling request: %v", err)
+		}
+		if failPage(req.PageNumber) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `<GetMyeBaySellingResponse><Ack>Success</Ack><ActiveList>
+			<ItemArray><Item><ItemID>item-%d</ItemID></Item></ItemArray>
+			<PaginationResult><TotalNumberOfPages>%d</TotalNumberOfPages><TotalNumberOfEntries>%d</TotalNumberOfEntries></PaginationResult>
+		</ActiveList></GetMyeBaySellingResponse>`, req.PageNumber, totalPages, totalPages*100)
+	})
+}
+
+func TestGetOffersReportsFailedPagesWithoutCaching(t *testing.T) {
+	var pageThreeDown atomic.Bool
+	pageThreeDown.Store(true)
+	serveListingPages(t, 4, func(pageNum int) bool { return pageNum == 3 && pageThreeDown.Load() })
+	h := newTestHandler(t)
+	alice := signIn(t, h, "alice")
+
+	type offersResponse struct {
+		Total       int   `json:"total"`
+		Cached      bool  `json:"cached"`
+		Partial     bool  `json:"partial"`
+		FailedPages []int `json:"failedPages"`
+	}
+	getOffers := func() offersResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.GetOffers(rec, sessionRequest(http.MethodGet, "/api/offers", "", alice))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		var resp offersResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	resp := getOffers()
+	if !resp.Partial || !reflect.DeepEqual(resp.FailedPages, []int{3}) || resp.Total != 3 {
+		t.Errorf("with page 3 down = %+v, want partial, failed page 3 and 3 offers", resp)
+	}
+
+	// The incomplete load wasn't cached, so page 3 is retried
+	pageThreeDown.Store(false)
+	resp = getOffers()
+	if resp.Partial || len(resp.FailedPages) != 0 || resp.Total != 4 || resp.Cached {
+		t.Errorf("after page 3 recovers = %+v, want a complete live load of 4 offers", resp)
+	}
+
+	// The complete load is cached
+	if resp = getOffers(); !resp.Cached || resp.Total != 4 {
+		t.Errorf("third request = %+v, want the 4 cached offers", resp)
+	}
+}
+
+func TestEndListing(t *testing.T) {
+	var calls atomic.Int32
+	serveEbay(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`<EndItemResponse><Ack>Success</Ack></EndItemResponse>`))
+	})
+	h := newTestHandler(t)
+	alice := signIn(t, h, "alice")
+	key := listingsCacheKey("alice", h.marketplaceID)
+	h.listingsCache[key] = &listingsCacheEntry{accountKey: "alice", offers: []map[string]interface{}{{"offerId": "1234"}}, cachedAt: time.Now()}
+
+	for _, body := range []string{`{"itemId":" ","reason":"Incorrect"}`, `{"itemId":"1234","reason":"Bored"}`} {
+		rec := httptest.NewRecorder()
+		h.EndListing(rec, sessionRequest(http.MethodPost, "/api/listings/end", body, alice))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", body, rec.Code, http.StatusBadRequest)
+		}
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("invalid requests made %d eBay calls", calls.Load())
+	}
+
+	rec := httptest.NewRecorder()
+	h.EndListing(rec, sessionRequest(http.MethodPost, "/api/listings/end", `{"itemId":"1234","reason":"Incorrect"}`, alice))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("eBay calls = %d, want 1", calls.Load())
+	}
+	if h.cachedListings(key) != nil {
+		t.Error("the ended listing is still in the cached listings")
+	}
+}
+
+func TestGetOffersRevalidation(t *testing.T) {
+	h := newTestHandler(t)
+	alice := signIn(t, h, "alice")
+	cachedAt := time.Now().Add(-time.Hour)
+	h.listingsCache[listingsCacheKey("alice", h.marketplaceID)] = &listingsCacheEntry{
+		accountKey: "alice",
+		offers:     []map[string]interface{}{{"offerId": "1"}, {"offerId": "2"}},
+		cachedAt:   cachedAt,
+	}
+
+	get := func(target string, header http.Header) *httptest.ResponseRecorder {
+		t.Helper()
+		r := sessionRequest(http.MethodGet, target, "", alice)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		h.GetOffers(rec, r)
+		return rec
+	}
+
+	first := get("/api/offers?limit=1", nil)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Header().Get("Last-Modified") == "" {
+		t.Fatalf("first request = %d with ETag %q and Last-Modified %q, want 200 with both", first.Code, etag, first.Header().Get("Last-Modified"))
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		header http.Header
+		want   int
+	}{
+		{"same ETag", "/api/offers?limit=1", http.Header{"If-None-Match": {etag}}, http.StatusNotModified},
+		{"ETag in a list", "/api/offers?limit=1", http.Header{"If-None-Match": {`"other", W/` + etag}}, http.StatusNotModified},
+		{"other page", "/api/offers?limit=1&offset=1", http.Header{"If-None-Match": {etag}}, http.StatusOK},
+		{"stale ETag", "/api/offers?limit=1", http.Header{"If-None-Match": {`"stale"`}}, http.StatusOK},
+		{"modified since", "/api/offers?limit=1", http.Header{"If-Modified-Since": {cachedAt.Add(-time.Minute).UTC().Format(http.TimeFormat)}}, http.StatusOK},
+		{"not modified since", "/api/offers?limit=1", http.Header{"If-Modified-Since": {cachedAt.UTC().Format(http.TimeFormat)}}, http.StatusNotModified},
+		// If-None-Match wins over If-Modified-Since
+		{"stale ETag, not modified since", "/api/offers?limit=1", http.Header{
+			"If-None-Match":     {`"stale"`},
+			"If-Modified-Since": {cachedAt.UTC().Format(http.TimeFormat)},
+		}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if rec := get(tt.target, tt.header); rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestListingsCacheIsolatedBySession(t *testing.T) {
+	h := newTestHandler(t)
+	sessions := map[string][]*http.Cookie{
+		"alice": signIn(t, h, "alice"),
+		"bob":   signIn(t, h, "bob"),
+	}
+	for account := range sessions {
+		h.listingsCache[listingsCacheKey(account, h.marketplaceID)] = &listingsCacheEntry{
+			accountKey: account,
+			offers:     []map[string]interface{}{{"offerId": account + "-offer"}},
+			cachedAt:   time.Now(),
+		}
+	}
+	// The last sign-in left bob as the process-wide account
+	bobAccount, _ := h.db.GetAccountByKey("bob")
+	h.currentAccount = bobAccount
+
+	for account, cookies := range sessions {
+		rec := httptest.NewRecorder()
+		h.GetOffers(rec, sessionRequest(http.MethodGet, "/api/offers", "", cookies))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", account, rec.Code, rec.Body)
+		}
+		var resp struct {
+			Offers []struct {
+				OfferID string `json:"offerId"`
+			} `json:"offers"`
+			Cached bool `json:"cached"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if !resp.Cached || len(resp.Offers) != 1 || resp.Offers[0].OfferID != account+"-offer" {
+			t.Errorf("%s saw %+v, want only its own cached offer", account, resp)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.Logout(rec, sessionRequest(http.MethodPost, "/auth/logout", "", sessions["alice"]))
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("logout status = %d", rec.Code)
+	}
+	if h.cachedListings(listingsCacheKey("alice", h.marketplaceID)) != nil {
+		t.Error("alice's cached listings survived logout")
+	}
+	if h.cachedListings(listingsCacheKey("bob", h.marketplaceID)) == nil {
+		t.Error("alice's logout dropped bob's cached listings")
+	}
+}
+
+func TestGetSyncHistoryQuery(t *testing.T) {
+	h := newTestHandler(t)
+	signIn(t, h, "alice")
+	alice, _ := h.db.GetAccountByKey("alice")
+	h.currentAccount = alice
+	for _, syncType := range []string{"export", "import", "export"} {
+		if err := h.db.CreateSyncHistory(&database.SyncHistory{AccountID: alice.ID, SyncType: syncType, Status: "success", StartedAt: time.Now()}); err != nil {
+			t.Fatalf("CreateSyncHistory: %v", err)
+		}
+	}
+
+	tests := []struct {
+		target     string
+		wantStatus int
+		wantLen    int
+		wantTotal  int
+	}{
+		{"/api/sync/history?syncType=export&limit=1", http.StatusOK, 1, 2},
+		{"/api/sync/history?syncType=export&limit=1&offset=1", http.StatusOK, 1, 2},
+		{"/api/sync/history?status=failed", http.StatusOK, 0, 0},
+		{"/api/sync/history?syncType=backup", http.StatusBadRequest, 0, 0},
+		{"/api/sync/history?status=done", http.StatusBadRequest, 0, 0},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.GetSyncHistory(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.target, rec.Code, tt.wantStatus)
+			continue
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+		var resp struct {
+			History []database.SyncHistory `json:"history"`
+			Total   int                    `json:"total"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: %v", tt.target, err)
+		}
+		if len(resp.History) != tt.wantLen || resp.Total != tt.wantTotal {
+			t.Errorf("%s: %d entries of %d, want %d of %d", tt.target, len(resp.History), resp.Total, tt.wantLen, tt.wantTotal)
+		}
+	}
+}
+
+func TestGetSyncHistoryUsesSessionAccount(t *testing.T) {
+	h := newTestHandler(t)
+	aliceCookies := signIn(t, h, "alice")
+	signIn(t, h, "bob")
+	alice, _ := h.db.GetAccountByKey("alice")
+	bob, _ := h.db.GetAccountByKey("bob")
+	for _, acc := range []*database.Account{alice, bob, bob} {
+		if err := h.db.CreateSyncHistory(&database.SyncHistory{AccountID: acc.ID, SyncType: "export", Status: "success", StartedAt: time.Now()}); err != nil {
+			t.Fatalf("CreateSyncHistory: %v", err)
+		}
+	}
+	h.mu.Lock()
+	h.currentAccount = bob // The last account to sign in on this instance
+	h.mu.Unlock()
+
+	// Logins and logouts on other sessions swap the shared account meanwhile
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			h.mu.Lock()
+			if h.currentAccount == nil {
+				h.currentAccount = bob
+			} else {
+				h.currentAccount = nil
+			}
+			h.mu.Unlock()
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		h.HealthCheck(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/health", nil))
+		h.GetSyncHistory(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/sync/history", nil))
+	}
+	<-done
+
+	rec := httptest.NewRecorder()
+	h.GetSyncHistory(rec, sessionRequest(http.MethodGet, "/api/sync/history", "", aliceCookies))
+	var resp struct {
+		Total int `json:"total"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 1 {
+		t.Errorf("alice's session sees %d history entries, want her 1", resp.Total)
+	}
+}
+
+func TestFetchListingPagesBoundsConcurrency(t *testing.T) {
+	for _, workers := range []int{1, 3, 5} {
+		var inFlight, peak atomic.Int32
+		var fetched atomic.Int32
+		pages, failed := fetchListingPages(context.Background(), 20, workers, func(pageNum int) ([]ebay.TradingItem, error) {
+			n := inFlight.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond) // Long enough for the other workers to pile up
+			inFlight.Add(-1)
+			fetched.Add(1)
+			return []ebay.TradingItem{{ItemID: fmt.Sprint("item-", pageNum)}}, nil
+		})
+		if got := peak.Load(); got != int32(workers) {
+			t.Errorf("%d workers: peak in-flight fetches = %d", workers, got)
+		}
+		if len(pages) != 19 || len(failed) != 0 || fetched.Load() != 19 {
+			t.Errorf("%d workers: fetched %d pages (%d results, %v failed), want pages 2-20", workers, fetched.Load(), len(pages), failed)
+		}
+	}
+}
+
+func TestListingsFetchConcurrencySetting(t *testing.T) {
+	h := newTestHandler(t)
+	if got := h.listingsFetchConcurrency(); got != defaultListingsFetchConcurrency {
+		t.Errorf("default = %d, want %d", got, defaultListingsFetchConcurrency)
+	}
+	for value, want := range map[string]int{"3": 3, "0": 1, "-2": 1, "10": 10, "50": 10, "lots": defaultListingsFetchConcurrency} {
+		if err := h.db.UpdateSetting("listings_fetch_concurrency", value); err != nil {
+			t.Fatal(err)
+		}
+		if got := h.listingsFetchConcurrency(); got != want {
+			t.Errorf("listings_fetch_concurrency=%s: workers = %d, want %d", value, got, want)
+		}
+	}
+}
+
+func TestFetchListingPagesStopsWhenCancelled(t *testing.T) {
+	// A single worker fetches in page order, so cancelling on page 4 must leave pages 5-20 untouched
+	ctx, cancel := context.WithCancel(context.Background())
+	var requested []int
+	pages, failed := fetchListingPages(ctx, 20, 1, func(pageNum int) ([]ebay.TradingItem, error) {
+		requested = append(requested, pageNum)
+		if pageNum == 4 {
+			cancel()
+		}
+		return nil, nil
+	})
+	if want := []int{2, 3, 4}; !reflect.DeepEqual(requested, want) {
+		t.Errorf("requested pages %v after cancelling on page 4, want %v", requested, want)
+	}
+	if len(pages) != 3 || len(failed) != 0 {
+		t.Errorf("got %d pages (%v failed), want the 3 fetched before cancelling", len(pages), failed)
+	}
+
+	// With several workers, fetches already in flight finish but no worker picks up another page
+	ctx, cancel = context.WithCancel(context.Background())
+	var fetched atomic.Int32
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fetchListingPages(ctx, 20, 4, func(pageNum int) ([]ebay.TradingItem, error) {
+			fetched.Add(1)
+			if pageNum == 2 {
+				cancel()
+			}
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("fetchListingPages did not return after cancellation")
+	}
+	if got := fetched.Load(); got < 1 || got > 4 {
+		t.Errorf("requested %d pages with 4 workers after cancelling, want at most the 4 in flight", got)
+	}
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/database"
+	"github.com/julienbonastre/ebay-helpers/internal/ebay"
+	"golang.org/x/oauth2"
+)
+
+// notificationKeyServer serves getPublicKey for one key ID and counts calls.
+// Any other key ID gets eBay's 404.
+type notificationKeyServer struct {
+	keyID   string
+	private *ecdsa.PrivateKey
+	calls   atomic.Int32
+}
+
+func newNotificationKeyServer(t *testing.T, keyID string) *notificationKeyServer {
+	t.Helper()
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	s := &notificationKeyServer{keyID: keyID, private: private}
+
+	der, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	// eBay returns the PEM markers without line breaks
+	pem := "-----BEGIN PUBLIC KEY-----" + base64.StdEncoding.EncodeToString(der) + "-----END PUBLIC KEY-----"
+
+	serveEbay(t, func(w http.ResponseWriter, r *http.Request) {
+		s.calls.Add(1)
+		if !strings.HasSuffix(r.URL.Path, "/public_key/"+keyID) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"errorId":195001,"message":"The specified key id is invalid"}]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(ebay.NotificationPublicKey{Key: pem, Algorithm: "ECDSA", Digest: "SHA1"})
+	})
+	return s
+}
+
+// sign returns an X-EBAY-SIGNATURE header for payload signed with keyID
+func (s *notificationKeyServer) sign(t *testing.T, keyID string, payload []byte) string {
+	t.Helper()
+	digest := sha1.Sum(payload)
+	signature, err := ecdsa.SignASN1(rand.Reader, s.private, digest[:])
+	if err != nil {
+		t.Fatalf("SignASN1: %v", err)
+	}
+	header, _ := json.Marshal(ebay.NotificationSignature{
+		Alg:       "ecdsa",
+		KeyID:     keyID,
+		Signature: base64.StdEncoding.EncodeToString(signature),
+		Digest:    "SHA1",
+	})
+	return base64.StdEncoding.EncodeToString(header)
+}
+
+// newNotificationTestHandler is newTestHandler with a valid application
+// token, so notification keys can be fetched
+func newNotificationTestHandler(t *testing.T) *Handler {
+	t.Helper()
+	h := newTestHandler(t)
+	h.appToken = &oauth2.Token{AccessToken: "app", Expiry: time.Now().Add(time.Hour)}
+	return h
+}
+
+func postNotification(h *Handler, signature string, payload []byte) int {
+	req := httptest.NewRequest(http.MethodPost, "/api/ebay/marketplace-account-deletion", strings.NewReader(string(payload)))
+	req.Header.Set("X-EBAY-SIGNATURE", signature)
+	rec := httptest.NewRecorder()
+	h.MarketplaceAccountDeletion(rec, req)
+	return rec.Code
+}
+
+const testNotificationPayload = `{"metadata":{"topic":"MARKETPLACE_ACCOUNT_DELETION"},"notification":{"notificationId":"n-1","eventDate":"2026-01-02T03:04:05.000Z","data":{"username":"buyer","userId":"u-1","eiasToken":"t-1"}}}`
+
+func TestDeletionNotificationSignature(t *testing.T) {
+	payload := []byte(testNotificationPayload)
+
+	t.Run("good signature", func(t *testing.T) {
+		keys := newNotificationKeyServer(t, "kid-1")
+		h := newNotificationTestHandler(t)
+		if code := postNotification(h, keys.sign(t, "kid-1", payload), payload); code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", code)
+		}
+		if code := postNotification(h, keys.sign(t, "kid-1", payload), payload); code != http.StatusOK {
+			t.Fatalf("redelivery status = %d, want 200", code)
+		}
+		if n := keys.calls.Load(); n != 1 {
+			t.Errorf("getPublicKey called %d times, want 1 (cached)", n)
+		}
+	})
+
+	t.Run("bad signature", func(t *testing.T) {
+		keys := newNotificationKeyServer(t, "kid-1")
+		h := newNotificationTestHandler(t)
+		signature := keys.sign(t, "kid-1", []byte(`{"something":"else"}`))
+		if code := postNotification(h, signature, payload); code != http.StatusPreconditionFailed {
+			t.Fatalf("status = %d, want 412", code)
+		}
+	})
+
+	t.Run("unknown kid", func(t *testing.T) {
+		keys := newNotificationKeyServer(t, "kid-1")
+		h := newNotificationTestHandler(t)
+		for i := 0; i < 3; i++ {
+			if code := postNotification(h, keys.sign(t, "made-up", payload), payload); code != http.StatusPreconditionFailed {
+				t.Fatalf("attempt %d: status = %d, want 412", i+1, code)
+			}
+		}
+		if n := keys.calls.Load(); n != 1 {
+			t.Errorf("getPublicKey called %d times, want 1 (unknown kid cached)", n)
+		}
+	})
+}
+
+func TestDeletionNotificationRedelivery(t *testing.T) {
+	keys := newNotificationKeyServer(t, "kid-1")
+	h := newNotificationTestHandler(t)
+	payload := []byte(testNotificationPayload)
+
+	stored := func() (rows int, processedAt string) {
+		t.Helper()
+		err := h.db.QueryRow(`
+			SELECT COUNT(*), COALESCE(MAX(processed_at), '') FROM deletion_notifications WHERE notification_id = 'n-1'
+		`).Scan(&rows, &processedAt)
+		if err != nil {
+			t.Fatalf("query deletion notifications: %v", err)
+		}
+		return rows, processedAt
+	}
+
+	if code := postNotification(h, keys.sign(t, "kid-1", payload), payload); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	rows, processedAt := stored()
+	if rows != 1 || processedAt == "" {
+		t.Fatalf("after first delivery: %d rows, processed at %q; want 1 processed row", rows, processedAt)
+	}
+
+	// eBay retries the same notificationId: acknowledged, stored once and not
+	// processed again
+	if code := postNotification(h, keys.sign(t, "kid-1", payload), payload); code != http.StatusOK {
+		t.Fatalf("redelivery status = %d, want 200", code)
+	}
+	if rows, again := stored(); rows != 1 || again != processedAt {
+		t.Errorf("after redelivery: %d rows, processed at %q; want 1 row still processed at %q", rows, again, processedAt)
+	}
+}
+
+func TestListDeletionNotificationsParams(t *testing.T) {
+	h := newTestHandler(t)
+	for _, id := range []string{"n-1", "n-2", "n-3"} {
+		if _, err := h.db.CreateDeletionNotification(&database.DeletionNotification{NotificationID: id, EventDate: time.Now(), RawPayload: "{}"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.db.MarkDeletionNotificationProcessed("n-2"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		target    string
+		want      []string
+		wantTotal int
+	}{
+		{"list", h.GetDeletionNotifications, "/api/deletion-notifications", []string{"n-3", "n-2", "n-1"}, 3},
+		{"unprocessed", h.GetDeletionNotifications, "/api/deletion-notifications?processed=false", []string{"n-3", "n-1"}, 2},
+		{"processed", h.GetDeletionNotifications, "/api/deletion-notifications?processed=true", []string{"n-2"}, 1},
+		{"paged", h.GetDeletionNotifications, "/api/deletion-notifications?limit=1&offset=1", []string{"n-2"}, 3},
+		{"admin replay order", h.AdminDeletionNotifications, "/api/admin/deletion-notifications?processed=false", []string{"n-1", "n-3"}, 2},
+		{"admin paged", h.AdminDeletionNotifications, "/api/admin/deletion-notifications?processed=false&limit=1&offset=1", []string{"n-3"}, 2},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		tt.handler(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", tt.name, rec.Code, rec.Body)
+		}
+		var resp struct {
+			Notifications []database.DeletionNotification `json:"notifications"`
+			Total         int                             `json:"total"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: decode: %v", tt.name, err)
+		}
+		var got []string
+		for _, dn := range resp.Notifications {
+			got = append(got, dn.NotificationID)
+		}
+		if !reflect.DeepEqual(got, tt.want) || resp.Total != tt.wantTotal {
+			t.Errorf("%s = %v (total %d), want %v (total %d)", tt.name, got, resp.Total, tt.want, tt.wantTotal)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.GetDeletionNotifications(rec, httptest.NewRequest(http.MethodGet, "/api/deletion-notifications?processed=maybe", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("processed=maybe: status = %d, want 400", rec.Code)
+	}
+}
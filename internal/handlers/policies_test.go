@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetPaymentAndReturnPolicies(t *testing.T) {
+	var requested []string
+	serveEbay(t, func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path+"?"+r.URL.RawQuery)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/payment_policy"):
+			w.Write([]byte(`{"paymentPolicies":[{"paymentPolicyId":"pp-1","name":"Card"}],"total":1}`))
+		case strings.HasSuffix(r.URL.Path, "/return_policy"):
+			w.Write([]byte(`{"returnPolicies":[{"returnPolicyId":"rp-1","name":"30 days"}],"total":1}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	h := newTestHandler(t)
+	alice := signIn(t, h, "alice")
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		path    string
+		listKey string
+	}{
+		{"payment", h.GetPaymentPolicies, "/api/policies/payment", "paymentPolicies"},
+		{"return", h.GetReturnPolicies, "/api/policies/return", "returnPolicies"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Without a session
+			rec := httptest.NewRecorder()
+			tt.handler(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("signed out: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+
+			requested = nil
+			rec = httptest.NewRecorder()
+			tt.handler(rec, sessionRequest(http.MethodGet, tt.path+"?marketplace_id=EBAY_GB", "", alice))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+			}
+			var resp map[string][]map[string]any
+			json.Unmarshal(rec.Body.Bytes(), &resp)
+			if len(resp[tt.listKey]) != 1 {
+				t.Errorf("response = %s, want one policy in %s", rec.Body, tt.listKey)
+			}
+			if len(requested) != 1 || !strings.HasSuffix(requested[0], "marketplace_id=EBAY_GB") {
+				t.Errorf("eBay requests = %v, want one for EBAY_GB", requested)
+			}
+
+			// An unknown marketplace is rejected before calling eBay
+			requested = nil
+			rec = httptest.NewRecorder()
+			tt.handler(rec, sessionRequest(http.MethodGet, tt.path+"?marketplace_id=EBAY_XX", "", alice))
+			if rec.Code != http.StatusBadRequest || len(requested) != 0 {
+				t.Errorf("unknown marketplace: status = %d with %d eBay calls, want 400 and none", rec.Code, len(requested))
+			}
+		})
+	}
+}
+
+func TestUnsupportedMarketplaceRejected(t *testing.T) {
+	calls := 0
+	serveEbay(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.NotFound(w, r)
+	})
+	h := newTestHandler(t)
+	alice := signIn(t, h, "alice")
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		path    string
+	}{
+		{"fulfillment policies", h.GetFulfillmentPolicies, http.MethodGet, "/api/policies/fulfillment"},
+		{"sync export", h.SyncExport, http.MethodPost, "/api/sync/export"},
+	}
+	for _, tt := range tests {
+		for _, id := range []string{"EBAY_AUS", "ebay_au"} {
+			calls = 0
+			rec := httptest.NewRecorder()
+			tt.handler(rec, sessionRequest(tt.method, tt.path+"?marketplace_id="+id, "", alice))
+			if rec.Code != http.StatusBadRequest || calls != 0 {
+				t.Errorf("%s with %s: status = %d with %d eBay calls, want 400 and none", tt.name, id, rec.Code, calls)
+			}
+			if !strings.Contains(rec.Body.String(), "unsupported marketplace") || !strings.Contains(rec.Body.String(), "EBAY_AU") {
+				t.Errorf("%s with %s: body = %s, want the valid marketplaces listed", tt.name, id, rec.Body)
+			}
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/calculator"
+	"github.com/julienbonastre/ebay-helpers/internal/database"
+	"github.com/julienbonastre/ebay-helpers/internal/ebay"
+)
+
+// testOrder returns a US order placed today for 100 AUD items with
+// paid postage, one line per item ID
+func testOrder(t *testing.T, orderID string, paid float64, itemIDs ...string) *ebay.Order {
+	t.Helper()
+	var lines []map[string]interface{}
+	for _, id := range itemIDs {
+		lines = append(lines, map[string]interface{}{
+			"lineItemId":   "line-" + id,
+			"legacyItemId": id,
+			"quantity":     1,
+			"lineItemCost": map[string]string{"value": "100.00", "currency": "AUD"},
+		})
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"orderId":        orderID,
+		"creationDate":   time.Now().UTC().Format(time.RFC3339),
+		"pricingSummary": map[string]interface{}{"deliveryCost": map[string]string{"value": fmt.Sprintf("%.2f", paid), "currency": "AUD"}},
+		"fulfillmentStartInstructions": []map[string]interface{}{
+			{"shippingStep": map[string]interface{}{"shipTo": map[string]interface{}{"contactAddress": map[string]string{"countryCode": "US"}}}},
+		},
+		"lineItems": lines,
+	})
+	var order ebay.Order
+	if err := json.Unmarshal(data, &order); err != nil {
+		t.Fatalf("Unmarshal order: %v", err)
+	}
+	return &order
+}
+
+// usPostage is the calculator's US postage for one 100 AUD Aje item in band
+func usPostage(t *testing.T, calc *calculator.CalculatorConfig, band string) float64 {
+	t.Helper()
+	zones, err := calc.CalculateAllZones(calculator.CalculateAllZonesParams{
+		ItemValueAUD:    100,
+		WeightBand:      band,
+		BrandName:       "Aje",
+		CountryOfOrigin: "China",
+		DiscountBand:    calc.FallbackDiscountBand,
+	})
+	if err != nil {
+		t.Fatalf("CalculateAllZones: %v", err)
+	}
+	return zones.Zone("3-USA & Canada").Total
+}
+
+func TestReconcileOrderUsesStoredWeight(t *testing.T) {
+	h := newTestHandler(t)
+	calc := h.calculator()
+	enriched := map[string]*database.EnrichedItem{
+		"item-1": {ItemID: "item-1", Brand: "Aje", CountryOfOrigin: "China"},
+	}
+	order := testOrder(t, "order-1", 0, "item-1")
+
+	guessed, _ := calc.ItemWeightBand("Aje", 0)
+	heavy := calculator.GetWeightBandFromGrams(1800)
+	if heavy == guessed {
+		t.Fatalf("test needs a stored weight outside the guessed %s band", guessed)
+	}
+
+	tests := []struct {
+		name    string
+		weights map[string]int
+		band    string
+	}{
+		{"no stored weight", nil, guessed},
+		{"stored weight", map[string]int{"item-1": 1800}, heavy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := h.reconcileOrder(calc, order, enriched, tt.weights)
+			want := math.Round(usPostage(t, calc, tt.band)*100) / 100
+			if result.CalculatedShipping != want {
+				t.Errorf("calculated shipping = %.2f, want %.2f (%s band)", result.CalculatedShipping, want, tt.band)
+			}
+		})
+	}
+}
+
+func TestReconcileOrderStatus(t *testing.T) {
+	h := newTestHandler(t)
+	calc := h.calculator()
+	enriched := map[string]*database.EnrichedItem{
+		"item-1": {ItemID: "item-1", Brand: "Aje", CountryOfOrigin: "China"},
+	}
+	band, _ := calc.ItemWeightBand("Aje", 0)
+	postage := math.Round(usPostage(t, calc, band)*100) / 100
+
+	tests := []struct {
+		name  string
+		order *ebay.Order
+		want  string
+	}{
+		{"profitable", testOrder(t, "order-1", postage+5, "item-1"), reconcileStatusOK},
+		{"underpriced", testOrder(t, "order-2", postage-5, "item-1"), reconcileStatusUndercharged},
+		{"partially matched", testOrder(t, "order-3", postage+5, "item-1", "item-unknown"), reconcileStatusPartial},
+		{"unmatched", testOrder(t, "order-4", postage, "item-unknown"), reconcileStatusUnmatched},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := h.reconcileOrder(calc, tt.order, enriched, nil)
+			if result.Status != tt.want {
+				t.Errorf("status = %q, want %q (paid %.2f, calculated %.2f)", result.Status, tt.want, result.PaidShipping, result.CalculatedShipping)
+			}
+		})
+	}
+
+	partial := h.reconcileOrder(calc, tests[2].order, enriched, nil)
+	if len(partial.UnmatchedItems) != 1 || partial.UnmatchedItems[0] != "item-unknown" {
+		t.Errorf("unmatched items = %v, want [item-unknown]", partial.UnmatchedItems)
+	}
+	if partial.CalculatedShipping != postage {
+		t.Errorf("partial calculated shipping = %.2f, want the matched item's %.2f", partial.CalculatedShipping, postage)
+	}
+}
+
+func TestReconcileOrderMissingRate(t *testing.T) {
+	h := newTestHandler(t)
+	calc := h.calculator()
+	enriched := map[string]*database.EnrichedItem{
+		"item-1": {ItemID: "item-1", Brand: "Aje", CountryOfOrigin: "China"},
+	}
+	order := testOrder(t, "order-1", 50, "item-1")
+	order.PricingSummary.DeliveryCost.Currency = "zzz"
+
+	result := h.reconcileOrder(calc, order, enriched, nil)
+	if result.Status != reconcileStatusNoRate {
+		t.Errorf("status = %q, want %q", result.Status, reconcileStatusNoRate)
+	}
+	if len(result.MissingRates) != 1 || result.MissingRates[0] != "ZZZ" {
+		t.Errorf("missing rates = %v, want [ZZZ]", result.MissingRates)
+	}
+	if result.PaidShipping != 0 {
+		t.Errorf("paid shipping = %.2f, want 0 rather than 50 ZZZ taken as AUD", result.PaidShipping)
+	}
+}
+
+func TestGetOrdersPagination(t *testing.T) {
+	var queries []url.Values
+	serveEbay(t, func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		w.Write([]byte(`{"total":1,"orders":[{"orderId":"12-34567-89012"}]}`))
+	})
+	h := newTestHandler(t)
+	alice := signIn(t, h, "alice")
+
+	rec := httptest.NewRecorder()
+	h.GetOrders(rec, httptest.NewRequest(http.MethodGet, "/api/orders", nil))
+	if rec.Code != http.StatusUnauthorized || len(queries) != 0 {
+		t.Errorf("signed out: status = %d with %d eBay calls, want 401 and none", rec.Code, len(queries))
+	}
+
+	tests := []struct {
+		target                string
+		wantLimit, wantOffset string
+		wantFilter            string
+	}{
+		{"/api/orders", "50", "0", ""},
+		{"/api/orders?limit=10&offset=20", "10", "20", ""},
+		{"/api/orders?limit=1000&offset=-5", "200", "0", ""},
+		{"/api/orders?filter=" + url.QueryEscape("orderfulfillmentstatus:{NOT_STARTED}"), "50", "0", "orderfulfillmentstatus:{NOT_STARTED}"},
+	}
+	for _, tt := range tests {
+		queries = nil
+		rec := httptest.NewRecorder()
+		h.GetOrders(rec, sessionRequest(http.MethodGet, tt.target, "", alice))
+		if rec.Code != http.StatusOK || len(queries) != 1 {
+			t.Fatalf("%s: status = %d with %d eBay calls: %s", tt.target, rec.Code, len(queries), rec.Body)
+		}
+		q := queries[0]
+		if q.Get("limit") != tt.wantLimit || q.Get("offset") != tt.wantOffset || q.Get("filter") != tt.wantFilter {
+			t.Errorf("%s: eBay query = %v, want limit %s offset %s filter %q", tt.target, q, tt.wantLimit, tt.wantOffset, tt.wantFilter)
+		}
+		var resp ebay.OrdersResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || len(resp.Orders) != 1 {
+			t.Errorf("%s: response = %+v (%v)", tt.target, resp, err)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/calculator"
+	"github.com/julienbonastre/ebay-helpers/internal/database"
+)
+
+func TestImportReferenceDataReloadsCalculator(t *testing.T) {
+	h := newTestHandler(t)
+	body := `{"version":2,"brands":[{"brandName":"Newbrand","primaryCoo":"India","secondaryCoos":["China"]}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/reference/import.json", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ImportReferenceData(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	brand, ok := h.calculator().Brands["Newbrand"]
+	if !ok {
+		t.Fatal("imported brand not in the calculator config")
+	}
+	if brand.PrimaryCOO != "India" || len(brand.SecondaryCOO) != 1 || brand.SecondaryCOO[0] != "China" {
+		t.Errorf("brand = %+v, want India with secondary China", brand)
+	}
+}
+
+func TestReferenceExportImportSkippingInvalidRoundTrip(t *testing.T) {
+	src := newTestHandler(t)
+	rec := httptest.NewRecorder()
+	src.ExportReferenceData(rec, httptest.NewRequest(http.MethodGet, "/api/reference/export.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export status = %d", rec.Code)
+	}
+	exported := rec.Body.String()
+
+	// Edit a brand and add one with an unknown COO, which is skipped
+	pkg := strings.Replace(exported, `"brands":[`, `"brands":[{"brandName":"Nowhere","primaryCoo":"Atlantis"},`, 1)
+	pkg = strings.Replace(pkg, `"brandName":"Aje","primaryCoo":"China"`, `"brandName":"Aje","primaryCoo":"India"`, 1)
+
+	// Without skipInvalid the bad row rejects the whole package
+	dst := newTestHandler(t)
+	rec = httptest.NewRecorder()
+	dst.ImportReferenceData(rec, httptest.NewRequest(http.MethodPost, "/api/reference/import.json", strings.NewReader(pkg)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("strict import status = %d, want 400", rec.Code)
+	}
+	if got := dst.calculator().Brands["Aje"].PrimaryCOO; got != "China" {
+		t.Errorf("Aje primary COO after rejected import = %q, want China", got)
+	}
+
+	rec = httptest.NewRecorder()
+	dst.ImportReferenceData(rec, httptest.NewRequest(http.MethodPost, "/api/reference/import.json?skipInvalid=true", strings.NewReader(pkg)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("import status = %d, body %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), `"skippedBrands":1`) {
+		t.Errorf("response = %s, want one skipped brand", rec.Body)
+	}
+
+	// The calculator is reloaded without a restart
+	aje := dst.calculator().Brands["Aje"]
+	if aje.PrimaryCOO != "India" {
+		t.Errorf("Aje primary COO = %q, want India", aje.PrimaryCOO)
+	}
+	if len(aje.SecondaryCOO) != 2 {
+		t.Errorf("Aje secondary COOs = %v, want the exported two", aje.SecondaryCOO)
+	}
+	if _, ok := dst.calculator().Brands["Nowhere"]; ok {
+		t.Error("skipped brand is in the calculator config")
+	}
+
+	// Exporting again gives the imported package back
+	rec = httptest.NewRecorder()
+	dst.ExportReferenceData(rec, httptest.NewRequest(http.MethodGet, "/api/reference/export.json", nil))
+	var before, after database.ReferenceData
+	if err := json.Unmarshal([]byte(strings.Replace(exported, `"brandName":"Aje","primaryCoo":"China"`, `"brandName":"Aje","primaryCoo":"India"`, 1)), &before); err != nil {
+		t.Fatalf("decode export: %v", err)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &after); err != nil {
+		t.Fatalf("decode re-export: %v", err)
+	}
+	if !reflect.DeepEqual(after.Brands, before.Brands) || !reflect.DeepEqual(after.Tariffs, before.Tariffs) {
+		t.Errorf("re-export differs from the imported package")
+	}
+}
+
+func TestEditedWeightBandChangesCalculation(t *testing.T) {
+	h := newTestHandler(t)
+	const zone = "3-USA & Canada"
+
+	listBands := func() []database.PostalRate {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ReferenceWeightBands(rec, httptest.NewRequest(http.MethodGet, "/api/reference/weight-bands?zone="+url.QueryEscape(zone), nil))
+		var resp struct {
+			WeightBands []database.PostalRate `json:"weightBands"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode weight bands: %v", err)
+		}
+		return resp.WeightBands
+	}
+	shipping := func() float64 {
+		t.Helper()
+		result, err := h.calculator().CalculateUSAShipping(calculator.CalculateUSAShippingParams{
+			ItemValueAUD: 50, BrandName: "Aje", WeightBand: "Medium",
+		})
+		if err != nil {
+			t.Fatalf("CalculateUSAShipping: %v", err)
+		}
+		return result.Breakdown.AusPostShipping
+	}
+
+	var medium *database.PostalRate
+	for _, rate := range listBands() {
+		if rate.WeightBand == "Medium" {
+			medium = &rate
+		}
+	}
+	if medium == nil || medium.ZoneID != zone || medium.Label == "" {
+		t.Fatalf("seeded Medium band = %+v", medium)
+	}
+	before := shipping()
+
+	update := func(body string) int {
+		rec := httptest.NewRecorder()
+		h.ReferenceWeightBandByID(rec, httptest.NewRequest(http.MethodPut,
+			"/api/reference/weight-bands/"+strconv.FormatInt(medium.ID, 10), strings.NewReader(body)))
+		return rec.Code
+	}
+	if code := update(`{"label":"Medium","maxWeightGrams":1000,"basePriceAud":-1}`); code != http.StatusBadRequest {
+		t.Errorf("negative base price status = %d, want 400", code)
+	}
+	body, _ := json.Marshal(weightBandRequest{Label: "Medium [< 1kg]", MaxWeightGrams: medium.MaxWeightGrams, BasePriceAUD: medium.BasePriceAUD * 2})
+	if code := update(string(body)); code != http.StatusOK {
+		t.Fatalf("update status = %d", code)
+	}
+	if after := shipping(); math.Abs(after-before*2) > 0.02 {
+		t.Errorf("AusPost shipping after doubling the base price = %.2f, want about %.2f", after, before*2)
+	}
+	for _, rate := range listBands() {
+		if rate.ID == medium.ID && rate.Label != "Medium [< 1kg]" {
+			t.Errorf("label = %q after update", rate.Label)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ReferenceWeightBandByID(rec, httptest.NewRequest(http.MethodPut, "/api/reference/weight-bands/999999", strings.NewReader(string(body))))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown band status = %d, want 404", rec.Code)
+	}
+
+	creates := []struct {
+		body string
+		want int
+	}{
+		{`{"zoneId":"3-USA & Canada","weightBand":"Medium","maxWeightGrams":1000,"basePriceAud":20}`, http.StatusConflict},
+		{`{"zoneId":"9-Mars","weightBand":"Medium","maxWeightGrams":1000,"basePriceAud":20}`, http.StatusBadRequest},
+		{`{"zoneId":"3-USA & Canada","weightBand":"Huge","maxWeightGrams":1000,"basePriceAud":20}`, http.StatusBadRequest},
+		{`{"zoneId":"3-USA & Canada","weightBand":"Medium","maxWeightGrams":0,"basePriceAud":20}`, http.StatusBadRequest},
+	}
+	for _, tt := range creates {
+		rec := httptest.NewRecorder()
+		h.ReferenceWeightBands(rec, httptest.NewRequest(http.MethodPost, "/api/reference/weight-bands", strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("create %s: status = %d, want %d", tt.body, rec.Code, tt.want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	h.ReferenceWeightBandByID(rec, httptest.NewRequest(http.MethodDelete, "/api/reference/weight-bands/"+strconv.FormatInt(medium.ID, 10), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete status = %d", rec.Code)
+	}
+	if _, err := h.calculator().CalculateUSAShipping(calculator.CalculateUSAShippingParams{
+		ItemValueAUD: 50, BrandName: "Aje", WeightBand: "Medium",
+	}); err == nil {
+		t.Error("calculation still priced the deleted Medium band")
+	}
+}
+
+func TestItemWeightOverride(t *testing.T) {
+	h := newTestHandler(t)
+	h.cacheEnrichment(publicEnrichment, &EnrichedItemData{
+		ItemID: "item-1", Brand: "Unmapped Label", CountryOfOrigin: "China",
+		ShippingCost: "30.00", ShippingCurrency: "AUD", EnrichedAt: time.Now(),
+	})
+
+	batch := func() BatchCalculateResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.BatchCalculate(rec, httptest.NewRequest(http.MethodPost, "/api/calculate/batch", strings.NewReader(`[{"itemId":"item-1","price":80}]`)))
+		var resp map[string]BatchCalculateResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode batch: %v", err)
+		}
+		return resp["item-1"]
+	}
+	weight := func(method, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ReferenceItemWeightByID(rec, httptest.NewRequest(method, "/api/reference/item-weights/item-1", strings.NewReader(body)))
+		return rec
+	}
+
+	// No stored weight: the default band
+	if got := batch(); got.WeightBand != "Medium" || got.WeightSource != calculator.WeightSourceDefault {
+		t.Errorf("without a weight: %s from %s, want Medium from default", got.WeightBand, got.WeightSource)
+	}
+	if rec := weight(http.MethodGet, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET without a weight: status = %d, want 404", rec.Code)
+	}
+
+	for _, body := range []string{`{"weightGrams":0}`, `{"weightGrams":2001}`, `{"weightGrams":"heavy"}`} {
+		if rec := weight(http.MethodPut, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("PUT %s: status = %d, want 400", body, rec.Code)
+		}
+	}
+
+	if rec := weight(http.MethodPut, `{"weightGrams":200}`); rec.Code != http.StatusOK {
+		t.Fatalf("PUT: status = %d: %s", rec.Code, rec.Body)
+	}
+	if got := batch(); got.WeightBand != "XSmall" || got.WeightSource != calculator.WeightSourceItem {
+		t.Errorf("with 200g stored: %s from %s, want XSmall from itemWeight", got.WeightBand, got.WeightSource)
+	}
+	rec := weight(http.MethodGet, "")
+	var stored database.ItemWeight
+	if err := json.NewDecoder(rec.Body).Decode(&stored); err != nil || stored.WeightGrams != 200 {
+		t.Errorf("GET = %+v (%v), want 200g", stored, err)
+	}
+
+	if rec := weight(http.MethodDelete, ""); rec.Code != http.StatusOK {
+		t.Fatalf("DELETE: status = %d", rec.Code)
+	}
+	if got := batch(); got.WeightBand != "Medium" || got.WeightSource != calculator.WeightSourceDefault {
+		t.Errorf("after removing the weight: %s from %s, want Medium from default", got.WeightBand, got.WeightSource)
+	}
+	if rec := weight(http.MethodDelete, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("second DELETE: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestTariffAndBrandEditsReloadCalculator(t *testing.T) {
+	h := newTestHandler(t)
+	send := func(handler http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		if rec.Code != http.StatusOK && rec.Code != http.StatusCreated {
+			t.Fatalf("%s %s = %d: %s", method, target, rec.Code, rec.Body)
+		}
+		return rec
+	}
+	created := func(rec *httptest.ResponseRecorder) string {
+		t.Helper()
+		var resp struct {
+			ID int64 `json:"id"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return strconv.FormatInt(resp.ID, 10)
+	}
+
+	var resp struct {
+		Tariffs []database.TariffRate `json:"tariffs"`
+	}
+	json.NewDecoder(send(h.ReferenceTariffs, http.MethodGet, "/api/reference/tariffs", "").Body).Decode(&resp)
+	var china *database.TariffRate
+	for i := range resp.Tariffs {
+		if resp.Tariffs[i].CountryName == "China" {
+			china = &resp.Tariffs[i]
+		}
+	}
+	if china == nil {
+		t.Fatal("no seeded China tariff")
+	}
+
+	send(h.ReferenceTariffByID, http.MethodPut, "/api/reference/tariffs/"+strconv.FormatInt(china.ID, 10), `{"countryName":"China","tariffRate":0.42}`)
+	if got := h.calculator().GetTariffRate("China"); got != 0.42 {
+		t.Errorf("China rate after update = %v, want 0.42", got)
+	}
+	id := created(send(h.ReferenceTariffs, http.MethodPost, "/api/reference/tariffs", `{"countryName":"Testland","tariffRate":0.15}`))
+	if got := h.calculator().USATariffs.Rates["Testland"]; got != 0.15 {
+		t.Errorf("Testland rate after create = %v, want 0.15", got)
+	}
+	send(h.ReferenceTariffByID, http.MethodDelete, "/api/reference/tariffs/"+id, "")
+	if _, ok := h.calculator().USATariffs.Rates["Testland"]; ok {
+		t.Error("deleted Testland tariff is still in the calculator")
+	}
+
+	id = created(send(h.ReferenceBrands, http.MethodPost, "/api/reference/brands", `{"brandName":"Newlabel","primaryCoo":"India"}`))
+	if got := h.calculator().Brands["Newlabel"].PrimaryCOO; got != "India" {
+		t.Errorf("Newlabel COO after create = %q, want India", got)
+	}
+	send(h.ReferenceBrandByID, http.MethodPut, "/api/reference/brands/"+id, `{"brandName":"Newlabel","primaryCoo":"China"}`)
+	if got := h.calculator().Brands["Newlabel"].PrimaryCOO; got != "China" {
+		t.Errorf("Newlabel COO after update = %q, want China", got)
+	}
+	send(h.ReferenceBrandByID, http.MethodDelete, "/api/reference/brands/"+id, "")
+	if _, ok := h.calculator().Brands["Newlabel"]; ok {
+		t.Error("deleted Newlabel brand is still in the calculator")
+	}
+}
+
+func TestFutureTariffAppliesOnItsEffectiveDate(t *testing.T) {
+	h := newTestHandler(t)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	tomorrow := today.AddDate(0, 0, 1)
+	current := h.calculator().GetTariffRate("China")
+
+	rec := httptest.NewRecorder()
+	h.ReferenceTariffs(rec, httptest.NewRequest(http.MethodPost, "/api/reference/tariffs",
+		strings.NewReader(`{"countryName":"China","tariffRate":0.5,"effectiveDate":"`+tomorrow.Format("2006-01-02")+`"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create = %d: %s", rec.Code, rec.Body)
+	}
+	calc := h.calculator()
+	if got := calc.GetTariffRate("China"); got != current {
+		t.Errorf("China rate before its effective date = %v, want the current %v", got, current)
+	}
+	if !calc.NextTariffChange.Equal(tomorrow) {
+		t.Errorf("next tariff change = %v, want %v", calc.NextTariffChange, tomorrow)
+	}
+	if calc.TariffsStale(tomorrow.Add(-time.Second)) || !calc.TariffsStale(tomorrow) {
+		t.Error("tariffs should go stale exactly at the effective date")
+	}
+
+	// Move the rate's date to today, as if tomorrow had come, and mark the
+	// loaded config as predating it: the next use picks the new rate up
+	if _, err := h.db.Exec(`UPDATE tariff_rates SET effective_date = ? WHERE effective_date = ?`,
+		today.Format("2006-01-02"), tomorrow.Format("2006-01-02")); err != nil {
+		t.Fatal(err)
+	}
+	calc.NextTariffChange = today
+	if got := h.calculator().GetTariffRate("China"); got != 0.5 {
+		t.Errorf("China rate once effective = %v, want 0.5", got)
+	}
+	if !h.calculator().NextTariffChange.IsZero() {
+		t.Errorf("next tariff change = %v after the last pending rate applied, want none", h.calculator().NextTariffChange)
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/database"
+)
+
+func TestValidateSettingDataTypes(t *testing.T) {
+	tests := []struct {
+		dataType, value string
+		valid           bool
+	}{
+		{"int", "5000", true},
+		{"int", "-1", true},
+		{"int", "abc", false},
+		{"int", "1.5", false},
+		{"int", "", false},
+		{"float", "0.25", true},
+		{"float", "100", true},
+		{"float", "abc", false},
+		{"float", "NaN", false},
+		{"float", "Inf", false},
+		{"bool", "true", true},
+		{"bool", "false", true},
+		{"bool", "yes", false},
+		{"bool", "", false},
+		{"json", `{"a":1}`, true},
+		{"json", `{"a":`, false},
+		{"string", "anything at all", true},
+		{"string", "", true},
+	}
+	for _, tt := range tests {
+		setting := &database.Setting{Key: "some_" + tt.dataType, DataType: tt.dataType}
+		if msg := validateSetting(setting, tt.value); (msg == "") != tt.valid {
+			t.Errorf("%s %q: validateSetting = %q, want valid %v", tt.dataType, tt.value, msg, tt.valid)
+		}
+	}
+}
+
+func TestUpdateSettingChecksKeyAndType(t *testing.T) {
+	h := newTestHandler(t)
+	tests := []struct {
+		key, value string
+		wantStatus int
+	}{
+		{"ebay_daily_call_budget", "100", http.StatusOK},
+		{"ebay_daily_call_budget", "lots", http.StatusBadRequest},
+		{"tariff_de_minimis_aud", "12.5", http.StatusOK},
+		{"tariff_de_minimis_aud", "cheap", http.StatusBadRequest},
+		{"trading_strict_ack", "true", http.StatusOK},
+		{"trading_strict_ack", "maybe", http.StatusBadRequest},
+		{"audit_shipping_currency", "AUD", http.StatusOK},
+		{"not_a_setting", "1", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		before, _ := h.db.GetSetting(tt.key)
+		body, _ := json.Marshal(UpdateSettingRequest{Value: tt.value})
+		rec := httptest.NewRecorder()
+		h.UpdateSetting(rec, httptest.NewRequest(http.MethodPut, "/api/settings/"+tt.key, strings.NewReader(string(body))))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s=%s: status = %d, want %d", tt.key, tt.value, rec.Code, tt.wantStatus)
+		}
+
+		after, err := h.db.GetSetting(tt.key)
+		if err != nil {
+			t.Fatalf("GetSetting(%s): %v", tt.key, err)
+		}
+		switch {
+		case tt.wantStatus == http.StatusNotFound && after != nil:
+			t.Errorf("%s: unknown setting was created", tt.key)
+		case tt.wantStatus == http.StatusOK && after.Value != tt.value:
+			t.Errorf("%s = %q after update, want %q", tt.key, after.Value, tt.value)
+		case tt.wantStatus == http.StatusBadRequest && after.Value != before.Value:
+			t.Errorf("%s changed to %q by a rejected update", tt.key, after.Value)
+		}
+	}
+}
+
+func TestValidateSettingBrandTypeWeightBands(t *testing.T) {
+	setting := &database.Setting{Key: "brand_type_weight_bands", DataType: "json"}
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{`{"Hats":"XSmall","Sneakers":"Large"}`, true},
+		{`{}`, true},
+		{`{"Hats":`, false},
+		{`["Hats","XSmall"]`, false},
+		{`{"Hats":"Tiny"}`, false},
+		{`{"Hats":1}`, false},
+	}
+	for _, tt := range tests {
+		if msg := validateSetting(setting, tt.value); (msg == "") != tt.valid {
+			t.Errorf("validateSetting(%s) = %q, want valid %v", tt.value, msg, tt.valid)
+		}
+	}
+}
+
+func TestBatchCalculateDefaultBandSettings(t *testing.T) {
+	h := newTestHandler(t)
+	h.cacheEnrichment(publicEnrichment, &EnrichedItemData{
+		ItemID: "item-1", Brand: "Unmapped Label", CountryOfOrigin: "China",
+		ShippingCost: "30.00", ShippingCurrency: "AUD", EnrichedAt: time.Now(),
+	})
+
+	batch := func() BatchCalculateResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.BatchCalculate(rec, httptest.NewRequest(http.MethodPost, "/api/calculate/batch", strings.NewReader(`[{"itemId":"item-1","price":80}]`)))
+		var resp map[string]BatchCalculateResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode batch: %v", err)
+		}
+		return resp["item-1"]
+	}
+	updateSetting := func(key, value string) int {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.UpdateSetting(rec, httptest.NewRequest(http.MethodPut, "/api/settings/"+key, strings.NewReader(`{"value":"`+value+`"}`)))
+		return rec.Code
+	}
+
+	before := batch()
+	if before.WeightBand != "Medium" {
+		t.Fatalf("default weight band = %s, want Medium", before.WeightBand)
+	}
+
+	if code := updateSetting("default_weight_band", "Large"); code != http.StatusOK {
+		t.Fatalf("set default_weight_band: status %d", code)
+	}
+	large := batch()
+	if large.WeightBand != "Large" || large.Breakdown.AusPostShipping <= before.Breakdown.AusPostShipping {
+		t.Errorf("with Large default: band %s, AusPost %.2f (was %.2f)", large.WeightBand, large.Breakdown.AusPostShipping, before.Breakdown.AusPostShipping)
+	}
+
+	// Band 0 is no discount, so postage goes up from band 3
+	if code := updateSetting("default_discount_band", "0"); code != http.StatusOK {
+		t.Fatalf("set default_discount_band: status %d", code)
+	}
+	if undiscounted := batch(); undiscounted.Breakdown.AusPostShipping <= large.Breakdown.AusPostShipping {
+		t.Errorf("with discount band 0: AusPost %.2f, want more than band 3's %.2f", undiscounted.Breakdown.AusPostShipping, large.Breakdown.AusPostShipping)
+	}
+
+	for _, tt := range []struct{ key, value string }{
+		{"default_weight_band", "Huge"},
+		{"default_discount_band", "6"},
+		{"default_discount_band", "-1"},
+		{"default_discount_band", "two"},
+	} {
+		if code := updateSetting(tt.key, tt.value); code != http.StatusBadRequest {
+			t.Errorf("%s=%s: status %d, want 400", tt.key, tt.value, code)
+		}
+	}
+
+	// Values stored some other way are checked on read and fall back
+	if err := h.db.UpdateSetting("default_weight_band", "Huge"); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.db.UpdateSetting("default_discount_band", "9"); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.reloadCalculator(); err != nil {
+		t.Fatal(err)
+	}
+	if got := batch(); got.WeightBand != "Medium" || got.Breakdown.AusPostShipping != before.Breakdown.AusPostShipping {
+		t.Errorf("with invalid stored settings: band %s, AusPost %.2f; want Medium, %.2f", got.WeightBand, got.Breakdown.AusPostShipping, before.Breakdown.AusPostShipping)
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInvalidShippingOverrideRejectedBeforeEbay(t *testing.T) {
+	var calls int
+	serveEbay(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.NotFound(w, r)
+	})
+	h := newTestHandler(t)
+	alice := signIn(t, h, "alice")
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		path    string
+		body    string
+		wantErr string
+	}{
+		{"single", h.UpdateOfferShipping, "/api/update-shipping",
+			`{"offerId":"1","overrides":[{"shippingServiceType":"INTERNATIONAL","priority":1,"shippingCost":{"value":"-5.00","currency":"AUD"}}]}`,
+			"override 0: shippingCost.value"},
+		{"batch", h.UpdateOfferShippingBatch, "/api/update-shipping/batch",
+			`[{"offerId":"1","overrides":[{"shippingServiceType":"DOMESTIC","priority":1}]},
+			  {"offerId":"2","overrides":[{"shippingServiceType":"EXPRESS","priority":1}]}]`,
+			"Update 1 (offer 2): override 0: shippingServiceType"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		tt.handler(rec, sessionRequest(http.MethodPost, tt.path, tt.body, alice))
+		var resp struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(resp.Error, tt.wantErr) {
+			t.Errorf("%s: %d %s, want 400 mentioning %q", tt.name, rec.Code, rec.Body, tt.wantErr)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("eBay was called %d times for invalid overrides", calls)
+	}
+
+	// A valid override gets as far as eBay
+	h.UpdateOfferShipping(httptest.NewRecorder(), sessionRequest(http.MethodPost, "/api/update-shipping",
+		`{"offerId":"1","overrides":[{"shippingServiceType":"INTERNATIONAL","priority":1,"shippingCost":{"value":"25.00","currency":"AUD"}}]}`, alice))
+	if calls == 0 {
+		t.Error("a valid override never reached eBay")
+	}
+}
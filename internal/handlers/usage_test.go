@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/ebay"
+)
+
+func TestEbayCallCounterFlush(t *testing.T) {
+	h := newTestHandler(t)
+	if h.callCounter == nil {
+		t.Fatal("NewHandler didn't install the call counter")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.ebayConfig.CallCounter.CountCall()
+		}()
+	}
+	wg.Wait()
+
+	// Nothing is written until a flush
+	day := ebay.QuotaDay(time.Now())
+	if calls, err := h.db.GetEbayCallCount(day); err != nil || calls != 0 {
+		t.Fatalf("calls before flush = %d, %v; want 0", calls, err)
+	}
+
+	// Reading usage flushes first
+	usage, err := h.ebayUsageToday()
+	if err != nil {
+		t.Fatalf("ebayUsageToday: %v", err)
+	}
+	if usage.Calls != 50 {
+		t.Errorf("usage.Calls = %d, want 50", usage.Calls)
+	}
+
+	h.ebayConfig.CallCounter.CountCall()
+	h.FlushCallCounts()
+	h.FlushCallCounts() // Nothing new to add
+	if calls, err := h.db.GetEbayCallCount(day); err != nil || calls != 51 {
+		t.Errorf("calls after flush = %d, %v; want 51", calls, err)
+	}
+}
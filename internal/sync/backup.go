@@ -0,0 +1,291 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/database"
+	"github.com/julienbonastre/ebay-helpers/internal/ebay"
+)
+
+// BackupVersion is bumped whenever the backup format changes incompatibly
+const BackupVersion = 1
+
+// ErrBackupInvalid is returned (wrapped) when a backup fails verification, so
+// callers can tell a bad file apart from a database error
+var ErrBackupInvalid = errors.New("backup failed verification")
+
+// Backup is a portable JSON snapshot of an account's synced data. The manifest
+// describes the data section so a truncated or edited file is caught before
+// anything is restored from it.
+type Backup struct {
+	Manifest BackupManifest `json:"manifest"`
+	Data     BackupData     `json:"data"`
+}
+
+// BackupManifest records what a backup should contain
+type BackupManifest struct {
+	Version    int            `json:"version"`
+	AccountKey string         `json:"accountKey"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	Counts     map[string]int `json:"counts"` // Resource -> number of records
+	SHA256     string         `json:"sha256"` // Hex SHA-256 of the data section (compact JSON)
+}
+
+// BackupData holds the raw eBay JSON for each record, exactly as exported
+type BackupData struct {
+	FulfillmentPolicies []json.RawMessage `json:"fulfillmentPolicies"`
+	PaymentPolicies     []json.RawMessage `json:"paymentPolicies"`
+	ReturnPolicies      []json.RawMessage `json:"returnPolicies"`
+	InventoryItems      []json.RawMessage `json:"inventoryItems"`
+	Offers              []json.RawMessage `json:"offers"`
+}
+
+// byResource returns the records for each resource, keyed like SyncResult counts
+func (d *BackupData) byResource() map[string][]json.RawMessage {
+	return map[string][]json.RawMessage{
+		ResourceFulfillmentPolicies: d.FulfillmentPolicies,
+		ResourcePaymentPolicies:     d.PaymentPolicies,
+		ResourceReturnPolicies:      d.ReturnPolicies,
+		ResourceInventoryItems:      d.InventoryItems,
+		ResourceOffers:              d.Offers,
+	}
+}
+
+// checksum hashes the data section. Records are re-marshalled (which compacts
+// them), so a backup that has been pretty-printed still verifies.
+func (d *BackupData) checksum() (string, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize backup data: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verify checks the manifest against the data section: version, per-resource
+// counts and checksum. Any mismatch wraps ErrBackupInvalid.
+func (b *Backup) Verify() error {
+	if b.Manifest.Version != BackupVersion {
+		return fmt.Errorf("%w: unsupported version %d (expected %d)", ErrBackupInvalid, b.Manifest.Version, BackupVersion)
+	}
+
+	records := b.Data.byResource()
+	for _, resource := range AllResources {
+		expected, ok := b.Manifest.Counts[resource]
+		if !ok {
+			return fmt.Errorf("%w: manifest has no count for %s", ErrBackupInvalid, resource)
+		}
+		if len(records[resource]) != expected {
+			return fmt.Errorf("%w: %s has %d records, manifest says %d", ErrBackupInvalid, resource, len(records[resource]), expected)
+		}
+	}
+
+	sum, err := b.Data.checksum()
+	if err != nil {
+		return err
+	}
+	if sum != b.Manifest.SHA256 {
+		return fmt.Errorf("%w: checksum mismatch (file may be truncated or modified)", ErrBackupInvalid)
+	}
+	return nil
+}
+
+// backupTables maps each resource to the table its raw JSON is stored in
+var backupTables = map[string]string{
+	ResourceFulfillmentPolicies: "fulfillment_policies",
+	ResourcePaymentPolicies:     "payment_policies",
+	ResourceReturnPolicies:      "return_policies",
+	ResourceInventoryItems:      "inventory_items",
+	ResourceOffers:              "offers",
+}
+
+// CreateBackup builds a backup of everything previously exported for account
+func (s *Service) CreateBackup(ctx context.Context, account *database.Account) (*Backup, error) {
+	backup := &Backup{
+		Manifest: BackupManifest{
+			Version:    BackupVersion,
+			AccountKey: account.AccountKey,
+			CreatedAt:  time.Now().UTC(),
+			Counts:     make(map[string]int, len(AllResources)),
+		},
+	}
+
+	targets := map[string]*[]json.RawMessage{
+		ResourceFulfillmentPolicies: &backup.Data.FulfillmentPolicies,
+		ResourcePaymentPolicies:     &backup.Data.PaymentPolicies,
+		ResourceReturnPolicies:      &backup.Data.ReturnPolicies,
+		ResourceInventoryItems:      &backup.Data.InventoryItems,
+		ResourceOffers:              &backup.Data.Offers,
+	}
+	for _, resource := range AllResources {
+		records, err := s.loadRawRecords(ctx, backupTables[resource], account.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", resource, err)
+		}
+		*targets[resource] = records
+		backup.Manifest.Counts[resource] = len(records)
+	}
+
+	sum, err := backup.Data.checksum()
+	if err != nil {
+		return nil, err
+	}
+	backup.Manifest.SHA256 = sum
+	return backup, nil
+}
+
+// loadRawRecords returns the stored eBay JSON for an account, oldest first.
+// Always non-nil so empty resources serialize as [] rather than null.
+func (s *Service) loadRawRecords(ctx context.Context, table string, accountID int64) ([]json.RawMessage, error) {
+	// Table names come from backupTables, never from user input
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT data FROM %s WHERE account_id = ? ORDER BY id", table), accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []json.RawMessage{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		records = append(records, json.RawMessage(data))
+	}
+	return records, rows.Err()
+}
+
+// RestoreBackup verifies backup and then writes its records for account in a
+// single transaction, so a bad record leaves the database untouched.
+// Existing records with the same IDs are replaced. Returns counts per resource.
+func (s *Service) RestoreBackup(ctx context.Context, account *database.Account, backup *Backup) (map[string]int, error) {
+	if err := backup.Verify(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback() // No-op once committed
+
+	restorers := map[string]func(*sql.Tx, int64, json.RawMessage) error{
+		ResourceFulfillmentPolicies: restoreFulfillmentPolicy,
+		ResourcePaymentPolicies:     restorePaymentPolicy,
+		ResourceReturnPolicies:      restoreReturnPolicy,
+		ResourceInventoryItems:      restoreInventoryItem,
+		ResourceOffers:              restoreOffer,
+	}
+	counts := make(map[string]int, len(AllResources))
+	records := backup.Data.byResource()
+	for _, resource := range AllResources {
+		for i, record := range records[resource] {
+			if err := restorers[resource](tx, account.ID, record); err != nil {
+				return nil, fmt.Errorf("%s record %d: %w", resource, i, err)
+			}
+		}
+		counts[resource] = len(records[resource])
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit restore: %w", err)
+	}
+
+	log.Printf("Restored backup into %s: %v", account.AccountKey, counts)
+	return counts, nil
+}
+
+func restoreFulfillmentPolicy(tx *sql.Tx, accountID int64, record json.RawMessage) error {
+	var policy ebay.FulfillmentPolicy
+	if err := json.Unmarshal(record, &policy); err != nil {
+		return err
+	}
+	if policy.FulfillmentPolicyID == "" {
+		return errors.New("missing fulfillmentPolicyId")
+	}
+	_, err := tx.Exec(`
+		INSERT OR REPLACE INTO fulfillment_policies (account_id, policy_id, name, marketplace_id, data, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, accountID, policy.FulfillmentPolicyID, policy.Name, policy.MarketplaceID, string(record))
+	return err
+}
+
+func restorePaymentPolicy(tx *sql.Tx, accountID int64, record json.RawMessage) error {
+	var policy ebay.PaymentPolicy
+	if err := json.Unmarshal(record, &policy); err != nil {
+		return err
+	}
+	if policy.PaymentPolicyID == "" {
+		return errors.New("missing paymentPolicyId")
+	}
+	_, err := tx.Exec(`
+		INSERT OR REPLACE INTO payment_policies (account_id, policy_id, name, marketplace_id, data, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, accountID, policy.PaymentPolicyID, policy.Name, policy.MarketplaceID, string(record))
+	return err
+}
+
+func restoreReturnPolicy(tx *sql.Tx, accountID int64, record json.RawMessage) error {
+	var policy ebay.ReturnPolicy
+	if err := json.Unmarshal(record, &policy); err != nil {
+		return err
+	}
+	if policy.ReturnPolicyID == "" {
+		return errors.New("missing returnPolicyId")
+	}
+	_, err := tx.Exec(`
+		INSERT OR REPLACE INTO return_policies (account_id, policy_id, name, marketplace_id, data, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, accountID, policy.ReturnPolicyID, policy.Name, policy.MarketplaceID, string(record))
+	return err
+}
+
+func restoreInventoryItem(tx *sql.Tx, accountID int64, record json.RawMessage) error {
+	var item ebay.InventoryItem
+	if err := json.Unmarshal(record, &item); err != nil {
+		return err
+	}
+	if item.SKU == "" {
+		return errors.New("missing sku")
+	}
+	title := ""
+	brand := ""
+	if item.Product != nil {
+		title = item.Product.Title
+		brand = item.Product.Brand
+	}
+	_, err := tx.Exec(`
+		INSERT OR REPLACE INTO inventory_items (account_id, sku, title, brand, condition, data, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, accountID, item.SKU, title, brand, item.Condition, string(record))
+	return err
+}
+
+func restoreOffer(tx *sql.Tx, accountID int64, record json.RawMessage) error {
+	var offer ebay.Offer
+	if err := json.Unmarshal(record, &offer); err != nil {
+		return err
+	}
+	if offer.OfferID == "" {
+		return errors.New("missing offerId")
+	}
+	listingID := ""
+	if offer.Listing != nil {
+		listingID = offer.Listing.ListingID
+	}
+	args := []any{accountID, offer.OfferID, offer.SKU, offer.MarketplaceID, listingID, offer.Status, string(record)}
+	_, err := tx.Exec(`
+		INSERT OR REPLACE INTO offers (account_id, offer_id, sku, marketplace_id, listing_id, status, data,
+			`+offerOverrideColumns+`, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, append(args, offerOverrideValues(&offer)...)...)
+	return err
+}
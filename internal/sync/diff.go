@@ -0,0 +1,264 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/julienbonastre/ebay-helpers/internal/ebay"
+)
+
+// AccountDiff compares two accounts' exported inventory and offers, from the
+// source's point of view: what importing source into target would add, what
+// only the target has, and what differs for SKUs both have
+type AccountDiff struct {
+	Added   []DiffItem `json:"added"`   // SKUs only in the source
+	Removed []DiffItem `json:"removed"` // SKUs only in the target
+	Changed []SKUDiff  `json:"changed"` // SKUs in both whose title, price or policies differ
+}
+
+// DiffItem is a SKU present in only one of the accounts
+type DiffItem struct {
+	SKU   string `json:"sku"`
+	Title string `json:"title,omitempty"`
+}
+
+// SKUDiff lists what differs for a SKU both accounts have
+type SKUDiff struct {
+	SKU     string        `json:"sku"`
+	Title   string        `json:"title,omitempty"` // Source title
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange is one differing value. Offer fields are compared per
+// marketplace; "offer" means only one account has an offer there.
+type FieldChange struct {
+	Field         string `json:"field"` // "title", "offer", "price", "fulfillmentPolicy", "paymentPolicy" or "returnPolicy"
+	MarketplaceID string `json:"marketplaceId,omitempty"`
+	Source        string `json:"source"` // "" when the source has none
+	Target        string `json:"target"`
+}
+
+// diffSide is one account's exported data, keyed by SKU
+type diffSide struct {
+	titles   map[string]string                // SKU -> inventory title
+	offers   map[string]map[string]ebay.Offer // SKU -> marketplace -> offer
+	policies map[string]string                // Policy ID -> name, all three types
+}
+
+// DiffAccounts compares the inventory items and offers exported for two
+// accounts. Policies are compared by name, since each account has its own
+// policy IDs; a policy that wasn't exported is shown by ID.
+func (s *Service) DiffAccounts(sourceAccountID, targetAccountID int64) (*AccountDiff, error) {
+	source, err := s.loadDiffSide(sourceAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load source account: %w", err)
+	}
+	target, err := s.loadDiffSide(targetAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load target account: %w", err)
+	}
+
+	diff := &AccountDiff{
+		Added:   []DiffItem{},
+		Removed: []DiffItem{},
+		Changed: []SKUDiff{},
+	}
+	for _, sku := range source.skus() {
+		if !target.has(sku) {
+			diff.Added = append(diff.Added, DiffItem{SKU: sku, Title: source.titles[sku]})
+			continue
+		}
+		if changes := compareSKU(source, target, sku); len(changes) > 0 {
+			diff.Changed = append(diff.Changed, SKUDiff{SKU: sku, Title: source.titles[sku], Changes: changes})
+		}
+	}
+	for _, sku := range target.skus() {
+		if !source.has(sku) {
+			diff.Removed = append(diff.Removed, DiffItem{SKU: sku, Title: target.titles[sku]})
+		}
+	}
+	return diff, nil
+}
+
+// compareSKU lists the differences for a SKU both accounts have
+func compareSKU(source, target *diffSide, sku string) []FieldChange {
+	var changes []FieldChange
+	// Titles come from inventory items; a side with only an offer has no title to compare
+	sTitle, sHasItem := source.titles[sku]
+	tTitle, tHasItem := target.titles[sku]
+	if sHasItem && tHasItem && sTitle != tTitle {
+		changes = append(changes, FieldChange{Field: "title", Source: sTitle, Target: tTitle})
+	}
+
+	marketplaces := make(map[string]bool)
+	for m := range source.offers[sku] {
+		marketplaces[m] = true
+	}
+	for m := range target.offers[sku] {
+		marketplaces[m] = true
+	}
+	for _, m := range sortedKeys(marketplaces) {
+		so, inSource := source.offers[sku][m]
+		to, inTarget := target.offers[sku][m]
+		if !inSource || !inTarget {
+			changes = append(changes, FieldChange{Field: "offer", MarketplaceID: m, Source: so.OfferID, Target: to.OfferID})
+			continue
+		}
+
+		if sp, tp := so.Price(), to.Price(); !samePrice(sp, tp) {
+			changes = append(changes, FieldChange{Field: "price", MarketplaceID: m, Source: formatAmount(sp), Target: formatAmount(tp)})
+		}
+		sPolicies, tPolicies := policyIDs(&so), policyIDs(&to)
+		for i, field := range []string{"fulfillmentPolicy", "paymentPolicy", "returnPolicy"} {
+			sName, tName := source.policyName(sPolicies[i]), target.policyName(tPolicies[i])
+			if sName != tName {
+				changes = append(changes, FieldChange{Field: field, MarketplaceID: m, Source: sName, Target: tName})
+			}
+		}
+	}
+	return changes
+}
+
+// loadDiffSide reads an account's exported inventory titles, offers and
+// policy names. Rows that fail to parse are logged and skipped.
+func (s *Service) loadDiffSide(accountID int64) (*diffSide, error) {
+	side := &diffSide{
+		titles:   make(map[string]string),
+		offers:   make(map[string]map[string]ebay.Offer),
+		policies: make(map[string]string),
+	}
+
+	rows, err := s.db.Query(`
+		SELECT sku, COALESCE(title, '')
+		FROM inventory_items
+		WHERE account_id = ?
+	`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sku, title string
+		if err := rows.Scan(&sku, &title); err != nil {
+			return nil, err
+		}
+		side.titles[sku] = title
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Several offers for one SKU and marketplace shouldn't happen; if they
+	// do, the numerically lowest offer ID is compared, as it's read last
+	offerRows, err := s.db.Query(`
+		SELECT offer_id, sku, COALESCE(marketplace_id, ''), data
+		FROM offers
+		WHERE account_id = ?
+		ORDER BY CAST(offer_id AS INTEGER) DESC, offer_id DESC
+	`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer offerRows.Close()
+	for offerRows.Next() {
+		var offerID, sku, marketplaceID, data string
+		if err := offerRows.Scan(&offerID, &sku, &marketplaceID, &data); err != nil {
+			return nil, err
+		}
+		var offer ebay.Offer
+		if err := json.Unmarshal([]byte(data), &offer); err != nil {
+			log.Printf("Failed to unmarshal offer %s: %v", offerID, err)
+			continue
+		}
+		offer.OfferID = offerID
+		if side.offers[sku] == nil {
+			side.offers[sku] = make(map[string]ebay.Offer)
+		}
+		side.offers[sku][marketplaceID] = offer
+	}
+	if err := offerRows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, table := range []string{"fulfillment_policies", "payment_policies", "return_policies"} {
+		stored, err := s.loadStoredPolicies(table, accountID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range stored {
+			side.policies[p.policyID] = p.name
+		}
+	}
+	return side, nil
+}
+
+// skus returns every SKU with an inventory item or offer, sorted
+func (d *diffSide) skus() []string {
+	set := make(map[string]bool, len(d.titles))
+	for sku := range d.titles {
+		set[sku] = true
+	}
+	for sku := range d.offers {
+		set[sku] = true
+	}
+	return sortedKeys(set)
+}
+
+func (d *diffSide) has(sku string) bool {
+	_, hasItem := d.titles[sku]
+	_, hasOffer := d.offers[sku]
+	return hasItem || hasOffer
+}
+
+// policyName returns the exported name of a policy, or its ID if it wasn't exported
+func (d *diffSide) policyName(id string) string {
+	if name, ok := d.policies[id]; ok {
+		return name
+	}
+	return id
+}
+
+// policyIDs returns an offer's fulfillment, payment and return policy IDs
+func policyIDs(o *ebay.Offer) [3]string {
+	if o.ListingPolicies == nil {
+		return [3]string{}
+	}
+	lp := o.ListingPolicies
+	return [3]string{lp.FulfillmentPolicyID, lp.PaymentPolicyID, lp.ReturnPolicyID}
+}
+
+// samePrice compares amounts numerically, so "12.5" and "12.50" match
+func samePrice(a, b ebay.Amount) bool {
+	if a.Currency != b.Currency {
+		return false
+	}
+	av, aErr := strconv.ParseFloat(a.Value, 64)
+	bv, bErr := strconv.ParseFloat(b.Value, 64)
+	if aErr != nil || bErr != nil {
+		return a.Value == b.Value
+	}
+	return av == bv
+}
+
+// formatAmount formats an amount as e.g. "49.95 AUD", or "" when unset
+func formatAmount(a ebay.Amount) string {
+	if a.Value == "" {
+		return ""
+	}
+	if a.Currency == "" {
+		return a.Value
+	}
+	return a.Value + " " + a.Currency
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,92 @@
+package sync
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// addDiffItem stores an exported inventory item
+func addDiffItem(t *testing.T, s *Service, accountID int64, sku, title string) {
+	t.Helper()
+	_, err := s.db.Exec(`INSERT INTO inventory_items (account_id, sku, title, data) VALUES (?, ?, ?, '{}')`, accountID, sku, title)
+	if err != nil {
+		t.Fatalf("insert inventory item %s: %v", sku, err)
+	}
+}
+
+// addDiffOffer stores an exported EBAY_AU offer with a price in AUD and, if
+// set, policy IDs
+func addDiffOffer(t *testing.T, s *Service, accountID int64, offerID, sku, price, fulfillmentID, paymentID string) {
+	t.Helper()
+	data := fmt.Sprintf(`{"sku":%q,"marketplaceId":"EBAY_AU","pricingSummary":{"price":{"value":%q,"currency":"AUD"}},
+		"listingPolicies":{"fulfillmentPolicyId":%q,"paymentPolicyId":%q}}`, sku, price, fulfillmentID, paymentID)
+	_, err := s.db.Exec(`INSERT INTO offers (account_id, offer_id, sku, marketplace_id, data) VALUES (?, ?, ?, 'EBAY_AU', ?)`, accountID, offerID, sku, data)
+	if err != nil {
+		t.Fatalf("insert offer %s: %v", offerID, err)
+	}
+}
+
+// addDiffPolicy stores an exported policy in table
+func addDiffPolicy(t *testing.T, s *Service, table string, accountID int64, policyID, name string) {
+	t.Helper()
+	_, err := s.db.Exec(`INSERT INTO `+table+` (account_id, policy_id, name, marketplace_id, data) VALUES (?, ?, ?, 'EBAY_AU', '{}')`, accountID, policyID, name)
+	if err != nil {
+		t.Fatalf("insert %s %s: %v", table, policyID, err)
+	}
+}
+
+func TestDiffAccounts(t *testing.T) {
+	s, source := newTestService(t)
+	target, err := s.db.GetOrCreateAccount("other", "other", "sandbox", "EBAY_AU")
+	if err != nil {
+		t.Fatalf("GetOrCreateAccount: %v", err)
+	}
+
+	// Disjoint: A and offer-only D in the source, E in the target
+	addDiffItem(t, s, source.ID, "A", "Alpha")
+	addDiffOffer(t, s, source.ID, "1", "D", "5.00", "", "")
+	addDiffItem(t, s, target.ID, "E", "Echo")
+
+	// B is in both with a new title. Its duplicate source offers compare
+	// offer 9, the numerically lowest, whose price matches; the policies have
+	// different IDs but the same name.
+	addDiffItem(t, s, source.ID, "B", "Bravo")
+	addDiffItem(t, s, target.ID, "B", "Bravo new")
+	addDiffOffer(t, s, source.ID, "9", "B", "12.00", "fp-s", "")
+	addDiffOffer(t, s, source.ID, "10", "B", "99.00", "fp-s", "")
+	addDiffOffer(t, s, target.ID, "20", "B", "12.0", "fp-t", "")
+	addDiffPolicy(t, s, "fulfillment_policies", source.ID, "fp-s", "Standard")
+	addDiffPolicy(t, s, "fulfillment_policies", target.ID, "fp-t", "Standard")
+
+	// C is in both, with no inventory item in the target, so its titles
+	// aren't compared. The target's payment policy wasn't exported.
+	addDiffItem(t, s, source.ID, "C", "Charlie")
+	addDiffOffer(t, s, source.ID, "3", "C", "20.00", "", "pp-s")
+	addDiffOffer(t, s, target.ID, "30", "C", "25.00", "", "pp-t")
+	addDiffPolicy(t, s, "payment_policies", source.ID, "pp-s", "Card")
+
+	diff, err := s.DiffAccounts(source.ID, target.ID)
+	if err != nil {
+		t.Fatalf("DiffAccounts: %v", err)
+	}
+
+	if want := []DiffItem{{SKU: "A", Title: "Alpha"}, {SKU: "D"}}; !reflect.DeepEqual(diff.Added, want) {
+		t.Errorf("added = %+v, want %+v", diff.Added, want)
+	}
+	if want := []DiffItem{{SKU: "E", Title: "Echo"}}; !reflect.DeepEqual(diff.Removed, want) {
+		t.Errorf("removed = %+v, want %+v", diff.Removed, want)
+	}
+	wantChanged := []SKUDiff{
+		{SKU: "B", Title: "Bravo", Changes: []FieldChange{
+			{Field: "title", Source: "Bravo", Target: "Bravo new"},
+		}},
+		{SKU: "C", Title: "Charlie", Changes: []FieldChange{
+			{Field: "price", MarketplaceID: "EBAY_AU", Source: "20.00 AUD", Target: "25.00 AUD"},
+			{Field: "paymentPolicy", MarketplaceID: "EBAY_AU", Source: "Card", Target: "pp-t"},
+		}},
+	}
+	if !reflect.DeepEqual(diff.Changed, wantChanged) {
+		t.Errorf("changed = %+v\nwant %+v", diff.Changed, wantChanged)
+	}
+}
@@ -0,0 +1,54 @@
+package sync
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/database"
+	"github.com/julienbonastre/ebay-helpers/internal/ebay"
+	"golang.org/x/oauth2"
+)
+
+// newTestService returns a Service over a fresh database with one account
+func newTestService(t *testing.T) (*Service, *database.Account) {
+	t.Helper()
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	acc, err := db.GetOrCreateAccount("seller", "seller", "sandbox", "EBAY_AU")
+	if err != nil {
+		t.Fatalf("GetOrCreateAccount: %v", err)
+	}
+	return NewService(db), acc
+}
+
+// newTestClient returns an authenticated sandbox client whose requests are
+// all served by handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *ebay.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	// Clients capture http.DefaultTransport when created
+	target, _ := url.Parse(server.URL)
+	orig := http.DefaultTransport
+	http.DefaultTransport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r.URL.Scheme, r.URL.Host = target.Scheme, target.Host
+		return orig.RoundTrip(r)
+	})
+	t.Cleanup(func() { http.DefaultTransport = orig })
+
+	client := ebay.NewClient(ebay.Config{Sandbox: true})
+	client.SetToken(&oauth2.Token{AccessToken: "test", Expiry: time.Now().Add(time.Hour)})
+	return client
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,242 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/julienbonastre/ebay-helpers/internal/ebay"
+)
+
+// PolicyIDMap maps policy IDs from the source account to the equivalent policy
+// in the target account, per policy type. Offers reference policies by ID, so
+// this is what an offer import needs to rewrite its listingPolicies.
+// In a dry run, policies that would be created map to "".
+type PolicyIDMap struct {
+	Fulfillment map[string]string `json:"fulfillment"`
+	Payment     map[string]string `json:"payment"`
+	Return      map[string]string `json:"return"`
+}
+
+// Import actions
+const (
+	ActionCreate = "create" // Created in the target (or would be, in a dry run)
+	ActionReuse  = "reuse"  // Already existed in the target and was matched by name
+	ActionSkip   = "skip"   // Not imported: creating it in the target isn't implemented yet
+)
+
+// ImportAction records one thing an import did, or would do in a dry run
+type ImportAction struct {
+	Resource string `json:"resource"`
+	Action   string `json:"action"`
+	Name     string `json:"name"`               // Policy name or SKU
+	SourceID string `json:"sourceId,omitempty"` // Policy ID in the source account
+	TargetID string `json:"targetId,omitempty"` // Policy ID in the target account, once known
+}
+
+// importRun carries per-import state through the import steps
+type importRun struct {
+	sourceAccountID int64
+	dryRun          bool
+	actions         []ImportAction
+}
+
+func (r *importRun) plan(resource, action, name, sourceID, targetID string) {
+	r.actions = append(r.actions, ImportAction{
+		Resource: resource,
+		Action:   action,
+		Name:     name,
+		SourceID: sourceID,
+		TargetID: targetID,
+	})
+}
+
+// storedPolicy is a policy row saved by a previous export
+type storedPolicy struct {
+	policyID      string
+	name          string
+	marketplaceID string
+	data          string
+}
+
+// loadStoredPolicies reads exported policies for an account from one of the policy tables
+func (s *Service) loadStoredPolicies(table string, accountID int64) ([]storedPolicy, error) {
+	// Table name comes from the fixed set of callers below, never from user input
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT policy_id, name, marketplace_id, data
+		FROM %s
+		WHERE account_id = ?
+		ORDER BY name
+	`, table), accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []storedPolicy
+	for rows.Next() {
+		var p storedPolicy
+		if err := rows.Scan(&p.policyID, &p.name, &p.marketplaceID, &p.data); err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// policyType describes one kind of business policy (fulfillment, payment or
+// return) for ensure, which is the same for all three
+type policyType[P any] struct {
+	resource string // Resource* constant recorded in the import plan
+	table    string // Table its exported policies are stored in
+	name     string // e.g. "fulfillment", for messages
+
+	// list returns the target's policies for a marketplace, name -> policy ID
+	list func(ctx context.Context, client *ebay.Client, marketplaceID string) (map[string]string, error)
+	// create creates policy in the target and returns its new ID
+	create func(ctx context.Context, client *ebay.Client, policy *P) (string, error)
+	// clearID blanks the source account's ID before policy is created
+	clearID func(policy *P)
+}
+
+var fulfillmentPolicies = policyType[ebay.FulfillmentPolicy]{
+	resource: ResourceFulfillmentPolicies,
+	table:    "fulfillment_policies",
+	name:     "fulfillment",
+	list: func(ctx context.Context, client *ebay.Client, marketplaceID string) (map[string]string, error) {
+		resp, err := client.GetFulfillmentPolicies(ctx, marketplaceID)
+		if err != nil {
+			return nil, err
+		}
+		ids := make(map[string]string, len(resp.FulfillmentPolicies))
+		for _, p := range resp.FulfillmentPolicies {
+			ids[p.Name] = p.FulfillmentPolicyID
+		}
+		return ids, nil
+	},
+	create: func(ctx context.Context, client *ebay.Client, policy *ebay.FulfillmentPolicy) (string, error) {
+		created, err := client.CreateFulfillmentPolicy(ctx, policy)
+		if err != nil {
+			return "", err
+		}
+		return created.FulfillmentPolicyID, nil
+	},
+	clearID: func(policy *ebay.FulfillmentPolicy) { policy.FulfillmentPolicyID = "" },
+}
+
+var paymentPolicies = policyType[ebay.PaymentPolicy]{
+	resource: ResourcePaymentPolicies,
+	table:    "payment_policies",
+	name:     "payment",
+	list: func(ctx context.Context, client *ebay.Client, marketplaceID string) (map[string]string, error) {
+		resp, err := client.GetPaymentPolicies(ctx, marketplaceID)
+		if err != nil {
+			return nil, err
+		}
+		ids := make(map[string]string, len(resp.PaymentPolicies))
+		for _, p := range resp.PaymentPolicies {
+			ids[p.Name] = p.PaymentPolicyID
+		}
+		return ids, nil
+	},
+	create: func(ctx context.Context, client *ebay.Client, policy *ebay.PaymentPolicy) (string, error) {
+		created, err := client.CreatePaymentPolicy(ctx, policy)
+		if err != nil {
+			return "", err
+		}
+		return created.PaymentPolicyID, nil
+	},
+	clearID: func(policy *ebay.PaymentPolicy) { policy.PaymentPolicyID = "" },
+}
+
+var returnPolicies = policyType[ebay.ReturnPolicy]{
+	resource: ResourceReturnPolicies,
+	table:    "return_policies",
+	name:     "return",
+	list: func(ctx context.Context, client *ebay.Client, marketplaceID string) (map[string]string, error) {
+		resp, err := client.GetReturnPolicies(ctx, marketplaceID)
+		if err != nil {
+			return nil, err
+		}
+		ids := make(map[string]string, len(resp.ReturnPolicies))
+		for _, p := range resp.ReturnPolicies {
+			ids[p.Name] = p.ReturnPolicyID
+		}
+		return ids, nil
+	},
+	create: func(ctx context.Context, client *ebay.Client, policy *ebay.ReturnPolicy) (string, error) {
+		created, err := client.CreateReturnPolicy(ctx, policy)
+		if err != nil {
+			return "", err
+		}
+		return created.ReturnPolicyID, nil
+	},
+	clearID: func(policy *ebay.ReturnPolicy) { policy.ReturnPolicyID = "" },
+}
+
+// ensure makes sure every stored policy of this type exists in the target
+// account, returning source -> target policy IDs. Policies are matched by
+// name, so re-running an import reuses the policies created last time instead
+// of creating duplicates.
+func (pt policyType[P]) ensure(ctx context.Context, s *Service, client *ebay.Client, run *importRun) (map[string]string, error) {
+	stored, err := s.loadStoredPolicies(pt.table, run.sourceAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	idMap := make(map[string]string)
+	existing := make(map[string]map[string]string) // marketplace -> name -> target policy ID
+	var failed int
+	var lastErr error
+
+	for _, p := range stored {
+		if _, ok := existing[p.marketplaceID]; !ok {
+			ids, err := pt.list(ctx, client, p.marketplaceID)
+			if err != nil {
+				return idMap, fmt.Errorf("failed to list target %s policies for %s: %w", pt.name, p.marketplaceID, err)
+			}
+			existing[p.marketplaceID] = ids
+		}
+
+		if targetID, ok := existing[p.marketplaceID][p.name]; ok {
+			log.Printf("Reusing %s policy %q, which already exists in target (%s)", pt.name, p.name, targetID)
+			idMap[p.policyID] = targetID
+			run.plan(pt.resource, ActionReuse, p.name, p.policyID, targetID)
+			continue
+		}
+
+		if run.dryRun {
+			// Target ID is unknown until the policy is actually created
+			idMap[p.policyID] = ""
+			run.plan(pt.resource, ActionCreate, p.name, p.policyID, "")
+			continue
+		}
+
+		var policy P
+		if err := json.Unmarshal([]byte(p.data), &policy); err != nil {
+			log.Printf("Failed to unmarshal %s policy %s: %v", pt.name, p.policyID, err)
+			failed++
+			lastErr = err
+			continue
+		}
+		pt.clearID(&policy)
+
+		createdID, err := pt.create(ctx, client, &policy)
+		if err != nil {
+			log.Printf("Failed to create %s policy %q: %v", pt.name, p.name, err)
+			failed++
+			lastErr = err
+			continue
+		}
+		log.Printf("Created %s policy %q (%s -> %s)", pt.name, p.name, p.policyID, createdID)
+		existing[p.marketplaceID][p.name] = createdID
+		idMap[p.policyID] = createdID
+		run.plan(pt.resource, ActionCreate, p.name, p.policyID, createdID)
+	}
+
+	if failed > 0 {
+		return idMap, fmt.Errorf("%d of %d %s policies could not be created: %w", failed, len(stored), pt.name, lastErr)
+	}
+	return idMap, nil
+}
@@ -0,0 +1,117 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/julienbonastre/ebay-helpers/internal/ebay"
+)
+
+// policyServer serves one policy type's list and create calls for a target
+// account that starts with a "Standard" policy. Created policies are listed
+// from then on.
+type policyServer struct {
+	path, listKey, idKey string
+
+	mu      sync.Mutex
+	names   map[string]string // Name -> target ID
+	created []map[string]any  // Create request bodies
+}
+
+func (ps *policyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if !strings.HasSuffix(r.URL.Path, ps.path) {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method == http.MethodPost {
+		var body map[string]any
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+		ps.created = append(ps.created, body)
+		name, _ := body["name"].(string)
+		ps.names[name] = fmt.Sprintf("t-%d", len(ps.names)+1)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{ps.idKey: ps.names[name], "name": name})
+		return
+	}
+	var policies []map[string]string
+	for name, id := range ps.names {
+		policies = append(policies, map[string]string{ps.idKey: id, "name": name})
+	}
+	json.NewEncoder(w).Encode(map[string]any{ps.listKey: policies})
+}
+
+func TestEnsurePolicies(t *testing.T) {
+	tests := []struct {
+		table    string
+		resource string
+		ensure   func(context.Context, *Service, *ebay.Client, *importRun) (map[string]string, error)
+		server   *policyServer
+	}{
+		{"fulfillment_policies", ResourceFulfillmentPolicies, fulfillmentPolicies.ensure, &policyServer{path: "/fulfillment_policy", listKey: "fulfillmentPolicies", idKey: "fulfillmentPolicyId"}},
+		{"payment_policies", ResourcePaymentPolicies, paymentPolicies.ensure, &policyServer{path: "/payment_policy", listKey: "paymentPolicies", idKey: "paymentPolicyId"}},
+		{"return_policies", ResourceReturnPolicies, returnPolicies.ensure, &policyServer{path: "/return_policy", listKey: "returnPolicies", idKey: "returnPolicyId"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.table, func(t *testing.T) {
+			tt.server.names = map[string]string{"Standard": "t-std"}
+			client := newTestClient(t, tt.server.ServeHTTP)
+			s, source := newTestService(t)
+			for _, p := range []struct{ id, name string }{{"src-1", "Standard"}, {"src-2", "Express"}} {
+				data := fmt.Sprintf(`{%q:%q,"name":%q,"marketplaceId":"EBAY_AU"}`, tt.server.idKey, p.id, p.name)
+				_, err := s.db.Exec(`INSERT INTO `+tt.table+` (account_id, policy_id, name, marketplace_id, data) VALUES (?, ?, ?, 'EBAY_AU', ?)`, source.ID, p.id, p.name, data)
+				if err != nil {
+					t.Fatalf("insert policy %s: %v", p.id, err)
+				}
+			}
+
+			// A dry run creates nothing
+			run := &importRun{sourceAccountID: source.ID, dryRun: true}
+			idMap, err := tt.ensure(context.Background(), s, client, run)
+			if err != nil {
+				t.Fatalf("dry run: %v", err)
+			}
+			if want := map[string]string{"src-1": "t-std", "src-2": ""}; !reflect.DeepEqual(idMap, want) {
+				t.Errorf("dry run IDs = %v, want %v", idMap, want)
+			}
+			if len(tt.server.created) != 0 {
+				t.Fatalf("dry run created %d policies", len(tt.server.created))
+			}
+
+			// Standard is reused and Express created, without the source ID
+			for i := 0; i < 2; i++ {
+				run = &importRun{sourceAccountID: source.ID}
+				idMap, err = tt.ensure(context.Background(), s, client, run)
+				if err != nil {
+					t.Fatalf("import %d: %v", i+1, err)
+				}
+				if want := map[string]string{"src-1": "t-std", "src-2": "t-2"}; !reflect.DeepEqual(idMap, want) {
+					t.Errorf("import %d IDs = %v, want %v", i+1, idMap, want)
+				}
+			}
+			if len(tt.server.created) != 1 {
+				t.Fatalf("created %d policies over two imports, want 1", len(tt.server.created))
+			}
+			if id, ok := tt.server.created[0][tt.server.idKey]; ok {
+				t.Errorf("created policy carries the source ID %v", id)
+			}
+			wantActions := []ImportAction{
+				{Resource: tt.resource, Action: ActionReuse, Name: "Express", SourceID: "src-2", TargetID: "t-2"},
+				{Resource: tt.resource, Action: ActionReuse, Name: "Standard", SourceID: "src-1", TargetID: "t-std"},
+			}
+			if !reflect.DeepEqual(run.actions, wantActions) {
+				t.Errorf("re-run actions = %+v, want %+v", run.actions, wantActions)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package sync
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AllResources lists every resource a sync handles, in sync order
+var AllResources = []string{
+	ResourceFulfillmentPolicies,
+	ResourcePaymentPolicies,
+	ResourceReturnPolicies,
+	ResourceInventoryItems,
+	ResourceOffers,
+}
+
+// resourceAliases are the short names accepted in ?resources=, each expanding
+// to one or more resources
+var resourceAliases = map[string][]string{
+	"policies":  {ResourceFulfillmentPolicies, ResourcePaymentPolicies, ResourceReturnPolicies},
+	"inventory": {ResourceInventoryItems},
+}
+
+// ParseResources parses a comma-separated resource list such as
+// "policies,inventory". Both the aliases ("policies", "inventory") and the
+// resource names themselves are accepted. An empty string means all resources.
+func ParseResources(param string) ([]string, error) {
+	var resources []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		expanded, ok := resourceAliases[name]
+		if !ok {
+			if !isResource(name) {
+				return nil, fmt.Errorf("unknown resource %q (valid: policies, inventory, %s)", name, strings.Join(AllResources, ", "))
+			}
+			expanded = []string{name}
+		}
+		for _, resource := range expanded {
+			if !seen[resource] {
+				seen[resource] = true
+				resources = append(resources, resource)
+			}
+		}
+	}
+	return resources, nil
+}
+
+func isResource(name string) bool {
+	for _, resource := range AllResources {
+		if resource == name {
+			return true
+		}
+	}
+	return false
+}
+
+// wantResource reports whether resource was selected. No selection means all.
+func wantResource(resources []string, resource string) bool {
+	if len(resources) == 0 {
+		return true
+	}
+	for _, r := range resources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package sync
+
+import (
+	"fmt"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/database"
+)
+
+// Resource names used as keys in SyncResult counts and errors
+const (
+	ResourceFulfillmentPolicies = "fulfillmentPolicies"
+	ResourcePaymentPolicies     = "paymentPolicies"
+	ResourceReturnPolicies      = "returnPolicies"
+	ResourceInventoryItems      = "inventoryItems"
+	ResourceOffers              = "offers"
+)
+
+// SyncResult reports the outcome of an export or import so handlers can show
+// the user exactly what was synced and what failed
+type SyncResult struct {
+	HistoryID  int64             `json:"historyId"`
+	SyncType   string            `json:"syncType"`         // "export" or "import"
+	Status     string            `json:"status"`           // "success" or "partial"
+	Counts     map[string]int    `json:"counts"`           // Resource -> records synced
+	Errors     map[string]string `json:"errors,omitempty"` // Resource -> error message
+	Phases     []PhaseResult     `json:"phases"`           // Per-resource outcome, in sync order
+	Summary    string            `json:"summary"`
+	PolicyIDs  *PolicyIDMap      `json:"policyIds,omitempty"` // Import only: source -> target policy IDs
+	Actions    []ImportAction    `json:"actions,omitempty"`   // Import only: what was (or would be) created/reused
+	DryRun     bool              `json:"dryRun,omitempty"`
+	TotalItems int               `json:"totalItems"`
+	StartedAt  time.Time         `json:"startedAt"`
+	DurationMs int64             `json:"durationMs"`
+}
+
+// PhaseResult is the outcome of syncing a single resource, so a partial sync
+// shows exactly which phases need retrying
+type PhaseResult struct {
+	Resource string `json:"resource"`
+	Status   string `json:"status"` // "ok" or "failed"
+	Count    int    `json:"count"`
+	Error    string `json:"error,omitempty"`
+}
+
+// String formats the phase as e.g. "offers: ok (340)" or "offers: failed (timeout)"
+func (p PhaseResult) String() string {
+	if p.Status == "failed" {
+		return fmt.Sprintf("%s: failed (%s)", p.Resource, p.Error)
+	}
+	return fmt.Sprintf("%s: ok (%d)", p.Resource, p.Count)
+}
+
+func newSyncResult(syncType string, startedAt time.Time) *SyncResult {
+	return &SyncResult{
+		SyncType:  syncType,
+		Status:    "running",
+		Counts:    make(map[string]int),
+		Errors:    make(map[string]string),
+		StartedAt: startedAt,
+	}
+}
+
+// record stores the outcome of a single resource's sync
+func (r *SyncResult) record(resource string, count int, err error) {
+	if err != nil {
+		r.Errors[resource] = err.Error()
+		r.Phases = append(r.Phases, PhaseResult{Resource: resource, Status: "failed", Error: err.Error()})
+		return
+	}
+	r.Phases = append(r.Phases, PhaseResult{Resource: resource, Status: "ok", Count: count})
+	r.Counts[resource] = count
+	r.TotalItems += count
+}
+
+// finish sets the final status, summary and duration
+func (r *SyncResult) finish(completedAt time.Time) {
+	phases := make([]string, len(r.Phases))
+	for i, p := range r.Phases {
+		phases[i] = p.String()
+	}
+	r.Summary = strings.Join(phases, ", ")
+
+	if len(r.Errors) > 0 {
+		r.Status = "partial"
+	} else {
+		r.Status = "success"
+	}
+	r.DurationMs = completedAt.Sub(r.StartedAt).Milliseconds()
+}
+
+// historyDetails converts the phases into the per-resource breakdown stored
+// with the sync history record
+func (r *SyncResult) historyDetails() map[string]database.SyncResourceDetail {
+	details := make(map[string]database.SyncResourceDetail, len(r.Phases))
+	for _, p := range r.Phases {
+		details[p.Resource] = database.SyncResourceDetail{Count: p.Count, Error: p.Error}
+	}
+	return details
+}
+
+// Progress event types
+const (
+	EventPhase = "phase" // A resource started syncing
+	EventCount = "count" // A resource finished; Count holds records synced
+	EventError = "error" // A resource failed; Error holds the message
+	EventDone  = "done"  // The whole sync finished; Result holds the outcome (sent by the caller)
+)
+
+// ProgressEvent reports sync progress as each resource starts and finishes
+type ProgressEvent struct {
+	Type     string      `json:"type"`
+	Resource string      `json:"resource,omitempty"`
+	Count    int         `json:"count,omitempty"`
+	Error    string      `json:"error,omitempty"`
+	Result   *SyncResult `json:"result,omitempty"`
+}
+
+// ProgressFunc receives progress events. Calls are serialized, so
+// implementations don't need their own locking.
+type ProgressFunc func(ProgressEvent)
+
+// progressReporter serializes calls to a ProgressFunc, since some resources
+// sync concurrently. A nil ProgressFunc is a no-op.
+type progressReporter struct {
+	mu gosync.Mutex
+	fn ProgressFunc
+}
+
+func (p *progressReporter) emit(event ProgressEvent) {
+	if p.fn == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fn(event)
+}
+
+// started reports that a resource has begun syncing
+func (p *progressReporter) started(resource string) {
+	p.emit(ProgressEvent{Type: EventPhase, Resource: resource})
+}
+
+// finished reports a resource's count or error
+func (p *progressReporter) finished(resource string, count int, err error) {
+	if err != nil {
+		p.emit(ProgressEvent{Type: EventError, Resource: resource, Error: err.Error()})
+		return
+	}
+	p.emit(ProgressEvent{Type: EventCount, Resource: resource, Count: count})
+}
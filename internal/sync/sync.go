@@ -5,12 +5,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
 	"time"
 
 	"github.com/julienbonastre/ebay-helpers/internal/database"
 	"github.com/julienbonastre/ebay-helpers/internal/ebay"
+	"golang.org/x/sync/errgroup"
 )
 
+// exportConcurrency caps how many resources ExportFromEbay fetches at once.
+// Each resource pages through eBay one call at a time.
+const exportConcurrency = 3
+
 // Service handles sync operations between eBay accounts and local database
 type Service struct {
 	db *database.DB
@@ -21,8 +27,24 @@ func NewService(db *database.DB) *Service {
 	return &Service{db: db}
 }
 
-// ExportFromEbay exports all data from eBay account to local database
-func (s *Service) ExportFromEbay(ctx context.Context, client *ebay.Client, accountID int64, marketplaceID string) error {
+// ExportOptions controls what ExportFromEbay fetches
+type ExportOptions struct {
+	// Resources limits the export to these resources (see ParseResources).
+	// Empty means all.
+	Resources []string
+
+	// Resume continues an inventory export that failed part-way from the last
+	// page it saved, instead of re-fetching every item from offset 0
+	Resume bool
+}
+
+// ExportFromEbay exports all data from eBay account to local database.
+// The returned SyncResult is non-nil whenever a sync history record was
+// created, even if some resources failed to export. progress may be nil;
+// otherwise it's called as each resource starts and finishes.
+func (s *Service) ExportFromEbay(ctx context.Context, client *ebay.Client, accountID int64, marketplaceID string, opts ExportOptions, progress ProgressFunc) (*SyncResult, error) {
+	reporter := &progressReporter{fn: progress}
+
 	syncHistory := &database.SyncHistory{
 		AccountID: accountID,
 		SyncType:  "export",
@@ -30,78 +52,85 @@ func (s *Service) ExportFromEbay(ctx context.Context, client *ebay.Client, accou
 		StartedAt: time.Now(),
 	}
 	if err := s.db.CreateSyncHistory(syncHistory); err != nil {
-		return fmt.Errorf("failed to create sync history: %w", err)
-	}
-
-	totalItems := 0
-	var lastErr error
-
-	// Export fulfillment policies
-	log.Printf("Exporting fulfillment policies...")
-	if count, err := s.exportFulfillmentPolicies(ctx, client, accountID, marketplaceID); err != nil {
-		log.Printf("Error exporting fulfillment policies: %v", err)
-		lastErr = err
-	} else {
-		totalItems += count
-		log.Printf("Exported %d fulfillment policies", count)
-	}
-
-	// Export payment policies
-	log.Printf("Exporting payment policies...")
-	if count, err := s.exportPaymentPolicies(ctx, client, accountID, marketplaceID); err != nil {
-		log.Printf("Error exporting payment policies: %v", err)
-		lastErr = err
-	} else {
-		totalItems += count
-		log.Printf("Exported %d payment policies", count)
+		return nil, fmt.Errorf("failed to create sync history: %w", err)
 	}
 
-	// Export return policies
-	log.Printf("Exporting return policies...")
-	if count, err := s.exportReturnPolicies(ctx, client, accountID, marketplaceID); err != nil {
-		log.Printf("Error exporting return policies: %v", err)
-		lastErr = err
-	} else {
-		totalItems += count
-		log.Printf("Exported %d return policies", count)
+	result := newSyncResult("export", syncHistory.StartedAt)
+	result.HistoryID = syncHistory.ID
+
+	// Export resources concurrently - each writes its own table, so there's no
+	// reason to wait on one before fetching the next. Every exporter pages
+	// through eBay sequentially, so exportConcurrency bounds the calls in flight.
+	exports := []struct {
+		resource string
+		name     string
+		export   func(context.Context, *ebay.Client, int64, string) (int, error)
+	}{
+		{ResourceFulfillmentPolicies, "fulfillment policies", s.exportFulfillmentPolicies},
+		{ResourcePaymentPolicies, "payment policies", s.exportPaymentPolicies},
+		{ResourceReturnPolicies, "return policies", s.exportReturnPolicies},
+		{ResourceInventoryItems, "inventory items", func(ctx context.Context, client *ebay.Client, accountID int64, _ string) (int, error) {
+			return s.exportInventoryItems(ctx, client, accountID, opts.Resume)
+		}},
+		{ResourceOffers, "offers", func(ctx context.Context, client *ebay.Client, accountID int64, _ string) (int, error) {
+			return s.exportOffers(ctx, client, accountID)
+		}},
 	}
+	counts := make([]int, len(exports))
+	errs := make([]error, len(exports))
 
-	// Export inventory items
-	log.Printf("Exporting inventory items...")
-	if count, err := s.exportInventoryItems(ctx, client, accountID); err != nil {
-		log.Printf("Error exporting inventory: %v", err)
-		lastErr = err
-	} else {
-		totalItems += count
-		log.Printf("Exported %d inventory items", count)
+	var g errgroup.Group
+	g.SetLimit(exportConcurrency)
+	for i, e := range exports {
+		if !wantResource(opts.Resources, e.resource) {
+			continue
+		}
+		g.Go(func() error {
+			log.Printf("Exporting %s...", e.name)
+			reporter.started(e.resource)
+			counts[i], errs[i] = e.export(ctx, client, accountID, marketplaceID)
+			reporter.finished(e.resource, counts[i], errs[i])
+			return errs[i]
+		})
 	}
-
-	// Export offers
-	log.Printf("Exporting offers...")
-	if count, err := s.exportOffers(ctx, client, accountID); err != nil {
-		log.Printf("Error exporting offers: %v", err)
-		lastErr = err
-	} else {
-		totalItems += count
-		log.Printf("Exported %d offers", count)
+	// Errors are collected per resource below; one failing doesn't stop the others
+	_ = g.Wait()
+
+	// Recorded in table order, so the result and history don't depend on which
+	// export finished first; the first failure is the one returned
+	var firstErr error
+	for i, e := range exports {
+		if !wantResource(opts.Resources, e.resource) {
+			continue
+		}
+		result.record(e.resource, counts[i], errs[i])
+		if errs[i] != nil {
+			log.Printf("Error exporting %s: %v", e.name, errs[i])
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+		} else {
+			log.Printf("Exported %d %s", counts[i], e.name)
+		}
 	}
 
 	// Update sync history
 	now := time.Now()
+	result.finish(now)
 	syncHistory.CompletedAt = &now
-	syncHistory.ItemsSynced = totalItems
-	if lastErr != nil {
-		syncHistory.Status = "partial"
-		syncHistory.ErrorMessage = lastErr.Error()
-	} else {
-		syncHistory.Status = "success"
+	syncHistory.ItemsSynced = result.TotalItems
+	syncHistory.Status = result.Status
+	syncHistory.Details = result.historyDetails()
+	if firstErr != nil {
+		// Record every phase, not just the last error, so it's clear what to retry
+		syncHistory.ErrorMessage = result.Summary
 	}
 	if err := s.db.UpdateSyncHistory(syncHistory); err != nil {
-		return fmt.Errorf("failed to update sync history: %w", err)
+		return result, fmt.Errorf("failed to update sync history: %w", err)
 	}
 
-	log.Printf("Export complete: %d total items", totalItems)
-	return lastErr
+	log.Printf("Export complete: %d total items (%s)", result.TotalItems, result.Summary)
+	return result, firstErr
 }
 
 func (s *Service) exportFulfillmentPolicies(ctx context.Context, client *ebay.Client, accountID int64, marketplaceID string) (int, error) {
@@ -179,11 +208,28 @@ func (s *Service) exportReturnPolicies(ctx context.Context, client *ebay.Client,
 	return len(resp.ReturnPolicies), nil
 }
 
-func (s *Service) exportInventoryItems(ctx context.Context, client *ebay.Client, accountID int64) (int, error) {
+// exportInventoryItems pages through the account's inventory, recording the
+// next offset in sync_cursors after each saved page. With resume it starts
+// from that cursor, so a failed export only re-fetches the page it failed on
+// (items added or removed since can shift eBay's offsets, so a full export
+// is the thorough option). The cursor is cleared once every page is saved.
+// The count is of items fetched by this run only.
+func (s *Service) exportInventoryItems(ctx context.Context, client *ebay.Client, accountID int64, resume bool) (int, error) {
 	const batchSize = 100
 	offset := 0
 	totalCount := 0
 
+	if resume {
+		cursor, err := s.db.GetSyncCursor(accountID, ResourceInventoryItems)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read inventory export cursor: %w", err)
+		}
+		if cursor > 0 {
+			log.Printf("Resuming inventory export at offset %d", cursor)
+		}
+		offset = cursor
+	}
+
 	for {
 		resp, err := client.GetInventoryItems(ctx, batchSize, offset)
 		if err != nil {
@@ -208,9 +254,18 @@ func (s *Service) exportInventoryItems(ctx context.Context, client *ebay.Client,
 				brand = item.Product.Brand
 			}
 
+			// updated_at only moves when the item actually changed, so it can be
+			// compared against enriched_items to find stale enrichment
 			_, err = s.db.Exec(`
-				INSERT OR REPLACE INTO inventory_items (account_id, sku, title, brand, condition, data, updated_at)
+				INSERT INTO inventory_items (account_id, sku, title, brand, condition, data, updated_at)
 				VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+				ON CONFLICT(account_id, sku) DO UPDATE SET
+					title = excluded.title,
+					brand = excluded.brand,
+					condition = excluded.condition,
+					data = excluded.data,
+					updated_at = CASE WHEN inventory_items.data = excluded.data
+						THEN inventory_items.updated_at ELSE CURRENT_TIMESTAMP END
 			`, accountID, item.SKU, title, brand, item.Condition, string(data))
 			if err != nil {
 				log.Printf("Failed to save item %s: %v", item.SKU, err)
@@ -224,15 +279,49 @@ func (s *Service) exportInventoryItems(ctx context.Context, client *ebay.Client,
 		if len(resp.InventoryItems) < batchSize {
 			break
 		}
+
+		if err := s.db.SetSyncCursor(accountID, ResourceInventoryItems, offset); err != nil {
+			return totalCount, fmt.Errorf("failed to save inventory export cursor: %w", err)
+		}
 	}
 
+	if err := s.db.ClearSyncCursor(accountID, ResourceInventoryItems); err != nil {
+		return totalCount, fmt.Errorf("failed to clear inventory export cursor: %w", err)
+	}
 	return totalCount, nil
 }
 
+// offerOverrideColumns are the offers columns holding the cost of an offer's
+// first domestic and international shipping override, so offers with custom
+// postage can be queried without parsing data. Filled by offerOverrideValues.
+const offerOverrideColumns = `override_domestic_cost, override_domestic_currency,
+					override_international_cost, override_international_currency`
+
+// offerOverrideValues returns offer's values for offerOverrideColumns, nil
+// (NULL) where it has no override of that type or the cost isn't a number
+func offerOverrideValues(offer *ebay.Offer) []any {
+	values := make([]any, 0, 4)
+	for _, serviceType := range []string{ebay.ShippingServiceDomestic, ebay.ShippingServiceInternational} {
+		cost := offer.ShippingCostOverride(serviceType)
+		if cost == nil {
+			values = append(values, nil, nil)
+			continue
+		}
+		value, err := strconv.ParseFloat(cost.Value, 64)
+		if err != nil {
+			values = append(values, nil, nil)
+			continue
+		}
+		values = append(values, value, cost.Currency)
+	}
+	return values
+}
+
 func (s *Service) exportOffers(ctx context.Context, client *ebay.Client, accountID int64) (int, error) {
 	const batchSize = 100
 	offset := 0
 	totalCount := 0
+	unpriced := 0
 
 	for {
 		resp, err := client.GetOffers(ctx, "", batchSize, offset)
@@ -255,11 +344,30 @@ func (s *Service) exportOffers(ctx context.Context, client *ebay.Client, account
 			if offer.Listing != nil {
 				listingID = offer.Listing.ListingID
 			}
+			// Stored as eBay sent it; a draft without pricing is still exported
+			if offer.Price().Value == "" {
+				unpriced++
+			}
 
+			// As with inventory items, updated_at only moves on a real change
+			args := []any{accountID, offer.OfferID, offer.SKU, offer.MarketplaceID, listingID, offer.Status, string(data)}
 			_, err = s.db.Exec(`
-				INSERT OR REPLACE INTO offers (account_id, offer_id, sku, marketplace_id, listing_id, status, data, updated_at)
-				VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
-			`, accountID, offer.OfferID, offer.SKU, offer.MarketplaceID, listingID, offer.Status, string(data))
+				INSERT INTO offers (account_id, offer_id, sku, marketplace_id, listing_id, status, data,
+					`+offerOverrideColumns+`, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+				ON CONFLICT(account_id, offer_id) DO UPDATE SET
+					sku = excluded.sku,
+					marketplace_id = excluded.marketplace_id,
+					listing_id = excluded.listing_id,
+					status = excluded.status,
+					data = excluded.data,
+					override_domestic_cost = excluded.override_domestic_cost,
+					override_domestic_currency = excluded.override_domestic_currency,
+					override_international_cost = excluded.override_international_cost,
+					override_international_currency = excluded.override_international_currency,
+					updated_at = CASE WHEN offers.data = excluded.data
+						THEN offers.updated_at ELSE CURRENT_TIMESTAMP END
+			`, append(args, offerOverrideValues(&offer)...)...)
 			if err != nil {
 				log.Printf("Failed to save offer %s: %v", offer.OfferID, err)
 			}
@@ -273,72 +381,143 @@ func (s *Service) exportOffers(ctx context.Context, client *ebay.Client, account
 		}
 	}
 
+	if unpriced > 0 {
+		log.Printf("Exported %d offers without pricing (usually unpublished drafts)", unpriced)
+	}
 	return totalCount, nil
 }
 
-// ImportToEbay reads from DB and creates items in target eBay account
-// NOTE: This is a basic implementation. Full policy creation requires additional eBay API methods.
-func (s *Service) ImportToEbay(ctx context.Context, client *ebay.Client, sourceAccountID, targetAccountID int64) error {
+// ImportOptions controls how ImportToEbay runs
+type ImportOptions struct {
+	// DryRun walks the source data and resolves policy remaps (read-only calls
+	// only) and reports what would be created, without calling any mutating
+	// eBay endpoints or recording sync history
+	DryRun bool
+
+	// Resources limits the import to these resources (see ParseResources).
+	// Empty means all.
+	Resources []string
+}
+
+// ImportToEbay reads from DB and creates items in target eBay account.
+// Policies are created first (or matched by name if they already exist) and
+// the resulting source -> target ID mapping is returned in SyncResult.PolicyIDs.
+// NOTE: Inventory and offer creation are not implemented yet.
+func (s *Service) ImportToEbay(ctx context.Context, client *ebay.Client, sourceAccountID, targetAccountID int64, opts ImportOptions) (*SyncResult, error) {
 	syncHistory := &database.SyncHistory{
 		AccountID: targetAccountID,
 		SyncType:  "import",
 		Status:    "running",
 		StartedAt: time.Now(),
 	}
-	if err := s.db.CreateSyncHistory(syncHistory); err != nil {
-		return fmt.Errorf("failed to create sync history: %w", err)
+	if !opts.DryRun {
+		if err := s.db.CreateSyncHistory(syncHistory); err != nil {
+			return nil, fmt.Errorf("failed to create sync history: %w", err)
+		}
 	}
 
-	totalItems := 0
+	result := newSyncResult("import", syncHistory.StartedAt)
+	result.HistoryID = syncHistory.ID
+	result.DryRun = opts.DryRun
+	run := &importRun{sourceAccountID: sourceAccountID, dryRun: opts.DryRun}
 	var lastErr error
 
+	if opts.DryRun {
+		log.Printf("Dry run: no changes will be made to the target account")
+	}
+
+	// Ensure policies exist in the target before anything that references them.
+	// Same-named policies are reused, so the import can be re-run safely.
+	policyIDs := &PolicyIDMap{}
+	policyImports := []struct {
+		resource string
+		name     string
+		ensure   func(context.Context, *Service, *ebay.Client, *importRun) (map[string]string, error)
+		idMap    *map[string]string
+	}{
+		{ResourceFulfillmentPolicies, "fulfillment policies", fulfillmentPolicies.ensure, &policyIDs.Fulfillment},
+		{ResourcePaymentPolicies, "payment policies", paymentPolicies.ensure, &policyIDs.Payment},
+		{ResourceReturnPolicies, "return policies", returnPolicies.ensure, &policyIDs.Return},
+	}
+	for _, pi := range policyImports {
+		if !wantResource(opts.Resources, pi.resource) {
+			continue
+		}
+		log.Printf("Ensuring %s exist in target...", pi.name)
+		idMap, err := pi.ensure(ctx, s, client, run)
+		*pi.idMap = idMap
+		result.record(pi.resource, len(idMap), err)
+		if err != nil {
+			log.Printf("Error importing %s: %v", pi.name, err)
+			lastErr = err
+		} else {
+			log.Printf("Mapped %d %s", len(idMap), pi.name)
+		}
+	}
+	result.PolicyIDs = policyIDs
+
 	// Import inventory items
-	log.Printf("Importing inventory items...")
-	if count, err := s.importInventoryItems(ctx, client, sourceAccountID); err != nil {
-		log.Printf("Error importing inventory: %v", err)
-		lastErr = err
-	} else {
-		totalItems += count
-		log.Printf("Imported %d inventory items", count)
+	if wantResource(opts.Resources, ResourceInventoryItems) {
+		log.Printf("Importing inventory items...")
+		skipped, err := s.importInventoryItems(ctx, client, run)
+		result.record(ResourceInventoryItems, 0, err)
+		if err != nil {
+			log.Printf("Error importing inventory: %v", err)
+			lastErr = err
+		} else {
+			log.Printf("Skipped %d inventory items: inventory import is not implemented yet", skipped)
+		}
 	}
 
 	// Import offers (listings)
-	// NOTE: Offers require policies to exist first. This is simplified for now.
-	log.Printf("NOTE: Offer import requires policies to be manually configured in sandbox first")
+	// NOTE: Offers need their listingPolicies rewritten using result.PolicyIDs.
+	log.Printf("NOTE: Offer import not yet implemented - policy ID mapping is available in the sync result")
 	log.Printf("Skipping offer import for now - will be enhanced in future")
 
-	// Update sync history
 	now := time.Now()
+	result.finish(now)
+	result.Actions = run.actions
+
+	if opts.DryRun {
+		log.Printf("Dry run complete: %d actions planned (%s)", len(result.Actions), result.Summary)
+		return result, lastErr
+	}
+
+	// Update sync history
 	syncHistory.CompletedAt = &now
-	syncHistory.ItemsSynced = totalItems
+	syncHistory.ItemsSynced = result.TotalItems
+	syncHistory.Status = result.Status
+	syncHistory.Details = result.historyDetails()
 	if lastErr != nil {
-		syncHistory.Status = "partial"
-		syncHistory.ErrorMessage = lastErr.Error()
-	} else {
-		syncHistory.Status = "success"
+		// Record every phase, not just the last error, so it's clear what to retry
+		syncHistory.ErrorMessage = result.Summary
 	}
 	if err := s.db.UpdateSyncHistory(syncHistory); err != nil {
-		return fmt.Errorf("failed to update sync history: %w", err)
+		return result, fmt.Errorf("failed to update sync history: %w", err)
 	}
 
-	log.Printf("Import complete: %d total items", totalItems)
-	return lastErr
+	log.Printf("Import complete: %d total items (%s)", result.TotalItems, result.Summary)
+	return result, lastErr
 }
 
-func (s *Service) importInventoryItems(ctx context.Context, client *ebay.Client, sourceAccountID int64) (int, error) {
+// importInventoryItems plans an ActionSkip for each of the source account's
+// stored inventory items and returns how many there are. Creating them in
+// the target isn't implemented yet, so nothing is imported and the phase
+// records 0 items.
+func (s *Service) importInventoryItems(ctx context.Context, client *ebay.Client, run *importRun) (int, error) {
 	// Read inventory items from database
 	rows, err := s.db.Query(`
 		SELECT sku, data
 		FROM inventory_items
 		WHERE account_id = ?
 		ORDER BY created_at
-	`, sourceAccountID)
+	`, run.sourceAccountID)
 	if err != nil {
 		return 0, err
 	}
 	defer rows.Close()
 
-	count := 0
+	skipped := 0
 	for rows.Next() {
 		var sku, data string
 		if err := rows.Scan(&sku, &data); err != nil {
@@ -353,12 +532,17 @@ func (s *Service) importInventoryItems(ctx context.Context, client *ebay.Client,
 			continue
 		}
 
+		run.plan(ResourceInventoryItems, ActionSkip, sku, "", "")
+		skipped++
+
 		// TODO: Create inventory item in target eBay account
 		// This requires implementing CreateInventoryItem method in ebay.Client
-		log.Printf("TODO: Would import inventory item: %s - %s", sku, item.Product.Title)
-		count++
+		title := ""
+		if item.Product != nil {
+			title = item.Product.Title
+		}
+		log.Printf("TODO: Would import inventory item: %s - %s", sku, title)
 	}
 
-	return count, rows.Err()
+	return skipped, rows.Err()
 }
-
@@ -0,0 +1,351 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/julienbonastre/ebay-helpers/internal/ebay"
+)
+
+// inventoryPages serves total inventory items a page at a time and records
+// the offsets requested. Requests at failAt get a 400 while fail is set.
+type inventoryPages struct {
+	total  int
+	failAt int
+
+	mu      sync.Mutex
+	fail    bool
+	offsets []int
+}
+
+func (p *inventoryPages) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	p.mu.Lock()
+	p.offsets = append(p.offsets, offset)
+	fail := p.fail && offset == p.failAt
+	p.mu.Unlock()
+
+	if fail {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"errors":[{"errorId":25001,"message":"page failed"}]}`))
+		return
+	}
+	resp := ebay.InventoryItemsResponse{Total: p.total, Limit: limit, Offset: offset}
+	for i := offset; i < offset+limit && i < p.total; i++ {
+		resp.InventoryItems = append(resp.InventoryItems, ebay.InventoryItem{SKU: fmt.Sprintf("SKU-%03d", i)})
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// takeOffsets returns the offsets requested so far and forgets them
+func (p *inventoryPages) takeOffsets() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	offsets := p.offsets
+	p.offsets = nil
+	return offsets
+}
+
+func TestExportInventoryItemsResume(t *testing.T) {
+	s, acc := newTestService(t)
+	pages := &inventoryPages{total: 350, failAt: 200, fail: true}
+	client := newTestClient(t, pages.ServeHTTP)
+	ctx := context.Background()
+
+	if _, err := s.exportInventoryItems(ctx, client, acc.ID, false); err == nil {
+		t.Fatal("export succeeded, want the page at offset 200 to fail")
+	}
+	if got := pages.takeOffsets(); fmt.Sprint(got) != "[0 100 200]" {
+		t.Errorf("first run fetched offsets %v, want [0 100 200]", got)
+	}
+	cursor, err := s.db.GetSyncCursor(acc.ID, ResourceInventoryItems)
+	if err != nil || cursor != 200 {
+		t.Fatalf("cursor after failure = %d, %v; want 200", cursor, err)
+	}
+
+	pages.mu.Lock()
+	pages.fail = false
+	pages.mu.Unlock()
+
+	count, err := s.exportInventoryItems(ctx, client, acc.ID, true)
+	if err != nil {
+		t.Fatalf("resumed export: %v", err)
+	}
+	if got := pages.takeOffsets(); fmt.Sprint(got) != "[200 300]" {
+		t.Errorf("resumed run fetched offsets %v, want [200 300]", got)
+	}
+	if count != 150 {
+		t.Errorf("resumed run exported %d items, want 150", count)
+	}
+
+	var stored int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM inventory_items WHERE account_id = ?`, acc.ID).Scan(&stored); err != nil {
+		t.Fatalf("count inventory items: %v", err)
+	}
+	if stored != 350 {
+		t.Errorf("stored %d inventory items, want 350", stored)
+	}
+	if cursor, _ := s.db.GetSyncCursor(acc.ID, ResourceInventoryItems); cursor != 0 {
+		t.Errorf("cursor after completing = %d, want cleared", cursor)
+	}
+}
+
+func TestExportOffersWithoutPricing(t *testing.T) {
+	s, acc := newTestService(t)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"total":3,"offers":[
+			{"offerId":"1","sku":"SKU-1","status":"PUBLISHED","pricingSummary":{"price":{"value":"80.00","currency":"AUD"}}},
+			{"offerId":"2","sku":"SKU-2","status":"UNPUBLISHED"},
+			{"offerId":"3","sku":"SKU-3","status":"UNPUBLISHED","pricingSummary":{}}
+		]}`))
+	})
+
+	count, err := s.exportOffers(context.Background(), client, acc.ID)
+	if err != nil {
+		t.Fatalf("exportOffers: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("exported %d offers, want 3", count)
+	}
+
+	rows, err := s.db.Query(`SELECT offer_id, data FROM offers WHERE account_id = ? ORDER BY offer_id`, acc.ID)
+	if err != nil {
+		t.Fatalf("query offers: %v", err)
+	}
+	defer rows.Close()
+	prices := map[string]string{}
+	for rows.Next() {
+		var offerID, data string
+		if err := rows.Scan(&offerID, &data); err != nil {
+			t.Fatal(err)
+		}
+		var offer ebay.Offer
+		if err := json.Unmarshal([]byte(data), &offer); err != nil {
+			t.Fatalf("offer %s: %v", offerID, err)
+		}
+		prices[offerID] = offer.Price().Value
+	}
+	if want := map[string]string{"1": "80.00", "2": "", "3": ""}; fmt.Sprint(prices) != fmt.Sprint(want) {
+		t.Errorf("stored prices = %v, want %v", prices, want)
+	}
+}
+
+func TestExportFromEbayRunsEveryExporter(t *testing.T) {
+	s, acc := newTestService(t)
+	inventory := &inventoryPages{total: 3}
+
+	var mu sync.Mutex
+	requested := map[string]bool{}
+	inFlight, peak := 0, 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[path.Base(r.URL.Path)] = true
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+		time.Sleep(10 * time.Millisecond) // Long enough for the exports to overlap
+
+		switch path.Base(r.URL.Path) {
+		case "fulfillment_policy":
+			w.Write([]byte(`{"total":2,"fulfillmentPolicies":[{"fulfillmentPolicyId":"fp-1","name":"Standard"},{"fulfillmentPolicyId":"fp-2","name":"Express"}]}`))
+		case "payment_policy":
+			w.Write([]byte(`{"total":1,"paymentPolicies":[{"paymentPolicyId":"pp-1","name":"Card"}]}`))
+		case "return_policy":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":[{"errorId":20403,"message":"return policies unavailable"}]}`))
+		case "inventory_item":
+			inventory.ServeHTTP(w, r)
+		case "offer":
+			w.Write([]byte(`{"total":2,"offers":[{"offerId":"1","sku":"SKU-000"},{"offerId":"2","sku":"SKU-001"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	var events []ProgressEvent
+	result, err := s.ExportFromEbay(context.Background(), client, acc.ID, "EBAY_AU", ExportOptions{}, func(e ProgressEvent) {
+		events = append(events, e) // Calls are serialized by ExportFromEbay
+	})
+	if err == nil || !strings.Contains(err.Error(), "return policies unavailable") {
+		t.Errorf("err = %v, want the return policy failure", err)
+	}
+	if result == nil {
+		t.Fatal("no result")
+	}
+
+	for _, p := range []string{"fulfillment_policy", "payment_policy", "return_policy", "inventory_item", "offer"} {
+		if !requested[p] {
+			t.Errorf("%s was never requested", p)
+		}
+	}
+	if peak > exportConcurrency {
+		t.Errorf("%d eBay requests in flight at once, want at most %d", peak, exportConcurrency)
+	}
+
+	wantCounts := map[string]int{
+		ResourceFulfillmentPolicies: 2,
+		ResourcePaymentPolicies:     1,
+		ResourceInventoryItems:      3,
+		ResourceOffers:              2,
+	}
+	if !reflect.DeepEqual(result.Counts, wantCounts) || result.TotalItems != 8 {
+		t.Errorf("counts = %v (total %d), want %v (total 8)", result.Counts, result.TotalItems, wantCounts)
+	}
+	if len(result.Errors) != 1 || result.Errors[ResourceReturnPolicies] == "" || result.Status != "partial" {
+		t.Errorf("status %s with errors %v, want partial with only return policies failed", result.Status, result.Errors)
+	}
+	var phases []string
+	for _, p := range result.Phases {
+		phases = append(phases, p.Resource)
+	}
+	wantPhases := []string{ResourceFulfillmentPolicies, ResourcePaymentPolicies, ResourceReturnPolicies, ResourceInventoryItems, ResourceOffers}
+	if !reflect.DeepEqual(phases, wantPhases) {
+		t.Errorf("phases = %v, want them in export order %v", phases, wantPhases)
+	}
+	finished := 0
+	for _, e := range events {
+		if e.Type == EventCount || e.Type == EventError {
+			finished++
+		}
+	}
+	if finished != 5 {
+		t.Errorf("%d exporters reported finishing, want 5: %+v", finished, events)
+	}
+
+	var status, errorMessage string
+	var itemsSynced int
+	var completed bool
+	err = s.db.QueryRow(`
+		SELECT status, items_synced, COALESCE(error_message, ''), completed_at IS NOT NULL FROM sync_history WHERE id = ?
+	`, result.HistoryID).Scan(&status, &itemsSynced, &errorMessage, &completed)
+	if err != nil {
+		t.Fatalf("query sync history: %v", err)
+	}
+	if status != "partial" || itemsSynced != 8 || !completed || errorMessage != result.Summary {
+		t.Errorf("sync history = %s, %d items, completed %v, error %q; want partial, 8, completed, %q",
+			status, itemsSynced, completed, errorMessage, result.Summary)
+	}
+}
+
+func TestExportOffersStoresShippingOverrides(t *testing.T) {
+	s, acc := newTestService(t)
+	withOverrides := true
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		overrides := `[]`
+		if withOverrides {
+			// The lowest priority international override is the one listed
+			overrides = `[
+				{"shippingServiceType":"INTERNATIONAL","priority":2,"shippingCost":{"value":"45.00","currency":"AUD"}},
+				{"shippingServiceType":"DOMESTIC","priority":1,"shippingCost":{"value":"10.00","currency":"AUD"}},
+				{"shippingServiceType":"INTERNATIONAL","priority":1,"shippingCost":{"value":"30.00","currency":"AUD"}}
+			]`
+		}
+		fmt.Fprintf(w, `{"total":3,"offers":[
+			{"offerId":"1","sku":"SKU-1","listing":{"listingId":"item-1"},"status":"PUBLISHED","listingPolicies":{"shippingCostOverrides":%s}},
+			{"offerId":"2","sku":"SKU-2","status":"PUBLISHED"},
+			{"offerId":"3","sku":"SKU-3","status":"PUBLISHED","listingPolicies":{"shippingCostOverrides":[
+				{"shippingServiceType":"DOMESTIC","priority":1,"shippingCost":{"value":"free","currency":"AUD"}}
+			]}}
+		]}`, overrides)
+	})
+
+	if _, err := s.exportOffers(context.Background(), client, acc.ID); err != nil {
+		t.Fatalf("exportOffers: %v", err)
+	}
+	overrides, err := s.db.GetOfferShippingOverrides(acc.ID)
+	if err != nil {
+		t.Fatalf("GetOfferShippingOverrides: %v", err)
+	}
+	if len(overrides) != 1 {
+		t.Fatalf("overrides = %+v, want only offer 1's", overrides)
+	}
+	o := overrides[0]
+	if o.OfferID != "1" || o.SKU != "SKU-1" || o.ListingID != "item-1" || o.AccountKey != acc.AccountKey {
+		t.Errorf("override = %+v, want offer 1 (SKU-1, item-1) of %s", o, acc.AccountKey)
+	}
+	if o.DomesticCost == nil || *o.DomesticCost != 10 || o.DomesticCurrency != "AUD" {
+		t.Errorf("domestic = %v %s, want 10 AUD", o.DomesticCost, o.DomesticCurrency)
+	}
+	if o.InternationalCost == nil || *o.InternationalCost != 30 || o.InternationalCurrency != "AUD" {
+		t.Errorf("international = %v %s, want 30 AUD", o.InternationalCost, o.InternationalCurrency)
+	}
+
+	// Exporting again after the overrides were removed on eBay clears them
+	withOverrides = false
+	if _, err := s.exportOffers(context.Background(), client, acc.ID); err != nil {
+		t.Fatalf("exportOffers: %v", err)
+	}
+	if overrides, err := s.db.GetOfferShippingOverrides(acc.ID); err != nil || len(overrides) != 0 {
+		t.Errorf("overrides after removal = %+v, %v; want none", overrides, err)
+	}
+}
+
+func TestImportToEbayDryRunWritesNothing(t *testing.T) {
+	s, source := newTestService(t)
+	target, err := s.db.GetOrCreateAccount("buyer", "buyer", "sandbox", "EBAY_AU")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed := []string{
+		`INSERT INTO fulfillment_policies (account_id, policy_id, name, marketplace_id, data) VALUES (?, 'fp-1', 'Standard', 'EBAY_AU', '{}')`,
+		`INSERT INTO inventory_items (account_id, sku, data) VALUES (?, 'SKU-1', '{"product":{"title":"Scarf"}}')`,
+		`INSERT INTO inventory_items (account_id, sku, data) VALUES (?, 'SKU-2', '{}')`, // No product
+	}
+	for _, stmt := range seed {
+		if _, err := s.db.Exec(stmt, source.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("dry run sent %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{}`)) // The target has no policies yet
+	})
+
+	result, err := s.ImportToEbay(context.Background(), client, source.ID, target.ID, ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("ImportToEbay: %v", err)
+	}
+	if !result.DryRun || result.HistoryID != 0 {
+		t.Errorf("dryRun = %v, historyId = %d; want a dry run without history", result.DryRun, result.HistoryID)
+	}
+	var runs int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM sync_history`).Scan(&runs); err != nil {
+		t.Fatal(err)
+	}
+	if runs != 0 {
+		t.Errorf("dry run recorded %d sync_history rows, want 0", runs)
+	}
+
+	want := []ImportAction{
+		{Resource: ResourceFulfillmentPolicies, Action: ActionCreate, Name: "Standard", SourceID: "fp-1"},
+		{Resource: ResourceInventoryItems, Action: ActionSkip, Name: "SKU-1"},
+		{Resource: ResourceInventoryItems, Action: ActionSkip, Name: "SKU-2"},
+	}
+	if !reflect.DeepEqual(result.Actions, want) {
+		t.Errorf("actions = %+v, want %+v", result.Actions, want)
+	}
+	if got := result.Counts[ResourceInventoryItems]; got != 0 {
+		t.Errorf("inventory items counted as imported = %d, want 0 (not implemented)", got)
+	}
+}